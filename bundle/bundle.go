@@ -0,0 +1,103 @@
+// Package bundle shares the connections, global saved queries and settings
+// of sqlcmder with a team: it writes them to a single file, without
+// credentials, and merges such a file into the ones of another instance.
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"sqlcmder/config"
+	"sqlcmder/models"
+)
+
+// Version is the version of the bundle file format
+const Version = 1
+
+// Bundle is what a bundle file holds
+type Bundle struct {
+	Version     int                 `toml:"version"`
+	AppConfig   *models.AppConfig   `toml:"application"`
+	Connections []models.Connection `toml:"database"`
+	Queries     []models.SavedQuery `toml:"queries"`
+}
+
+// New bundles settings, connections and saved queries. The connections are
+// stripped of their passwords, and those kept in the vault, which is not
+// shared, are asked for when connecting instead.
+func New(appConfig *models.AppConfig, connections []models.Connection, queries []models.SavedQuery) Bundle {
+	stripped := config.WithoutPasswords(connections)
+	for i := range stripped {
+		if stripped[i].PasswordSource == models.PasswordSourceVault {
+			stripped[i].PasswordSource = models.PasswordSourcePrompt
+		}
+	}
+
+	return Bundle{
+		Version:     Version,
+		AppConfig:   appConfig,
+		Connections: stripped,
+		Queries:     append([]models.SavedQuery{}, queries...),
+	}
+}
+
+// Write writes a bundle to a TOML file
+func Write(path string, bundle Bundle) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := toml.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Read reads a bundle file, building the DSNs of its connections like the
+// ones of the config are. The settings are nil when the bundle has none.
+func Read(path string) (Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	var sections map[string]any
+	if err := toml.Unmarshal(data, &sections); err != nil {
+		return Bundle{}, fmt.Errorf("failed to read bundle %s: %w", path, err)
+	}
+
+	// Settings missing from the bundle get their defaults, like in the config
+	bundle := Bundle{AppConfig: config.DefaultConfig().AppConfig}
+	if err := toml.Unmarshal(data, &bundle); err != nil {
+		return Bundle{}, fmt.Errorf("failed to read bundle %s: %w", path, err)
+	}
+	if _, ok := sections["application"]; !ok {
+		bundle.AppConfig = nil
+	}
+
+	if bundle.Version > Version {
+		return Bundle{}, fmt.Errorf("bundle %s is of version %d, newer than the supported %d", path, bundle.Version, Version)
+	}
+
+	for i, connection := range bundle.Connections {
+		if connection.Name == "" {
+			return Bundle{}, fmt.Errorf("bundle %s: connection %d has no name", path, i+1)
+		}
+	}
+	for i, query := range bundle.Queries {
+		if query.Name == "" {
+			return Bundle{}, fmt.Errorf("bundle %s: query %d has no name", path, i+1)
+		}
+	}
+
+	if err := config.PrepareConnections(bundle.Connections); err != nil {
+		return Bundle{}, fmt.Errorf("bundle %s: %w", path, err)
+	}
+
+	return bundle, nil
+}
@@ -0,0 +1,178 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestNew_StripsCredentials(t *testing.T) {
+	connections := []models.Connection{
+		{Name: "billing", DSN: "postgres://app:s3cret@db:5432/billing", Password: "s3cret"},
+		{Name: "shop", DSN: "mysql://root@db/shop", PasswordSource: models.PasswordSourceVault},
+		{Name: "ci", DSN: "postgres://ci@db/ci", PasswordSource: models.PasswordSourceEnv, PasswordEnv: "CI_PASSWORD"},
+	}
+
+	bundle := New(&models.AppConfig{Theme: models.ThemeDark}, connections, nil)
+
+	path := filepath.Join(t.TempDir(), "team.toml")
+	if err := Write(path, bundle); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatalf("expected no password in the bundle, got:\n%s", data)
+	}
+
+	sources := []string{}
+	for _, connection := range bundle.Connections {
+		sources = append(sources, connection.PasswordSource)
+	}
+	expected := []string{models.PasswordSourcePrompt, models.PasswordSourcePrompt, models.PasswordSourceEnv}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Fatalf("expected password sources %v, got %v", expected, sources)
+	}
+	if connections[0].Password != "s3cret" {
+		t.Fatalf("expected the connections given to be left alone")
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	bundle := New(
+		&models.AppConfig{Theme: models.ThemeNord, DefaultPageSize: 50},
+		[]models.Connection{{Name: "billing", Driver: "postgres", Hostname: "db", Username: "app", DBName: "billing", Group: "payments"}},
+		[]models.SavedQuery{{Name: "active users", Folder: "reports", Query: "SELECT * FROM users WHERE active"}},
+	)
+
+	path := filepath.Join(t.TempDir(), "team.toml")
+	if err := Write(path, bundle); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	read, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if read.AppConfig.Theme != models.ThemeNord || read.AppConfig.DefaultPageSize != 50 {
+		t.Fatalf("expected the settings back, got %+v", read.AppConfig)
+	}
+	if len(read.Connections) != 1 || read.Connections[0].GetDSN() != "postgres://app@db/billing" || read.Connections[0].Group != "payments" {
+		t.Fatalf("expected the connection back, got %+v", read.Connections)
+	}
+	if len(read.Queries) != 1 || read.Queries[0].Path() != "reports/active users" {
+		t.Fatalf("expected the query back, got %+v", read.Queries)
+	}
+
+	if err := os.WriteFile(path, []byte("version = 1\n[application]\ntheme = 'nord'\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if read, err := Read(path); err != nil || read.AppConfig.Theme != models.ThemeNord || read.AppConfig.Guard.SelectLimit != 1000 {
+		t.Fatalf("expected settings missing from the bundle to get their defaults, got %+v, %v", read.AppConfig, err)
+	}
+
+	if err := os.WriteFile(path, []byte("version = 1\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if read, err := Read(path); err != nil || read.AppConfig != nil {
+		t.Fatalf("expected no settings, got %+v, %v", read.AppConfig, err)
+	}
+
+	if err := os.WriteFile(path, []byte("version = 99\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Fatalf("expected a newer version to be refused")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := Bundle{
+		AppConfig: &models.AppConfig{Theme: models.ThemeDark},
+		Connections: []models.Connection{
+			{Name: "billing", DSN: "postgres://app@old/billing"},
+			{Name: "billing (2)", DSN: "postgres://app@other/billing"},
+		},
+		Queries: []models.SavedQuery{{Name: "top", Folder: "reports", Query: "SELECT 1"}},
+	}
+	imported := Bundle{
+		AppConfig: &models.AppConfig{Theme: models.ThemeLight},
+		Connections: []models.Connection{
+			{Name: "billing", DSN: "postgres://app@new/billing"},
+			{Name: "shop", DSN: "mysql://root@db/shop"},
+		},
+		Queries: []models.SavedQuery{
+			{Name: "top", Folder: "reports", Query: "SELECT 2"},
+			{Name: "top", Query: "SELECT 3"},
+		},
+	}
+
+	if conflicts := Conflicts(current, imported); conflicts != 2 {
+		t.Fatalf("expected 2 conflicts, got %d", conflicts)
+	}
+
+	tests := []struct {
+		resolution  Resolution
+		connections []string
+		queries     []string
+		theme       string
+		result      Result
+	}{
+		{
+			resolution:  KeepExisting,
+			connections: []string{"billing=postgres://app@old/billing", "billing (2)=postgres://app@other/billing", "shop=mysql://root@db/shop"},
+			queries:     []string{"reports/top=SELECT 1", "top=SELECT 3"},
+			theme:       models.ThemeDark,
+			result:      Result{Added: 2, Skipped: 2},
+		},
+		{
+			resolution:  Replace,
+			connections: []string{"billing=postgres://app@new/billing", "billing (2)=postgres://app@other/billing", "shop=mysql://root@db/shop"},
+			queries:     []string{"reports/top=SELECT 2", "top=SELECT 3"},
+			theme:       models.ThemeLight,
+			result:      Result{Added: 2, Replaced: 2, Settings: true},
+		},
+		{
+			resolution:  KeepBoth,
+			connections: []string{"billing=postgres://app@old/billing", "billing (2)=postgres://app@other/billing", "billing (3)=postgres://app@new/billing", "shop=mysql://root@db/shop"},
+			queries:     []string{"reports/top=SELECT 1", "reports/top (2)=SELECT 2", "top=SELECT 3"},
+			theme:       models.ThemeDark,
+			result:      Result{Added: 2, Renamed: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.result.String(), func(t *testing.T) {
+			merged, result := Merge(current, imported, tt.resolution)
+
+			connections := []string{}
+			for _, connection := range merged.Connections {
+				connections = append(connections, connection.Name+"="+connection.DSN)
+			}
+			queries := []string{}
+			for _, query := range merged.Queries {
+				queries = append(queries, query.Path()+"="+query.Query)
+			}
+
+			if !reflect.DeepEqual(connections, tt.connections) {
+				t.Fatalf("expected connections %v, got %v", tt.connections, connections)
+			}
+			if !reflect.DeepEqual(queries, tt.queries) {
+				t.Fatalf("expected queries %v, got %v", tt.queries, queries)
+			}
+			if merged.AppConfig.Theme != tt.theme {
+				t.Fatalf("expected theme %s, got %s", tt.theme, merged.AppConfig.Theme)
+			}
+			if result != tt.result {
+				t.Fatalf("expected %+v, got %+v", tt.result, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package bundle
+
+import (
+	"fmt"
+
+	"sqlcmder/models"
+)
+
+// Resolution is what a merge does with an imported connection or query
+// named like an existing one
+type Resolution int
+
+const (
+	// KeepExisting skips the imported item
+	KeepExisting Resolution = iota
+	// Replace replaces the existing item, and the settings too
+	Replace
+	// KeepBoth adds the imported item with a number after its name
+	KeepBoth
+)
+
+// Result counts what a merge did
+type Result struct {
+	Added    int // Items without a conflict
+	Replaced int
+	Renamed  int
+	Skipped  int
+
+	Settings bool // Whether the settings were replaced
+}
+
+// String describes the result for the status bar
+func (r Result) String() string {
+	text := fmt.Sprintf("%d added, %d replaced, %d renamed, %d skipped", r.Added, r.Replaced, r.Renamed, r.Skipped)
+	if r.Settings {
+		text += ", settings replaced"
+	}
+	return text
+}
+
+// Conflicts returns the number of connections and queries of imported named
+// like the ones of current
+func Conflicts(current, imported Bundle) int {
+	connections := map[string]bool{}
+	for _, connection := range current.Connections {
+		connections[connection.Name] = true
+	}
+	queries := map[string]bool{}
+	for _, query := range current.Queries {
+		queries[query.Path()] = true
+	}
+
+	conflicts := 0
+	for _, connection := range imported.Connections {
+		if connections[connection.Name] {
+			conflicts++
+		}
+	}
+	for _, query := range imported.Queries {
+		if queries[query.Path()] {
+			conflicts++
+		}
+	}
+
+	return conflicts
+}
+
+// Merge merges imported into current, connections being matched by name and
+// saved queries by folder and name. The settings of imported replace the
+// current ones only with Replace.
+func Merge(current, imported Bundle, resolution Resolution) (Bundle, Result) {
+	result := Result{}
+	merged := Bundle{
+		Version:   Version,
+		AppConfig: current.AppConfig,
+	}
+
+	if resolution == Replace && imported.AppConfig != nil {
+		merged.AppConfig = imported.AppConfig
+		result.Settings = true
+	}
+
+	merged.Connections = mergeItems(current.Connections, imported.Connections, resolution, &result,
+		func(connection models.Connection) string { return connection.Name },
+		func(connection models.Connection) string { return connection.Name },
+		func(connection models.Connection, name string) models.Connection {
+			connection.Name = name
+			return connection
+		})
+
+	merged.Queries = mergeItems(current.Queries, imported.Queries, resolution, &result,
+		models.SavedQuery.Path,
+		func(query models.SavedQuery) string { return query.Name },
+		func(query models.SavedQuery, name string) models.SavedQuery {
+			query.Name = name
+			return query
+		})
+
+	return merged, result
+}
+
+// mergeItems merges items matched by key. Renaming an item changes its
+// name, part of the key like the name of a query is of its path.
+func mergeItems[T any](current, imported []T, resolution Resolution, result *Result, key func(T) string, name func(T) string, rename func(T, string) T) []T {
+	merged := append([]T{}, current...)
+	index := map[string]int{}
+	for i, item := range merged {
+		index[key(item)] = i
+	}
+
+	for _, item := range imported {
+		i, exists := index[key(item)]
+		switch {
+		case !exists:
+			result.Added++
+		case resolution == Replace:
+			merged[i] = item
+			result.Replaced++
+			continue
+		case resolution == KeepBoth:
+			base := name(item)
+			for n := 2; ; n++ {
+				item = rename(item, fmt.Sprintf("%s (%d)", base, n))
+				if _, taken := index[key(item)]; !taken {
+					break
+				}
+			}
+			result.Renamed++
+		default:
+			result.Skipped++
+			continue
+		}
+
+		index[key(item)] = len(merged)
+		merged = append(merged, item)
+	}
+
+	return merged
+}
@@ -55,6 +55,11 @@ func ImportDatabase(filename string, ctx Context, onSuccess func(string), onErro
 		return
 	}
 
+	if ctx.ReadOnly() {
+		onError(ErrReadOnly)
+		return
+	}
+
 	conn := ctx.ConnectionModel
 	provider := strings.ToLower(conn.Driver)
 	dbName := ctx.CurrentDatabase
@@ -3,6 +3,7 @@ package commands
 import (
 	"sqlcmder/drivers"
 	"sqlcmder/models"
+	"sqlcmder/sqlparse"
 )
 
 // Context holds the current context for command execution
@@ -12,5 +13,19 @@ type Context struct {
 	CurrentTable    string
 	Connection      string
 	ConnectionModel *models.Connection // Full connection details for backup/import
+
+	// Rules SQL is checked with before it is run, risky statements running
+	// once Confirm calls run. They are refused when Confirm is nil.
+	Lint    sqlparse.LintOptions
+	Confirm func(warnings []sqlparse.Warning, run func())
+}
+
+// ErrReadOnly is the message of the commands refused on read-only connections
+const ErrReadOnly = "The connection is read-only"
+
+// ReadOnly reports whether the connection of the context is read-only, so
+// commands changing the database are refused
+func (ctx Context) ReadOnly() bool {
+	return ctx.ConnectionModel != nil && ctx.ConnectionModel.ReadOnly
 }
 
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"testing"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// TestReadOnlyContext tests that commands changing the database are refused
+// on read-only connections before anything is sent
+func TestReadOnlyContext(t *testing.T) {
+	ctx := Context{
+		DB:              &drivers.SQLite{Provider: drivers.DriverSqlite},
+		CurrentDatabase: "main",
+		Connection:      "analytics",
+		ConnectionModel: &models.Connection{Driver: drivers.DriverSqlite, DBName: "app.db", ReadOnly: true},
+	}
+
+	testCases := []struct {
+		name string
+		run  func(onError func(string))
+	}{
+		{"table drop", func(onError func(string)) {
+			ExecuteTableCommand([]string{"drop", "users"}, ctx, nil, onError, nil)
+		}},
+		{"db create", func(onError func(string)) {
+			ExecuteDatabaseCommand([]string{"create", "other"}, ctx, nil, onError, nil, nil)
+		}},
+		{"db import", func(onError func(string)) {
+			ExecuteDatabaseCommand([]string{"import", "dump.sql"}, ctx, nil, onError, nil, nil)
+		}},
+		{"import", func(onError func(string)) {
+			ImportDatabase("dump.sql", ctx, nil, onError, nil)
+		}},
+		{"sql", func(onError func(string)) {
+			ExecuteSQL("SELECT 1; DELETE FROM users", ctx, nil, onError, nil)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			refused := false
+			tc.run(func(string) { refused = true })
+			if !refused {
+				t.Fatalf("expected the command to be refused")
+			}
+		})
+	}
+
+	if !ctx.ReadOnly() {
+		t.Fatalf("expected the context to be read-only")
+	}
+	ctx.ConnectionModel = nil
+	if ctx.ReadOnly() {
+		t.Fatalf("expected a context without connection not to be read-only")
+	}
+}
@@ -12,6 +12,14 @@ func ExecuteDatabaseCommand(args []string, ctx Context, onSuccess func(string),
 	}
 
 	action := args[0]
+	switch action {
+	case "create", "c", "drop", "d", "import", "i":
+		if ctx.ReadOnly() {
+			onError(ErrReadOnly)
+			return
+		}
+	}
+
 	switch action {
 	case "create", "c":
 		createDatabase(args, ctx, onSuccess, onError, onRefresh)
@@ -1,11 +1,45 @@
 package commands
 
 import (
+	"strings"
+
+	"sqlcmder/drivers"
 	"sqlcmder/logger"
+	"sqlcmder/sqlparse"
 )
 
 // ExecuteSQL executes arbitrary SQL statement
+// Statements other than queries are refused on read-only connections, and
+// risky statements run once confirmed
 func ExecuteSQL(sql string, ctx Context, onSuccess func(string), onError func(string), onRefresh func()) {
+	if ctx.ReadOnly() && !sqlparse.IsReadOnly(ctx.DB.GetProvider(), sql) {
+		onError(ErrReadOnly + ", only queries can be run")
+		return
+	}
+
+	warnings := sqlparse.Lint(ctx.DB.GetProvider(), sql, ctx.Lint, func(table string) (int64, bool) {
+		return drivers.EstimateRows(ctx.DB, table)
+	})
+	if len(warnings) == 0 {
+		executeSQL(sql, ctx, onSuccess, onError, onRefresh)
+		return
+	}
+
+	if ctx.Confirm == nil {
+		messages := []string{}
+		for _, warning := range warnings {
+			messages = append(messages, warning.Message())
+		}
+		onError("Refused: " + strings.Join(messages, "; "))
+		return
+	}
+
+	ctx.Confirm(warnings, func() {
+		executeSQL(sql, ctx, onSuccess, onError, onRefresh)
+	})
+}
+
+func executeSQL(sql string, ctx Context, onSuccess func(string), onError func(string), onRefresh func()) {
 	logger.Info("Execute SQL", map[string]any{"sql": sql})
 
 	_, err := ctx.DB.ExecuteDMLStatement(sql)
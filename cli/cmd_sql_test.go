@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"sqlcmder/drivers"
+	"sqlcmder/sqlparse"
+)
+
+// TestExecuteSQL_Guard tests that risky statements run only once confirmed
+func TestExecuteSQL_Guard(t *testing.T) {
+	db := &drivers.SQLite{}
+	if err := db.Connect(filepath.Join(t.TempDir(), "guard.db")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer db.Connection.Close()
+
+	if _, err := db.Connection.Exec("CREATE TABLE orders (id INTEGER); INSERT INTO orders VALUES (1), (2)"); err != nil {
+		t.Fatalf("setup error = %v", err)
+	}
+
+	count := func() int {
+		var rows int
+		if err := db.Connection.QueryRow("SELECT COUNT(*) FROM orders").Scan(&rows); err != nil {
+			t.Fatalf("count error = %v", err)
+		}
+		return rows
+	}
+
+	ctx := Context{DB: db, Lint: sqlparse.LintOptions{UnfilteredChanges: true}}
+	onSuccess := func(string) {}
+	onRefresh := func() {}
+
+	refused := ""
+	ExecuteSQL("DELETE FROM orders", ctx, onSuccess, func(message string) { refused = message }, onRefresh)
+	if refused != "Refused: DELETE without WHERE changes every row of orders" || count() != 2 {
+		t.Fatalf("expected the statement to be refused, got %q with %d rows", refused, count())
+	}
+
+	confirmed := []sqlparse.Warning{}
+	ctx.Confirm = func(warnings []sqlparse.Warning, run func()) {
+		confirmed = warnings
+		run()
+	}
+	ExecuteSQL("DELETE FROM orders", ctx, onSuccess, func(message string) { t.Fatalf("unexpected error %q", message) }, onRefresh)
+	if len(confirmed) != 1 || confirmed[0].Rule != sqlparse.RuleUnfilteredChange || count() != 0 {
+		t.Fatalf("expected the statement to run once confirmed, got %v with %d rows", confirmed, count())
+	}
+}
@@ -7,6 +7,11 @@ func ExecuteTableCommand(args []string, ctx Context, onSuccess func(string), onE
 		return
 	}
 
+	if ctx.ReadOnly() {
+		onError(ErrReadOnly)
+		return
+	}
+
 	action := args[0]
 	switch action {
 	case "create", "c":
@@ -10,6 +10,7 @@ const (
 	SwitchToConnectionsView
 	HelpPopup
 	ToggleQueryHistory
+	GlobalHistory
 
 	// Movement: Basic
 	MoveUp
@@ -72,6 +73,17 @@ const (
 	ToggleSidebar
 	ShowRowJSONViewer
 	ShowCellJSONViewer
+	DiffTable
+	FollowForeignKey
+	ListReferencingRows
+	NavigateBack
+	ShowERDiagram
+	ExportMermaid
+	ExportDOT
+	Complete
+	FormatQuery
+	CopyFormatted
+	Rerun
 
 	// Connection
 	NewConnection
@@ -80,6 +92,12 @@ const (
 	EditConnection
 	DeleteConnection
 	AutoGenerateDSN
+	ForgetCredentials
+	Disconnect
+	CommandLog
+	ImportConnections
+	ExportBundle
+	ImportBundle
 )
 
 func (c Command) String() string {
@@ -95,6 +113,8 @@ func (c Command) String() string {
 		return "HelpPopup"
 	case ToggleQueryHistory:
 		return "ToggleQueryHistory"
+	case GlobalHistory:
+		return "GlobalHistory"
 
 	// Movement: Basic
 	case MoveUp:
@@ -174,6 +194,18 @@ func (c Command) String() string {
 		return "EditConnection"
 	case DeleteConnection:
 		return "DeleteConnection"
+	case ForgetCredentials:
+		return "ForgetCredentials"
+	case Disconnect:
+		return "Disconnect"
+	case CommandLog:
+		return "CommandLog"
+	case ImportConnections:
+		return "ImportConnections"
+	case ExportBundle:
+		return "ExportBundle"
+	case ImportBundle:
+		return "ImportBundle"
 	case Refresh:
 		return "Refresh"
 	case UnfocusEditor:
@@ -218,6 +250,28 @@ func (c Command) String() string {
 		return "ShowCellJSONViewer"
 	case AutoGenerateDSN:
 		return "AutoGenerateDSN"
+	case DiffTable:
+		return "DiffTable"
+	case FollowForeignKey:
+		return "FollowForeignKey"
+	case ListReferencingRows:
+		return "ListReferencingRows"
+	case NavigateBack:
+		return "NavigateBack"
+	case ShowERDiagram:
+		return "ShowERDiagram"
+	case ExportMermaid:
+		return "ExportMermaid"
+	case ExportDOT:
+		return "ExportDOT"
+	case Complete:
+		return "Complete"
+	case FormatQuery:
+		return "FormatQuery"
+	case CopyFormatted:
+		return "CopyFormatted"
+	case Rerun:
+		return "Rerun"
 	}
 
 	return "Unknown"
@@ -12,6 +12,7 @@ import (
 
 	"sqlcmder/config"
 	"sqlcmder/models"
+	"sqlcmder/process"
 )
 
 var (
@@ -154,10 +155,12 @@ func (a *Application) Run(root *tview.Pages, configFile string) error {
 	return a.Application.Run()
 }
 
-// Stop cancels the application context, waits for all
-// tasks to finish, and then stops the application.
+// Stop cancels the application context, kills the commands
+// started for connections, waits for all tasks to finish,
+// and then stops the application.
 func (a *Application) Stop() {
 	a.cancelFn()
+	process.StopAll()
 	a.waitGroup.Wait()
 	a.Application.Stop()
 }
@@ -5,10 +5,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 
 	"sqlcmder/drivers"
+	"sqlcmder/helpers"
 	"sqlcmder/models"
 )
 
@@ -25,6 +27,19 @@ func DefaultConfig() *Config {
 			SidebarOverlay:               false,
 			MaxQueryHistoryPerConnection: 100,
 			Theme:                        models.ThemeDark, // Default to dark theme
+			Formatter: models.FormatterConfig{
+				KeywordCase: "upper",
+				IndentWidth: 2,
+				CommaStyle:  "trailing",
+				LineWidth:   80,
+			},
+			Guard: models.GuardConfig{
+				UnfilteredChanges:  true,
+				Destructive:        true,
+				AlterRowThreshold:  1000000,
+				SelectRowThreshold: 100000,
+				SelectLimit:        1000,
+			},
 		},
 	}
 }
@@ -64,14 +79,38 @@ func LoadConfig(configFile string, config *Config) error {
 		return err
 	}
 
-	for i, conn := range config.Connections {
-		config.Connections[i].DSN = parseConfigDSN(&conn)
-		config.Connections[i].SetDSNValue() // Ensure DsnValue is set
+	return PrepareConnections(config.Connections)
+}
+
+// PrepareConnections migrates connections read from a config written by an
+// older version and builds the DSNs of those without one.
+func PrepareConnections(connections []models.Connection) error {
+	for i := range connections {
+		conn := &connections[i]
+
+		if err := migrateDSNParams(conn); err != nil {
+			return fmt.Errorf("DSNParams of connection %s: %w", conn.Name, err)
+		}
+
+		dsn, err := parseConfigDSN(conn)
+		if err != nil {
+			return fmt.Errorf("connection %s: %w", conn.Name, err)
+		}
+		conn.DSN = dsn
+		conn.SetDSNValue() // Ensure DsnValue is set
+
+		// A password left in a DSN by an older version is kept in memory only
+		if _, password := helpers.SplitPassword(conn.GetDSN()); password != "" && conn.Password == "" {
+			conn.Password = password
+		}
 	}
 
 	return nil
 }
 
+// SaveConnections writes the connections to the config file, without their
+// passwords. Passwords are read from the password source of a connection at
+// connect time.
 func (c *Config) SaveConnections(connections []models.Connection) error {
 	c.Connections = connections
 
@@ -85,52 +124,97 @@ func (c *Config) SaveConnections(connections []models.Connection) error {
 	}
 	defer file.Close()
 
-	return toml.NewEncoder(file).Encode(c)
+	saved := *c
+	saved.Connections = WithoutPasswords(connections)
+
+	return toml.NewEncoder(file).Encode(saved)
+}
+
+// WithoutPasswords returns copies of the connections without passwords, in
+// their fields or their DSNs. A connection that had a password but no source
+// to read it from asks for it when connecting.
+func WithoutPasswords(connections []models.Connection) []models.Connection {
+	stripped := make([]models.Connection, len(connections))
+
+	for i, conn := range connections {
+		hadPassword := conn.Password != ""
+
+		for _, dsn := range []*string{&conn.DSN, &conn.DsnCustom, &conn.DsnAuto, &conn.DsnValue} {
+			var password string
+			*dsn, password = helpers.SplitPassword(*dsn)
+			hadPassword = hadPassword || password != ""
+		}
+
+		if hadPassword && conn.PasswordSource == "" {
+			conn.PasswordSource = models.PasswordSourcePrompt
+		}
+
+		conn.Password = ""
+		stripped[i] = conn
+	}
+
+	return stripped
 }
 
 // parseConfigDSN automatically generates the DSN from the connection struct
 // if the DSN is empty. It is useful for handling usernames and passwords with
-// special characters. NOTE: Only MSSQL is supported for now!
-func parseConfigDSN(conn *models.Connection) string {
+// special characters.
+func parseConfigDSN(conn *models.Connection) (string, error) {
 	// Handle new DSN structure with priority: custom > auto-generated
 	if conn.DsnCustom != "" {
 		conn.DsnValue = conn.DsnCustom
-		return conn.DsnCustom
+		return conn.DsnCustom, nil
 	}
-	
+
 	if conn.DsnAuto != "" {
 		conn.DsnValue = conn.DsnAuto
-		return conn.DsnAuto
+		return conn.DsnAuto, nil
 	}
-	
+
 	// Fallback to legacy DSN field for backward compatibility
-	if conn.DSN != "" {
+	if conn.DSN != "" || conn.Driver == "" {
 		conn.DsnValue = conn.DSN
-		return conn.DSN
+		return conn.DSN, nil
 	}
 
-	// Only MSSQL is supported for now.
-	if conn.Driver != drivers.DriverMSSQL {
-		return conn.DSN
+	autoDSN, err := drivers.BuildDSN(*conn)
+	if err != nil {
+		return "", err
 	}
 
-	user := url.QueryEscape(conn.Username)
-	pass := url.QueryEscape(conn.Password)
-
-	autoDSN := fmt.Sprintf(
-		"%s://%s:%s@%s:%s?database=%s%s",
-		conn.Driver,
-		user,
-		pass,
-		conn.Hostname,
-		conn.Port,
-		conn.DBName,
-		conn.DSNParams,
-	)
-	
 	// Update the new DSN fields
 	conn.DsnAuto = autoDSN
 	conn.DsnValue = autoDSN
-	
-	return autoDSN
+
+	return autoDSN, nil
+}
+
+// migrateDSNParams moves the DSN parameters of an older config to the
+// options of the connection
+func migrateDSNParams(conn *models.Connection) error {
+	if conn.DSNParams == "" {
+		return nil
+	}
+
+	params, err := url.ParseQuery(strings.TrimLeft(conn.DSNParams, "?&"))
+	if err != nil {
+		return err
+	}
+
+	options, err := drivers.OptionsFromParams(conn.Driver, params)
+	if err != nil {
+		return err
+	}
+
+	if conn.Options == nil {
+		conn.Options = map[string]string{}
+	}
+	for name, value := range options {
+		if _, ok := conn.Options[name]; !ok {
+			conn.Options[name] = value
+		}
+	}
+
+	conn.DSNParams = ""
+	return nil
 }
@@ -0,0 +1,138 @@
+package history
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"sqlcmder/models"
+)
+
+// Fields a filter term can match
+const (
+	filterDatabase   = "db"
+	filterConnection = "connection"
+	filterSource     = "source"
+	filterStatus     = "status"
+	filterError      = "error"
+	filterDuration   = "duration"
+	filterRows       = "rows"
+)
+
+// comparisonOperators are checked longest first so ">=" is not read as ">"
+var comparisonOperators = []string{">=", "<=", ">", "<", "=", ":"}
+
+// filterTerm is a word of a filter text, either searched in the query or a
+// condition on a field of the item
+type filterTerm struct {
+	field    string
+	operator string
+	value    string
+}
+
+func parseFilterTerm(word string) filterTerm {
+	lower := strings.ToLower(word)
+
+	for _, field := range []string{filterDuration, filterRows} {
+		rest, ok := strings.CutPrefix(lower, field)
+		if !ok {
+			continue
+		}
+		for _, operator := range comparisonOperators {
+			if value, ok := strings.CutPrefix(rest, operator); ok && value != "" {
+				return filterTerm{field: field, operator: operator, value: value}
+			}
+		}
+	}
+
+	for _, field := range []string{filterDatabase, filterConnection, filterSource, filterStatus, filterError} {
+		if value, ok := strings.CutPrefix(lower, field+":"); ok && value != "" {
+			return filterTerm{field: field, operator: ":", value: value}
+		}
+	}
+
+	return filterTerm{value: lower}
+}
+
+// matches reports whether an item satisfies the term. Terms with a value
+// that cannot be compared match nothing.
+func (term filterTerm) matches(item models.QueryHistoryItem) bool {
+	switch term.field {
+	case filterDatabase:
+		return strings.Contains(strings.ToLower(item.Database), term.value)
+	case filterConnection:
+		return strings.Contains(strings.ToLower(item.Connection), term.value)
+	case filterSource:
+		return strings.EqualFold(item.Source, term.value)
+	case filterStatus:
+		switch term.value {
+		case "ok", "success":
+			return item.Error == ""
+		case "error", "failed":
+			return item.Error != ""
+		}
+		return false
+	case filterError:
+		return item.Error != "" && strings.Contains(strings.ToLower(item.Error), term.value)
+	case filterDuration:
+		duration, err := time.ParseDuration(term.value)
+		if err != nil {
+			return false
+		}
+		return compare(int64(item.Duration), term.operator, int64(duration))
+	case filterRows:
+		rows, err := strconv.ParseInt(term.value, 10, 64)
+		if err != nil {
+			return false
+		}
+		return compare(item.Rows, term.operator, rows)
+	}
+
+	return strings.Contains(strings.ToLower(item.QueryText), term.value)
+}
+
+func compare(value int64, operator string, other int64) bool {
+	switch operator {
+	case ">":
+		return value > other
+	case ">=":
+		return value >= other
+	case "<":
+		return value < other
+	case "<=":
+		return value <= other
+	}
+	return value == other
+}
+
+// Filter returns the items matching every word of a filter text. A word is
+// searched in the query unless it is a condition on a field:
+//
+//	db:<name>, connection:<name>  the database or connection contains the name
+//	source:<source>               the query came from the editor, a filter or a command
+//	status:ok, status:error       the query succeeded or failed
+//	error:<text>                  the error message contains the text
+//	duration>500ms, rows<=10      the duration or rows compared with >, >=, <, <= or =
+func Filter(items []models.QueryHistoryItem, text string) []models.QueryHistoryItem {
+	terms := []filterTerm{}
+	for _, word := range strings.Fields(text) {
+		terms = append(terms, parseFilterTerm(word))
+	}
+
+	filtered := []models.QueryHistoryItem{}
+	for _, item := range items {
+		matchesAll := true
+		for _, term := range terms {
+			if !term.matches(item) {
+				matchesAll = false
+				break
+			}
+		}
+
+		if matchesAll {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered
+}
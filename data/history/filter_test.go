@@ -0,0 +1,53 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"sqlcmder/models"
+)
+
+func TestFilter(t *testing.T) {
+	items := []models.QueryHistoryItem{
+		{QueryText: "SELECT * FROM users", Database: "app", Duration: 20 * time.Millisecond, Rows: 120, Source: models.QuerySourceEditor},
+		{QueryText: "DELETE FROM sessions", Database: "app", Duration: 2 * time.Second, Rows: 3, Source: models.QuerySourceCommand},
+		{QueryText: "SELECT * FROM orders", Database: "shop", Duration: 700 * time.Millisecond, Error: "relation \"orders\" does not exist", Source: models.QuerySourceFilter},
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   []string
+	}{
+		{name: "empty", filter: "", want: []string{"SELECT * FROM users", "DELETE FROM sessions", "SELECT * FROM orders"}},
+		{name: "query text", filter: "select", want: []string{"SELECT * FROM users", "SELECT * FROM orders"}},
+		{name: "database", filter: "db:shop", want: []string{"SELECT * FROM orders"}},
+		{name: "source", filter: "source:command", want: []string{"DELETE FROM sessions"}},
+		{name: "failed", filter: "status:error", want: []string{"SELECT * FROM orders"}},
+		{name: "succeeded", filter: "status:ok select", want: []string{"SELECT * FROM users"}},
+		{name: "error message", filter: "error:exist", want: []string{"SELECT * FROM orders"}},
+		{name: "slow", filter: "duration>=500ms", want: []string{"DELETE FROM sessions", "SELECT * FROM orders"}},
+		{name: "rows", filter: "rows>100", want: []string{"SELECT * FROM users"}},
+		{name: "invalid duration", filter: "duration>soon", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Filter(items, tt.filter)
+
+			queries := []string{}
+			for _, item := range got {
+				queries = append(queries, item.QueryText)
+			}
+
+			if len(queries) != len(tt.want) {
+				t.Fatalf("Filter(%q) = %v, want %v", tt.filter, queries, tt.want)
+			}
+			for i := range queries {
+				if queries[i] != tt.want[i] {
+					t.Fatalf("Filter(%q) = %v, want %v", tt.filter, queries, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -1,11 +1,11 @@
 package history
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -17,9 +17,11 @@ import (
 )
 
 const (
-	historyDirName        = "history"
-	sqlcmderConfigDirName = "sqlcmder" // This should match your application's config directory name
-	historyFileExtension  = ".json"
+	historyDirName             = "history"
+	sqlcmderConfigDirName      = "sqlcmder" // This should match your application's config directory name
+	historyFileExtension       = ".ndjson"
+	legacyHistoryFileExtension = ".json"
+	defaultHistoryLimit        = 100
 )
 
 // GetAppConfigDir returns the application's configuration directory.
@@ -76,119 +78,134 @@ func GetHistoryFilePath(connectionIdentifier string) (string, error) {
 	return filepath.Join(historyDirPath, sanitizedIdentifier+historyFileExtension), nil
 }
 
-// ReadHistory reads query history items from the specified file.
-// The 'limit' parameter is not strictly enforced in this read function yet,
-// but is available for future use (e.g., if the modal itself doesn't limit or sort).
-// The QueryHistoryModal currently sorts all items passed to it.
-func ReadHistory(filePath string, _ int) ([]models.QueryHistoryItem, error) {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		logger.Info("History file does not exist, returning empty history.", map[string]any{"path": filePath})
-		return []models.QueryHistoryItem{}, nil // No history file yet, return empty.
+// ReadHistory reads the query history items of the specified file, newest
+// first. A query run several times in a row in the same database is listed
+// once, with its latest run. A positive limit keeps only the newest items.
+func ReadHistory(filePath string, limit int) ([]models.QueryHistoryItem, error) {
+	if _, err := os.Stat(legacyHistoryFilePath(filePath)); err == nil {
+		if err := migrate(filePath); err != nil {
+			logger.Warn("Failed to migrate legacy history file.", map[string]any{"path": filePath, "error": err})
+		}
 	}
 
-	data, err := os.ReadFile(filePath)
+	lock, err := lockHistory(filePath, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read history file %s: %w", filePath, err)
+		return []models.QueryHistoryItem{}, err
 	}
+	defer lock.release()
 
-	if len(data) == 0 { // Empty file
-		logger.Info("History file is empty.", map[string]any{"path": filePath})
-		return []models.QueryHistoryItem{}, nil
+	items, err := readItems(filePath)
+	if err != nil {
+		return []models.QueryHistoryItem{}, err
 	}
 
-	var items []models.QueryHistoryItem
-	if err := json.Unmarshal(data, &items); err != nil {
-		// It's possible the file is corrupted. Log and consider returning empty or error.
-		logger.Error("Failed to unmarshal history data, file might be corrupted.", map[string]any{"path": filePath, "error": err})
-		// Depending on desired UX, could return empty to allow app to continue, or error to signal issue.
-		// For now, return empty so the app doesn't crash, but log the error.
-		return []models.QueryHistoryItem{}, fmt.Errorf("failed to unmarshal history data from %s: %w", filePath, err)
-	}
+	items = collapse(items)
+	slices.Reverse(items)
 
-	// The QueryHistoryModal will sort these items by timestamp.
-	// If a limit were to be applied here, it should be after sorting.
-	// Example:
-	// sort.SliceStable(items, func(i, j int) bool {
-	// 	return items[i].Timestamp.After(items[j].Timestamp)
-	// })
-	// if limit > 0 && len(items) > limit {
-	// 	items = items[:limit]
-	// }
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
 
 	return items, nil
 }
 
+func migrate(filePath string) error {
+	lock, err := lockHistory(filePath, true)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	return migrateLegacyHistory(filePath)
+}
+
 // AddQueryToHistory adds a query to the history for the given connection.
-// It ensures the history does not exceed the configured limit and avoids immediate duplicates.
 func AddQueryToHistory(connectionIdentifier string, queryText string) error {
-	if strings.TrimSpace(queryText) == "" {
+	return AddItem(connectionIdentifier, models.QueryHistoryItem{QueryText: queryText})
+}
+
+// AddItem adds a history item with the details of a query run, successful
+// or not, to the history of the given connection. The item is appended, so
+// sqlcmder instances sharing a connection can add items at the same time.
+func AddItem(connectionIdentifier string, newItem models.QueryHistoryItem) error {
+	if strings.TrimSpace(newItem.QueryText) == "" {
 		logger.Info("Attempted to add empty query to history, skipping.", map[string]any{"connection": connectionIdentifier})
 		return nil // Don't add empty or whitespace-only queries
 	}
 
 	historyFilePath, err := GetHistoryFilePath(connectionIdentifier)
 	if err != nil {
-		return fmt.Errorf("failed to get history file path for AddQueryToHistory: %w", err)
-	}
-
-	items, err := ReadHistory(historyFilePath, 0) // Limit is managed on write
-	if err != nil {
-		// If ReadHistory failed (e.g. corrupted JSON), it returns an error and empty items.
-		// We log this and proceed with an empty list, effectively overwriting corrupted history.
-		logger.Warn("Error reading existing history, will proceed as if history is empty or create new.", map[string]any{"path": historyFilePath, "error": err, "connection": connectionIdentifier})
-		items = []models.QueryHistoryItem{} // Reset to ensure we don't append to potentially problematic data
+		return fmt.Errorf("failed to get history file path for AddItem: %w", err)
 	}
 
-	// Avoid adding duplicate of the most recent query by updating its timestamp
-	// Sort by timestamp descending to easily check the latest
-	sort.SliceStable(items, func(i, j int) bool {
-		return items[i].Timestamp.After(items[j].Timestamp)
-	})
-
-	if len(items) > 0 && items[0].QueryText == queryText {
-		logger.Info("Query is identical to the most recent history entry, updating timestamp.", map[string]any{"connection": connectionIdentifier})
-		items[0].Timestamp = time.Now().UTC()
-	} else {
-		newItem := models.QueryHistoryItem{
-			QueryText: queryText,
-			Timestamp: time.Now().UTC(),
-		}
-		items = append(items, newItem) // Add as a new entry
+	if newItem.Connection == "" {
+		newItem.Connection = connectionIdentifier
 	}
+	newItem.Timestamp = time.Now().UTC()
 
-	// Re-sort by timestamp descending (newest first) after potential addition or timestamp update
-	sort.SliceStable(items, func(i, j int) bool {
-		return items[i].Timestamp.After(items[j].Timestamp)
-	})
-
-	// Enforce history limit
 	limit := app.App.Config().MaxQueryHistoryPerConnection
 	if limit <= 0 { // Ensure a positive limit, fallback to a default
-		limit = 100
-		logger.Info("MaxQueryHistoryPerConnection is not set or invalid, using default limit.", map[string]any{"defaultLimit": limit, "connection": connectionIdentifier})
+		limit = defaultHistoryLimit
 	}
 
-	if len(items) > limit {
-		items = items[:limit] // Keep only the newest 'limit' items
+	if err := appendItem(historyFilePath, newItem, limit); err != nil {
+		return fmt.Errorf("failed to add query to history of %s: %w", connectionIdentifier, err)
 	}
 
-	// Write updated history back to file
-	data, err := json.MarshalIndent(items, "", "  ") // Use MarshalIndent for readability
+	logger.Info("Query successfully added to history.", map[string]any{"connection": connectionIdentifier, "path": historyFilePath})
+	return nil
+}
+
+// ReadAllHistory reads the history of every connection, newest first. Items
+// recorded before their connection was kept get the name of their file.
+func ReadAllHistory() ([]models.QueryHistoryItem, error) {
+	appConfigDir, err := GetAppConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to marshal history items for %s: %w", connectionIdentifier, err)
+		return nil, fmt.Errorf("failed to get app config dir: %w", err)
 	}
 
-	// Ensure directory exists (GetHistoryFilePath should do this, but good for robustness before write)
-	historyDir := filepath.Dir(historyFilePath)
-	if err := os.MkdirAll(historyDir, 0700); err != nil { // 0700: rwx for user only
-		return fmt.Errorf("failed to ensure history directory exists %s for %s: %w", historyDir, connectionIdentifier, err)
+	historyDirPath := filepath.Join(appConfigDir, historyDirName)
+	entries, err := os.ReadDir(historyDirPath)
+	if os.IsNotExist(err) {
+		return []models.QueryHistoryItem{}, nil
 	}
-
-	err = os.WriteFile(historyFilePath, data, 0600) // 0600: rw for user only
 	if err != nil {
-		return fmt.Errorf("failed to write history file %s for %s: %w", historyFilePath, connectionIdentifier, err)
+		return nil, fmt.Errorf("failed to read history directory %s: %w", historyDirPath, err)
 	}
 
-	logger.Info("Query successfully added/updated in history.", map[string]any{"connection": connectionIdentifier, "path": historyFilePath})
-	return nil
+	all := []models.QueryHistoryItem{}
+	read := map[string]bool{}
+
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if entry.IsDir() || (extension != historyFileExtension && extension != legacyHistoryFileExtension) {
+			continue
+		}
+
+		// A legacy file is migrated when the history of its connection is read
+		identifier := strings.TrimSuffix(entry.Name(), extension)
+		if read[identifier] {
+			continue
+		}
+		read[identifier] = true
+
+		items, err := ReadHistory(filepath.Join(historyDirPath, identifier+historyFileExtension), 0)
+		if err != nil {
+			logger.Error("Failed to read query history", map[string]any{"error": err, "connection": identifier})
+			continue
+		}
+
+		for i := range items {
+			if items[i].Connection == "" {
+				items[i].Connection = identifier
+			}
+		}
+		all = append(all, items...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Timestamp.After(all[j].Timestamp)
+	})
+
+	return all, nil
 }
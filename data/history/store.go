@@ -0,0 +1,309 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sqlcmder/logger"
+	"sqlcmder/models"
+)
+
+// A history file holds one JSON record per line, oldest first. Queries are
+// appended to it, and it is compacted down to the newest items once it has
+// doubled in size since its last compaction.
+const (
+	lockFileExtension = ".lock"
+
+	// compactionItemSize estimates the size of an item before a file has
+	// been compacted for the first time
+	compactionItemSize = 512
+)
+
+// historyLock is held on the lock file of a history file while it is read or
+// written, by any sqlcmder instance. The data file itself is not locked, so a
+// compaction can replace it. The lock file holds the size of the history file
+// after its last compaction.
+type historyLock struct {
+	file *os.File
+}
+
+func lockHistory(filePath string, exclusive bool) (*historyLock, error) {
+	file, err := os.OpenFile(filePath+lockFileExtension, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history lock file %s: %w", filePath+lockFileExtension, err)
+	}
+
+	if err := lockFile(file, exclusive); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock history file %s: %w", filePath, err)
+	}
+
+	return &historyLock{file: file}, nil
+}
+
+func (lock *historyLock) release() {
+	if err := unlockFile(lock.file); err != nil {
+		logger.Error("Failed to unlock history file", map[string]any{"path": lock.file.Name(), "error": err})
+	}
+	lock.file.Close()
+}
+
+// compactedSize returns the size of the history file after its last
+// compaction, or 0 if it has not been compacted
+func (lock *historyLock) compactedSize() int64 {
+	data := make([]byte, 32)
+	n, _ := lock.file.ReadAt(data, 0)
+	size, _ := strconv.ParseInt(strings.TrimSpace(string(data[:n])), 10, 64)
+	return size
+}
+
+func (lock *historyLock) setCompactedSize(size int64) error {
+	if err := lock.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := lock.file.WriteAt([]byte(strconv.FormatInt(size, 10)), 0)
+	return err
+}
+
+// readItems reads the items of a history file, oldest first. A record that
+// cannot be decoded, like one cut short by a crash, is skipped so the others
+// are kept.
+func readItems(filePath string) ([]models.QueryHistoryItem, error) {
+	items := []models.QueryHistoryItem{}
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return items, nil
+	}
+	if err != nil {
+		return items, fmt.Errorf("failed to open history file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			var item models.QueryHistoryItem
+			if jsonErr := json.Unmarshal(data, &item); jsonErr != nil || item.QueryText == "" {
+				logger.Warn("Skipping corrupted history record.", map[string]any{"path": filePath, "line": line, "error": jsonErr})
+			} else {
+				items = append(items, item)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return items, fmt.Errorf("failed to read history file %s: %w", filePath, err)
+		}
+	}
+}
+
+// collapse keeps only the latest run of a query run several times in a row
+// in the same database
+func collapse(items []models.QueryHistoryItem) []models.QueryHistoryItem {
+	collapsed := []models.QueryHistoryItem{}
+	for _, item := range items {
+		last := len(collapsed) - 1
+		if last >= 0 && collapsed[last].QueryText == item.QueryText && collapsed[last].Database == item.Database {
+			collapsed[last] = item
+			continue
+		}
+		collapsed = append(collapsed, item)
+	}
+	return collapsed
+}
+
+// appendItem writes an item at the end of a history file, then compacts the
+// file if it has doubled in size since it was last compacted
+func appendItem(filePath string, item models.QueryHistoryItem, limit int) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history item: %w", err)
+	}
+	data = append(data, '\n')
+
+	lock, err := lockHistory(filePath, true)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if err := migrateLegacyHistory(filePath); err != nil {
+		logger.Warn("Failed to migrate legacy history file.", map[string]any{"path": filePath, "error": err})
+	}
+
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat history file %s: %w", filePath, err)
+	}
+
+	// A record cut short by a crash must not swallow the new one
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to append to history file %s: %w", filePath, err)
+	}
+
+	threshold := 2 * lock.compactedSize()
+	if threshold == 0 {
+		threshold = int64(2 * limit * compactionItemSize)
+	}
+
+	if info.Size()+int64(len(data)) > threshold {
+		return compact(filePath, limit, lock)
+	}
+
+	return nil
+}
+
+// compact rewrites a history file with its newest items only. The caller
+// holds the exclusive lock.
+func compact(filePath string, limit int, lock *historyLock) error {
+	items, err := readItems(filePath)
+	if err != nil {
+		return err
+	}
+
+	items = collapse(items)
+	if len(items) > limit {
+		items = items[len(items)-limit:]
+	}
+
+	size, err := writeItems(filePath, items)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Compacted history file.", map[string]any{"path": filePath, "items": len(items)})
+	return lock.setCompactedSize(size)
+}
+
+// writeItems replaces a history file with the given items, returning its size
+func writeItems(filePath string, items []models.QueryHistoryItem) (int64, error) {
+	var buffer bytes.Buffer
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal history item: %w", err)
+		}
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+
+	// The new file is renamed over the old one so a reader never sees it half written
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create history file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buffer.Bytes()); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write history file %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write history file %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return 0, fmt.Errorf("failed to replace history file %s: %w", filePath, err)
+	}
+
+	return int64(buffer.Len()), nil
+}
+
+// legacyHistoryFilePath returns the path of the JSON file a history was kept
+// in before it was appended to
+func legacyHistoryFilePath(filePath string) string {
+	return strings.TrimSuffix(filePath, historyFileExtension) + legacyHistoryFileExtension
+}
+
+// migrateLegacyHistory moves the items of a legacy JSON history file into the
+// history file, keeping the legacy file as a .bak copy. The caller holds the
+// exclusive lock.
+func migrateLegacyHistory(filePath string) error {
+	legacyPath := legacyHistoryFilePath(filePath)
+	if _, err := os.Stat(legacyPath); err != nil {
+		return nil
+	}
+
+	logger.Info("Migrating legacy history file.", map[string]any{"path": legacyPath})
+
+	legacyItems, err := readLegacyItems(legacyPath)
+	if err != nil {
+		return err
+	}
+
+	items, err := readItems(filePath)
+	if err != nil {
+		return err
+	}
+
+	items = append(legacyItems, items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp.Before(items[j].Timestamp)
+	})
+
+	if _, err := writeItems(filePath, items); err != nil {
+		return err
+	}
+
+	return os.Rename(legacyPath, legacyPath+".bak")
+}
+
+// readLegacyItems reads a JSON array of items, keeping the ones before the
+// first corrupted record
+func readLegacyItems(legacyPath string) ([]models.QueryHistoryItem, error) {
+	items := []models.QueryHistoryItem{}
+
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		return items, fmt.Errorf("failed to read legacy history file %s: %w", legacyPath, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return items, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		logger.Warn("Legacy history file is corrupted, no record could be recovered.", map[string]any{"path": legacyPath, "error": err})
+		return items, nil
+	}
+
+	for decoder.More() {
+		var item models.QueryHistoryItem
+		if err := decoder.Decode(&item); err != nil {
+			logger.Warn("Legacy history file is corrupted, recovered the records before it.", map[string]any{"path": legacyPath, "items": len(items), "error": err})
+			break
+		}
+		if item.QueryText != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
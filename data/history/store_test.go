@@ -0,0 +1,189 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sqlcmder/models"
+)
+
+func queryTexts(items []models.QueryHistoryItem) []string {
+	texts := []string{}
+	for _, item := range items {
+		texts = append(texts, item.QueryText)
+	}
+	return texts
+}
+
+func TestAppendItem_ReadsNewestFirst(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "local"+historyFileExtension)
+
+	for _, item := range []models.QueryHistoryItem{
+		{QueryText: "SELECT 1", Database: "app"},
+		{QueryText: "SELECT 2", Database: "app"},
+		{QueryText: "SELECT 2", Database: "app", Rows: 5},
+		{QueryText: "SELECT 2", Database: "shop"},
+	} {
+		if err := appendItem(filePath, item, 100); err != nil {
+			t.Fatalf("appendItem failed: %v", err)
+		}
+	}
+
+	items, err := ReadHistory(filePath, 0)
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+
+	if got, want := strings.Join(queryTexts(items), ","), "SELECT 2,SELECT 2,SELECT 1"; got != want {
+		t.Fatalf("ReadHistory() = %s, want %s", got, want)
+	}
+	if items[1].Rows != 5 {
+		t.Fatalf("expected the latest run of a repeated query, got %+v", items[1])
+	}
+
+	if items, _ := ReadHistory(filePath, 1); len(items) != 1 || items[0].Database != "shop" {
+		t.Fatalf("expected the limit to keep the newest item, got %+v", items)
+	}
+}
+
+func TestReadHistory_RecoversCorruptedRecords(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "local"+historyFileExtension)
+
+	content := `{"QueryText":"SELECT 1"}` + "\n" + "not json\n" + `{"QueryText":"SELECT 2"}` + "\n" + `{"QueryText":"SELECT`
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appendItem(filePath, models.QueryHistoryItem{QueryText: "SELECT 3"}, 100); err != nil {
+		t.Fatalf("appendItem failed: %v", err)
+	}
+
+	items, err := ReadHistory(filePath, 0)
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+
+	if got, want := strings.Join(queryTexts(items), ","), "SELECT 3,SELECT 2,SELECT 1"; got != want {
+		t.Fatalf("ReadHistory() = %s, want %s", got, want)
+	}
+}
+
+func TestAppendItem_Compacts(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "local"+historyFileExtension)
+	limit := 3
+
+	for i := range 200 {
+		item := models.QueryHistoryItem{QueryText: fmt.Sprintf("SELECT %d, '%s'", i, strings.Repeat("x", 100))}
+		if err := appendItem(filePath, item, limit); err != nil {
+			t.Fatalf("appendItem failed: %v", err)
+		}
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxSize := int64(2 * limit * compactionItemSize); info.Size() > maxSize {
+		t.Fatalf("expected the file to be compacted below %d bytes, got %d", maxSize, info.Size())
+	}
+
+	items, err := ReadHistory(filePath, limit)
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if len(items) != limit || !strings.HasPrefix(items[0].QueryText, "SELECT 199,") {
+		t.Fatalf("expected the newest %d items, got %v", limit, queryTexts(items))
+	}
+}
+
+func TestAppendItem_Concurrent(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "local"+historyFileExtension)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := appendItem(filePath, models.QueryHistoryItem{QueryText: fmt.Sprintf("SELECT %d", i)}, 1000); err != nil {
+				t.Errorf("appendItem failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items, err := ReadHistory(filePath, 0)
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if len(items) != 50 {
+		t.Fatalf("expected 50 items, got %d", len(items))
+	}
+}
+
+func TestReadHistory_MigratesLegacyFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "local"+historyFileExtension)
+	legacyPath := legacyHistoryFilePath(filePath)
+
+	now := time.Now().UTC()
+	legacy, err := json.MarshalIndent([]models.QueryHistoryItem{
+		{QueryText: "SELECT 2", Timestamp: now},
+		{QueryText: "SELECT 1", Timestamp: now.Add(-time.Minute)},
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyPath, legacy, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := ReadHistory(filePath, 0)
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if got, want := strings.Join(queryTexts(items), ","), "SELECT 2,SELECT 1"; got != want {
+		t.Fatalf("ReadHistory() = %s, want %s", got, want)
+	}
+
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the legacy file to be moved, got %v", err)
+	}
+	if _, err := os.Stat(legacyPath + ".bak"); err != nil {
+		t.Fatalf("expected a backup of the legacy file: %v", err)
+	}
+}
+
+func TestReadAllHistory(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	localPath, err := GetHistoryFilePath("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appendItem(localPath, models.QueryHistoryItem{QueryText: "SELECT 1", Connection: "local", Timestamp: time.Now().Add(-time.Hour)}, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	prodPath, err := GetHistoryFilePath("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := `[{"QueryText":"SELECT 2","Timestamp":"` + time.Now().UTC().Format(time.RFC3339) + `"}]`
+	if err := os.WriteFile(legacyHistoryFilePath(prodPath), []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := ReadAllHistory()
+	if err != nil {
+		t.Fatalf("ReadAllHistory failed: %v", err)
+	}
+
+	if len(items) != 2 || items[0].QueryText != "SELECT 2" || items[0].Connection != "prod" || items[1].Connection != "local" {
+		t.Fatalf("unexpected items %+v", items)
+	}
+}
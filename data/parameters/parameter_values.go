@@ -0,0 +1,119 @@
+package parameters
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sqlcmder/config"
+	"sqlcmder/data/queries"
+)
+
+const (
+	parametersDirName        = "parameters"
+	sqlcmderConfigDirName    = "sqlcmder"
+	parametersFileExtension  = ".json"
+	maxRememberedQueryValues = 200
+)
+
+// storedValues maps a query to the last values given to its parameters
+type storedValues struct {
+	Queries []queryValues `json:"queries"`
+}
+
+type queryValues struct {
+	Query  string            `json:"query"`
+	Values map[string]string `json:"values"`
+}
+
+// GetParameterValuesFilePath returns the path to the file remembering the
+// parameter values of a connection's queries.
+func GetParameterValuesFilePath(connectionIdentifier string) (string, error) {
+	configDir, err := config.GetConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+
+	parametersDirPath := filepath.Join(configDir, sqlcmderConfigDirName, parametersDirName)
+
+	if err := os.MkdirAll(parametersDirPath, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create parameters directory %s: %w", parametersDirPath, err)
+	}
+
+	return filepath.Join(parametersDirPath, queries.SanitizeFilename(connectionIdentifier)+parametersFileExtension), nil
+}
+
+func readStoredValues(connectionIdentifier string) (storedValues, error) {
+	stored := storedValues{}
+
+	filePath, err := GetParameterValuesFilePath(connectionIdentifier)
+	if err != nil {
+		return stored, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) || (err == nil && len(data) == 0) {
+		return stored, nil
+	}
+	if err != nil {
+		return stored, fmt.Errorf("failed to read parameter values file %s: %w", filePath, err)
+	}
+
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return stored, fmt.Errorf("failed to unmarshal parameter values from %s: %w", filePath, err)
+	}
+
+	return stored, nil
+}
+
+// ReadValues returns the values last given to the parameters of a query.
+func ReadValues(connectionIdentifier, query string) (map[string]string, error) {
+	stored, err := readStoredValues(connectionIdentifier)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	query = strings.TrimSpace(query)
+	for _, item := range stored.Queries {
+		if item.Query == query {
+			return item.Values, nil
+		}
+	}
+
+	return map[string]string{}, nil
+}
+
+// SaveValues remembers the values given to the parameters of a query. The
+// most recently used queries are kept first.
+func SaveValues(connectionIdentifier, query string, values map[string]string) error {
+	stored, err := readStoredValues(connectionIdentifier)
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSpace(query)
+	remembered := []queryValues{{Query: query, Values: values}}
+	for _, item := range stored.Queries {
+		if item.Query != query && len(remembered) < maxRememberedQueryValues {
+			remembered = append(remembered, item)
+		}
+	}
+
+	data, err := json.MarshalIndent(storedValues{Queries: remembered}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameter values: %w", err)
+	}
+
+	filePath, err := GetParameterValuesFilePath(connectionIdentifier)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write parameter values file %s: %w", filePath, err)
+	}
+
+	return nil
+}
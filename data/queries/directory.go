@@ -0,0 +1,264 @@
+package queries
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"sqlcmder/logger"
+	"sqlcmder/models"
+)
+
+// ScopeDirectory holds queries stored as .sql files in a directory, one per
+// query, so they can be shared through a repository
+const ScopeDirectory = "directory"
+
+const sqlFileExtension = ".sql"
+
+// Keys of the comment header of a .sql file
+const (
+	headerName        = "name"
+	headerDescription = "description"
+	headerTags        = "tags"
+	headerConnection  = "connection"
+)
+
+var headerKeys = []string{headerName, headerDescription, headerTags, headerConnection}
+
+// ExpandDirectory resolves a leading ~ of a configured directory.
+func ExpandDirectory(directory string) string {
+	if directory == "~" || strings.HasPrefix(directory, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(directory, "~"))
+		}
+	}
+	return directory
+}
+
+// parseQueryFile reads a query from the content of a .sql file. The leading
+// comments of the form "-- key: value" hold its name, description, tags and
+// target connection; other comments belong to the query.
+func parseQueryFile(content string) models.SavedQuery {
+	query := models.SavedQuery{}
+
+	lines := strings.SplitAfter(content, "\n")
+	body := 0
+	for ; body < len(lines); body++ {
+		line := strings.TrimSpace(lines[body])
+
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			break
+		}
+
+		key, value, ok := strings.Cut(comment, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || !slices.Contains(headerKeys, key) {
+			// A comment like "-- TODO: ..." starts the query
+			break
+		}
+
+		value = strings.TrimSpace(value)
+		switch key {
+		case headerName:
+			query.Name = value
+		case headerDescription:
+			query.Description = value
+		case headerTags:
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					query.Tags = append(query.Tags, tag)
+				}
+			}
+		case headerConnection:
+			query.Connection = value
+		}
+	}
+
+	query.Query = strings.TrimSpace(strings.Join(lines[body:], ""))
+
+	return query
+}
+
+// formatQueryFile returns the content of the .sql file of a query
+func formatQueryFile(query models.SavedQuery) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "-- %s: %s\n", headerName, query.Name)
+	if query.Description != "" {
+		fmt.Fprintf(&builder, "-- %s: %s\n", headerDescription, query.Description)
+	}
+	if len(query.Tags) > 0 {
+		fmt.Fprintf(&builder, "-- %s: %s\n", headerTags, strings.Join(query.Tags, ", "))
+	}
+	if query.Connection != "" {
+		fmt.Fprintf(&builder, "-- %s: %s\n", headerConnection, query.Connection)
+	}
+
+	builder.WriteString(strings.TrimSpace(query.Query))
+	builder.WriteString("\n")
+
+	return builder.String()
+}
+
+// readDirectory reads the .sql files of a directory and its subdirectories,
+// which become the folders of the queries.
+func readDirectory(directory string) ([]models.SavedQuery, error) {
+	directoryQueries := []models.SavedQuery{}
+
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == directory && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != directory && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(path), sqlFileExtension) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read saved query file %s: %w", path, err)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		query := parseQueryFile(string(content))
+		if query.Name == "" {
+			query.Name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+
+		if folder, _ := filepath.Rel(directory, filepath.Dir(path)); folder != "." {
+			query.Folder = filepath.ToSlash(folder)
+		}
+
+		query.File = path
+		query.Scope = ScopeDirectory
+		query.CreatedAt, query.UpdatedAt = info.ModTime(), info.ModTime()
+
+		directoryQueries = append(directoryQueries, query)
+		return nil
+	})
+
+	return directoryQueries, err
+}
+
+// queryFilePath returns the path of the file a query is written to in a directory
+func queryFilePath(directory string, query models.SavedQuery) string {
+	return filepath.Join(directory, filepath.FromSlash(query.Folder), SanitizeFilename(query.Name)+sqlFileExtension)
+}
+
+func writeQueryFile(path string, query models.SavedQuery) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create saved queries directory %s: %w", filepath.Dir(path), err)
+	}
+
+	if err := os.WriteFile(path, []byte(formatQueryFile(query)), 0o644); err != nil {
+		return fmt.Errorf("failed to write saved query file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readDirectories reads the queries of the library's directories meant for
+// its connection, leaving out the ones targeting another connection.
+func (library Library) readDirectories() ([]models.SavedQuery, error) {
+	directoryQueries := []models.SavedQuery{}
+
+	for _, directory := range library.Directories {
+		logger.Info("Reading saved queries from directory", map[string]any{"directory": directory})
+
+		read, err := readDirectory(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, query := range read {
+			if query.Connection == "" || strings.EqualFold(query.Connection, library.ConnectionIdentifier) {
+				directoryQueries = append(directoryQueries, query)
+			}
+		}
+	}
+
+	return directoryQueries, nil
+}
+
+// directoryOf returns the library directory a query file is in, or the first
+// directory for a query that has no file yet
+func (library Library) directoryOf(file string) string {
+	for _, directory := range library.Directories {
+		if relative, err := filepath.Rel(directory, file); err == nil && !strings.HasPrefix(relative, "..") {
+			return directory
+		}
+	}
+	return library.Directories[0]
+}
+
+// saveFile writes a query to a file of the library's directories, replacing
+// the file of the original query when it is renamed.
+func (library Library) saveFile(original *models.SavedQuery, query models.SavedQuery) error {
+	if len(library.Directories) == 0 {
+		return fmt.Errorf("no saved queries directory is configured")
+	}
+
+	path := queryFilePath(library.Directories[0], query)
+	if original != nil && original.File != "" {
+		path = original.File
+		if original.Path() != query.Path() {
+			path = queryFilePath(library.directoryOf(original.File), query)
+		}
+	}
+
+	if original == nil || original.File != path {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("a query file %s already exists", path)
+		}
+	}
+
+	if err := writeQueryFile(path, query); err != nil {
+		return err
+	}
+
+	if original != nil && original.File != "" && original.File != path {
+		return os.Remove(original.File)
+	}
+
+	return nil
+}
+
+// Signature changes whenever a .sql file of the library's directories is
+// added, removed or written, to reload queries edited outside the application.
+func (library Library) Signature() string {
+	hash := sha256.New()
+
+	for _, directory := range library.Directories {
+		_ = filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(path), sqlFileExtension) {
+				return nil
+			}
+
+			if info, err := entry.Info(); err == nil {
+				fmt.Fprintf(hash, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		})
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
@@ -0,0 +1,138 @@
+package queries
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestParseQueryFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    models.SavedQuery
+	}{
+		{
+			name:    "header",
+			content: "-- name: active users\n-- description: Users seen this week\n-- tags: users, weekly\n-- connection: prod\nSELECT *\nFROM users;\n",
+			want: models.SavedQuery{
+				Name:        "active users",
+				Description: "Users seen this week",
+				Tags:        []string{"users", "weekly"},
+				Connection:  "prod",
+				Query:       "SELECT *\nFROM users;",
+			},
+		},
+		{
+			name:    "other comments belong to the query",
+			content: "-- Name: locks\n-- TODO: filter idle\nSELECT * FROM pg_locks\n",
+			want:    models.SavedQuery{Name: "locks", Query: "-- TODO: filter idle\nSELECT * FROM pg_locks"},
+		},
+		{
+			name:    "no header",
+			content: "SELECT 1\n",
+			want:    models.SavedQuery{Query: "SELECT 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseQueryFile(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseQueryFile() = %+v, want %+v", got, tt.want)
+			}
+
+			if tt.want.Name != "" {
+				if again := parseQueryFile(formatQueryFile(got)); !reflect.DeepEqual(again, got) {
+					t.Fatalf("formatted query parsed as %+v, want %+v", again, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLibrary_Directory(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	directory := t.TempDir()
+	library := NewLibrary("local", "postgres")
+	library.Directories = []string{directory}
+
+	files := map[string]string{
+		"users.sql":          "-- description: All users\nSELECT * FROM users\n",
+		"reports/sales.sql":  "-- name: monthly sales\n-- connection: LOCAL\nSELECT 1\n",
+		"reports/other.sql":  "-- connection: prod\nSELECT 2\n",
+		".git/ignored.sql":   "SELECT 3\n",
+		"reports/readme.txt": "not a query",
+	}
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := library.All()
+	if err != nil {
+		t.Fatalf("All failed: %v", err)
+	}
+
+	paths := []string{}
+	for _, query := range all {
+		if query.Scope != ScopeDirectory || query.File == "" {
+			t.Fatalf("expected a query of the directory, got %+v", query)
+		}
+		paths = append(paths, query.Path())
+	}
+	if want := []string{"reports/monthly sales", "users"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+
+	signature := library.Signature()
+
+	sales := all[0]
+	renamed := sales
+	renamed.Name = "sales by month"
+	if err := library.Update(sales, renamed); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if _, err := os.Stat(sales.File); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, got %v", sales.File, err)
+	}
+	content, err := os.ReadFile(filepath.Join(directory, "reports", "sales_by_month.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseQueryFile(string(content)); got.Name != "sales by month" || got.Connection != "LOCAL" {
+		t.Fatalf("unexpected renamed query %+v", got)
+	}
+
+	if library.Signature() == signature {
+		t.Fatalf("expected the signature to change after a rename")
+	}
+
+	if err := library.Save(models.SavedQuery{Name: "users", Query: "SELECT 4", Scope: ScopeDirectory}); err == nil {
+		t.Fatalf("expected saving over an existing file to fail")
+	}
+
+	moved := all[1]
+	moved.Scope = ScopeGlobal
+	if err := library.Update(all[1], moved); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	global, err := library.Read(ScopeGlobal)
+	if err != nil || len(global) != 1 || global[0].Description != "All users" {
+		t.Fatalf("expected the query to move to the global scope, got %+v (%v)", global, err)
+	}
+	if _, err := os.Stat(all[1].File); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, got %v", all[1].File, err)
+	}
+}
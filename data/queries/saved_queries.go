@@ -5,9 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/lithammer/fuzzysearch/fuzzy"
 
 	"sqlcmder/config"
 	"sqlcmder/logger"
@@ -18,8 +21,31 @@ const (
 	SavedQueriesDirName       = "saved_queries"
 	sqlcmderConfigDirName     = "sqlcmder"
 	savedQueriesFileExtension = ".toml"
+
+	// sharedDirName holds the driver and global scopes, apart from the
+	// connection files so their names cannot collide
+	sharedDirName = "shared"
+
+	// savedQueriesVersion is the version of the saved queries file format.
+	// Files without a version hold only names and queries.
+	savedQueriesVersion = 2
+)
+
+// Scopes a saved query can be shared in
+const (
+	ScopeConnection = "connection"
+	ScopeDriver     = "driver"
+	ScopeGlobal     = "global"
 )
 
+// Scopes lists the scopes from the most to the least specific
+var Scopes = []string{ScopeConnection, ScopeDriver, ScopeGlobal}
+
+type savedQueriesFile struct {
+	Version int                 `toml:"version"`
+	Queries []models.SavedQuery `toml:"queries"`
+}
+
 // GetAppConfigDir returns the application's configuration directory.
 func GetAppConfigDir() (string, error) {
 	configDir, err := config.GetConfigPath()
@@ -73,17 +99,92 @@ func GetSavedQueriesFilePath(connectionIdentifier string) (string, error) {
 	return filepath.Join(savedQueriesDirPath, sanitizedIdentifier+savedQueriesFileExtension), nil
 }
 
-// ReadSavedQueries reads the saved queries from the TOML file for a specific connection.
-func ReadSavedQueries(connectionIdentifier string) ([]models.SavedQuery, error) {
-	filePath, err := GetSavedQueriesFilePath(connectionIdentifier)
+// getSharedFilePath returns the path to the saved queries file of a driver,
+// or of the global scope when the driver is empty.
+func getSharedFilePath(driver string) (string, error) {
+	appConfigDir, err := GetAppConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get app config dir: %w", err)
+	}
+
+	sharedDirPath := filepath.Join(appConfigDir, SavedQueriesDirName, sharedDirName)
+	if err := os.MkdirAll(sharedDirPath, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create saved queries directory %s: %w", sharedDirPath, err)
+	}
+
+	name := ScopeGlobal
+	if driver != "" {
+		name = "driver_" + SanitizeFilename(driver)
+	}
+
+	return filepath.Join(sharedDirPath, name+savedQueriesFileExtension), nil
+}
+
+// Library gives access to the saved queries a connection can use: its own,
+// the ones shared by connections of the same driver, the global ones and the
+// ones of its directories of .sql files.
+type Library struct {
+	ConnectionIdentifier string
+	Driver               string
+	Directories          []string
+}
+
+// NewLibrary creates the library of a connection.
+func NewLibrary(connectionIdentifier, driver string) Library {
+	return Library{ConnectionIdentifier: connectionIdentifier, Driver: driver}
+}
+
+// AvailableScopes returns the scopes queries of the library can be saved in
+func (library Library) AvailableScopes() []string {
+	scopes := []string{}
+	for _, scope := range Scopes {
+		if scope != ScopeDriver || library.Driver != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if len(library.Directories) > 0 {
+		scopes = append(scopes, ScopeDirectory)
+	}
+
+	return scopes
+}
+
+func (library Library) filePath(scope string) (string, error) {
+	switch scope {
+	case ScopeConnection:
+		return GetSavedQueriesFilePath(library.ConnectionIdentifier)
+	case ScopeDriver:
+		if library.Driver == "" {
+			return "", fmt.Errorf("the driver scope needs a driver")
+		}
+		return getSharedFilePath(library.Driver)
+	case ScopeGlobal:
+		return getSharedFilePath("")
+	}
+
+	return "", fmt.Errorf("unknown saved queries scope '%s'", scope)
+}
+
+// Read reads the saved queries of a scope, migrating files of older versions.
+func (library Library) Read(scope string) ([]models.SavedQuery, error) {
+	if scope == ScopeDirectory {
+		return library.readDirectories()
+	}
+
+	filePath, err := library.filePath(scope)
 	if err != nil {
 		return nil, err
 	}
 	logger.Info("Reading saved queries from file", map[string]any{"file": filePath})
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
 		return []models.SavedQuery{}, nil
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved queries file %s: %w", filePath, err)
+	}
 
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -94,63 +195,206 @@ func ReadSavedQueries(connectionIdentifier string) ([]models.SavedQuery, error)
 		return []models.SavedQuery{}, nil
 	}
 
-	var savedQueries struct {
-		Queries []models.SavedQuery `toml:"queries"`
+	var file savedQueriesFile
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal saved queries from %s: %w", filePath, err)
 	}
 
-	if err := toml.Unmarshal(data, &savedQueries); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal saved queries from %s: %w", filePath, err)
+	if file.Version < savedQueriesVersion {
+		if err := migrate(filePath, data, file.Queries, info.ModTime()); err != nil {
+			return nil, err
+		}
 	}
 
-	return savedQueries.Queries, nil
+	for i := range file.Queries {
+		file.Queries[i].Scope = scope
+	}
+
+	return file.Queries, nil
 }
 
-// SaveQuery saves a new query to the TOML file for a specific connection.
-func SaveQuery(connectionIdentifier, name, query string) error {
-	savedQueries, err := ReadSavedQueries(connectionIdentifier)
+// migrate rewrites a file of an older version, keeping a copy of it. Queries
+// saved before timestamps existed get the time the file was last written.
+func migrate(filePath string, data []byte, queries []models.SavedQuery, modTime time.Time) error {
+	logger.Info("Migrating saved queries file", map[string]any{"file": filePath})
+
+	if err := os.WriteFile(filePath+".bak", data, 0o600); err != nil {
+		return fmt.Errorf("failed to back up saved queries file %s: %w", filePath, err)
+	}
+
+	for i := range queries {
+		if queries[i].CreatedAt.IsZero() {
+			queries[i].CreatedAt = modTime
+		}
+		if queries[i].UpdatedAt.IsZero() {
+			queries[i].UpdatedAt = queries[i].CreatedAt
+		}
+	}
+
+	return writeFile(filePath, queries)
+}
+
+// All reads the saved queries of every scope, sorted by folder and name.
+func (library Library) All() ([]models.SavedQuery, error) {
+	all := []models.SavedQuery{}
+
+	for _, scope := range library.AvailableScopes() {
+		scopeQueries, err := library.Read(scope)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, scopeQueries...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return strings.ToLower(all[i].Path()) < strings.ToLower(all[j].Path())
+	})
+
+	return all, nil
+}
+
+// Save adds a query to the library in the scope of the query.
+func (library Library) Save(query models.SavedQuery) error {
+	if query.Scope == ScopeDirectory {
+		return library.saveFile(nil, query)
+	}
+
+	savedQueries, err := library.Read(query.Scope)
 	if err != nil {
 		return err
 	}
 
 	for _, q := range savedQueries {
-		if q.Name == name {
-			return fmt.Errorf("a query with the name '%s' already exists", name)
+		if q.Path() == query.Path() {
+			return fmt.Errorf("a query with the name '%s' already exists", query.Path())
 		}
 	}
 
-	newQuery := models.SavedQuery{Name: name, Query: query}
-	savedQueries = append(savedQueries, newQuery)
+	query.UpdatedAt = time.Now()
+	if query.CreatedAt.IsZero() {
+		query.CreatedAt = query.UpdatedAt
+	}
+	query.File = ""
+	savedQueries = append(savedQueries, query)
+
+	return library.write(query.Scope, savedQueries)
+}
 
-	return writeSavedQueries(connectionIdentifier, savedQueries)
+// Replace writes queries as every saved query of a scope, like the ones of
+// an imported bundle.
+func (library Library) Replace(scope string, queries []models.SavedQuery) error {
+	if scope == ScopeDirectory {
+		return fmt.Errorf("the queries of directories cannot be replaced")
+	}
+	return library.write(scope, queries)
 }
 
-// DeleteSavedQuery deletes a saved query from the TOML file for a specific connection.
-func DeleteSavedQuery(connectionIdentifier, name string) error {
-	savedQueries, err := ReadSavedQueries(connectionIdentifier)
+// Update replaces a saved query, which renames it, moves it to another
+// folder or scope or edits it in place.
+func (library Library) Update(original, updated models.SavedQuery) error {
+	if original.Scope != updated.Scope || original.Path() != updated.Path() {
+		existing, err := library.Read(updated.Scope)
+		if err != nil {
+			return err
+		}
+
+		for _, q := range existing {
+			if q.Path() == updated.Path() {
+				return fmt.Errorf("a query with the name '%s' already exists", updated.Path())
+			}
+		}
+	}
+
+	// Files keep their own timestamps, so a query moving from or to a
+	// directory is saved again and removed from where it was
+	if original.Scope == ScopeDirectory || updated.Scope == ScopeDirectory {
+		if original.Scope == ScopeDirectory && updated.Scope == ScopeDirectory {
+			return library.saveFile(&original, updated)
+		}
+
+		if err := library.Save(updated); err != nil {
+			return err
+		}
+		return library.Delete(original)
+	}
+
+	savedQueries, err := library.Read(original.Scope)
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, q := range savedQueries {
+		if q.Path() == original.Path() {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		return fmt.Errorf("a query with the name '%s' does not exist", original.Path())
+	}
+
+	updated.CreatedAt = savedQueries[index].CreatedAt
+	updated.UpdatedAt = time.Now()
+
+	if original.Scope == updated.Scope {
+		savedQueries[index] = updated
+		return library.write(updated.Scope, savedQueries)
+	}
+
+	target, err := library.Read(updated.Scope)
+	if err != nil {
+		return err
+	}
+
+	if err := library.write(updated.Scope, append(target, updated)); err != nil {
+		return err
+	}
+
+	return library.write(original.Scope, append(savedQueries[:index], savedQueries[index+1:]...))
+}
+
+// Delete removes a saved query from its scope.
+func (library Library) Delete(query models.SavedQuery) error {
+	if query.Scope == ScopeDirectory {
+		if query.File == "" {
+			return fmt.Errorf("the query '%s' has no file", query.Path())
+		}
+		if err := os.Remove(query.File); err != nil {
+			return fmt.Errorf("failed to delete saved query file %s: %w", query.File, err)
+		}
+		return nil
+	}
+
+	savedQueries, err := library.Read(query.Scope)
 	if err != nil {
 		return err
 	}
 
 	var newQueries []models.SavedQuery
 	for _, q := range savedQueries {
-		if q.Name != name {
+		if q.Path() != query.Path() {
 			newQueries = append(newQueries, q)
 		}
 	}
 
 	if len(newQueries) == len(savedQueries) {
-		return fmt.Errorf("a query with the name '%s' does not exist", name)
+		return fmt.Errorf("a query with the name '%s' does not exist", query.Path())
 	}
 
-	return writeSavedQueries(connectionIdentifier, newQueries)
+	return library.write(query.Scope, newQueries)
 }
 
-func writeSavedQueries(connectionIdentifier string, queries []models.SavedQuery) error {
-	filePath, err := GetSavedQueriesFilePath(connectionIdentifier)
+func (library Library) write(scope string, queries []models.SavedQuery) error {
+	filePath, err := library.filePath(scope)
 	if err != nil {
 		return err
 	}
+	return writeFile(filePath, queries)
+}
 
+func writeFile(filePath string, queries []models.SavedQuery) error {
 	file, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create saved queries file %s: %w", filePath, err)
@@ -158,7 +402,89 @@ func writeSavedQueries(connectionIdentifier string, queries []models.SavedQuery)
 	defer file.Close()
 
 	encoder := toml.NewEncoder(file)
-	return encoder.Encode(struct {
-		Queries []models.SavedQuery `toml:"queries"`
-	}{Queries: queries})
+	return encoder.Encode(savedQueriesFile{Version: savedQueriesVersion, Queries: queries})
+}
+
+// Search returns the queries matching every word of the text, best matches
+// first. Words are matched fuzzily against the path and tags of a query and
+// as substrings of its description and body.
+func Search(savedQueries []models.SavedQuery, text string) []models.SavedQuery {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return savedQueries
+	}
+
+	type match struct {
+		query models.SavedQuery
+		rank  int
+	}
+
+	matches := []match{}
+	for _, query := range savedQueries {
+		total, matchesAll := 0, true
+
+		for _, word := range words {
+			rank := searchRank(query, word)
+			if rank == -1 {
+				matchesAll = false
+				break
+			}
+			total += rank
+		}
+
+		if matchesAll {
+			matches = append(matches, match{query: query, rank: total})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].rank < matches[j].rank
+	})
+
+	results := make([]models.SavedQuery, len(matches))
+	for i, m := range matches {
+		results[i] = m.query
+	}
+
+	return results
+}
+
+// searchRank returns how well a word matches a query, lower being better,
+// or -1 if it does not match
+func searchRank(query models.SavedQuery, word string) int {
+	best := -1
+	consider := func(rank int) {
+		if rank != -1 && (best == -1 || rank < best) {
+			best = rank
+		}
+	}
+
+	consider(fuzzy.RankMatchNormalizedFold(word, query.Path()))
+	for _, tag := range query.Tags {
+		consider(fuzzy.RankMatchNormalizedFold(word, tag))
+	}
+
+	// The body and description only match as substrings, ranked after
+	// names and tags
+	lowerWord := strings.ToLower(word)
+	if strings.Contains(strings.ToLower(query.Description), lowerWord) || strings.Contains(strings.ToLower(query.Query), lowerWord) {
+		consider(1000)
+	}
+
+	return best
+}
+
+// ReadSavedQueries reads the saved queries of a specific connection.
+func ReadSavedQueries(connectionIdentifier string) ([]models.SavedQuery, error) {
+	return NewLibrary(connectionIdentifier, "").Read(ScopeConnection)
+}
+
+// SaveQuery saves a new query for a specific connection.
+func SaveQuery(connectionIdentifier, name, query string) error {
+	return NewLibrary(connectionIdentifier, "").Save(models.SavedQuery{Name: name, Query: query, Scope: ScopeConnection})
+}
+
+// DeleteSavedQuery deletes a saved query of a specific connection.
+func DeleteSavedQuery(connectionIdentifier, name string) error {
+	return NewLibrary(connectionIdentifier, "").Delete(models.SavedQuery{Name: name, Scope: ScopeConnection})
 }
@@ -0,0 +1,136 @@
+package queries
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+
+	"sqlcmder/models"
+)
+
+func TestLibrary_MigratesLegacyFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	filePath, err := GetSavedQueriesFilePath("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := "[[queries]]\nname = \"users\"\nquery = \"SELECT * FROM users\"\n"
+	if err := os.WriteFile(filePath, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	savedQueries, err := NewLibrary("local", "postgres").Read(ScopeConnection)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if len(savedQueries) != 1 || savedQueries[0].Name != "users" || savedQueries[0].CreatedAt.IsZero() || savedQueries[0].Scope != ScopeConnection {
+		t.Fatalf("unexpected queries after migration: %+v", savedQueries)
+	}
+
+	if backup, err := os.ReadFile(filePath + ".bak"); err != nil || string(backup) != legacy {
+		t.Fatalf("expected a backup of the legacy file, got %q (%v)", backup, err)
+	}
+
+	var file savedQueriesFile
+	data, _ := os.ReadFile(filePath)
+	if err := toml.Unmarshal(data, &file); err != nil || file.Version != savedQueriesVersion {
+		t.Fatalf("expected the file to be rewritten with version %d, got %d (%v)", savedQueriesVersion, file.Version, err)
+	}
+}
+
+func TestLibrary_Scopes(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	library := NewLibrary("local", "postgres")
+	other := NewLibrary("other", "postgres")
+
+	for _, query := range []models.SavedQuery{
+		{Name: "mine", Query: "SELECT 1", Scope: ScopeConnection},
+		{Name: "locks", Folder: "admin", Tags: []string{"ops"}, Query: "SELECT * FROM pg_locks", Scope: ScopeDriver},
+		{Name: "now", Query: "SELECT now()", Scope: ScopeGlobal},
+	} {
+		if err := library.Save(query); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	if err := library.Save(models.SavedQuery{Name: "now", Scope: ScopeGlobal}); err == nil {
+		t.Fatal("expected an error saving a duplicate name")
+	}
+
+	all, err := other.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Path() != "admin/locks" || all[1].Path() != "now" {
+		t.Fatalf("expected the shared queries only, got %+v", all)
+	}
+
+	if _, err := os.Stat(filepath.Join(configDir, sqlcmderConfigDirName, SavedQueriesDirName, sharedDirName, "driver_postgres.toml")); err != nil {
+		t.Fatalf("expected a driver file: %v", err)
+	}
+
+	original := all[0]
+	moved := original
+	moved.Name, moved.Folder, moved.Scope = "pg_locks", "", ScopeConnection
+	if err := other.Update(original, moved); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	mine, _ := other.Read(ScopeConnection)
+	if len(mine) != 1 || mine[0].Name != "pg_locks" || !mine[0].CreatedAt.Equal(original.CreatedAt) || !mine[0].UpdatedAt.After(original.UpdatedAt) {
+		t.Fatalf("unexpected query after moving: %+v", mine)
+	}
+
+	if driverQueries, _ := other.Read(ScopeDriver); len(driverQueries) != 0 {
+		t.Fatalf("expected the query to leave the driver scope, got %+v", driverQueries)
+	}
+
+	if err := other.Delete(mine[0]); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	savedQueries := []models.SavedQuery{
+		{Name: "orders by customer", Query: "SELECT * FROM orders WHERE customer_id = :id"},
+		{Name: "revenue", Folder: "reports", Tags: []string{"finance"}, Query: "SELECT sum(total) FROM orders"},
+		{Name: "users", Query: "SELECT * FROM users"},
+	}
+
+	testCases := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "", expected: []string{"orders by customer", "revenue", "users"}},
+		{text: "ordcust", expected: []string{"orders by customer"}},
+		{text: "finance", expected: []string{"revenue"}},
+		{text: "orders", expected: []string{"orders by customer", "revenue"}},
+		{text: "rep rev", expected: []string{"revenue"}},
+		{text: "missing", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			names := []string{}
+			for _, query := range Search(savedQueries, tc.text) {
+				names = append(names, query.Name)
+			}
+
+			if len(names) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, names)
+			}
+			for i := range names {
+				if names[i] != tc.expected[i] {
+					t.Fatalf("expected %v, got %v", tc.expected, names)
+				}
+			}
+		})
+	}
+}
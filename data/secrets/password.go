@@ -0,0 +1,216 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"sqlcmder/models"
+)
+
+const (
+	// VaultPassphraseEnv holds the master passphrase of the vault, so it is not
+	// asked for
+	VaultPassphraseEnv = "SQLCMDER_VAULT_PASSPHRASE"
+
+	passwordCommandTimeout = 30 * time.Second
+)
+
+// Prompt asks the user for a secret, the label telling which one. It is
+// called off the UI goroutine and blocks until the user answers.
+type Prompt func(label string) (string, error)
+
+// ErrNoPrompt is returned when a secret has to be asked for but there is no
+// way to ask for it.
+var ErrNoPrompt = errors.New("no prompt to ask for the password")
+
+var (
+	vaultMu       sync.Mutex
+	unlockedVault *Vault
+
+	// Passwords asked for are kept for the lifetime of the process only
+	promptedMu        sync.Mutex
+	promptedPasswords = map[string]string{}
+)
+
+// Password returns the password of a connection, read from its password
+// source. A connection without a source has the password it holds, if any.
+func Password(connection models.Connection, prompt Prompt) (string, error) {
+	switch connection.PasswordSource {
+	case "":
+		return connection.Password, nil
+
+	case models.PasswordSourcePrompt:
+		return promptedPassword(connection, prompt)
+
+	case models.PasswordSourceEnv:
+		if connection.PasswordEnv == "" {
+			return "", fmt.Errorf("no environment variable set for the password of %s", connection.Name)
+		}
+		password, ok := os.LookupEnv(connection.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", connection.PasswordEnv)
+		}
+		return password, nil
+
+	case models.PasswordSourceCommand:
+		return runPasswordCommand(connection.PasswordCommand)
+
+	case models.PasswordSourceVault:
+		vault, err := UnlockVault(prompt)
+		if err != nil {
+			return "", err
+		}
+		password, ok := vault.Get(connection.Name)
+		if !ok {
+			return "", fmt.Errorf("no password for %s in the vault", connection.Name)
+		}
+		return password, nil
+	}
+
+	return "", fmt.Errorf("unknown password source %q", connection.PasswordSource)
+}
+
+// promptedPassword asks for the password of a connection, unless it was
+// asked for already
+func promptedPassword(connection models.Connection, prompt Prompt) (string, error) {
+	promptedMu.Lock()
+	password, ok := promptedPasswords[connection.Name]
+	promptedMu.Unlock()
+
+	if ok {
+		return password, nil
+	}
+
+	if prompt == nil {
+		return "", ErrNoPrompt
+	}
+
+	label := "Password"
+	if connection.Name != "" {
+		label = "Password of " + connection.Name
+	}
+
+	password, err := prompt(label)
+	if err != nil {
+		return "", err
+	}
+
+	promptedMu.Lock()
+	promptedPasswords[connection.Name] = password
+	promptedMu.Unlock()
+
+	return password, nil
+}
+
+// Forget drops the password asked for a connection, so it is asked for again,
+// like after it was mistyped.
+func Forget(name string) {
+	promptedMu.Lock()
+	defer promptedMu.Unlock()
+
+	delete(promptedPasswords, name)
+}
+
+// ForgetAll drops every password asked for and locks the vault again.
+func ForgetAll() {
+	promptedMu.Lock()
+	clear(promptedPasswords)
+	promptedMu.Unlock()
+
+	vaultMu.Lock()
+	unlockedVault = nil
+	vaultMu.Unlock()
+}
+
+// UnlockVault opens the default vault, asking for its passphrase unless it
+// is set in the environment. The vault stays unlocked for the lifetime of
+// the process.
+func UnlockVault(prompt Prompt) (*Vault, error) {
+	vaultMu.Lock()
+	defer vaultMu.Unlock()
+
+	if unlockedVault != nil {
+		return unlockedVault, nil
+	}
+
+	path, err := DefaultVaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, ok := os.LookupEnv(VaultPassphraseEnv)
+	if !ok {
+		if prompt == nil {
+			return nil, ErrNoPrompt
+		}
+
+		_, statErr := os.Stat(path)
+		creating := os.IsNotExist(statErr)
+
+		label := "Vault passphrase"
+		if creating {
+			label = "New vault passphrase"
+		}
+
+		if passphrase, err = prompt(label); err != nil {
+			return nil, err
+		}
+
+		// A mistyped new passphrase would lock the vault for good
+		if creating {
+			confirmation, err := prompt("Repeat new vault passphrase")
+			if err != nil {
+				return nil, err
+			}
+			if confirmation != passphrase {
+				return nil, errors.New("the vault passphrases do not match")
+			}
+		}
+	}
+
+	vault, err := OpenVault(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	unlockedVault = vault
+	return vault, nil
+}
+
+// runPasswordCommand runs a command printing a password, like a password
+// manager, and returns the first line of its output. The command is run by
+// the shell, so it can quote arguments and paths with spaces.
+func runPasswordCommand(command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("no password command set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command) // #nosec G204
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("password command failed: %w: %s", err, message)
+		}
+		return "", fmt.Errorf("password command failed: %w", err)
+	}
+
+	password, _, _ := strings.Cut(stdout.String(), "\n")
+	return strings.TrimRight(password, "\r"), nil
+}
@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestPassword(t *testing.T) {
+	t.Cleanup(ForgetAll)
+	t.Setenv("SQLCMDER_TEST_PASSWORD", "from-env")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(VaultPassphraseEnv, "master")
+
+	vault, err := UnlockVault(nil)
+	if err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+	if err := vault.Set("prod", "from-vault"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	prompt := func(label string) (string, error) {
+		return "from-prompt", nil
+	}
+
+	tests := []struct {
+		name       string
+		connection models.Connection
+		want       string
+		wantErr    bool
+	}{
+		{"no source", models.Connection{Password: "kept"}, "kept", false},
+		{"prompt", models.Connection{PasswordSource: models.PasswordSourcePrompt}, "from-prompt", false},
+		{"env", models.Connection{PasswordSource: models.PasswordSourceEnv, PasswordEnv: "SQLCMDER_TEST_PASSWORD"}, "from-env", false},
+		{"unset env", models.Connection{PasswordSource: models.PasswordSourceEnv, PasswordEnv: "SQLCMDER_TEST_UNSET"}, "", true},
+		{"command", models.Connection{PasswordSource: models.PasswordSourceCommand, PasswordCommand: "echo from-command"}, "from-command", false},
+		{"failing command", models.Connection{PasswordSource: models.PasswordSourceCommand, PasswordCommand: "false"}, "", true},
+		{"quoted command", models.Connection{PasswordSource: models.PasswordSourceCommand, PasswordCommand: `printf '%s\n' "two words"`}, "two words", false},
+		{"vault", models.Connection{Name: "prod", PasswordSource: models.PasswordSourceVault}, "from-vault", false},
+		{"not in vault", models.Connection{Name: "dev", PasswordSource: models.PasswordSourceVault}, "", true},
+		{"unknown source", models.Connection{PasswordSource: "keyring"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Password(tt.connection, prompt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Password() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Password() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptedPasswordIsCached(t *testing.T) {
+	t.Cleanup(ForgetAll)
+
+	prompts := 0
+	prompt := func(label string) (string, error) {
+		prompts++
+		return "typed", nil
+	}
+
+	connection := models.Connection{Name: "prod", PasswordSource: models.PasswordSourcePrompt}
+
+	for range 2 {
+		if got, err := Password(connection, prompt); err != nil || got != "typed" {
+			t.Fatalf("Password() = %q, %v, want typed", got, err)
+		}
+	}
+	if prompts != 1 {
+		t.Fatalf("password asked for %d times, want once", prompts)
+	}
+
+	Forget("prod")
+	if _, err := Password(connection, prompt); err != nil {
+		t.Fatalf("Password() error = %v", err)
+	}
+	if prompts != 2 {
+		t.Fatalf("password asked for %d times after Forget, want twice", prompts)
+	}
+
+	ForgetAll()
+	if _, err := Password(connection, nil); err != ErrNoPrompt {
+		t.Fatalf("Password() after ForgetAll error = %v, want %v", err, ErrNoPrompt)
+	}
+}
+
+func TestUnlockVaultConfirmsNewPassphrase(t *testing.T) {
+	t.Cleanup(ForgetAll)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(VaultPassphraseEnv, "")
+	os.Unsetenv(VaultPassphraseEnv)
+
+	answers := []string{"master", "mistyped"}
+	labels := []string{}
+	prompt := func(label string) (string, error) {
+		labels = append(labels, label)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+
+	if _, err := UnlockVault(prompt); err == nil {
+		t.Fatal("UnlockVault() error = nil for passphrases that do not match")
+	}
+
+	want := []string{"New vault passphrase", "Repeat new vault passphrase"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Fatalf("prompts = %q, want %q", labels, want)
+	}
+
+	answers = []string{"master", "master"}
+	if _, err := UnlockVault(prompt); err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+
+	"sqlcmder/config"
+)
+
+const (
+	vaultFileName = "vault.json"
+	vaultVersion  = 1
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keySize = 32
+)
+
+// ErrWrongPassphrase is returned when a vault cannot be decrypted with the
+// passphrase it is opened with.
+var ErrWrongPassphrase = errors.New("wrong vault passphrase")
+
+// vaultFile is the content of a vault file. The passwords are encrypted with
+// AES-GCM, using a key derived from the master passphrase with scrypt.
+type vaultFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Vault holds the passwords of connections, by connection name, in a file
+// encrypted with a master passphrase.
+type Vault struct {
+	mu         sync.Mutex
+	path       string
+	passphrase string
+	passwords  map[string]string
+}
+
+// DefaultVaultPath returns the path of the vault in the config directory.
+func DefaultVaultPath() (string, error) {
+	configDir, err := config.GetConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "sqlcmder", vaultFileName), nil
+}
+
+// OpenVault decrypts the vault at the given path. A vault that does not exist
+// yet is empty, and is created with the passphrase when it is first saved.
+func OpenVault(path string, passphrase string) (*Vault, error) {
+	vault := &Vault{path: path, passphrase: passphrase, passwords: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return vault, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault %s: %w", path, err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode vault %s: %w", path, err)
+	}
+	if file.Version != vaultVersion {
+		return nil, fmt.Errorf("unsupported vault version %d", file.Version)
+	}
+
+	gcm, err := newCipher(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	if err := json.Unmarshal(plaintext, &vault.passwords); err != nil {
+		return nil, fmt.Errorf("failed to decode vault %s: %w", path, err)
+	}
+
+	return vault, nil
+}
+
+// Get returns the password of a connection.
+func (v *Vault) Get(name string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	password, ok := v.passwords[name]
+	return password, ok
+}
+
+// Set stores the password of a connection and saves the vault.
+func (v *Vault) Set(name string, password string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.passwords[name] = password
+	return v.save()
+}
+
+// Delete removes the password of a connection and saves the vault.
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.passwords[name]; !ok {
+		return nil
+	}
+
+	delete(v.passwords, name)
+	return v.save()
+}
+
+// save encrypts the passwords with a new salt and nonce, and replaces the
+// vault file. The caller holds the lock.
+func (v *Vault) save() error {
+	plaintext, err := json.Marshal(v.passwords)
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %w", err)
+	}
+
+	file := vaultFile{Version: vaultVersion, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return fmt.Errorf("failed to generate vault salt: %w", err)
+	}
+
+	gcm, err := newCipher(v.passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("failed to generate vault nonce: %w", err)
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create vault directory: %w", err)
+	}
+
+	// The new file is renamed over the old one so a crash cannot corrupt it
+	tmp, err := os.CreateTemp(filepath.Dir(v.path), filepath.Base(v.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create vault: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vault %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write vault %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), v.path); err != nil {
+		return fmt.Errorf("failed to replace vault %s: %w", v.path, err)
+	}
+
+	return nil
+}
+
+func newCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), vaultFileName)
+
+	vault, err := OpenVault(path, "master")
+	if err != nil {
+		t.Fatalf("OpenVault() error = %v", err)
+	}
+	if err := vault.Set("prod", "s3cret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := vault.Set("staging", "other"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatalf("vault file holds a password in plaintext: %s", data)
+	}
+
+	t.Run("reopen", func(t *testing.T) {
+		reopened, err := OpenVault(path, "master")
+		if err != nil {
+			t.Fatalf("OpenVault() error = %v", err)
+		}
+		if password, ok := reopened.Get("prod"); !ok || password != "s3cret" {
+			t.Fatalf("Get(prod) = %q, %v, want s3cret", password, ok)
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		if _, err := OpenVault(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+			t.Fatalf("OpenVault() error = %v, want %v", err, ErrWrongPassphrase)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := vault.Delete("staging"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		reopened, err := OpenVault(path, "master")
+		if err != nil {
+			t.Fatalf("OpenVault() error = %v", err)
+		}
+		if _, ok := reopened.Get("staging"); ok {
+			t.Fatalf("Get(staging) found a deleted password")
+		}
+	})
+}
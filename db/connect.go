@@ -0,0 +1,142 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+
+	"sqlcmder/data/secrets"
+	"sqlcmder/drivers"
+	"sqlcmder/helpers"
+	"sqlcmder/models"
+	"sqlcmder/tunnel"
+)
+
+// OpenTunnel opens the SSH tunnel of a connection to address, the database
+// host of its DSN, and returns the local address of the tunnel
+type OpenTunnel func(connection models.Connection, address string) (localAddress string, closeTunnel func(), err error)
+
+// Tunnel returns an OpenTunnel asking for passphrases with prompt
+func Tunnel(prompt secrets.Prompt) OpenTunnel {
+	return func(connection models.Connection, address string) (string, func(), error) {
+		sshTunnel, err := tunnel.Open(*connection.SSH, address, prompt)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return sshTunnel.LocalAddress(), func() { sshTunnel.Close() }, nil
+	}
+}
+
+// ConnectionDSN reads the password of a connection from its source and
+// returns its DSN holding it
+func ConnectionDSN(connection *models.Connection, prompt secrets.Prompt) (string, error) {
+	password, err := secrets.Password(*connection, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	connection.Password = password
+	return helpers.WithPassword(connection.GetDSN(), password), nil
+}
+
+// OpenDSN returns the DSN to connect to a connection with, holding its TLS
+// settings, opening read-only sessions when the connection is read-only and
+// going through its SSH tunnel when it has one. The tunnel is closed with
+// closeTunnel.
+func OpenDSN(connection models.Connection, dsn string, openTunnel OpenTunnel) (opened string, closeTunnel func(), err error) {
+	closeTunnel = func() {}
+	settings := ConnectionTLS(connection, dsn)
+
+	if connection.SSH != nil && connection.SSH.Host != "" {
+		address, ok := helpers.DSNAddress(dsn)
+		if !ok {
+			return "", nil, fmt.Errorf("the DSN of %s has no host to open an SSH tunnel to", connection.Name)
+		}
+
+		localAddress, closeOpened, err := openTunnel(connection, address)
+		if err != nil {
+			return "", nil, err
+		}
+		closeTunnel = closeOpened
+		dsn = helpers.WithAddress(dsn, localAddress)
+	}
+
+	opened, err = drivers.WithTLS(dsn, settings)
+	if err == nil && connection.ReadOnly {
+		opened, err = drivers.WithReadOnly(connection.Driver, opened)
+	}
+	if err != nil {
+		closeTunnel()
+		return "", nil, err
+	}
+
+	return opened, closeTunnel, nil
+}
+
+// ConnectionTLS returns the TLS settings of a connection to dsn. Through an
+// SSH tunnel, the server name defaults to the host of the DSN, as the
+// certificate of the server holds its name, not the address of the tunnel.
+func ConnectionTLS(connection models.Connection, dsn string) models.TLSSettings {
+	settings := models.TLSSettings{}
+	if connection.TLS != nil {
+		settings = *connection.TLS
+	}
+
+	if connection.SSH != nil && connection.SSH.Host != "" && settings.ServerName == "" {
+		if address, ok := helpers.DSNAddress(dsn); ok {
+			if host, _, err := net.SplitHostPort(address); err == nil {
+				settings.ServerName = host
+			}
+		}
+	}
+
+	return settings
+}
+
+// Connect connects a new driver of a connection to dsn, which holds its
+// password, the way OpenDSN opens it. The driver and the tunnel are closed
+// with closeConnection.
+func Connect(connection models.Connection, dsn string, openTunnel OpenTunnel) (driver drivers.Driver, closeConnection func(), err error) {
+	driver, err = NewDriver(connection.Driver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opened, closeTunnel, err := OpenDSN(connection, dsn, openTunnel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := driver.Connect(opened); err != nil {
+		closeTunnel()
+		return nil, nil, err
+	}
+
+	return driver, func() {
+		Close(driver)
+		closeTunnel()
+	}, nil
+}
+
+// Close closes the connection of a driver
+func Close(driver drivers.Driver) {
+	if connection := SQL(driver); connection != nil {
+		connection.Close()
+	}
+}
+
+// SQL returns the database handle of a driver, nil when it is not connected
+func SQL(driver drivers.Driver) *sql.DB {
+	switch driver := driver.(type) {
+	case *drivers.MySQL:
+		return driver.Connection
+	case *drivers.Postgres:
+		return driver.Connection
+	case *drivers.SQLite:
+		return driver.Connection
+	case *drivers.MSSQL:
+		return driver.Connection
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestOpenDSNThroughTunnel(t *testing.T) {
+	connection := models.Connection{
+		Name:   "tunneled",
+		Driver: "postgres",
+		SSH:    &models.SSHTunnel{Host: "bastion"},
+	}
+
+	closed := false
+	openTunnel := func(_ models.Connection, address string) (string, func(), error) {
+		if address != "db.internal:5432" {
+			t.Fatalf("tunnel opened to %q, want db.internal:5432", address)
+		}
+		return "127.0.0.1:40000", func() { closed = true }, nil
+	}
+
+	opened, closeTunnel, err := OpenDSN(connection, "postgres://app:pw@db.internal:5432/app", openTunnel)
+	if err != nil {
+		t.Fatalf("OpenDSN() error = %v", err)
+	}
+	if want := "postgres://app:pw@127.0.0.1:40000/app"; opened != want {
+		t.Fatalf("OpenDSN() = %q, want %q", opened, want)
+	}
+
+	closeTunnel()
+	if !closed {
+		t.Fatal("closeTunnel did not close the tunnel")
+	}
+}
+
+func TestConnectionTLS(t *testing.T) {
+	connection := models.Connection{
+		SSH: &models.SSHTunnel{Host: "bastion"},
+		TLS: &models.TLSSettings{Mode: "verify-full"},
+	}
+
+	settings := ConnectionTLS(connection, "postgres://app@db.internal:5432/app")
+	if settings.ServerName != "db.internal" || settings.Mode != "verify-full" {
+		t.Fatalf("ConnectionTLS() = %+v, want the DSN host as server name", settings)
+	}
+
+	connection.SSH = nil
+	if settings := ConnectionTLS(connection, "postgres://app@db.internal:5432/app"); settings.ServerName != "" {
+		t.Fatalf("ConnectionTLS() server name = %q without a tunnel, want none", settings.ServerName)
+	}
+}
@@ -2,51 +2,66 @@ package db
 
 import (
 	"fmt"
-	"strings"
 
+	"sqlcmder/data/secrets"
 	"sqlcmder/drivers"
 	"sqlcmder/helpers"
 	"sqlcmder/models"
 )
 
 // InitFromArg initializes a database connection from a connection string argument
-// Returns the connection, driver, and any error
-func InitFromArg(connectionString string) (*models.Connection, drivers.Driver, error) {
-	parsed, err := helpers.ParseConnectionString(connectionString)
+// The password of a connection string with a username but no password is asked for with prompt
+// Returns the connection, driver, the function closing them, and any error
+func InitFromArg(connectionString string, prompt secrets.Prompt) (*models.Connection, drivers.Driver, func(), error) {
+	connection, err := connectionFromArg(connectionString)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse connection string: %s", err)
+		return nil, nil, nil, fmt.Errorf("could not parse connection string: %s", err)
 	}
-	DBName := strings.Split(parsed.Normalize(",", "NULL", 0), ",")[3]
 
-	if DBName == "NULL" {
-		DBName = ""
+	dsn := connectionString
+	if connection.Password == "" && connection.Username != "" {
+		connection.PasswordSource = models.PasswordSourcePrompt
+
+		dsn, err = ConnectionDSN(&connection, prompt)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not get the password of %s: %s", connection.DSN, err)
+		}
+	}
+
+	newDBDriver, closeConnection, err := Connect(connection, dsn, Tunnel(prompt))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not connect to database %s: %s", connection.DSN, err)
 	}
 
-	connection := models.Connection{
-		Name:   "",
-		Driver: parsed.Driver,
-		DBName: DBName,
-		DSN:    connectionString,
+	return &connection, newDBDriver, closeConnection, nil
+}
+
+// connectionFromArg returns the connection of a connection string argument.
+// Its DSN is the connection string as given, without its password, so
+// parameters and parts the options don't describe, like the instance of a
+// SQL Server URL, are kept.
+func connectionFromArg(connectionString string) (models.Connection, error) {
+	connection, err := drivers.ParseDSN(connectionString)
+	if err != nil {
+		return connection, err
 	}
 
-	var newDBDriver drivers.Driver
-	switch connection.Driver {
+	connection.DSN, _ = helpers.SplitPassword(connectionString)
+	return connection, nil
+}
+
+// NewDriver returns an unconnected driver for the given driver name
+func NewDriver(driver string) (drivers.Driver, error) {
+	switch driver {
 	case drivers.DriverMySQL:
-		newDBDriver = &drivers.MySQL{}
+		return &drivers.MySQL{}, nil
 	case drivers.DriverPostgres:
-		newDBDriver = &drivers.Postgres{}
+		return &drivers.Postgres{}, nil
 	case drivers.DriverSqlite:
-		newDBDriver = &drivers.SQLite{}
+		return &drivers.SQLite{}, nil
 	case drivers.DriverMSSQL:
-		newDBDriver = &drivers.MSSQL{}
-	default:
-		return nil, nil, fmt.Errorf("could not handle database driver %s", connection.Driver)
-	}
-
-	err = newDBDriver.Connect(connection.GetDSN())
-	if err != nil {
-		return nil, nil, fmt.Errorf("could not connect to database %s: %s", connectionString, err)
+		return &drivers.MSSQL{}, nil
 	}
 
-	return &connection, newDBDriver, nil
+	return nil, fmt.Errorf("could not handle database driver %s", driver)
 }
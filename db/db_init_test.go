@@ -0,0 +1,41 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestConnectionFromArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		wantDSN string
+	}{
+		{
+			name:    "sqlserver instance",
+			arg:     "sqlserver://sa:secret@db/SQLEXPRESS?database=master",
+			wantDSN: "sqlserver://sa@db/SQLEXPRESS?database=master",
+		},
+		{
+			name:    "postgres unix socket",
+			arg:     "postgres://app@/app?host=/var/run/postgresql&sslmode=prefer&target_session_attrs=read-write",
+			wantDSN: "postgres://app@/app?host=/var/run/postgresql&sslmode=prefer&target_session_attrs=read-write",
+		},
+		{
+			name:    "mysql parameters",
+			arg:     "mysql://root:secret@db:3306/app?multiStatements=true",
+			wantDSN: "mysql://root@db:3306/app?multiStatements=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection, err := connectionFromArg(tt.arg)
+			if err != nil {
+				t.Fatalf("connectionFromArg() error = %v", err)
+			}
+			if connection.DSN != tt.wantDSN {
+				t.Fatalf("DSN = %q, want %q", connection.DSN, tt.wantDSN)
+			}
+		})
+	}
+}
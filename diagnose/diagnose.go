@@ -0,0 +1,402 @@
+// Package diagnose checks a connection step by step, from parsing its DSN to
+// the privileges of its user, to tell where connecting to it fails.
+package diagnose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+
+	"sqlcmder/db"
+	"sqlcmder/drivers"
+	"sqlcmder/helpers"
+	"sqlcmder/models"
+)
+
+// Status of a step
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusRunning
+	StatusPassed
+	StatusFailed
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusRunning:
+		return "running"
+	case StatusPassed:
+		return "pass"
+	case StatusFailed:
+		return "fail"
+	case StatusSkipped:
+		return "skip"
+	}
+	return "pending"
+}
+
+// Names of the steps, in the order they run
+const (
+	StepParse    = "Parse DSN"
+	StepResolve  = "Resolve host"
+	StepConnect  = "Connect over TCP"
+	StepTLS      = "TLS handshake"
+	StepAuth     = "Authenticate"
+	StepDatabase = "Open database"
+	StepServer   = "Server version and privileges"
+)
+
+const dialTimeout = 10 * time.Second
+
+// Step is the result of a step of a diagnosis
+type Step struct {
+	Name     string
+	Status   Status
+	Detail   string
+	Duration time.Duration
+}
+
+// Target is the connection to diagnose
+type Target struct {
+	Driver string // Detected from the DSN when empty
+	DSN    string // DSN holding the password, like given to Driver.Connect
+	TLS    *models.TLSSettings
+}
+
+// skipped is returned by a step that does not apply to the target
+type skipped string
+
+func (s skipped) Error() string {
+	return string(s)
+}
+
+// diagnosis holds what the steps learn for the next ones
+type diagnosis struct {
+	ctx    context.Context
+	target Target
+	steps  []Step
+	report func([]Step)
+
+	host      string // Host name of the server, as in its certificate
+	address   string // host:port dialed
+	database  string
+	addresses []string
+	driver    drivers.Driver
+	connErr   error
+}
+
+// Run diagnoses a connection, calling report with a copy of the steps each
+// time one starts or ends. The steps after a failed one are skipped.
+func Run(ctx context.Context, target Target, report func([]Step)) []Step {
+	d := &diagnosis{ctx: ctx, target: target, report: report}
+	defer d.close()
+
+	runs := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{StepParse, d.parse},
+		{StepResolve, d.resolve},
+		{StepConnect, d.connect},
+		{StepTLS, d.handshake},
+		{StepAuth, d.authenticate},
+		{StepDatabase, d.openDatabase},
+		{StepServer, d.checkServer},
+	}
+
+	for _, run := range runs {
+		d.steps = append(d.steps, Step{Name: run.name})
+	}
+
+	for i, run := range runs {
+		d.steps[i].Status = StatusRunning
+		d.notify()
+
+		start := time.Now()
+		detail, err := run.run()
+		d.steps[i].Duration = time.Since(start)
+
+		var skip skipped
+		switch {
+		case errors.As(err, &skip):
+			d.steps[i].Status = StatusSkipped
+			d.steps[i].Detail = skip.Error()
+		case err != nil:
+			d.steps[i].Status = StatusFailed
+			d.steps[i].Detail = err.Error()
+			for j := i + 1; j < len(d.steps); j++ {
+				d.steps[j].Status = StatusSkipped
+				d.steps[j].Detail = "skipped after " + strings.ToLower(run.name) + " failed"
+			}
+			d.notify()
+			return d.steps
+		default:
+			d.steps[i].Status = StatusPassed
+			d.steps[i].Detail = detail
+		}
+		d.notify()
+	}
+
+	return d.steps
+}
+
+// Failed returns the first failed step.
+func Failed(steps []Step) (Step, bool) {
+	for _, step := range steps {
+		if step.Status == StatusFailed {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
+
+func (d *diagnosis) notify() {
+	if d.report != nil {
+		d.report(append([]Step(nil), d.steps...))
+	}
+}
+
+func (d *diagnosis) close() {
+	if d.driver != nil {
+		db.Close(d.driver)
+	}
+}
+
+func (d *diagnosis) parse() (string, error) {
+	// SQLite takes a file name, with no scheme
+	if d.target.Driver == drivers.DriverSqlite && !strings.Contains(d.target.DSN, "://") {
+		d.database, _, _ = strings.Cut(d.target.DSN, "?")
+		return "SQLite file " + d.database, nil
+	}
+
+	parsed, err := dburl.Parse(d.target.DSN)
+	if err != nil {
+		return "", err
+	}
+	if d.target.Driver == "" {
+		d.target.Driver = parsed.Driver
+	}
+	if d.target.Driver != parsed.Driver {
+		return "", fmt.Errorf("the DSN is for %s, not %s", parsed.Driver, d.target.Driver)
+	}
+
+	switch parsed.Driver {
+	case drivers.DriverSqlite:
+		d.database = parsed.Opaque
+		if d.database == "" {
+			d.database = parsed.Path
+		}
+		d.database, _, _ = strings.Cut(d.database, "?")
+		return "SQLite file " + d.database, nil
+	case drivers.DriverPostgres, drivers.DriverMySQL, drivers.DriverMSSQL:
+	default:
+		return "", fmt.Errorf("unsupported driver %q", parsed.Driver)
+	}
+
+	address, ok := helpers.DSNAddress(d.target.DSN)
+	if !ok {
+		return "", errors.New("the DSN has no host")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	d.host = host
+	d.address = address
+
+	// With hostaddr, lib/pq dials that address and checks the certificate
+	// against the host
+	if hostaddr := parsed.Query().Get("hostaddr"); parsed.Driver == drivers.DriverPostgres && hostaddr != "" {
+		d.address = net.JoinHostPort(hostaddr, port)
+	}
+
+	d.database = strings.TrimPrefix(parsed.Path, "/")
+	if parsed.Driver == drivers.DriverMSSQL {
+		d.database = parsed.Query().Get("database")
+	}
+
+	detail := fmt.Sprintf("%s server at %s", parsed.Driver, d.address)
+	if d.database != "" {
+		detail += ", database " + d.database
+	}
+	if parsed.User != nil && parsed.User.Username() != "" {
+		detail += ", user " + parsed.User.Username()
+	}
+	return detail, nil
+}
+
+func (d *diagnosis) resolve() (string, error) {
+	if d.target.Driver == drivers.DriverSqlite {
+		return "", skipped("not needed for a file")
+	}
+
+	host, _, _ := net.SplitHostPort(d.address)
+	if net.ParseIP(host) != nil {
+		d.addresses = []string{host}
+		return host + " is an IP address", nil
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, dialTimeout)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	d.addresses = addresses
+	return host + " is " + strings.Join(addresses, ", "), nil
+}
+
+func (d *diagnosis) connect() (string, error) {
+	if d.target.Driver == drivers.DriverSqlite {
+		return "", skipped("not needed for a file")
+	}
+
+	start := time.Now()
+	conn, err := d.dial()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return fmt.Sprintf("connected to %s in %s", conn.RemoteAddr(), time.Since(start).Round(time.Microsecond)), nil
+}
+
+func (d *diagnosis) dial() (net.Conn, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	return dialer.DialContext(d.ctx, "tcp", d.address)
+}
+
+func (d *diagnosis) handshake() (string, error) {
+	if d.target.Driver == drivers.DriverSqlite {
+		return "", skipped("not needed for a file")
+	}
+	if d.target.TLS == nil || d.target.TLS.Mode == "" || d.target.TLS.Mode == models.TLSModeDisable {
+		return "", skipped("TLS is not enabled")
+	}
+
+	settings := *d.target.TLS
+	switch d.target.Driver {
+	case drivers.DriverPostgres:
+		return handshake(d, settings, startPostgresTLS)
+	case drivers.DriverMySQL:
+		return handshake(d, settings, startMySQLTLS)
+	}
+	return "", skipped("checked by the driver while authenticating")
+}
+
+func (d *diagnosis) authenticate() (string, error) {
+	if d.target.Driver == drivers.DriverSqlite {
+		if d.database == ":memory:" {
+			return "", skipped("in-memory database")
+		}
+		// The SQLite driver creates missing files, so they are looked for
+		// first
+		info, err := os.Stat(d.database)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", d.database)
+		}
+	}
+
+	driver, err := db.NewDriver(d.target.Driver)
+	if err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- driver.Connect(d.target.DSN)
+	}()
+
+	select {
+	case err = <-done:
+	case <-d.ctx.Done():
+		return "", d.ctx.Err()
+	}
+
+	d.driver = driver
+	if err != nil {
+		if !missingDatabase(err) {
+			return "", err
+		}
+		// The server checked the credentials before looking for the
+		// database
+		d.connErr = err
+	}
+
+	if d.target.Driver == drivers.DriverSqlite {
+		return "the file opened", nil
+	}
+	return "the server accepted the credentials", nil
+}
+
+func (d *diagnosis) openDatabase() (string, error) {
+	if d.connErr != nil {
+		return "", d.connErr
+	}
+	if d.database == "" {
+		return "connected to the default database", nil
+	}
+	return "connected to " + d.database, nil
+}
+
+func (d *diagnosis) checkServer() (string, error) {
+	checks, ok := serverChecks[d.target.Driver]
+	if !ok {
+		return "", skipped("no check for " + d.target.Driver)
+	}
+
+	version, err := d.queryValue(checks.version)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the server version: %w", err)
+	}
+
+	detail := version
+	for _, privilege := range checks.privileges {
+		value, err := d.queryValue(privilege.query)
+		if err != nil {
+			detail += fmt.Sprintf(", %s unknown (%s)", privilege.name, err)
+			continue
+		}
+		detail += fmt.Sprintf(", %s: %s", privilege.name, value)
+	}
+
+	if d.target.Driver == drivers.DriverSqlite && d.database != ":memory:" {
+		file, err := os.OpenFile(d.database, os.O_WRONLY, 0)
+		if err == nil {
+			file.Close()
+		}
+		detail += fmt.Sprintf(", writable: %t", err == nil)
+	}
+	return detail, nil
+}
+
+// queryValue returns the first value of the results of a query
+func (d *diagnosis) queryValue(query string) (string, error) {
+	records, _, err := d.driver.ExecuteQuery(query)
+	if err != nil {
+		return "", err
+	}
+	// The first record holds the column names
+	if len(records) < 2 || len(records[1]) == 0 {
+		return "", errors.New("no result")
+	}
+
+	var values []string
+	for _, record := range records[1:] {
+		values = append(values, record[0])
+	}
+	return strings.Join(values, "; "), nil
+}
@@ -0,0 +1,247 @@
+package diagnose
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// selfSigned returns a certificate for name signed by itself
+func selfSigned(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startPostgresServer starts a server answering the SSL request of Postgres
+// clients with a TLS handshake, then closing the connection
+func startPostgresServer(t *testing.T, certificate tls.Certificate) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				// Connections not starting with the SSL request are closed
+				request := make([]byte, 8)
+				if _, err := io.ReadFull(conn, request); err != nil || binary.BigEndian.Uint32(request[4:]) != 80877103 {
+					return
+				}
+				if _, err := conn.Write([]byte("S")); err != nil {
+					return
+				}
+				server := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{certificate}})
+				_ = server.Handshake()
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// closedAddress returns an address nothing listens on
+func closedAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func statuses(steps []Step) string {
+	var values []string
+	for _, step := range steps {
+		values = append(values, step.Status.String())
+	}
+	return strings.Join(values, " ")
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.db")
+	sqlite := &drivers.SQLite{}
+	if err := sqlite.Connect(file); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := sqlite.Connection.Exec("CREATE TABLE items (id INTEGER)"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	sqlite.Connection.Close()
+
+	postgres := startPostgresServer(t, selfSigned(t, "db.example.com"))
+
+	tests := []struct {
+		name     string
+		target   Target
+		statuses string
+		detail   map[string]string // Text in the detail of steps
+	}{
+		{
+			name:     "invalid dsn",
+			target:   Target{DSN: "nosuchdriver://localhost"},
+			statuses: "fail skip skip skip skip skip skip",
+		},
+		{
+			name:     "connection refused",
+			target:   Target{DSN: "postgres://app@" + closedAddress(t) + "/app"},
+			statuses: "pass pass fail skip skip skip skip",
+			detail:   map[string]string{StepParse: "database app, user app", StepResolve: "is an IP address"},
+		},
+		{
+			name: "postgres tls",
+			target: Target{
+				DSN: "postgres://app@" + postgres + "/app?sslmode=require",
+				TLS: &models.TLSSettings{Mode: models.TLSModeRequire, ServerName: "db.example.com"},
+			},
+			statuses: "pass pass pass pass fail skip skip",
+			detail:   map[string]string{StepConnect: "connected to " + postgres, StepTLS: "subject CN=db.example.com"},
+		},
+		{
+			name:     "postgres without tls",
+			target:   Target{DSN: "postgres://app@" + postgres + "/app?sslmode=disable"},
+			statuses: "pass pass pass skip fail skip skip",
+			detail:   map[string]string{StepTLS: "TLS is not enabled"},
+		},
+		{
+			name:     "sqlite",
+			target:   Target{Driver: drivers.DriverSqlite, DSN: file},
+			statuses: "pass skip skip skip pass pass pass",
+			detail:   map[string]string{StepServer: "SQLite 3.", StepDatabase: "connected to " + file},
+		},
+		{
+			name:     "missing sqlite file",
+			target:   Target{Driver: drivers.DriverSqlite, DSN: filepath.Join(dir, "missing.db")},
+			statuses: "pass skip skip skip fail skip skip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports := 0
+			steps := Run(context.Background(), tt.target, func([]Step) { reports++ })
+
+			if got := statuses(steps); got != tt.statuses {
+				t.Fatalf("statuses = %s, want %s\n%+v", got, tt.statuses, steps)
+			}
+			if reports == 0 {
+				t.Fatalf("report was never called")
+			}
+			for _, step := range steps {
+				if want, ok := tt.detail[step.Name]; ok && !strings.Contains(step.Detail, want) {
+					t.Fatalf("%s detail = %q, want it to contain %q", step.Name, step.Detail, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStartMySQLTLS(t *testing.T) {
+	// greeting returns the handshake packet of a server with capability flags
+	greeting := func(flags uint16) []byte {
+		payload := []byte{10}
+		payload = append(payload, "8.0.36\x00"...)
+		payload = append(payload, 1, 0, 0, 0)    // Thread id
+		payload = append(payload, "abcdefgh"...) // Auth data
+		payload = append(payload, 0)             // Filler
+		payload = binary.LittleEndian.AppendUint16(payload, flags)
+		payload = append(payload, 45, 2, 0, 0, 0) // Charset, status, upper flags
+		header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), 0}
+		return append(header, payload...)
+	}
+
+	tests := []struct {
+		name    string
+		packet  []byte
+		wantErr string
+	}{
+		{"ssl", greeting(mysqlClientProtocol41 | mysqlClientSSL), ""},
+		{"no ssl", greeting(mysqlClientProtocol41), "does not accept TLS"},
+		{"error", append([]byte{11, 0, 0, 0, 0xff, 0x69, 0x04}, "too many"...), "too many"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+
+			requests := make(chan []byte, 1)
+			go func() {
+				defer server.Close()
+				if _, err := server.Write(tt.packet); err != nil {
+					return
+				}
+				request := make([]byte, 36)
+				if _, err := io.ReadFull(server, request); err == nil {
+					requests <- request
+				}
+			}()
+
+			err := startMySQLTLS(client)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("startMySQLTLS() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("startMySQLTLS() error = %v", err)
+			}
+
+			request := <-requests
+			if request[0] != 32 || request[3] != 1 {
+				t.Fatalf("request header = %v, want length 32 and sequence 1", request[:4])
+			}
+			if flags := binary.LittleEndian.Uint32(request[4:8]); flags&mysqlClientSSL == 0 {
+				t.Fatalf("request flags = %#x, want CLIENT_SSL", flags)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package diagnose
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+
+	"sqlcmder/drivers"
+)
+
+// privilegeCheck is a query telling about a privilege of the user
+type privilegeCheck struct {
+	name  string
+	query string
+}
+
+// checks are the queries reading the version of a server and the privileges
+// of the user
+type checks struct {
+	version    string
+	privileges []privilegeCheck
+}
+
+var serverChecks = map[string]checks{
+	drivers.DriverPostgres: {
+		version: "SELECT version()",
+		privileges: []privilegeCheck{
+			{"user", "SELECT current_user"},
+			{"superuser", "SELECT rolsuper FROM pg_roles WHERE rolname = current_user"},
+			{"create in database", "SELECT has_database_privilege(current_database(), 'CREATE')"},
+		},
+	},
+	drivers.DriverMySQL: {
+		version: "SELECT CONCAT('MySQL ', VERSION())",
+		privileges: []privilegeCheck{
+			{"user", "SELECT CURRENT_USER()"},
+			{"grants", "SHOW GRANTS"},
+		},
+	},
+	drivers.DriverMSSQL: {
+		version: "SELECT CAST(SERVERPROPERTY('ProductVersion') AS nvarchar(128)) + ' ' + CAST(SERVERPROPERTY('Edition') AS nvarchar(128))",
+		privileges: []privilegeCheck{
+			{"user", "SELECT SUSER_SNAME()"},
+			{"sysadmin", "SELECT IS_SRVROLEMEMBER('sysadmin')"},
+			{"create table", "SELECT HAS_PERMS_BY_NAME(DB_NAME(), 'DATABASE', 'CREATE TABLE')"},
+		},
+	},
+	drivers.DriverSqlite: {
+		version: "SELECT 'SQLite ' || sqlite_version()",
+		privileges: []privilegeCheck{
+			{"journal mode", "PRAGMA journal_mode"},
+			{"read only", "PRAGMA query_only"},
+		},
+	},
+}
+
+// missingDatabase tells whether a connection failed on a database that does
+// not exist, once the server accepted the credentials
+func missingDatabase(err error) bool {
+	var pqError *pq.Error
+	if errors.As(err, &pqError) {
+		return pqError.Code == "3D000" // invalid_catalog_name
+	}
+
+	var mysqlError *mysql.MySQLError
+	if errors.As(err, &mysqlError) {
+		return mysqlError.Number == 1049 // ER_BAD_DB_ERROR
+	}
+
+	var mssqlError mssql.Error
+	if errors.As(err, &mssqlError) {
+		return mssqlError.Number == 4060 // Cannot open database
+	}
+
+	return false
+}
@@ -0,0 +1,144 @@
+package diagnose
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// startTLS asks the server of conn to go on with TLS, the way its protocol
+// does before the handshake
+type startTLS func(conn net.Conn) error
+
+// handshake does the TLS handshake of a server and describes its certificate
+func handshake(d *diagnosis, settings models.TLSSettings, start startTLS) (string, error) {
+	config, err := drivers.TLSConfig(settings, d.host)
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := d.dial()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(dialTimeout)); err != nil {
+		return "", err
+	}
+	if err := start(conn); err != nil {
+		return "", err
+	}
+
+	client := tls.Client(conn, config)
+	if err := client.HandshakeContext(d.ctx); err != nil {
+		return "", err
+	}
+
+	state := client.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return "", errors.New("the server sent no certificate")
+	}
+
+	return fmt.Sprintf("%s, %s", tls.VersionName(state.Version), describeCertificate(state.PeerCertificates[0])), nil
+}
+
+// describeCertificate tells who a certificate is for, who issued it and
+// until when it is valid
+func describeCertificate(certificate *x509.Certificate) string {
+	detail := fmt.Sprintf("subject %s, issued by %s, expires %s",
+		certificate.Subject.String(),
+		certificate.Issuer.String(),
+		certificate.NotAfter.Format(time.DateOnly),
+	)
+
+	names := append([]string(nil), certificate.DNSNames...)
+	for _, ip := range certificate.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) > 0 {
+		detail += ", names " + strings.Join(names, " ")
+	}
+
+	if time.Now().After(certificate.NotAfter) {
+		detail += " (expired)"
+	}
+	return detail
+}
+
+// startPostgresTLS sends the SSLRequest message, answered with S by servers
+// taking TLS
+func startPostgresTLS(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	answer := make([]byte, 1)
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return err
+	}
+	if answer[0] != 'S' {
+		return errors.New("the server does not accept TLS connections")
+	}
+	return nil
+}
+
+// MySQL capability flags
+const (
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientSSL              = 0x00000800
+	mysqlClientSecureConnection = 0x00008000
+)
+
+// startMySQLTLS reads the greeting of the server and sends the SSLRequest
+// packet when it takes TLS
+func startMySQLTLS(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return err
+	}
+
+	if len(payload) > 3 && payload[0] == 0xff {
+		// The error packet ends with its message
+		return fmt.Errorf("the server refused the connection: %s", payload[3:])
+	}
+
+	// Protocol version, server version ending with a zero, thread id, first
+	// part of the auth data and a filler come before the capability flags
+	end := 1
+	for end < len(payload) && payload[end] != 0 {
+		end++
+	}
+	flagsAt := end + 1 + 4 + 8 + 1
+	if len(payload) < flagsAt+2 {
+		return errors.New("unexpected greeting from the server")
+	}
+	if binary.LittleEndian.Uint16(payload[flagsAt:])&mysqlClientSSL == 0 {
+		return errors.New("the server does not accept TLS connections")
+	}
+
+	request := make([]byte, 4+32)
+	request[0] = 32
+	request[3] = header[3] + 1
+	binary.LittleEndian.PutUint32(request[4:8], mysqlClientProtocol41|mysqlClientSSL|mysqlClientSecureConnection)
+	binary.LittleEndian.PutUint32(request[8:12], 1<<24)
+	request[12] = 45 // utf8mb4_general_ci
+	_, err := conn.Write(request)
+	return err
+}
@@ -0,0 +1,154 @@
+package drivers
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Catalog keeps the tables and columns of the databases of a connection,
+// loaded in the background, for the editor completions.
+type Catalog struct {
+	mu        sync.RWMutex
+	driver    Driver
+	databases map[string]map[string][]string
+	current   string
+}
+
+func NewCatalog(driver Driver) *Catalog {
+	return &Catalog{
+		driver:    driver,
+		databases: map[string]map[string][]string{},
+	}
+}
+
+// Load reads the tables and columns of a database, replacing what was
+// loaded before. An empty database loads the first one of the connection.
+func (catalog *Catalog) Load(database string) error {
+	if database == "" {
+		databases, err := catalog.driver.GetDatabases()
+		if err != nil {
+			return err
+		}
+		if len(databases) == 0 {
+			return errors.New("no database to load")
+		}
+		database = databases[0]
+	}
+
+	tableNames, err := GetTableNames(catalog.driver, database)
+	if err != nil {
+		return err
+	}
+
+	tables := map[string][]string{}
+
+	for _, tableName := range tableNames {
+		columns, err := catalog.driver.GetTableColumns(database, tableName)
+		if err != nil {
+			return err
+		}
+
+		names := []string{}
+		for i, column := range columns {
+			// The first row holds the column headers
+			if i == 0 || len(column) == 0 {
+				continue
+			}
+			names = append(names, column[0])
+		}
+
+		tables[tableName] = names
+	}
+
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+
+	catalog.databases[database] = tables
+	if catalog.current == "" {
+		catalog.current = database
+	}
+
+	return nil
+}
+
+// Reload loads again every database that was loaded
+func (catalog *Catalog) Reload() error {
+	catalog.mu.RLock()
+	databases := []string{}
+	for database := range catalog.databases {
+		databases = append(databases, database)
+	}
+	catalog.mu.RUnlock()
+
+	for _, database := range databases {
+		if err := catalog.Load(database); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Loaded reports whether the database has been loaded
+func (catalog *Catalog) Loaded(database string) bool {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	_, ok := catalog.databases[database]
+	return ok
+}
+
+// Current returns the first database that was loaded
+func (catalog *Catalog) Current() string {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	return catalog.current
+}
+
+// Tables returns the sorted table names of a database
+func (catalog *Catalog) Tables(database string) []string {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	tables := []string{}
+	for table := range catalog.databases[database] {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+
+	return tables
+}
+
+// Columns returns the columns of a table. The table may be given without
+// its schema, as long as only one schema has a table with that name.
+func (catalog *Catalog) Columns(database, table string) []string {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	tables := catalog.databases[database]
+
+	for name, columns := range tables {
+		if strings.EqualFold(name, table) {
+			return columns
+		}
+	}
+
+	var found []string
+	matches := 0
+
+	for name, columns := range tables {
+		if strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(table)) {
+			found = columns
+			matches++
+		}
+	}
+
+	if matches != 1 {
+		return nil
+	}
+
+	return found
+}
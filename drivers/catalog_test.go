@@ -0,0 +1,64 @@
+package drivers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	db := newSQLiteMemoryDB(t, "catalog", []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER)",
+	})
+
+	catalog := NewCatalog(db)
+
+	if catalog.Loaded("main") {
+		t.Fatal("expected the catalog to start empty")
+	}
+
+	if err := catalog.Load("main"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if catalog.Current() != "main" {
+		t.Fatalf("expected main to be the current database, got %q", catalog.Current())
+	}
+
+	if !reflect.DeepEqual(catalog.Tables("main"), []string{"orders", "users"}) {
+		t.Fatalf("unexpected tables %v", catalog.Tables("main"))
+	}
+
+	if !reflect.DeepEqual(catalog.Columns("main", "USERS"), []string{"id", "name"}) {
+		t.Fatalf("unexpected columns %v", catalog.Columns("main", "USERS"))
+	}
+
+	if _, err := db.Connection.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	if err := catalog.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(catalog.Tables("main"), []string{"items", "orders", "users"}) {
+		t.Fatalf("expected the new table after a reload, got %v", catalog.Tables("main"))
+	}
+}
+
+func TestCatalog_ColumnsWithoutSchema(t *testing.T) {
+	catalog := NewCatalog(nil)
+	catalog.databases["db"] = map[string][]string{
+		"public.users": {"id"},
+		"public.items": {"id"},
+		"audit.items":  {"id", "at"},
+	}
+
+	if !reflect.DeepEqual(catalog.Columns("db", "users"), []string{"id"}) {
+		t.Fatalf("expected users to resolve to public.users")
+	}
+
+	if catalog.Columns("db", "items") != nil {
+		t.Fatalf("expected an ambiguous table to have no columns")
+	}
+}
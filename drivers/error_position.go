@@ -0,0 +1,110 @@
+package drivers
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+var (
+	mysqlErrorPosition  = regexp.MustCompile(`near '((?s).*)' at line (\d+)`)
+	sqliteErrorPosition = regexp.MustCompile(`near "([^"]*)": syntax error`)
+)
+
+// ErrorPosition returns the byte offset in the query where the database
+// reports an error, when the error carries one.
+func ErrorPosition(err error, query string) (int, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Position != "" {
+		// Postgres counts characters from 1
+		position, convErr := strconv.Atoi(pqErr.Position)
+		if convErr != nil || position < 1 {
+			return 0, false
+		}
+		return runeOffset(query, position-1), true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		match := mysqlErrorPosition.FindStringSubmatch(mysqlErr.Message)
+		if match == nil {
+			return 0, false
+		}
+
+		line, _ := strconv.Atoi(match[2])
+		offset, ok := lineOffset(query, line)
+		if !ok {
+			return 0, false
+		}
+
+		return nearOffset(query, offset, match[1]), true
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) && mssqlErr.LineNo > 0 {
+		return lineOffset(query, int(mssqlErr.LineNo))
+	}
+
+	// The SQLite driver only reports the token the error is near
+	if match := sqliteErrorPosition.FindStringSubmatch(err.Error()); match != nil && match[1] != "" {
+		if index := strings.Index(query, match[1]); index != -1 {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+// runeOffset converts a character position into a byte offset
+func runeOffset(query string, position int) int {
+	count := 0
+	for offset := range query {
+		if count == position {
+			return offset
+		}
+		count++
+	}
+	return len(query)
+}
+
+// lineOffset returns the byte offset where a line, counted from 1, starts
+func lineOffset(query string, line int) (int, bool) {
+	if line < 1 {
+		return 0, false
+	}
+
+	offset := 0
+	for i := 1; i < line; i++ {
+		index := strings.IndexByte(query[offset:], '\n')
+		if index == -1 {
+			return 0, false
+		}
+		offset += index + 1
+	}
+
+	return offset, true
+}
+
+// nearOffset looks for the text an error is near from the start of its line
+func nearOffset(query string, lineStart int, near string) int {
+	// MySQL truncates the quoted text, a short prefix is enough to find it
+	near = strings.TrimSpace(near)
+	if len(near) > 20 {
+		near = near[:20]
+	}
+
+	if near == "" {
+		return lineStart
+	}
+
+	if index := strings.Index(query[lineStart:], near); index != -1 {
+		return lineStart + index
+	}
+
+	return lineStart
+}
@@ -0,0 +1,53 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+func TestErrorPosition(t *testing.T) {
+	query := "SELECT *\nFORM users\nWHERE é = 1 AND x"
+
+	sqlite := newSQLiteMemoryDB(t, "error_position", nil)
+	_, sqliteErr := sqlite.Connection.Exec("SELECT * FORM users")
+
+	testCases := []struct {
+		name     string
+		err      error
+		query    string
+		expected int
+		ok       bool
+	}{
+		{name: "Postgres", err: &pq.Error{Position: "10"}, query: query, expected: 9, ok: true},
+		{name: "Postgres multibyte", err: &pq.Error{Position: "33"}, query: query, expected: 33, ok: true},
+		{name: "Postgres wrapped", err: fmt.Errorf("query failed: %w", &pq.Error{Position: "1"}), query: query, expected: 0, ok: true},
+		{name: "Postgres without position", err: &pq.Error{}, query: query, ok: false},
+		{
+			name:     "MySQL",
+			err:      &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near 'users\nWHERE é = 1 AND x' at line 2"},
+			query:    query,
+			expected: 14,
+			ok:       true,
+		},
+		{name: "SQL Server", err: mssql.Error{Message: "Incorrect syntax", LineNo: 3}, query: query, expected: 20, ok: true},
+		{name: "SQLite", err: sqliteErr, query: "SELECT * FORM users", expected: 9, ok: true},
+		{name: "Unknown error", err: errors.New("connection refused"), query: query, ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			offset, ok := ErrorPosition(tc.err, tc.query)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %v, got %v (%v)", tc.ok, ok, tc.err)
+			}
+			if ok && offset != tc.expected {
+				t.Fatalf("expected offset %d, got %d", tc.expected, offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package drivers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"sqlcmder/models"
+)
+
+// GetTableNames returns the tables of a database in the format the other
+// driver methods expect them, which is "schema.table" for Postgres.
+func GetTableNames(db Driver, database string) ([]string, error) {
+	tables, err := db.GetTables(database)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for key, values := range tables {
+		for _, table := range values {
+			if db.GetProvider() == DriverPostgres {
+				names = append(names, fmt.Sprintf("%s.%s", key, table))
+			} else {
+				names = append(names, table)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// ParseForeignKeys converts the rows returned by GetForeignKeys into foreign keys.
+// Rows are grouped by constraint so composite keys become a single entry.
+func ParseForeignKeys(provider, table string, rows [][]string) []models.ForeignKey {
+	if len(rows) < 2 {
+		return nil
+	}
+
+	header := map[string]int{}
+	for i, name := range rows[0] {
+		header[strings.ToLower(name)] = i
+	}
+
+	value := func(row []string, column string) string {
+		index, ok := header[column]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return row[index]
+	}
+
+	foreignKeys := []models.ForeignKey{}
+	indexByName := map[string]int{}
+
+	for _, row := range rows[1:] {
+		var foreignKey models.ForeignKey
+		var column, referencedColumn string
+
+		switch provider {
+		case DriverSqlite:
+			foreignKey = models.ForeignKey{
+				Name:            "fk_" + value(row, "id"),
+				Table:           table,
+				ReferencedTable: value(row, "table"),
+			}
+			column = value(row, "from")
+			referencedColumn = value(row, "to")
+		case DriverPostgres:
+			// The referenced table may be in another schema than the table
+			schema := value(row, "foreign_table_schema")
+			if schema != "" {
+				schema += "."
+			} else if split := strings.SplitN(table, ".", 2); len(split) == 2 {
+				schema = split[0] + "."
+			}
+			foreignKey = models.ForeignKey{
+				Name:            value(row, "constraint_name"),
+				Table:           table,
+				ReferencedTable: schema + value(row, "foreign_table_name"),
+			}
+			column = value(row, "column_name")
+			referencedColumn = value(row, "foreign_column_name")
+		case DriverMySQL:
+			// MySQL returns the foreign keys pointing to the table, so the
+			// owning table comes from the row itself.
+			foreignKey = models.ForeignKey{
+				Name:            value(row, "constraint_name"),
+				Table:           value(row, "table_name"),
+				ReferencedTable: value(row, "referenced_table_name"),
+			}
+			column = value(row, "column_name")
+			referencedColumn = value(row, "referenced_column_name")
+		case DriverMSSQL:
+			referencedTable := value(row, "referenced_table")
+			if split := strings.SplitN(referencedTable, ".", 2); len(split) == 2 {
+				referencedTable = split[1]
+			}
+			foreignKey = models.ForeignKey{
+				Name:            value(row, "constraint_name"),
+				Table:           table,
+				ReferencedTable: referencedTable,
+			}
+			column = value(row, "column_name")
+			referencedColumn = value(row, "referenced_column")
+		default:
+			continue
+		}
+
+		key := foreignKey.Table + "." + foreignKey.Name
+		index, ok := indexByName[key]
+		if !ok {
+			foreignKeys = append(foreignKeys, foreignKey)
+			index = len(foreignKeys) - 1
+			indexByName[key] = index
+		}
+
+		existing := &foreignKeys[index]
+		if !slices.Contains(existing.Columns, column) {
+			existing.Columns = append(existing.Columns, column)
+			existing.ReferencedColumns = append(existing.ReferencedColumns, referencedColumn)
+		}
+	}
+
+	return foreignKeys
+}
+
+// GetAllForeignKeys returns the foreign keys of every table in a database
+func GetAllForeignKeys(db Driver, database string) ([]models.ForeignKey, error) {
+	tables, err := GetTableNames(db, database)
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeys := []models.ForeignKey{}
+	seen := map[string]bool{}
+
+	for _, table := range tables {
+		rows, err := db.GetForeignKeys(database, table)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, foreignKey := range ParseForeignKeys(db.GetProvider(), table, rows) {
+			key := foreignKey.Table + "." + foreignKey.Name
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			foreignKeys = append(foreignKeys, foreignKey)
+		}
+	}
+
+	return foreignKeys, nil
+}
+
+// ForeignKeyCache loads the foreign keys of a database once and keeps them
+// until Invalidate is called.
+type ForeignKeyCache struct {
+	mu        sync.Mutex
+	driver    Driver
+	databases map[string][]models.ForeignKey
+}
+
+func NewForeignKeyCache(driver Driver) *ForeignKeyCache {
+	return &ForeignKeyCache{
+		driver:    driver,
+		databases: map[string][]models.ForeignKey{},
+	}
+}
+
+func (cache *ForeignKeyCache) Get(database string) ([]models.ForeignKey, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if foreignKeys, ok := cache.databases[database]; ok {
+		return foreignKeys, nil
+	}
+
+	foreignKeys, err := GetAllForeignKeys(cache.driver, database)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.databases[database] = foreignKeys
+
+	return foreignKeys, nil
+}
+
+// Outgoing returns the foreign keys declared on the given table
+func (cache *ForeignKeyCache) Outgoing(database, table string) ([]models.ForeignKey, error) {
+	foreignKeys, err := cache.Get(database)
+	if err != nil {
+		return nil, err
+	}
+
+	outgoing := []models.ForeignKey{}
+	for _, foreignKey := range foreignKeys {
+		if foreignKey.Table == table {
+			outgoing = append(outgoing, foreignKey)
+		}
+	}
+
+	return outgoing, nil
+}
+
+// Incoming returns the foreign keys of other tables referencing the given table
+func (cache *ForeignKeyCache) Incoming(database, table string) ([]models.ForeignKey, error) {
+	foreignKeys, err := cache.Get(database)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming := []models.ForeignKey{}
+	for _, foreignKey := range foreignKeys {
+		if foreignKey.ReferencedTable == table {
+			incoming = append(incoming, foreignKey)
+		}
+	}
+
+	return incoming, nil
+}
+
+func (cache *ForeignKeyCache) Invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.databases = map[string][]models.ForeignKey{}
+}
+
+// BuildForeignKeyFilter returns a WHERE clause matching the given columns
+// against values, or an empty string if any value is NULL.
+func BuildForeignKeyFilter(db Driver, columns, values []string) string {
+	conditions := make([]string, len(columns))
+
+	for i, column := range columns {
+		if values[i] == "NULL&" {
+			return ""
+		}
+
+		value := values[i]
+		if value == "EMPTY&" {
+			value = ""
+		}
+
+		conditions[i] = fmt.Sprintf("%s = %s", db.FormatReference(column), db.FormatArgForQueryString(value))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
@@ -0,0 +1,144 @@
+package drivers
+
+import (
+	"reflect"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestParseForeignKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider string
+		table    string
+		rows     [][]string
+		expected []models.ForeignKey
+	}{
+		{
+			name:     "SQLite composite key",
+			provider: DriverSqlite,
+			table:    "order_items",
+			rows: [][]string{
+				{"id", "seq", "table", "from", "to", "on_update", "on_delete", "match"},
+				{"0", "0", "orders", "order_id", "id", "NO ACTION", "CASCADE", "NONE"},
+				{"0", "1", "orders", "shop_id", "shop_id", "NO ACTION", "CASCADE", "NONE"},
+				{"1", "0", "products", "product_id", "id", "NO ACTION", "NO ACTION", "NONE"},
+			},
+			expected: []models.ForeignKey{
+				{Name: "fk_0", Table: "order_items", Columns: []string{"order_id", "shop_id"}, ReferencedTable: "orders", ReferencedColumns: []string{"id", "shop_id"}},
+				{Name: "fk_1", Table: "order_items", Columns: []string{"product_id"}, ReferencedTable: "products", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name:     "Postgres keeps the schema",
+			provider: DriverPostgres,
+			table:    "public.orders",
+			rows: [][]string{
+				{"constraint_name", "column_name", "foreign_table_name", "foreign_column_name"},
+				{"orders_customer_id_fkey", "customer_id", "customers", "id"},
+			},
+			expected: []models.ForeignKey{
+				{Name: "orders_customer_id_fkey", Table: "public.orders", Columns: []string{"customer_id"}, ReferencedTable: "public.customers", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name:     "Postgres composite key in another schema",
+			provider: DriverPostgres,
+			table:    "sales.order_items",
+			rows: [][]string{
+				{"constraint_name", "column_name", "foreign_table_schema", "foreign_table_name", "foreign_column_name"},
+				{"order_items_order_fkey", "order_id", "billing", "orders", "id"},
+				{"order_items_order_fkey", "shop_id", "billing", "orders", "shop_id"},
+				{"order_items_product_fkey", "product_id", "sales", "products", "id"},
+			},
+			expected: []models.ForeignKey{
+				{Name: "order_items_order_fkey", Table: "sales.order_items", Columns: []string{"order_id", "shop_id"}, ReferencedTable: "billing.orders", ReferencedColumns: []string{"id", "shop_id"}},
+				{Name: "order_items_product_fkey", Table: "sales.order_items", Columns: []string{"product_id"}, ReferencedTable: "sales.products", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name:     "MySQL uses the owning table from the row",
+			provider: DriverMySQL,
+			table:    "customers",
+			rows: [][]string{
+				{"TABLE_NAME", "COLUMN_NAME", "CONSTRAINT_NAME", "REFERENCED_COLUMN_NAME", "REFERENCED_TABLE_NAME"},
+				{"orders", "customer_id", "fk_orders_customer", "id", "customers"},
+			},
+			expected: []models.ForeignKey{
+				{Name: "fk_orders_customer", Table: "orders", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name:     "MSSQL strips the schema",
+			provider: DriverMSSQL,
+			table:    "orders",
+			rows: [][]string{
+				{"constraint_name", "column_name", "current_database", "referenced_table", "referenced_column", "delete_rule", "update_rule"},
+				{"FK_orders_customers", "customer_id", "shop", "dbo.customers", "id", "NO_ACTION", "NO_ACTION"},
+			},
+			expected: []models.ForeignKey{
+				{Name: "FK_orders_customers", Table: "orders", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name:     "Header only",
+			provider: DriverSqlite,
+			table:    "orders",
+			rows:     [][]string{{"id", "seq", "table", "from", "to"}},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseForeignKeys(tc.provider, tc.table, tc.rows)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestForeignKeyCache(t *testing.T) {
+	db := newSQLiteMemoryDB(t, "foreign_keys", []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers(id))",
+		"CREATE TABLE invoices (id INTEGER PRIMARY KEY, order_id INTEGER REFERENCES orders(id), customer_id INTEGER REFERENCES customers(id))",
+	})
+
+	cache := NewForeignKeyCache(db)
+
+	outgoing, err := cache.Outgoing("foreign_keys", "invoices")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outgoing) != 2 {
+		t.Fatalf("expected 2 outgoing foreign keys, got %+v", outgoing)
+	}
+
+	incoming, err := cache.Incoming("foreign_keys", "customers")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tables := []string{}
+	for _, foreignKey := range incoming {
+		tables = append(tables, foreignKey.Table)
+	}
+	if !reflect.DeepEqual(tables, []string{"invoices", "orders"}) {
+		t.Fatalf("expected customers to be referenced by invoices and orders, got %v", tables)
+	}
+}
+
+func TestBuildForeignKeyFilter(t *testing.T) {
+	db := &SQLite{}
+
+	if got := BuildForeignKeyFilter(db, []string{"id", "name"}, []string{"1", "O'Reilly"}); got != "`id` = '1' AND `name` = 'O''Reilly'" {
+		t.Fatalf("unexpected filter %q", got)
+	}
+
+	if got := BuildForeignKeyFilter(db, []string{"id"}, []string{"NULL&"}); got != "" {
+		t.Fatalf("expected no filter for NULL values, got %q", got)
+	}
+}
@@ -14,10 +14,14 @@ type Driver interface {
 	GetForeignKeys(database, table string) ([][]string, error)
 	GetIndexes(database, table string) ([][]string, error)
 	GetRecords(database, table, where, sort string, offset, limit int) ([][]string, int, string, error)
+	// GetKeysetRecords returns the header and the first limit records of a
+	// table matching where, whose placeholders are bound to args, without
+	// counting the records the way GetRecords does
+	GetKeysetRecords(database, table, where string, args []any, sort string, limit int) ([][]string, error)
 	UpdateRecord(database, table, column, value, primaryKeyColumnName, primaryKeyValue string) error
 	DeleteRecord(database, table string, primaryKeyColumnName, primaryKeyValue string) error
-	ExecuteDMLStatement(query string) (string, error)
-	ExecuteQuery(query string) ([][]string, int, error)
+	ExecuteDMLStatement(query string, args ...any) (string, error)
+	ExecuteQuery(query string, args ...any) ([][]string, int, error)
 	ExecutePendingChanges(changes []models.DBDMLChange) error
 	GetProvider() string
 	GetPrimaryKeyColumnNames(database, table string) ([]string, error)
@@ -34,3 +38,10 @@ type Driver interface {
 	// find a better way to do it. See *ResultsTable.GetPrimaryKeyValue()
 	SetProvider(provider string)
 }
+
+// DatabaseSwitcher is implemented by drivers whose connection is bound to one
+// database at a time, where queries of the editor run.
+type DatabaseSwitcher interface {
+	GetCurrentDatabase() string
+	SwitchDatabase(database string) error
+}
@@ -254,6 +254,17 @@ func (db *MSSQL) GetIndexes(database, table string) ([][]string, error) {
 }
 
 func (db *MSSQL) GetRecords(database, table, where, sort string, offset, limit int) (results [][]string, totalRecords int, displayQueryString string, err error) {
+	return db.getRecords(database, table, where, nil, sort, offset, limit, true)
+}
+
+func (db *MSSQL) GetKeysetRecords(database, table, where string, args []any, sort string, limit int) ([][]string, error) {
+	records, _, _, err := db.getRecords(database, table, where, args, sort, 0, limit, false)
+	return records, err
+}
+
+// getRecords returns a page of the records of a table matching where, whose
+// placeholders are bound to args, and their count when count is set
+func (db *MSSQL) getRecords(database, table, where string, args []any, sort string, offset, limit int, count bool) (results [][]string, totalRecords int, displayQueryString string, err error) {
 	if database == "" {
 		return nil, 0, "", errors.New("database name is required")
 	}
@@ -281,12 +292,12 @@ func (db *MSSQL) GetRecords(database, table, where, sort string, offset, limit i
 	}
 
 	// Query for execution with placeholders
-	executableQuery := fmt.Sprintf("%s ORDER BY %s OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY", baseQuery, sort)
+	executableQuery := fmt.Sprintf("%s ORDER BY %s OFFSET %s ROWS FETCH NEXT %s ROWS ONLY", baseQuery, sort, db.FormatPlaceholder(len(args)+1), db.FormatPlaceholder(len(args)+2))
 
 	// Query for display with actual values
 	displayQueryString = fmt.Sprintf("%s ORDER BY %s OFFSET %s ROWS FETCH NEXT %s ROWS ONLY", baseQuery, sort, db.FormatArg(offset, models.String), db.FormatArg(limit, models.String))
 
-	rows, err := db.Connection.Query(executableQuery, offset, limit)
+	rows, err := db.Connection.Query(executableQuery, append(args[:len(args):len(args)], offset, limit)...)
 	if err != nil {
 		return nil, 0, displayQueryString, err // Return display query even on error
 	}
@@ -369,6 +380,10 @@ func (db *MSSQL) GetRecords(database, table, where, sort string, offset, limit i
 		return nil, 0, displayQueryString, err
 	}
 
+	if !count {
+		return results, 0, displayQueryString, nil
+	}
+
 	countQuery := "SELECT COUNT(*) FROM "
 	countQuery += db.FormatReference(table)
 
@@ -377,7 +392,7 @@ func (db *MSSQL) GetRecords(database, table, where, sort string, offset, limit i
 	}
 
 	totalRecords = 0
-	countRow := db.Connection.QueryRow(countQuery)
+	countRow := db.Connection.QueryRow(countQuery, args...)
 	if err := countRow.Scan(&totalRecords); err != nil {
 		return results, 0, displayQueryString, err // Return display query even on count error
 	}
@@ -448,12 +463,12 @@ func (db *MSSQL) DeleteRecord(database, table, primaryKeyColumnName, primaryKeyV
 	return err
 }
 
-func (db *MSSQL) ExecuteDMLStatement(query string) (string, error) {
+func (db *MSSQL) ExecuteDMLStatement(query string, args ...any) (string, error) {
 	if query == "" {
 		return "", errors.New("query is required")
 	}
 
-	res, err := db.Connection.Exec(query)
+	res, err := db.Connection.Exec(query, args...)
 	if err != nil {
 		return "", err
 	}
@@ -466,12 +481,12 @@ func (db *MSSQL) ExecuteDMLStatement(query string) (string, error) {
 	return fmt.Sprintf("%d rows affected", rowsAffected), nil
 }
 
-func (db *MSSQL) ExecuteQuery(query string) ([][]string, int, error) {
+func (db *MSSQL) ExecuteQuery(query string, args ...any) ([][]string, int, error) {
 	if query == "" {
 		return nil, 0, errors.New("query can not be empty")
 	}
 
-	rows, err := db.Connection.Query(query)
+	rows, err := db.Connection.Query(query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -291,6 +291,17 @@ func (db *MySQL) GetIndexes(database, table string) (results [][]string, err err
 }
 
 func (db *MySQL) GetRecords(database, table, where, sort string, offset, limit int) (paginatedResults [][]string, totalRecords int, queryString string, err error) {
+	return db.getRecords(database, table, where, nil, sort, offset, limit, true)
+}
+
+func (db *MySQL) GetKeysetRecords(database, table, where string, args []any, sort string, limit int) ([][]string, error) {
+	records, _, _, err := db.getRecords(database, table, where, args, sort, 0, limit, false)
+	return records, err
+}
+
+// getRecords returns a page of the records of a table matching where, whose
+// placeholders are bound to args, and their count when count is set
+func (db *MySQL) getRecords(database, table, where string, args []any, sort string, offset, limit int, count bool) (paginatedResults [][]string, totalRecords int, queryString string, err error) {
 	if table == "" {
 		return nil, 0, "", errors.New("table name is required")
 	}
@@ -316,7 +327,7 @@ func (db *MySQL) GetRecords(database, table, where, sort string, offset, limit i
 
 	queryString += " LIMIT ?, ?"
 
-	paginatedRows, err := db.Connection.Query(queryString, offset, limit)
+	paginatedRows, err := db.Connection.Query(queryString, append(args[:len(args):len(args)], offset, limit)...)
 	if err != nil {
 		return nil, 0, queryString, err
 	}
@@ -365,13 +376,17 @@ func (db *MySQL) GetRecords(database, table, where, sort string, offset, limit i
 		return nil, 0, queryString, err
 	}
 
+	if !count {
+		return paginatedResults, 0, queryString, nil
+	}
+
 	countQuery := "SELECT COUNT(*) FROM "
 	countQuery += fmt.Sprintf("`%s`.", database)
 	countQuery += fmt.Sprintf("`%s`", table)
 	if where != "" { // Add WHERE clause to count query as well if it exists
 		countQuery += fmt.Sprintf(" %s", where)
 	}
-	countRow := db.Connection.QueryRow(countQuery)
+	countRow := db.Connection.QueryRow(countQuery, args...)
 	if err := countRow.Scan(&totalRecords); err != nil {
 		// Return the main query string even if count fails, for debugging.
 		return paginatedResults, 0, queryString, err
@@ -384,8 +399,8 @@ func (db *MySQL) GetRecords(database, table, where, sort string, offset, limit i
 	return paginatedResults, totalRecords, queryString, nil
 }
 
-func (db *MySQL) ExecuteQuery(query string) ([][]string, int, error) {
-	rows, err := db.Connection.Query(query)
+func (db *MySQL) ExecuteQuery(query string, args ...any) ([][]string, int, error) {
+	rows, err := db.Connection.Query(query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -444,8 +459,8 @@ func (db *MySQL) DeleteRecord(database, table, primaryKeyColumnName, primaryKeyV
 	return err
 }
 
-func (db *MySQL) ExecuteDMLStatement(query string) (result string, err error) {
-	res, err := db.Connection.Exec(query)
+func (db *MySQL) ExecuteDMLStatement(query string, args ...any) (result string, err error) {
+	res, err := db.Connection.Exec(query, args...)
 	if err != nil {
 		return "", err
 	}
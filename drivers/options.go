@@ -0,0 +1,353 @@
+package drivers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+
+	"sqlcmder/models"
+)
+
+// OptionKind is the type of the value of a connection option
+type OptionKind int
+
+const (
+	OptionString OptionKind = iota
+	OptionInt
+	OptionBool
+	OptionDuration
+	OptionEnum
+)
+
+// Option is a connection option of a driver, set as a DSN parameter.
+// Options a driver has no Option for are DSN parameters passed through as
+// they are, named after the parameter, or _pragma(name) for SQLite pragmas.
+type Option struct {
+	Name   string // Name in the config and the connection form
+	Param  string // DSN parameter set by the option
+	Kind   OptionKind
+	Values []string // Values allowed for an OptionEnum
+	Pragma bool     // Set with a _pragma parameter, for SQLite
+}
+
+// driverOptions are the options each driver takes
+var driverOptions = map[string][]Option{
+	DriverPostgres: {
+		{Name: "connect_timeout", Param: "connect_timeout", Kind: OptionInt},
+		{Name: "application_name", Param: "application_name", Kind: OptionString},
+		{Name: "search_path", Param: "search_path", Kind: OptionString},
+		{Name: "sslmode", Param: "sslmode", Kind: OptionEnum, Values: []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}},
+		{Name: "sslrootcert", Param: "sslrootcert", Kind: OptionString},
+		{Name: "sslcert", Param: "sslcert", Kind: OptionString},
+		{Name: "sslkey", Param: "sslkey", Kind: OptionString},
+	},
+	DriverMySQL: {
+		{Name: "timeout", Param: "timeout", Kind: OptionDuration},
+		{Name: "read_timeout", Param: "readTimeout", Kind: OptionDuration},
+		{Name: "write_timeout", Param: "writeTimeout", Kind: OptionDuration},
+		{Name: "charset", Param: "charset", Kind: OptionString},
+		{Name: "collation", Param: "collation", Kind: OptionString},
+		{Name: "parse_time", Param: "parseTime", Kind: OptionBool},
+		{Name: "loc", Param: "loc", Kind: OptionString},
+		{Name: "tls", Param: "tls", Kind: OptionString},
+	},
+	DriverMSSQL: {
+		{Name: "connection_timeout", Param: "connection timeout", Kind: OptionInt},
+		{Name: "dial_timeout", Param: "dial timeout", Kind: OptionInt},
+		{Name: "app_name", Param: "app name", Kind: OptionString},
+		{Name: "encrypt", Param: "encrypt", Kind: OptionEnum, Values: []string{"disable", "false", "true", "strict"}},
+		{Name: "trust_server_certificate", Param: "TrustServerCertificate", Kind: OptionBool},
+		{Name: "certificate", Param: "certificate", Kind: OptionString},
+		{Name: "hostname_in_certificate", Param: "hostNameInCertificate", Kind: OptionString},
+		{Name: "packet_size", Param: "packet size", Kind: OptionInt},
+	},
+	DriverSqlite: {
+		{Name: "busy_timeout", Param: "busy_timeout", Kind: OptionInt, Pragma: true},
+		{Name: "journal_mode", Param: "journal_mode", Kind: OptionEnum, Values: []string{"delete", "truncate", "persist", "memory", "wal", "off"}, Pragma: true},
+		{Name: "synchronous", Param: "synchronous", Kind: OptionEnum, Values: []string{"off", "normal", "full", "extra"}, Pragma: true},
+		{Name: "foreign_keys", Param: "foreign_keys", Kind: OptionBool, Pragma: true},
+		{Name: "txlock", Param: "_txlock", Kind: OptionEnum, Values: []string{"deferred", "immediate", "exclusive"}},
+	},
+}
+
+// Options returns the connection options of a driver.
+func Options(driver string) []Option {
+	return driverOptions[driver]
+}
+
+// ValidateOptions checks the options known to the driver have valid values.
+// Other options are passed through as DSN parameters and are not checked.
+func ValidateOptions(driver string, options map[string]string) error {
+	for _, name := range sortedKeys(options) {
+		option, ok := findOption(driver, name)
+		if !ok {
+			continue
+		}
+		if err := option.validate(options[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o Option) validate(value string) error {
+	var err error
+
+	switch o.Kind {
+	case OptionInt:
+		var number int
+		if number, err = strconv.Atoi(value); err == nil && number < 0 {
+			err = fmt.Errorf("option %s must not be negative", o.Name)
+		}
+	case OptionBool:
+		_, err = strconv.ParseBool(value)
+	case OptionDuration:
+		_, err = time.ParseDuration(value)
+	case OptionEnum:
+		if !slices.Contains(o.Values, strings.ToLower(value)) {
+			err = fmt.Errorf("option %s must be one of %s", o.Name, strings.Join(o.Values, ", "))
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value %q for option %s: %w", value, o.Name, err)
+	}
+	return nil
+}
+
+// BuildDSN builds the DSN of a connection from its fields and options, with
+// its username, password and database escaped.
+func BuildDSN(connection models.Connection) (string, error) {
+	if err := ValidateOptions(connection.Driver, connection.Options); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	for _, name := range sortedKeys(connection.Options) {
+		option, ok := findOption(connection.Driver, name)
+		if !ok {
+			option = passthroughOption(name)
+		}
+		option.addParam(params, connection.Options[name])
+	}
+
+	var user *url.Userinfo
+	switch {
+	case connection.Username != "" && connection.Password != "":
+		user = url.UserPassword(connection.Username, connection.Password)
+	case connection.Username != "":
+		user = url.User(connection.Username)
+	}
+
+	host := connection.Hostname
+	if connection.Port != "" {
+		host = net.JoinHostPort(host, connection.Port)
+	}
+
+	switch connection.Driver {
+	case DriverPostgres, DriverMySQL:
+		u := url.URL{Scheme: connection.Driver, User: user, Host: host, Path: "/" + connection.DBName, RawQuery: params.Encode()}
+		return u.String(), nil
+
+	case DriverMSSQL:
+		if connection.DBName != "" {
+			params.Set("database", connection.DBName)
+		}
+		u := url.URL{Scheme: connection.Driver, User: user, Host: host, RawQuery: params.Encode()}
+		return u.String(), nil
+
+	case DriverSqlite:
+		// The SQLite driver takes a file name followed by its parameters
+		if len(params) == 0 {
+			return connection.DBName, nil
+		}
+		return connection.DBName + "?" + params.Encode(), nil
+	}
+
+	return "", fmt.Errorf("unknown driver %q", connection.Driver)
+}
+
+// ParseDSN returns the connection described by a DSN, its parameters becoming
+// options of its driver.
+func ParseDSN(dsn string) (models.Connection, error) {
+	parsed, err := dburl.Parse(dsn)
+	if err != nil {
+		return models.Connection{}, err
+	}
+
+	connection := models.Connection{Driver: parsed.Driver}
+	params := parsed.Query()
+
+	switch parsed.Driver {
+	case DriverSqlite:
+		connection.DBName = parsed.Opaque
+		if connection.DBName == "" {
+			connection.DBName = parsed.Path
+		}
+	default:
+		connection.Hostname = parsed.Hostname()
+		connection.Port = parsed.Port()
+		if parsed.User != nil {
+			connection.Username = parsed.User.Username()
+			connection.Password, _ = parsed.User.Password()
+		}
+	}
+
+	switch parsed.Driver {
+	case DriverPostgres, DriverMySQL:
+		connection.DBName = strings.TrimPrefix(parsed.Path, "/")
+	case DriverMSSQL:
+		// The path of a SQL Server URL is its instance, which BuildDSN
+		// doesn't write, the database and password are parameters
+		for key := range params {
+			switch {
+			case strings.EqualFold(key, "database"):
+				connection.DBName = params.Get(key)
+				params.Del(key)
+			case strings.EqualFold(key, "password"):
+				if connection.Password == "" {
+					connection.Password = params.Get(key)
+				}
+				params.Del(key)
+			}
+		}
+	}
+
+	connection.Options, err = OptionsFromParams(parsed.Driver, params)
+	if err != nil {
+		return models.Connection{}, err
+	}
+
+	return connection, nil
+}
+
+// OptionsFromParams returns the options set by DSN parameters. Parameters
+// no option of the driver sets are kept as they are, to be passed through.
+func OptionsFromParams(driver string, params url.Values) (map[string]string, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	options := map[string]string{}
+
+	for _, param := range sortedKeys(params) {
+		for _, value := range params[param] {
+			name, optionValue, _ := optionOfParam(driver, param, value)
+			options[name] = optionValue
+		}
+	}
+
+	if err := ValidateOptions(driver, options); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// optionOfParam returns the option set by a DSN parameter, and its value.
+// When the driver has no such option, it is the parameter as is and ok is
+// false.
+func optionOfParam(driver string, param string, value string) (name string, optionValue string, ok bool) {
+	pragma := param == "_pragma"
+	if pragma {
+		pragmaName, rest, found := strings.Cut(value, "(")
+		if !found || !strings.HasSuffix(rest, ")") {
+			return param, value, false
+		}
+		param, value = pragmaName, strings.TrimSuffix(rest, ")")
+	}
+
+	for _, option := range Options(driver) {
+		if option.Pragma == pragma && strings.EqualFold(option.Param, param) {
+			return option.Name, value, true
+		}
+	}
+
+	if pragma {
+		return "_pragma(" + param + ")", value, false
+	}
+	return param, value, false
+}
+
+// passthroughOption returns the option of a DSN parameter the driver has no
+// option for, which is set as it is
+func passthroughOption(name string) Option {
+	if pragma, found := strings.CutPrefix(name, "_pragma("); found && strings.HasSuffix(pragma, ")") {
+		return Option{Name: name, Param: strings.TrimSuffix(pragma, ")"), Pragma: true}
+	}
+	return Option{Name: name, Param: name}
+}
+
+func (o Option) addParam(params url.Values, value string) {
+	if !o.Pragma {
+		params.Set(o.Param, value)
+		return
+	}
+
+	if o.Kind == OptionBool {
+		if enabled, _ := strconv.ParseBool(value); enabled {
+			value = "1"
+		} else {
+			value = "0"
+		}
+	}
+	params.Add("_pragma", o.Param+"("+value+")")
+}
+
+// ParseOptions parses options written as name=value pairs separated by
+// semicolons, like in the connection form.
+func ParseOptions(text string) (map[string]string, error) {
+	options := map[string]string{}
+
+	for _, pair := range strings.Split(text, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("option %q has no value", pair)
+		}
+		options[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+	return options, nil
+}
+
+// FormatOptions writes options the way ParseOptions reads them.
+func FormatOptions(options map[string]string) string {
+	pairs := make([]string, 0, len(options))
+	for _, name := range sortedKeys(options) {
+		pairs = append(pairs, name+"="+options[name])
+	}
+	return strings.Join(pairs, "; ")
+}
+
+func findOption(driver string, name string) (Option, bool) {
+	for _, option := range Options(driver) {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,185 @@
+package drivers
+
+import (
+	"reflect"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestBuildDSN(t *testing.T) {
+	tests := []struct {
+		name       string
+		connection models.Connection
+		want       string
+		wantErr    bool
+	}{
+		{
+			name: "postgres escapes credentials",
+			connection: models.Connection{
+				Driver: DriverPostgres, Username: "app@corp", Password: "p@ss:w/rd?", Hostname: "db", Port: "5432", DBName: "app",
+				Options: map[string]string{"sslmode": "disable", "application_name": "sql cmder"},
+			},
+			want: "postgres://app%40corp:p%40ss%3Aw%2Frd%3F@db:5432/app?application_name=sql+cmder&sslmode=disable",
+		},
+		{
+			name:       "mysql renames parameters",
+			connection: models.Connection{Driver: DriverMySQL, Username: "root", Hostname: "db", Port: "3306", DBName: "app", Options: map[string]string{"read_timeout": "30s", "parse_time": "true"}},
+			want:       "mysql://root@db:3306/app?parseTime=true&readTimeout=30s",
+		},
+		{
+			name:       "sqlserver database parameter",
+			connection: models.Connection{Driver: DriverMSSQL, Username: "sa", Password: "a&b", Hostname: "db", Port: "1433", DBName: "app", Options: map[string]string{"app_name": "sqlcmder"}},
+			want:       "sqlserver://sa:a&b@db:1433?app+name=sqlcmder&database=app",
+		},
+		{
+			name:       "sqlite file",
+			connection: models.Connection{Driver: DriverSqlite, DBName: "./app.db"},
+			want:       "./app.db",
+		},
+		{
+			name:       "sqlite pragmas",
+			connection: models.Connection{Driver: DriverSqlite, DBName: "./app.db", Options: map[string]string{"busy_timeout": "5000", "foreign_keys": "true", "txlock": "immediate"}},
+			want:       "./app.db?_pragma=busy_timeout%285000%29&_pragma=foreign_keys%281%29&_txlock=immediate",
+		},
+		{
+			name:       "unknown options pass through",
+			connection: models.Connection{Driver: DriverPostgres, Hostname: "db", DBName: "app", Options: map[string]string{"sslmode": "prefer", "target_session_attrs": "read-write"}},
+			want:       "postgres://db/app?sslmode=prefer&target_session_attrs=read-write",
+		},
+		{
+			name:       "sqlite unknown pragma",
+			connection: models.Connection{Driver: DriverSqlite, DBName: "./app.db", Options: map[string]string{"_pragma(cache_size)": "-2000"}},
+			want:       "./app.db?_pragma=cache_size%28-2000%29",
+		},
+		{
+			name:       "invalid number",
+			connection: models.Connection{Driver: DriverPostgres, Hostname: "db", Options: map[string]string{"connect_timeout": "soon"}},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid enum",
+			connection: models.Connection{Driver: DriverSqlite, DBName: "./app.db", Options: map[string]string{"journal_mode": "fast"}},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid duration",
+			connection: models.Connection{Driver: DriverMySQL, Hostname: "db", Options: map[string]string{"timeout": "10"}},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown driver",
+			connection: models.Connection{Driver: "oracle", Hostname: "db"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildDSN(tt.connection)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildDSN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("BuildDSN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     string
+		want    models.Connection
+		wantErr bool
+	}{
+		{
+			name: "postgres",
+			dsn:  "postgres://app%40corp:secret@db:5432/app?sslmode=require&connect_timeout=5",
+			want: models.Connection{
+				Driver: DriverPostgres, Username: "app@corp", Password: "secret", Hostname: "db", Port: "5432", DBName: "app",
+				Options: map[string]string{"sslmode": "require", "connect_timeout": "5"},
+			},
+		},
+		{
+			name: "sqlserver",
+			dsn:  "sqlserver://sa@db:1433?Database=app&Encrypt=true",
+			want: models.Connection{
+				Driver: DriverMSSQL, Username: "sa", Hostname: "db", Port: "1433", DBName: "app",
+				Options: map[string]string{"encrypt": "true"},
+			},
+		},
+		{
+			name: "sqlite pragmas",
+			dsn:  "sqlite:./app.db?_pragma=journal_mode(wal)",
+			want: models.Connection{
+				Driver: DriverSqlite, DBName: "./app.db",
+				Options: map[string]string{"journal_mode": "wal"},
+			},
+		},
+		{
+			name: "mysql unknown parameter",
+			dsn:  "mysql://root@db:3306/app?multiStatements=true&parseTime=true",
+			want: models.Connection{
+				Driver: DriverMySQL, Username: "root", Hostname: "db", Port: "3306", DBName: "app",
+				Options: map[string]string{"multiStatements": "true", "parse_time": "true"},
+			},
+		},
+		{
+			name: "postgres unix socket",
+			dsn:  "postgres://app@/app?host=/var/run/postgresql&sslmode=allow",
+			want: models.Connection{
+				Driver: DriverPostgres, Username: "app", DBName: "app",
+				Options: map[string]string{"host": "/var/run/postgresql", "sslmode": "allow"},
+			},
+		},
+		{
+			name: "sqlserver instance",
+			dsn:  "sqlserver://sa:secret@db/SQLEXPRESS?database=master",
+			want: models.Connection{
+				Driver: DriverMSSQL, Username: "sa", Password: "secret", Hostname: "db", DBName: "master",
+			},
+		},
+		{
+			name: "sqlite unknown pragma",
+			dsn:  "sqlite:./app.db?_pragma=cache_size(-2000)",
+			want: models.Connection{
+				Driver: DriverSqlite, DBName: "./app.db",
+				Options: map[string]string{"_pragma(cache_size)": "-2000"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDSN(tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDSN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseDSN() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	options, err := ParseOptions(" search_path=app,public ; connect_timeout = 10;")
+	if err != nil {
+		t.Fatalf("ParseOptions() error = %v", err)
+	}
+
+	want := map[string]string{"search_path": "app,public", "connect_timeout": "10"}
+	if !reflect.DeepEqual(options, want) {
+		t.Fatalf("ParseOptions() = %v, want %v", options, want)
+	}
+
+	if got := FormatOptions(options); got != "connect_timeout=10; search_path=app,public" {
+		t.Fatalf("FormatOptions() = %q", got)
+	}
+
+	if _, err := ParseOptions("connect_timeout"); err == nil {
+		t.Fatalf("ParseOptions() error = nil for an option without value")
+	}
+}
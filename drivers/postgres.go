@@ -1,14 +1,17 @@
 package drivers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
-	// import postgresql driver
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/xo/dburl"
 
 	"sqlcmder/logger"
@@ -34,7 +37,7 @@ func (db *Postgres) TestConnection(urlstr string) error {
 func (db *Postgres) Connect(urlstr string) error {
 	db.SetProvider(DriverPostgres)
 
-	connection, err := dburl.Open(urlstr)
+	connection, err := openPostgres(urlstr)
 	if err != nil {
 		return err
 	}
@@ -309,23 +312,32 @@ func (db *Postgres) GetForeignKeys(database, table string) ([][]string, error) {
 	tableSchema := splitTableString[0]
 	tableName := splitTableString[1]
 
-	rows, err := db.Connection.Query(fmt.Sprintf(`
+	// The referenced columns are matched by their position in the key they
+	// reference, so the columns of composite keys are paired one to one
+	rows, err := db.Connection.Query(`
         SELECT
             tc.constraint_name,
             kcu.column_name,
-            ccu.table_name AS foreign_table_name,
-            ccu.column_name AS foreign_column_name
+            fkcu.table_schema AS foreign_table_schema,
+            fkcu.table_name AS foreign_table_name,
+            fkcu.column_name AS foreign_column_name
         FROM
             information_schema.table_constraints AS tc
             JOIN information_schema.key_column_usage AS kcu ON tc.constraint_name = kcu.constraint_name
             AND tc.table_schema = kcu.table_schema
-            JOIN information_schema.constraint_column_usage AS ccu ON ccu.constraint_name = tc.constraint_name
-            AND ccu.table_schema = tc.table_schema
+            JOIN information_schema.referential_constraints AS rc ON rc.constraint_name = tc.constraint_name
+            AND rc.constraint_schema = tc.table_schema
+            JOIN information_schema.key_column_usage AS fkcu ON fkcu.constraint_name = rc.unique_constraint_name
+            AND fkcu.constraint_schema = rc.unique_constraint_schema
+            AND fkcu.ordinal_position = kcu.position_in_unique_constraint
         WHERE
             tc.constraint_type = 'FOREIGN KEY'
-          	AND tc.table_schema = '%s'
-            AND tc.table_name = '%s'
-  `, tableSchema, tableName))
+            AND tc.table_schema = $1
+            AND tc.table_name = $2
+        ORDER BY
+            tc.constraint_name,
+            kcu.ordinal_position
+  `, tableSchema, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -452,6 +464,17 @@ func (db *Postgres) GetIndexes(database, table string) ([][]string, error) {
 }
 
 func (db *Postgres) GetRecords(database, table, where, sort string, offset, limit int) (records [][]string, totalRecords int, queryString string, err error) {
+	return db.getRecords(database, table, where, nil, sort, offset, limit, true)
+}
+
+func (db *Postgres) GetKeysetRecords(database, table, where string, args []any, sort string, limit int) ([][]string, error) {
+	records, _, _, err := db.getRecords(database, table, where, args, sort, 0, limit, false)
+	return records, err
+}
+
+// getRecords returns a page of the records of a table matching where, whose
+// placeholders are bound to args, and their count when count is set
+func (db *Postgres) getRecords(database, table, where string, args []any, sort string, offset, limit int, count bool) (records [][]string, totalRecords int, queryString string, err error) {
 	if database == "" {
 		return nil, 0, "", errors.New("database name is required")
 	}
@@ -488,13 +511,15 @@ func (db *Postgres) GetRecords(database, table, where, sort string, offset, limi
 		queryString += fmt.Sprintf(" ORDER BY %s", sort)
 	}
 
-	queryString += " LIMIT $1 OFFSET $2"
+	limitPlaceholder := db.FormatPlaceholder(len(args) + 1)
+	offsetPlaceholder := db.FormatPlaceholder(len(args) + 2)
+	queryString += fmt.Sprintf(" LIMIT %s OFFSET %s", limitPlaceholder, offsetPlaceholder)
 
 	if limit == 0 {
 		limit = DefaultRowLimit
 	}
 
-	paginatedRows, err := db.Connection.Query(queryString, limit, offset)
+	paginatedRows, err := db.Connection.Query(queryString, append(args[:len(args):len(args)], limit, offset)...)
 	if err != nil {
 		return nil, 0, queryString, err
 	}
@@ -542,6 +567,10 @@ func (db *Postgres) GetRecords(database, table, where, sort string, offset, limi
 		return nil, 0, queryString, err
 	}
 
+	if !count {
+		return records, 0, queryString, nil
+	}
+
 	countQuery := "SELECT COUNT(*) FROM "
 	countQuery += formattedTableName
 
@@ -549,15 +578,15 @@ func (db *Postgres) GetRecords(database, table, where, sort string, offset, limi
 		countQuery += fmt.Sprintf(" %s", where)
 	}
 
-	countRow := db.Connection.QueryRow(countQuery)
+	countRow := db.Connection.QueryRow(countQuery, args...)
 
 	if err := countRow.Scan(&totalRecords); err != nil {
 		return records, 0, queryString, err
 	}
 
 	// Replace the limit and offset with actual values in the query string
-	queryString = strings.Replace(queryString, "$1", strconv.Itoa(limit), 1)
-	queryString = strings.Replace(queryString, "$2", strconv.Itoa(offset), 1)
+	queryString = strings.Replace(queryString, limitPlaceholder, strconv.Itoa(limit), 1)
+	queryString = strings.Replace(queryString, offsetPlaceholder, strconv.Itoa(offset), 1)
 
 	return records, totalRecords, queryString, nil
 }
@@ -649,8 +678,8 @@ func (db *Postgres) DeleteRecord(database, table, primaryKeyColumnName, primaryK
 	return err
 }
 
-func (db *Postgres) ExecuteDMLStatement(query string) (result string, err error) {
-	res, err := db.Connection.Exec(query)
+func (db *Postgres) ExecuteDMLStatement(query string, args ...any) (result string, err error) {
+	res, err := db.Connection.Exec(query, args...)
 	if err != nil {
 		return result, err
 	}
@@ -661,8 +690,8 @@ func (db *Postgres) ExecuteDMLStatement(query string) (result string, err error)
 	return fmt.Sprintf("%d rows affected", rowsAffected), nil
 }
 
-func (db *Postgres) ExecuteQuery(query string) ([][]string, int, error) {
-	rows, err := db.Connection.Query(query)
+func (db *Postgres) ExecuteQuery(query string, args ...any) ([][]string, int, error) {
+	rows, err := db.Connection.Query(query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -804,6 +833,11 @@ func (db *Postgres) GetProvider() string {
 	return db.Provider
 }
 
+// GetCurrentDatabase returns the database the connection is bound to
+func (db *Postgres) GetCurrentDatabase() string {
+	return db.CurrentDatabase
+}
+
 func (db *Postgres) SwitchDatabase(database string) error {
 	parsedConn, err := dburl.Parse(db.Urlstr)
 	if err != nil {
@@ -812,9 +846,9 @@ func (db *Postgres) SwitchDatabase(database string) error {
 
 	user := parsedConn.User.Username()
 	password, _ := parsedConn.User.Password()
-	host := parsedConn.Host
+	host := parsedConn.Hostname()
 	port := parsedConn.Port()
-	dbname := parsedConn.Path
+	dbname := strings.TrimPrefix(parsedConn.Path, "/")
 
 	if port == "" {
 		port = defaultPort
@@ -824,7 +858,13 @@ func (db *Postgres) SwitchDatabase(database string) error {
 		dbname = database
 	}
 
-	connection, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname='%s' sslmode=disable", host, port, user, password, dbname))
+	// The parameters of the URL, like its TLS settings, are kept
+	switchedConn := parsedConn.URL
+	switchedConn.User = url.UserPassword(user, password)
+	switchedConn.Host = net.JoinHostPort(host, port)
+	switchedConn.Path = "/" + dbname
+
+	connection, err := openPostgres(switchedConn.String())
 	if err != nil {
 		return err
 	}
@@ -841,6 +881,63 @@ func (db *Postgres) SwitchDatabase(database string) error {
 	return nil
 }
 
+// openPostgres opens a connection from a URL. A hostaddr parameter, like the
+// libpq one, is the address connected to, while the host of the URL is the
+// name checked in the server certificate.
+func openPostgres(urlstr string) (*sql.DB, error) {
+	parsed, err := dburl.Parse(urlstr)
+	if err != nil {
+		return nil, err
+	}
+
+	query := parsed.Query()
+	hostaddr := query.Get("hostaddr")
+	if hostaddr == "" {
+		return dburl.Open(urlstr)
+	}
+
+	query.Del("hostaddr")
+	parsed.RawQuery = query.Encode()
+
+	// The DSN is built again without hostaddr, which lib/pq does not know
+	parsed, err = dburl.Parse(parsed.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := pq.NewConnector(parsed.DSN)
+	if err != nil {
+		return nil, err
+	}
+	connector.Dialer(hostaddrDialer{hostaddr: hostaddr})
+
+	return sql.OpenDB(connector), nil
+}
+
+// hostaddrDialer connects to hostaddr instead of the host it is given
+type hostaddrDialer struct {
+	hostaddr string
+}
+
+func (d hostaddrDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d hostaddrDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+func (d hostaddrDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if _, port, err := net.SplitHostPort(address); err == nil {
+		address = net.JoinHostPort(d.hostaddr, port)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, address)
+}
+
 func (db *Postgres) formatTableName(table string) (string, error) {
 	splitTableString := strings.Split(table, ".")
 
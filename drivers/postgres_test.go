@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -309,31 +310,36 @@ func TestPostgres_GetForeignKeys(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"constraint_name", "column_name",
-		"foreign_table_name", "foreign_column_name",
-		"update_rule", "delete_rule",
+		"foreign_table_schema", "foreign_table_name", "foreign_column_name",
 	}).AddRow(
 		"fk_test", "user_id",
-		"users", "id",
-		"CASCADE", "SET NULL",
+		"public", "users", "id",
 	)
 
-	mock.ExpectQuery(fmt.Sprintf(`
+	mock.ExpectQuery(regexp.QuoteMeta(`
         SELECT
             tc.constraint_name,
             kcu.column_name,
-            ccu.table_name AS foreign_table_name,
-            ccu.column_name AS foreign_column_name
+            fkcu.table_schema AS foreign_table_schema,
+            fkcu.table_name AS foreign_table_name,
+            fkcu.column_name AS foreign_column_name
         FROM
             information_schema.table_constraints AS tc
             JOIN information_schema.key_column_usage AS kcu ON tc.constraint_name = kcu.constraint_name
             AND tc.table_schema = kcu.table_schema
-            JOIN information_schema.constraint_column_usage AS ccu ON ccu.constraint_name = tc.constraint_name
-            AND ccu.table_schema = tc.table_schema
+            JOIN information_schema.referential_constraints AS rc ON rc.constraint_name = tc.constraint_name
+            AND rc.constraint_schema = tc.table_schema
+            JOIN information_schema.key_column_usage AS fkcu ON fkcu.constraint_name = rc.unique_constraint_name
+            AND fkcu.constraint_schema = rc.unique_constraint_schema
+            AND fkcu.ordinal_position = kcu.position_in_unique_constraint
         WHERE
             tc.constraint_type = 'FOREIGN KEY'
-          	AND tc.table_schema = '%s'
-            AND tc.table_name = '%s'
-  `, schemaPostgres, tableNamePostgres)).WillReturnRows(rows)
+            AND tc.table_schema = $1
+            AND tc.table_name = $2
+        ORDER BY
+            tc.constraint_name,
+            kcu.ordinal_position
+  `)).WithArgs(schemaPostgres, tableNamePostgres).WillReturnRows(rows)
 
 	constraints, err := pg.GetForeignKeys(DBNamePostgres, schemaAndTablePostgres)
 	if err != nil {
@@ -341,8 +347,8 @@ func TestPostgres_GetForeignKeys(t *testing.T) {
 	}
 
 	expected := [][]string{
-		{"constraint_name", "column_name", "foreign_table_name", "foreign_column_name", "update_rule", "delete_rule"},
-		{"fk_test", "user_id", "users", "id", "CASCADE", "SET NULL"},
+		{"constraint_name", "column_name", "foreign_table_schema", "foreign_table_name", "foreign_column_name"},
+		{"fk_test", "user_id", "public", "users", "id"},
 	}
 
 	if !reflect.DeepEqual(constraints, expected) {
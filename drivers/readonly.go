@@ -0,0 +1,62 @@
+package drivers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// WithReadOnly adds to a DSN the parameter opening read-only sessions with
+// its driver: default_transaction_read_only for Postgres, the
+// transaction_read_only session variable for MySQL, ApplicationIntent for SQL
+// Server and mode=ro for SQLite, which needs a file: URI for it.
+func WithReadOnly(driver string, dsn string) (string, error) {
+	if driver == DriverSqlite {
+		return sqliteReadOnly(dsn), nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	switch driver {
+	case DriverPostgres:
+		query.Set("default_transaction_read_only", "on")
+	case DriverMySQL:
+		query.Set("transaction_read_only", "1")
+	case DriverMSSQL:
+		for key := range query {
+			if strings.EqualFold(key, "applicationintent") {
+				query.Del(key)
+			}
+		}
+		query.Set("ApplicationIntent", "ReadOnly")
+	default:
+		return "", fmt.Errorf("read-only sessions are not supported with %s", driver)
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// sqliteReadOnly turns a SQLite file name and its parameters into a file: URI
+// opened with mode=ro. The driver only reads the parameters of file: URIs
+// past its own ones.
+func sqliteReadOnly(dsn string) string {
+	name, rawQuery, _ := strings.Cut(dsn, "?")
+
+	path, isURI := strings.CutPrefix(name, "file:")
+	if !isURI {
+		path = (&url.URL{Path: name}).EscapedPath()
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		query = url.Values{}
+	}
+	query.Set("mode", "ro")
+
+	return "file:" + path + "?" + query.Encode()
+}
@@ -0,0 +1,96 @@
+package drivers
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWithReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		dsn     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "postgres",
+			driver: DriverPostgres,
+			dsn:    "postgres://app@db:5432/app?sslmode=disable",
+			want:   "postgres://app@db:5432/app?default_transaction_read_only=on&sslmode=disable",
+		},
+		{
+			name:   "mysql",
+			driver: DriverMySQL,
+			dsn:    "mysql://root@db:3306/app",
+			want:   "mysql://root@db:3306/app?transaction_read_only=1",
+		},
+		{
+			name:   "sqlserver",
+			driver: DriverMSSQL,
+			dsn:    "sqlserver://sa@db:1433?applicationintent=ReadWrite&database=app",
+			want:   "sqlserver://sa@db:1433?ApplicationIntent=ReadOnly&database=app",
+		},
+		{
+			name:   "sqlite file name",
+			driver: DriverSqlite,
+			dsn:    "/data/my app.db?_pragma=busy_timeout(5000)",
+			want:   "file:/data/my%20app.db?_pragma=busy_timeout%285000%29&mode=ro",
+		},
+		{
+			name:   "sqlite uri",
+			driver: DriverSqlite,
+			dsn:    "file:app.db?mode=rw&cache=shared",
+			want:   "file:app.db?cache=shared&mode=ro",
+		},
+		{
+			name:    "unknown driver",
+			driver:  "oracle",
+			dsn:     "oracle://db/app",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WithReadOnly(tt.driver, tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithReadOnly() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("WithReadOnly() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteReadOnlySession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "read only.db")
+
+	writable := &SQLite{}
+	if err := writable.Connect(path); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := writable.Connection.Exec("CREATE TABLE t (id INTEGER)"); err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+	writable.Connection.Close()
+
+	dsn, err := WithReadOnly(DriverSqlite, path)
+	if err != nil {
+		t.Fatalf("WithReadOnly() error = %v", err)
+	}
+
+	readOnly := &SQLite{}
+	if err := readOnly.Connect(dsn); err != nil {
+		t.Fatalf("Connect(%q) error = %v", dsn, err)
+	}
+	defer readOnly.Connection.Close()
+
+	if _, err := readOnly.Connection.Exec("SELECT * FROM t"); err != nil {
+		t.Fatalf("SELECT error = %v", err)
+	}
+	if _, err := readOnly.Connection.Exec("INSERT INTO t VALUES (1)"); err == nil {
+		t.Fatalf("INSERT on a read-only session succeeded")
+	}
+}
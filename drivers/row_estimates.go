@@ -0,0 +1,54 @@
+package drivers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EstimateRows returns about how many rows a table has, read from the
+// statistics of the database rather than counted. The table is named like in
+// a query of the current database, optionally qualified by its schema. It
+// reports false when the database has no estimate for the table.
+func EstimateRows(db Driver, table string) (int64, bool) {
+	schema, name, qualified := strings.Cut(table, ".")
+	if !qualified {
+		schema, name = "", table
+	}
+
+	var rows [][]string
+	var err error
+
+	switch db.GetProvider() {
+	case DriverPostgres:
+		rows, _, err = db.ExecuteQuery("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)", table)
+	case DriverMySQL:
+		var database any
+		if qualified {
+			database = schema
+		}
+		rows, _, err = db.ExecuteQuery("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?", database, name)
+	case DriverMSSQL:
+		rows, _, err = db.ExecuteQuery("SELECT SUM(rows) FROM sys.partitions WHERE object_id = OBJECT_ID(@p1) AND index_id IN (0, 1)", table)
+	case DriverSqlite:
+		// SQLite keeps no row counts, the largest rowid is an upper bound
+		// read from the end of the table
+		reference := db.FormatReference(strings.ReplaceAll(name, "`", "``"))
+		if qualified {
+			reference = db.FormatReference(strings.ReplaceAll(schema, "`", "``")) + "." + reference
+		}
+		rows, _, err = db.ExecuteQuery("SELECT MAX(rowid) FROM " + reference)
+	default:
+		return 0, false
+	}
+
+	// The first row holds the column names
+	if err != nil || len(rows) < 2 || len(rows[1]) == 0 {
+		return 0, false
+	}
+
+	estimate, err := strconv.ParseInt(rows[1][0], 10, 64)
+	if err != nil || estimate < 0 {
+		return 0, false
+	}
+	return estimate, true
+}
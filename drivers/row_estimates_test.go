@@ -0,0 +1,92 @@
+package drivers
+
+import (
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestEstimateRowsSQLite(t *testing.T) {
+	db := &SQLite{}
+	if err := db.Connect(filepath.Join(t.TempDir(), "estimates.db")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer db.Connection.Close()
+
+	if _, err := db.Connection.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY); INSERT INTO orders (id) VALUES (1), (2), (40)"); err != nil {
+		t.Fatalf("setup error = %v", err)
+	}
+
+	tests := []struct {
+		table  string
+		want   int64
+		wantOK bool
+	}{
+		{"orders", 40, true},
+		{"main.orders", 40, true},
+		{"missing", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			got, ok := EstimateRows(db, tt.table)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("EstimateRows(%q) = %d, %t, want %d, %t", tt.table, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEstimateRowsMySQL(t *testing.T) {
+	tests := []struct {
+		table  string
+		args   []driver.Value
+		rows   *sqlmock.Rows
+		want   int64
+		wantOK bool
+	}{
+		{
+			table:  "orders",
+			args:   []driver.Value{nil, "orders"},
+			rows:   sqlmock.NewRows([]string{"TABLE_ROWS"}).AddRow("1200000"),
+			want:   1200000,
+			wantOK: true,
+		},
+		{
+			table:  "shop.orders",
+			args:   []driver.Value{"shop", "orders"},
+			rows:   sqlmock.NewRows([]string{"TABLE_ROWS"}).AddRow("15"),
+			want:   15,
+			wantOK: true,
+		},
+		{
+			table: "missing",
+			args:  []driver.Value{nil, "missing"},
+			rows:  sqlmock.NewRows([]string{"TABLE_ROWS"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			connection, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("sqlmock.New() error = %v", err)
+			}
+			defer connection.Close()
+
+			mock.ExpectQuery("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?").
+				WithArgs(tt.args...).
+				WillReturnRows(tt.rows)
+
+			got, ok := EstimateRows(&MySQL{Connection: connection, Provider: DriverMySQL}, tt.table)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("EstimateRows(%q) = %d, %t, want %d, %t", tt.table, got, ok, tt.want, tt.wantOK)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
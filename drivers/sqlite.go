@@ -292,6 +292,17 @@ func (db *SQLite) GetIndexes(_, table string) (results [][]string, err error) {
 }
 
 func (db *SQLite) GetRecords(_, table, where, sort string, offset, limit int) (paginatedResults [][]string, totalRecords int, queryString string, err error) {
+	return db.getRecords(table, where, nil, sort, offset, limit, true)
+}
+
+func (db *SQLite) GetKeysetRecords(_, table, where string, args []any, sort string, limit int) ([][]string, error) {
+	records, _, _, err := db.getRecords(table, where, args, sort, 0, limit, false)
+	return records, err
+}
+
+// getRecords returns a page of the records of a table matching where, whose
+// placeholders are bound to args, and their count when count is set
+func (db *SQLite) getRecords(table, where string, args []any, sort string, offset, limit int, count bool) (paginatedResults [][]string, totalRecords int, queryString string, err error) {
 	if table == "" {
 		return nil, 0, "", errors.New("table name is required")
 	}
@@ -313,7 +324,7 @@ func (db *SQLite) GetRecords(_, table, where, sort string, offset, limit int) (p
 
 	queryString += " LIMIT ?, ?"
 
-	paginatedRows, err := db.Connection.Query(queryString, offset, limit)
+	paginatedRows, err := db.Connection.Query(queryString, append(args[:len(args):len(args)], offset, limit)...)
 	if err != nil {
 		return nil, 0, queryString, err
 	}
@@ -363,12 +374,16 @@ func (db *SQLite) GetRecords(_, table, where, sort string, offset, limit int) (p
 		return nil, 0, queryString, err
 	}
 
+	if !count {
+		return paginatedResults, 0, queryString, nil
+	}
+
 	countQuery := "SELECT COUNT(*) FROM "
 	countQuery += db.formatTableName(table)
 	if where != "" { // Add WHERE clause to count query as well if it exists
 		countQuery += fmt.Sprintf(" %s", where)
 	}
-	countRow := db.Connection.QueryRow(countQuery)
+	countRow := db.Connection.QueryRow(countQuery, args...)
 	if err := countRow.Scan(&totalRecords); err != nil {
 		return paginatedResults, 0, queryString, err
 	}
@@ -379,8 +394,8 @@ func (db *SQLite) GetRecords(_, table, where, sort string, offset, limit int) (p
 	return paginatedResults, totalRecords, queryString, nil
 }
 
-func (db *SQLite) ExecuteQuery(query string) ([][]string, int, error) {
-	rows, err := db.Connection.Query(query)
+func (db *SQLite) ExecuteQuery(query string, args ...any) ([][]string, int, error) {
+	rows, err := db.Connection.Query(query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -476,8 +491,8 @@ func (db *SQLite) DeleteRecord(_, table, primaryKeyColumnName, primaryKeyValue s
 	return err
 }
 
-func (db *SQLite) ExecuteDMLStatement(query string) (result string, err error) {
-	res, err := db.Connection.Exec(query)
+func (db *SQLite) ExecuteDMLStatement(query string, args ...any) (result string, err error) {
+	res, err := db.Connection.Exec(query, args...)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,572 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"sqlcmder/models"
+)
+
+// DefaultDiffChunkSize is the number of rows fetched from each side per round trip
+const DefaultDiffChunkSize = 500
+
+type TableDiffType int8
+
+const (
+	// TableDiffInserted is a row that only exists in the source table
+	TableDiffInserted TableDiffType = iota
+	// TableDiffDeleted is a row that only exists in the target table
+	TableDiffDeleted
+	// TableDiffChanged is a row that exists on both sides with different values
+	TableDiffChanged
+)
+
+func (t TableDiffType) String() string {
+	switch t {
+	case TableDiffInserted:
+		return "INSERT"
+	case TableDiffDeleted:
+		return "DELETE"
+	case TableDiffChanged:
+		return "UPDATE"
+	}
+
+	return "UNKNOWN"
+}
+
+// TableReference points to a table on a given connection
+type TableReference struct {
+	Driver   Driver
+	Database string
+	Table    string
+}
+
+// TableRowDiff is a single row difference between two tables.
+// Source and Target hold the row values in the order of TableDiff.Columns,
+// one of them is nil for inserted and deleted rows.
+type TableRowDiff struct {
+	Type           TableDiffType
+	PrimaryKeyInfo []models.PrimaryKeyInfo
+	Source         []string
+	Target         []string
+	ChangedColumns []int
+}
+
+// TableDiff compares the rows of two tables matched by primary key.
+type TableDiff struct {
+	Source      TableReference
+	Target      TableReference
+	ChunkSize   int
+	Columns     []string
+	PrimaryKeys []string
+	keyKinds    []diffKeyKind
+}
+
+// diffKeyKind tells how a primary key column is ordered by the database and
+// compared while merging both tables, the two have to agree.
+type diffKeyKind int8
+
+const (
+	// diffKeyOther is ordered by the database default and compared byte-wise
+	diffKeyOther diffKeyKind = iota
+	// diffKeyNumeric is ordered and compared as an exact number
+	diffKeyNumeric
+	// diffKeyText is ordered with a binary collation and compared byte-wise
+	diffKeyText
+)
+
+func NewTableDiff(source, target TableReference) *TableDiff {
+	return &TableDiff{
+		Source:    source,
+		Target:    target,
+		ChunkSize: DefaultDiffChunkSize,
+	}
+}
+
+// Run walks both tables ordered by primary key, fetching ChunkSize rows at a
+// time from each side, and calls onDiff for every row that differs.
+// Returning an error from onDiff stops the comparison.
+func (d *TableDiff) Run(onDiff func(diff TableRowDiff) error) error {
+	primaryKeys, err := d.Source.Driver.GetPrimaryKeyColumnNames(d.Source.Database, d.Source.Table)
+	if err != nil {
+		return err
+	}
+
+	if len(primaryKeys) == 0 {
+		return fmt.Errorf("table %s has no primary key", d.Source.Table)
+	}
+
+	d.PrimaryKeys = primaryKeys
+
+	d.keyKinds, err = diffKeyKinds(d.Source, primaryKeys)
+	if err != nil {
+		return err
+	}
+
+	source := newDiffCursor(d.Source, primaryKeys, d.keyKinds, d.ChunkSize)
+	target := newDiffCursor(d.Target, primaryKeys, d.keyKinds, d.ChunkSize)
+
+	sourceRow, err := source.next()
+	if err != nil {
+		return err
+	}
+
+	targetRow, err := target.next()
+	if err != nil {
+		return err
+	}
+
+	if err := d.resolveColumns(source, target); err != nil {
+		return err
+	}
+
+	for sourceRow != nil || targetRow != nil {
+		var diff *TableRowDiff
+
+		cmp := 0
+		switch {
+		case sourceRow == nil:
+			cmp = 1
+		case targetRow == nil:
+			cmp = -1
+		default:
+			cmp = comparePrimaryKeys(d.keyKinds, source.primaryKeyValues(sourceRow), target.primaryKeyValues(targetRow))
+		}
+
+		switch {
+		case cmp < 0:
+			diff = &TableRowDiff{
+				Type:           TableDiffInserted,
+				PrimaryKeyInfo: d.primaryKeyInfo(source.primaryKeyValues(sourceRow)),
+				Source:         source.project(sourceRow),
+			}
+			sourceRow, err = source.next()
+		case cmp > 0:
+			diff = &TableRowDiff{
+				Type:           TableDiffDeleted,
+				PrimaryKeyInfo: d.primaryKeyInfo(target.primaryKeyValues(targetRow)),
+				Target:         target.project(targetRow),
+			}
+			targetRow, err = target.next()
+		default:
+			sourceValues := source.project(sourceRow)
+			targetValues := target.project(targetRow)
+
+			changed := []int{}
+			for i := range sourceValues {
+				if sourceValues[i] != targetValues[i] {
+					changed = append(changed, i)
+				}
+			}
+
+			if len(changed) > 0 {
+				diff = &TableRowDiff{
+					Type:           TableDiffChanged,
+					PrimaryKeyInfo: d.primaryKeyInfo(source.primaryKeyValues(sourceRow)),
+					Source:         sourceValues,
+					Target:         targetValues,
+					ChangedColumns: changed,
+				}
+			}
+
+			sourceRow, err = source.next()
+			if err == nil {
+				targetRow, err = target.next()
+			}
+		}
+
+		if diff != nil {
+			if cbErr := onDiff(*diff); cbErr != nil {
+				return cbErr
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncChanges returns the changes that make the target table match the source
+func (d *TableDiff) SyncChanges(diffs []TableRowDiff) []models.DBDMLChange {
+	changes := make([]models.DBDMLChange, 0, len(diffs))
+
+	for _, diff := range diffs {
+		change := models.DBDMLChange{
+			Database:       d.Target.Database,
+			Table:          d.Target.Table,
+			PrimaryKeyInfo: diff.PrimaryKeyInfo,
+		}
+
+		switch diff.Type {
+		case TableDiffInserted:
+			change.Type = models.DMLInsertType
+			for i, column := range d.Columns {
+				change.Values = append(change.Values, diffCellValue(column, diff.Source[i]))
+			}
+		case TableDiffChanged:
+			change.Type = models.DMLUpdateType
+			for _, i := range diff.ChangedColumns {
+				change.Values = append(change.Values, diffCellValue(d.Columns[i], diff.Source[i]))
+			}
+		case TableDiffDeleted:
+			change.Type = models.DMLDeleteType
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// SyncQueries returns the changes from SyncChanges as query strings
+func (d *TableDiff) SyncQueries(diffs []TableRowDiff) ([]string, error) {
+	queries := []string{}
+
+	for _, change := range d.SyncChanges(diffs) {
+		query, err := d.Target.Driver.DMLChangeToQueryString(change)
+		if err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, query)
+	}
+
+	return queries, nil
+}
+
+// resolveColumns keeps the columns present on both sides, in source order
+func (d *TableDiff) resolveColumns(source, target *diffCursor) error {
+	d.Columns = []string{}
+	source.projection = []int{}
+	target.projection = []int{}
+
+	for sourceIndex, column := range source.header {
+		targetIndex := target.columnIndex(column)
+		if targetIndex == -1 {
+			continue
+		}
+
+		d.Columns = append(d.Columns, column)
+		source.projection = append(source.projection, sourceIndex)
+		target.projection = append(target.projection, targetIndex)
+	}
+
+	for _, primaryKey := range d.PrimaryKeys {
+		if source.columnIndex(primaryKey) == -1 || target.columnIndex(primaryKey) == -1 {
+			return fmt.Errorf("primary key column %s is missing in one of the tables", primaryKey)
+		}
+	}
+
+	source.resolvePrimaryKeys()
+	target.resolvePrimaryKeys()
+
+	return nil
+}
+
+func (d *TableDiff) primaryKeyInfo(values []string) []models.PrimaryKeyInfo {
+	info := make([]models.PrimaryKeyInfo, len(d.PrimaryKeys))
+	for i, name := range d.PrimaryKeys {
+		info[i] = models.PrimaryKeyInfo{Name: name, Value: values[i]}
+	}
+	return info
+}
+
+type diffCursor struct {
+	table       TableReference
+	primaryKeys []string
+	keys        []string // Primary key expressions used to order and page the table
+	kinds       []diffKeyKind
+	sort        string
+	chunkSize   int
+	last        []string // Primary key values of the last row read
+	header      []string
+	rows        [][]string
+	position    int
+	exhausted   bool
+	projection  []int
+	keyIndexes  []int
+}
+
+func newDiffCursor(table TableReference, primaryKeys []string, kinds []diffKeyKind, chunkSize int) *diffCursor {
+	if chunkSize <= 0 {
+		chunkSize = DefaultDiffChunkSize
+	}
+
+	keys := make([]string, len(primaryKeys))
+	sort := make([]string, len(primaryKeys))
+	for i, primaryKey := range primaryKeys {
+		keys[i] = table.Driver.FormatReference(primaryKey)
+		if kinds[i] == diffKeyText {
+			keys[i] = binaryCollation(table.Driver.GetProvider(), keys[i])
+		}
+		sort[i] = keys[i] + " ASC"
+	}
+
+	return &diffCursor{
+		table:       table,
+		primaryKeys: primaryKeys,
+		keys:        keys,
+		kinds:       kinds,
+		sort:        strings.Join(sort, ", "),
+		chunkSize:   chunkSize,
+	}
+}
+
+// next returns the next row or nil when the table has been read entirely.
+// Chunks are fetched by key, after the last row read, and the rows of the
+// table are not counted, so a chunk reads its own rows only, no matter how
+// far into the table the comparison is.
+func (c *diffCursor) next() ([]string, error) {
+	if c.position >= len(c.rows) {
+		if c.exhausted {
+			return nil, nil
+		}
+
+		where, args := c.after()
+		records, err := c.table.Driver.GetKeysetRecords(c.table.Database, c.table.Table, where, args, c.sort, c.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(records) == 0 {
+			return nil, errors.New("no header returned for table " + c.table.Table)
+		}
+
+		c.header = records[0]
+		c.rows = records[1:]
+		c.position = 0
+		c.exhausted = len(c.rows) < c.chunkSize
+
+		if len(c.rows) == 0 {
+			return nil, nil
+		}
+	}
+
+	row := c.rows[c.position]
+	c.position++
+
+	if c.keyIndexes != nil {
+		c.last = c.primaryKeyValues(row)
+	}
+
+	return row, nil
+}
+
+// after returns the WHERE clause selecting the rows that follow the last row
+// read, with the arguments of its placeholders. The keys are compared as a
+// row value, or expanded into one condition per key on SQL Server, which
+// doesn't support row value comparison.
+func (c *diffCursor) after() (string, []any) {
+	if c.last == nil {
+		return "", nil
+	}
+
+	var args []any
+	placeholder := func(i int) string {
+		args = append(args, c.arg(i))
+		return c.table.Driver.FormatPlaceholder(len(args))
+	}
+
+	if len(c.keys) == 1 {
+		return fmt.Sprintf("WHERE %s > %s", c.keys[0], placeholder(0)), args
+	}
+
+	if c.table.Driver.GetProvider() != DriverMSSQL {
+		placeholders := make([]string, len(c.keys))
+		for i := range c.keys {
+			placeholders[i] = placeholder(i)
+		}
+		return fmt.Sprintf("WHERE (%s) > (%s)", strings.Join(c.keys, ", "), strings.Join(placeholders, ", ")), args
+	}
+
+	conditions := make([]string, len(c.keys))
+	for i := range c.keys {
+		parts := []string{}
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = %s", c.keys[j], placeholder(j)))
+		}
+		parts = append(parts, fmt.Sprintf("%s > %s", c.keys[i], placeholder(i)))
+
+		conditions[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+
+	return "WHERE " + strings.Join(conditions, " OR "), args
+}
+
+// arg returns the value of a primary key of the last row read bound to the
+// query of the next chunk. Integer keys are bound as integers, as MySQL
+// compares integer columns with strings as floating point numbers.
+func (c *diffCursor) arg(i int) any {
+	if c.kinds[i] == diffKeyNumeric {
+		if value, err := strconv.ParseInt(c.last[i], 10, 64); err == nil {
+			return value
+		}
+	}
+	return c.last[i]
+}
+
+func (c *diffCursor) columnIndex(column string) int {
+	for i, name := range c.header {
+		if name == column {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *diffCursor) resolvePrimaryKeys() {
+	c.keyIndexes = make([]int, len(c.primaryKeys))
+	for i, primaryKey := range c.primaryKeys {
+		c.keyIndexes[i] = c.columnIndex(primaryKey)
+	}
+
+	// The first row was read before the header was known
+	if c.position > 0 {
+		c.last = c.primaryKeyValues(c.rows[c.position-1])
+	}
+}
+
+func (c *diffCursor) primaryKeyValues(row []string) []string {
+	values := make([]string, len(c.keyIndexes))
+	for i, index := range c.keyIndexes {
+		values[i] = normalizeDiffValue(row[index])
+	}
+	return values
+}
+
+func (c *diffCursor) project(row []string) []string {
+	values := make([]string, len(c.projection))
+	for i, index := range c.projection {
+		values[i] = normalizeDiffValue(row[index])
+	}
+	return values
+}
+
+// normalizeDiffValue maps the "EMPTY&" marker some drivers emit to an empty string,
+// so it compares equal with drivers that return empty strings as is
+func normalizeDiffValue(value string) string {
+	if value == "EMPTY&" {
+		return ""
+	}
+	return value
+}
+
+// comparePrimaryKeys compares two keys in the order the cursors read them:
+// numbers exactly, and everything else byte-wise, which matches the binary
+// collation text keys are ordered with
+func comparePrimaryKeys(kinds []diffKeyKind, a, b []string) int {
+	for i := range a {
+		if kinds[i] == diffKeyNumeric {
+			if cmp, ok := compareNumbers(a[i], b[i]); ok {
+				if cmp != 0 {
+					return cmp
+				}
+				continue
+			}
+		}
+
+		if cmp := strings.Compare(a[i], b[i]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+// compareNumbers compares integers and decimals without going through
+// float64, which can't tell bigint keys above 2^53 apart
+func compareNumbers(a, b string) (int, bool) {
+	aInt, aOk := new(big.Int).SetString(a, 10)
+	bInt, bOk := new(big.Int).SetString(b, 10)
+	if aOk && bOk {
+		return aInt.Cmp(bInt), true
+	}
+
+	aRat, aOk := new(big.Rat).SetString(a)
+	bRat, bOk := new(big.Rat).SetString(b)
+	if aOk && bOk {
+		return aRat.Cmp(bRat), true
+	}
+
+	return 0, false
+}
+
+// diffKeyKinds looks up the type of each primary key column
+func diffKeyKinds(table TableReference, primaryKeys []string) ([]diffKeyKind, error) {
+	columns, err := table.Driver.GetTableColumns(table.Database, table.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	types := map[string]string{}
+	for i, column := range columns {
+		// The first row holds the column headers
+		if i == 0 || len(column) < 2 {
+			continue
+		}
+		types[column[0]] = column[1]
+	}
+
+	kinds := make([]diffKeyKind, len(primaryKeys))
+	for i, primaryKey := range primaryKeys {
+		kinds[i] = keyKind(types[primaryKey])
+	}
+
+	return kinds, nil
+}
+
+// keyKind classifies a column type by its base name, ignoring length,
+// precision and modifiers like "unsigned"
+func keyKind(columnType string) diffKeyKind {
+	base := strings.ToLower(strings.TrimSpace(columnType))
+	if index := strings.IndexAny(base, "( "); index != -1 {
+		base = base[:index]
+	}
+
+	switch base {
+	case "int", "integer", "bigint", "smallint", "tinyint", "mediumint", "int2", "int4", "int8",
+		"serial", "bigserial", "smallserial", "numeric", "decimal", "number", "real", "double",
+		"float", "float4", "float8", "money", "smallmoney":
+		return diffKeyNumeric
+	case "char", "varchar", "character", "nchar", "nvarchar", "varchar2", "text", "tinytext",
+		"mediumtext", "longtext", "ntext", "clob", "string":
+		return diffKeyText
+	}
+
+	return diffKeyOther
+}
+
+// binaryCollation makes a text expression sort by bytes (code points for
+// SQL Server) instead of the column collation, which can be case-insensitive
+// or locale-aware
+func binaryCollation(provider, expression string) string {
+	switch provider {
+	case DriverPostgres:
+		return expression + ` COLLATE "C"`
+	case DriverMySQL:
+		return "CAST(" + expression + " AS BINARY)"
+	case DriverMSSQL:
+		return expression + " COLLATE Latin1_General_BIN2"
+	case DriverSqlite:
+		return expression + " COLLATE BINARY"
+	}
+
+	return expression
+}
+
+func diffCellValue(column, value string) models.CellValue {
+	switch value {
+	case "NULL&":
+		return models.CellValue{Column: column, Type: models.Null}
+	case "":
+		return models.CellValue{Column: column, Type: models.Empty}
+	}
+
+	return models.CellValue{Column: column, Value: value, Type: models.String}
+}
@@ -0,0 +1,362 @@
+package drivers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func newSQLiteMemoryDB(t *testing.T, name string, statements []string) *SQLite {
+	t.Helper()
+
+	db := &SQLite{}
+	if err := db.Connect("file:" + name + "?mode=memory&cache=shared"); err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Connection.Close() })
+
+	for _, statement := range statements {
+		if _, err := db.Connection.Exec(statement); err != nil {
+			t.Fatalf("failed to execute %q: %v", statement, err)
+		}
+	}
+
+	return db
+}
+
+func TestTableDiff_Run(t *testing.T) {
+	source := newSQLiteMemoryDB(t, "diff_source", []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT)",
+		"INSERT INTO users VALUES (1, 'alice', 'alice@example.com')",
+		"INSERT INTO users VALUES (2, 'bob', 'bob@example.com')",
+		"INSERT INTO users VALUES (3, 'carol', NULL)",
+		"INSERT INTO users VALUES (10, 'dave', 'dave@example.com')",
+	})
+
+	target := newSQLiteMemoryDB(t, "diff_target", []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT)",
+		"INSERT INTO users VALUES (1, 'alice', 'alice@example.com')",
+		"INSERT INTO users VALUES (2, 'bobby', 'bob@example.com')",
+		"INSERT INTO users VALUES (4, 'erin', 'erin@example.com')",
+		"INSERT INTO users VALUES (10, 'dave', 'dave@example.com')",
+	})
+
+	diff := NewTableDiff(
+		TableReference{Driver: source, Database: "main", Table: "users"},
+		TableReference{Driver: target, Database: "main", Table: "users"},
+	)
+	// Small chunks so the comparison has to cross chunk boundaries
+	diff.ChunkSize = 2
+
+	var diffs []TableRowDiff
+	err := diff.Run(func(d TableRowDiff) error {
+		diffs = append(diffs, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(diff.Columns, []string{"id", "name", "email"}) {
+		t.Fatalf("unexpected columns %v", diff.Columns)
+	}
+
+	expectedTypes := []TableDiffType{TableDiffChanged, TableDiffInserted, TableDiffDeleted}
+	if len(diffs) != len(expectedTypes) {
+		t.Fatalf("expected %d diffs, got %d: %+v", len(expectedTypes), len(diffs), diffs)
+	}
+
+	for i, expected := range expectedTypes {
+		if diffs[i].Type != expected {
+			t.Errorf("diff %d: expected %s, got %s", i, expected, diffs[i].Type)
+		}
+	}
+
+	if !reflect.DeepEqual(diffs[0].ChangedColumns, []int{1}) {
+		t.Errorf("expected only the name column to change, got %v", diffs[0].ChangedColumns)
+	}
+
+	queries, err := diff.SyncQueries(diffs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedQueries := []string{
+		"UPDATE `users` SET `name` = 'bob' WHERE `id` = '2'",
+		"INSERT INTO `users` (id, name, email) VALUES ('3', 'carol', NULL)",
+		"DELETE FROM `users` WHERE `id` = '4'",
+	}
+	if !reflect.DeepEqual(queries, expectedQueries) {
+		t.Fatalf("expected queries %q, got %q", expectedQueries, queries)
+	}
+
+	for _, query := range queries {
+		if _, err := target.Connection.Exec(query); err != nil {
+			t.Fatalf("failed to apply %q: %v", query, err)
+		}
+	}
+
+	diffs = nil
+	if err := diff.Run(func(d TableRowDiff) error {
+		diffs = append(diffs, d)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(diffs) != 0 {
+		t.Fatalf("expected tables to match after sync, got %+v", diffs)
+	}
+}
+
+func TestTableDiff_RunWithoutPrimaryKey(t *testing.T) {
+	source := newSQLiteMemoryDB(t, "diff_no_pk", []string{
+		"CREATE TABLE logs (message TEXT)",
+	})
+
+	diff := NewTableDiff(
+		TableReference{Driver: source, Database: "main", Table: "logs"},
+		TableReference{Driver: source, Database: "main", Table: "logs"},
+	)
+
+	err := diff.Run(func(TableRowDiff) error { return nil })
+	if err == nil {
+		t.Fatal("expected an error for a table without primary key")
+	}
+}
+
+func TestTableDiff_RunWithCaseInsensitiveKeys(t *testing.T) {
+	// NOCASE orders "a" before "B", the comparison must not read that as rows
+	// missing on one side
+	schema := "CREATE TABLE codes (code TEXT COLLATE NOCASE PRIMARY KEY, label TEXT)"
+
+	source := newSQLiteMemoryDB(t, "diff_nocase_source", []string{
+		schema,
+		"INSERT INTO codes VALUES ('a', 'lower a')",
+		"INSERT INTO codes VALUES ('B', 'upper b')",
+		"INSERT INTO codes VALUES ('c', 'lower c')",
+		"INSERT INTO codes VALUES ('D', 'upper d')",
+	})
+
+	target := newSQLiteMemoryDB(t, "diff_nocase_target", []string{
+		schema,
+		"INSERT INTO codes VALUES ('B', 'upper b')",
+		"INSERT INTO codes VALUES ('c', 'changed')",
+		"INSERT INTO codes VALUES ('D', 'upper d')",
+	})
+
+	diff := NewTableDiff(
+		TableReference{Driver: source, Database: "main", Table: "codes"},
+		TableReference{Driver: target, Database: "main", Table: "codes"},
+	)
+	diff.ChunkSize = 1
+
+	var diffs []TableRowDiff
+	if err := diff.Run(func(d TableRowDiff) error {
+		diffs = append(diffs, d)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		diffType TableDiffType
+		key      string
+	}{
+		{TableDiffInserted, "a"},
+		{TableDiffChanged, "c"},
+	}
+
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d diffs, got %+v", len(expected), diffs)
+	}
+
+	for i, e := range expected {
+		if diffs[i].Type != e.diffType || diffs[i].PrimaryKeyInfo[0].Value != e.key {
+			t.Errorf("diff %d: expected %s %s, got %s %s", i, e.diffType, e.key, diffs[i].Type, diffs[i].PrimaryKeyInfo[0].Value)
+		}
+	}
+}
+
+func TestTableDiff_RunWithLargeIntegerKeys(t *testing.T) {
+	schema := "CREATE TABLE events (id INTEGER PRIMARY KEY, name TEXT)"
+
+	source := newSQLiteMemoryDB(t, "diff_bigint_source", []string{
+		schema,
+		"INSERT INTO events VALUES (9007199254740992, 'first')",
+		"INSERT INTO events VALUES (9007199254740993, 'second')",
+	})
+
+	target := newSQLiteMemoryDB(t, "diff_bigint_target", []string{
+		schema,
+		"INSERT INTO events VALUES (9007199254740992, 'first')",
+		"INSERT INTO events VALUES (9007199254740994, 'third')",
+	})
+
+	diff := NewTableDiff(
+		TableReference{Driver: source, Database: "main", Table: "events"},
+		TableReference{Driver: target, Database: "main", Table: "events"},
+	)
+
+	var diffs []TableRowDiff
+	if err := diff.Run(func(d TableRowDiff) error {
+		diffs = append(diffs, d)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		diffType TableDiffType
+		key      string
+	}{
+		{TableDiffInserted, "9007199254740993"},
+		{TableDiffDeleted, "9007199254740994"},
+	}
+
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d diffs, got %+v", len(expected), diffs)
+	}
+
+	for i, e := range expected {
+		if diffs[i].Type != e.diffType || diffs[i].PrimaryKeyInfo[0].Value != e.key {
+			t.Errorf("diff %d: expected %s %s, got %s %s", i, e.diffType, e.key, diffs[i].Type, diffs[i].PrimaryKeyInfo[0].Value)
+		}
+	}
+}
+
+func TestTableDiff_RunWithCompositeKeys(t *testing.T) {
+	schema := "CREATE TABLE lines (invoice INTEGER, line INTEGER, amount TEXT, PRIMARY KEY (invoice, line))"
+
+	source := newSQLiteMemoryDB(t, "diff_composite_source", []string{
+		schema,
+		"INSERT INTO lines VALUES (1, 1, '10'), (1, 2, '20'), (1, 10, '30'), (2, 1, '40'), (2, 2, '50')",
+	})
+
+	target := newSQLiteMemoryDB(t, "diff_composite_target", []string{
+		schema,
+		"INSERT INTO lines VALUES (1, 1, '10'), (1, 10, '31'), (2, 1, '40'), (2, 2, '50'), (3, 1, '60')",
+	})
+
+	diff := NewTableDiff(
+		TableReference{Driver: source, Database: "main", Table: "lines"},
+		TableReference{Driver: target, Database: "main", Table: "lines"},
+	)
+	diff.ChunkSize = 2
+
+	var got []string
+	if err := diff.Run(func(d TableRowDiff) error {
+		got = append(got, fmt.Sprintf("%s %v/%v", d.Type, d.PrimaryKeyInfo[0].Value, d.PrimaryKeyInfo[1].Value))
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"INSERT 1/2", "UPDATE 1/10", "DELETE 3/1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffs = %v, want %v", got, want)
+	}
+}
+
+func TestDiffCursorAfter(t *testing.T) {
+	postgres := &Postgres{}
+	postgres.SetProvider(DriverPostgres)
+	mssql := &MSSQL{}
+	mssql.SetProvider(DriverMSSQL)
+
+	tests := []struct {
+		name      string
+		driver    Driver
+		keys      []string
+		kinds     []diffKeyKind
+		wantWhere string
+		wantArgs  []any
+	}{
+		{
+			name:      "single key",
+			driver:    postgres,
+			keys:      []string{"id"},
+			kinds:     []diffKeyKind{diffKeyNumeric},
+			wantWhere: `WHERE "id" > $1`,
+			wantArgs:  []any{int64(7)},
+		},
+		{
+			name:      "row value",
+			driver:    postgres,
+			keys:      []string{"id", "code"},
+			kinds:     []diffKeyKind{diffKeyNumeric, diffKeyText},
+			wantWhere: `WHERE ("id", "code" COLLATE "C") > ($1, $2)`,
+			wantArgs:  []any{int64(7), "b"},
+		},
+		{
+			name:      "expanded on SQL Server",
+			driver:    mssql,
+			keys:      []string{"id", "code"},
+			kinds:     []diffKeyKind{diffKeyNumeric, diffKeyText},
+			wantWhere: "WHERE ([id] > @p1) OR ([id] = @p2 AND [code] COLLATE Latin1_General_BIN2 > @p3)",
+			wantArgs:  []any{int64(7), int64(7), "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := newDiffCursor(TableReference{Driver: tt.driver, Table: "t"}, tt.keys, tt.kinds, 0)
+			cursor.last = []string{"7", "b"}[:len(tt.keys)]
+
+			where, args := cursor.after()
+			if where != tt.wantWhere {
+				t.Errorf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestComparePrimaryKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kinds    []diffKeyKind
+		a        []string
+		b        []string
+		expected int
+	}{
+		{name: "Numeric", kinds: []diffKeyKind{diffKeyNumeric}, a: []string{"9"}, b: []string{"10"}, expected: -1},
+		{name: "Large integers", kinds: []diffKeyKind{diffKeyNumeric}, a: []string{"9007199254740993"}, b: []string{"9007199254740992"}, expected: 1},
+		{name: "Decimals", kinds: []diffKeyKind{diffKeyNumeric}, a: []string{"0.1"}, b: []string{"0.10"}, expected: 0},
+		{name: "Text", kinds: []diffKeyKind{diffKeyText}, a: []string{"b"}, b: []string{"a"}, expected: 1},
+		{name: "Text digits", kinds: []diffKeyKind{diffKeyText}, a: []string{"9"}, b: []string{"10"}, expected: 1},
+		{name: "Mixed case", kinds: []diffKeyKind{diffKeyText}, a: []string{"B"}, b: []string{"a"}, expected: -1},
+		{name: "Composite", kinds: []diffKeyKind{diffKeyNumeric, diffKeyText}, a: []string{"1", "x"}, b: []string{"1", "x"}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := comparePrimaryKeys(tc.kinds, tc.a, tc.b); got != tc.expected {
+				t.Fatalf("expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestKeyKind(t *testing.T) {
+	testCases := map[string]diffKeyKind{
+		"INTEGER":           diffKeyNumeric,
+		"bigint unsigned":   diffKeyNumeric,
+		"decimal(10,2)":     diffKeyNumeric,
+		"double precision":  diffKeyNumeric,
+		"character varying": diffKeyText,
+		"varchar(255)":      diffKeyText,
+		"nvarchar":          diffKeyText,
+		"interval":          diffKeyOther,
+		"uuid":              diffKeyOther,
+		"":                  diffKeyOther,
+	}
+
+	for columnType, expected := range testCases {
+		if got := keyKind(columnType); got != expected {
+			t.Errorf("%q: expected %d, got %d", columnType, expected, got)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package drivers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/xo/dburl"
+
+	"sqlcmder/models"
+)
+
+// WithTLS adds TLS settings to a DSN, in the parameters of its driver. MySQL
+// gets a TLS config registered for the settings, Postgres and SQL Server get
+// the matching sslmode and encrypt parameters. SQLite DSNs are returned
+// unchanged.
+func WithTLS(dsn string, settings models.TLSSettings) (string, error) {
+	if settings.Mode == "" {
+		return dsn, nil
+	}
+
+	switch settings.Mode {
+	case models.TLSModeDisable, models.TLSModeRequire, models.TLSModeVerifyCA, models.TLSModeVerifyFull:
+	default:
+		return "", fmt.Errorf("unknown TLS mode %q", settings.Mode)
+	}
+
+	parsed, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	settings.CAFile = expandHome(settings.CAFile)
+	settings.CertFile = expandHome(settings.CertFile)
+	settings.KeyFile = expandHome(settings.KeyFile)
+
+	switch parsed.Driver {
+	case DriverPostgres:
+		return postgresTLS(parsed, settings), nil
+	case DriverMySQL:
+		return mysqlTLS(parsed, settings)
+	case DriverMSSQL:
+		return mssqlTLS(parsed, settings)
+	}
+
+	return dsn, nil
+}
+
+// TLSConfig returns the tls.Config of TLS settings, for a server at host.
+func TLSConfig(settings models.TLSSettings, host string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: settings.ServerName, MinVersion: tls.VersionTLS12}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	if settings.CAFile != "" {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA file %s", settings.CAFile)
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	switch settings.Mode {
+	case models.TLSModeRequire:
+		config.InsecureSkipVerify = true // #nosec G402
+	case models.TLSModeVerifyCA:
+		// The chain is checked without the name, which crypto/tls can only do
+		// with its own verification turned off
+		config.InsecureSkipVerify = true // #nosec G402
+		config.VerifyConnection = verifyChain(config.RootCAs)
+	}
+
+	return config, nil
+}
+
+// verifyChain checks the server certificate is signed by one of roots, the
+// system ones when nil
+func verifyChain(roots *x509.CertPool) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("the server sent no certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, certificate := range state.PeerCertificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
+
+// postgresTLS sets the sslmode parameters of lib/pq. lib/pq checks the
+// certificate against the host it connects to, so an other server name
+// becomes the host and the address connected to goes in hostaddr, like with
+// libpq.
+func postgresTLS(parsed *dburl.URL, settings models.TLSSettings) string {
+	query := parsed.Query()
+	query.Set("sslmode", settings.Mode)
+	setOrDelete(query, "sslrootcert", settings.CAFile)
+	setOrDelete(query, "sslcert", settings.CertFile)
+	setOrDelete(query, "sslkey", settings.KeyFile)
+
+	u := parsed.URL
+	if host := u.Hostname(); settings.ServerName != "" && settings.ServerName != host && settings.Mode != models.TLSModeDisable {
+		query.Set("hostaddr", host)
+		u.Host = settings.ServerName
+		if port := parsed.Port(); port != "" {
+			u.Host = net.JoinHostPort(settings.ServerName, port)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// mysqlTLS registers a TLS config for the settings with the MySQL driver and
+// names it in the tls parameter
+func mysqlTLS(parsed *dburl.URL, settings models.TLSSettings) (string, error) {
+	query := parsed.Query()
+	u := parsed.URL
+
+	if settings.Mode == models.TLSModeDisable {
+		query.Set("tls", "false")
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	config, err := TLSConfig(settings, u.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	// The same settings always get the same name, so connecting again does
+	// not register more configs
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v %s", settings, u.Hostname())))
+	name := "sqlcmder-" + hex.EncodeToString(sum[:8])
+
+	if err := mysql.RegisterTLSConfig(name, config); err != nil {
+		return "", err
+	}
+
+	query.Set("tls", name)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// mssqlTLS sets the encrypt parameters of go-mssqldb, which checks server
+// certificates with their name only and has no client certificates
+func mssqlTLS(parsed *dburl.URL, settings models.TLSSettings) (string, error) {
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		return "", errors.New("client certificates are not supported with SQL Server")
+	}
+
+	query := parsed.Query()
+	for key := range query {
+		switch strings.ToLower(key) {
+		case "encrypt", "trustservercertificate", "certificate", "hostnameincertificate":
+			query.Del(key)
+		}
+	}
+
+	switch settings.Mode {
+	case models.TLSModeDisable:
+		query.Set("encrypt", "disable")
+	case models.TLSModeRequire:
+		query.Set("encrypt", "true")
+		query.Set("trustservercertificate", "true")
+	case models.TLSModeVerifyCA:
+		return "", errors.New("verify-ca is not supported with SQL Server, use verify-full")
+	case models.TLSModeVerifyFull:
+		query.Set("encrypt", "true")
+		query.Set("trustservercertificate", "false")
+		setOrDelete(query, "certificate", settings.CAFile)
+		setOrDelete(query, "hostnameincertificate", settings.ServerName)
+	}
+
+	u := parsed.URL
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func setOrDelete(query url.Values, key string, value string) {
+	if value == "" {
+		query.Del(key)
+	} else {
+		query.Set(key, value)
+	}
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,370 @@
+package drivers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sqlcmder/models"
+)
+
+// testCA signs the certificates of the in-process TLS servers and clients
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	file        string
+}
+
+func newTestCA(t *testing.T, dir string, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+
+	file := filepath.Join(dir, name+".pem")
+	writePEM(t, file, "CERTIFICATE", der)
+
+	return &testCA{certificate: certificate, key: key, file: file}
+}
+
+// issue returns a certificate signed by the CA, and writes it with its key
+// to files named after it
+func (ca *testCA) issue(t *testing.T, dir string, name string, usage x509.ExtKeyUsage) (tls.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair() error = %v", err)
+	}
+	return certificate, certFile, keyFile
+}
+
+func writePEM(t *testing.T, file string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+// startTLSServer starts a TLS server requiring client certificates signed by
+// clientCA, which writes "ok" to every client it accepts
+func startTLSServer(t *testing.T, certificate tls.Certificate, clientCA *testCA) string {
+	t.Helper()
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.certificate)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err == nil {
+					conn.Write([]byte("ok"))
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	otherCA := newTestCA(t, dir, "other-ca")
+	serverCertificate, _, _ := ca.issue(t, dir, "db.example.com", x509.ExtKeyUsageServerAuth)
+	_, certFile, keyFile := ca.issue(t, dir, "client", x509.ExtKeyUsageClientAuth)
+	address := startTLSServer(t, serverCertificate, ca)
+
+	tests := []struct {
+		name     string
+		settings models.TLSSettings
+		wantErr  bool
+	}{
+		{"require", models.TLSSettings{Mode: models.TLSModeRequire}, false},
+		{"verify-ca", models.TLSSettings{Mode: models.TLSModeVerifyCA, CAFile: ca.file}, false},
+		{"verify-ca with an other CA", models.TLSSettings{Mode: models.TLSModeVerifyCA, CAFile: otherCA.file}, true},
+		{"verify-full", models.TLSSettings{Mode: models.TLSModeVerifyFull, CAFile: ca.file, ServerName: "db.example.com"}, false},
+		{"verify-full with the address", models.TLSSettings{Mode: models.TLSModeVerifyFull, CAFile: ca.file}, true},
+		{"no client certificate", models.TLSSettings{Mode: models.TLSModeRequire}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name != "no client certificate" {
+				tt.settings.CertFile, tt.settings.KeyFile = certFile, keyFile
+			}
+
+			config, err := TLSConfig(tt.settings, "127.0.0.1")
+			if err != nil {
+				t.Fatalf("TLSConfig() error = %v", err)
+			}
+
+			err = dialTLS(address, config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dial error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// dialTLS connects to the test server and reads its greeting, as a client
+// certificate may only be refused after the handshake with TLS 1.3
+func dialTLS(address string, config *tls.Config) error {
+	conn, err := tls.Dial("tcp", address, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = io.ReadFull(conn, make([]byte, 2))
+	return err
+}
+
+func TestWithTLS(t *testing.T) {
+	tests := []struct {
+		name     string
+		dsn      string
+		settings models.TLSSettings
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "no mode",
+			dsn:  "postgres://app@db:5432/app?sslmode=disable",
+			want: "postgres://app@db:5432/app?sslmode=disable",
+		},
+		{
+			name:     "postgres verify-full",
+			dsn:      "postgres://app@db:5432/app?sslmode=disable",
+			settings: models.TLSSettings{Mode: models.TLSModeVerifyFull, CAFile: "/ca.pem", CertFile: "/client.crt", KeyFile: "/client.key"},
+			want:     "postgres://app@db:5432/app?sslcert=%2Fclient.crt&sslkey=%2Fclient.key&sslmode=verify-full&sslrootcert=%2Fca.pem",
+		},
+		{
+			name:     "postgres server name",
+			dsn:      "postgres://app@127.0.0.1:6543/app",
+			settings: models.TLSSettings{Mode: models.TLSModeVerifyFull, ServerName: "db.example.com"},
+			want:     "postgres://app@db.example.com:6543/app?hostaddr=127.0.0.1&sslmode=verify-full",
+		},
+		{
+			name:     "sqlserver require",
+			dsn:      "sqlserver://sa@db:1433?database=app&encrypt=disable",
+			settings: models.TLSSettings{Mode: models.TLSModeRequire},
+			want:     "sqlserver://sa@db:1433?database=app&encrypt=true&trustservercertificate=true",
+		},
+		{
+			name:     "sqlserver verify-full",
+			dsn:      "sqlserver://sa@db:1433?database=app&Encrypt=true",
+			settings: models.TLSSettings{Mode: models.TLSModeVerifyFull, CAFile: "/ca.pem", ServerName: "db.example.com"},
+			want:     "sqlserver://sa@db:1433?certificate=%2Fca.pem&database=app&encrypt=true&hostnameincertificate=db.example.com&trustservercertificate=false",
+		},
+		{
+			name:     "sqlserver verify-ca",
+			dsn:      "sqlserver://sa@db:1433?database=app",
+			settings: models.TLSSettings{Mode: models.TLSModeVerifyCA},
+			wantErr:  true,
+		},
+		{
+			name:     "sqlserver client certificate",
+			dsn:      "sqlserver://sa@db:1433?database=app",
+			settings: models.TLSSettings{Mode: models.TLSModeRequire, CertFile: "/client.crt", KeyFile: "/client.key"},
+			wantErr:  true,
+		},
+		{
+			name:     "mysql disable",
+			dsn:      "mysql://root@db:3306/app",
+			settings: models.TLSSettings{Mode: models.TLSModeDisable},
+			want:     "mysql://root@db:3306/app?tls=false",
+		},
+		{
+			name:     "sqlite",
+			dsn:      "sqlite:./app.db",
+			settings: models.TLSSettings{Mode: models.TLSModeRequire},
+			want:     "sqlite:./app.db",
+		},
+		{
+			name:     "unknown mode",
+			dsn:      "postgres://app@db:5432/app",
+			settings: models.TLSSettings{Mode: "prefer"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WithTLS(tt.dsn, tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithTLS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("WithTLS() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTLSRegistersMySQLConfig(t *testing.T) {
+	settings := models.TLSSettings{Mode: models.TLSModeRequire}
+
+	first, err := WithTLS("mysql://root@db:3306/app", settings)
+	if err != nil {
+		t.Fatalf("WithTLS() error = %v", err)
+	}
+	second, err := WithTLS("mysql://root@db:3306/app", settings)
+	if err != nil {
+		t.Fatalf("WithTLS() error = %v", err)
+	}
+
+	parsed, err := url.Parse(first)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if name := parsed.Query().Get("tls"); !strings.HasPrefix(name, "sqlcmder-") {
+		t.Fatalf("tls = %q, want a registered config", name)
+	}
+	if first != second {
+		t.Fatalf("WithTLS() = %q then %q, want the same config name", first, second)
+	}
+}
+
+// TestPostgresTLSHandshake connects lib/pq to a server answering the SSL
+// request, checking the server name is sent while hostaddr is dialed
+func TestPostgresTLSHandshake(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	serverCertificate, _, _ := ca.issue(t, dir, "db.example.com", x509.ExtKeyUsageServerAuth)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	serverNames := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// The SSL request is 8 bytes, answered with S to go on with TLS
+		if _, err := io.ReadFull(conn, make([]byte, 8)); err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte("S")); err != nil {
+			return
+		}
+
+		server := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{serverCertificate}})
+		if err := server.Handshake(); err != nil {
+			serverNames <- "handshake failed: " + err.Error()
+			return
+		}
+		serverNames <- server.ConnectionState().ServerName
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	dsn, err := WithTLS("postgres://app@127.0.0.1:"+port+"/app", models.TLSSettings{
+		Mode:       models.TLSModeVerifyFull,
+		CAFile:     ca.file,
+		ServerName: "db.example.com",
+	})
+	if err != nil {
+		t.Fatalf("WithTLS() error = %v", err)
+	}
+
+	// The server closes the connection after the handshake
+	db := &Postgres{}
+	if err := db.Connect(dsn); err == nil {
+		t.Fatalf("Connect() error = nil, want the connection closed")
+	}
+
+	select {
+	case name := <-serverNames:
+		if name != "db.example.com" {
+			t.Fatalf("server name = %q, want db.example.com", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no TLS handshake")
+	}
+}
@@ -0,0 +1,154 @@
+package erd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+func newTestGraph(t *testing.T) *Graph {
+	t.Helper()
+
+	db := &drivers.SQLite{}
+	if err := db.Connect("file:erd?mode=memory&cache=shared"); err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Connection.Close() })
+
+	for _, statement := range []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers(id))",
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, order_id INTEGER REFERENCES orders(id))",
+		"CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT)",
+	} {
+		if _, err := db.Connection.Exec(statement); err != nil {
+			t.Fatalf("failed to execute %q: %v", statement, err)
+		}
+	}
+
+	graph, err := Load(db, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return graph
+}
+
+func TestLoad(t *testing.T) {
+	graph := newTestGraph(t)
+
+	if !reflect.DeepEqual(graph.TableNames(), []string{"customers", "items", "orders", "settings"}) {
+		t.Fatalf("unexpected tables %v", graph.TableNames())
+	}
+
+	if len(graph.ForeignKeys) != 2 {
+		t.Fatalf("expected 2 foreign keys, got %+v", graph.ForeignKeys)
+	}
+
+	expected := []Column{
+		{Name: "id", Type: "INTEGER", PrimaryKey: true},
+		{Name: "customer_id", Type: "INTEGER", ForeignKey: true},
+	}
+	if !reflect.DeepEqual(graph.Table("orders").Columns, expected) {
+		t.Fatalf("unexpected columns %+v", graph.Table("orders").Columns)
+	}
+}
+
+func TestGraph_Neighbourhood(t *testing.T) {
+	graph := newTestGraph(t)
+
+	testCases := []struct {
+		name     string
+		table    string
+		hops     int
+		expected []string
+	}{
+		{name: "Table only", table: "orders", hops: 0, expected: []string{"orders"}},
+		{name: "One hop", table: "orders", hops: 1, expected: []string{"customers", "items", "orders"}},
+		{name: "Two hops", table: "items", hops: 2, expected: []string{"customers", "items", "orders"}},
+		{name: "Unrelated", table: "settings", hops: 3, expected: []string{"settings"}},
+		{name: "Unknown table", table: "missing", hops: 1, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			neighbourhood := graph.Neighbourhood(tc.table, tc.hops)
+			if !reflect.DeepEqual(neighbourhood.TableNames(), tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, neighbourhood.TableNames())
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	graph := newTestGraph(t).Neighbourhood("orders", 1)
+
+	rendered := Render(graph)
+	lines := strings.Split(rendered, "\n")
+
+	for _, expected := range []string{"| customers", "| orders", "| items", "PK id", "FK customer_id INTEGER"} {
+		if !strings.Contains(rendered, expected) {
+			t.Fatalf("expected %q in diagram:\n%s", expected, rendered)
+		}
+	}
+
+	// Referenced tables are placed left of the tables referencing them
+	column := func(text string) int {
+		for _, line := range lines {
+			if i := strings.Index(line, text); i != -1 {
+				return i
+			}
+		}
+		return -1
+	}
+	if !(column("| customers") < column("| orders") && column("| orders") < column("| items")) {
+		t.Fatalf("unexpected layout:\n%s", rendered)
+	}
+
+	if strings.Count(rendered, "<") != 2 {
+		t.Fatalf("expected an arrow per foreign key:\n%s", rendered)
+	}
+
+	if Render(&Graph{}) != "" {
+		t.Fatal("expected an empty diagram for an empty graph")
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	graph := newTestGraph(t).Neighbourhood("orders", 1)
+
+	mermaid := Mermaid(graph)
+
+	for _, expected := range []string{
+		"erDiagram\n",
+		"    orders {\n        INTEGER id PK\n        INTEGER customer_id FK\n    }\n",
+		`    customers ||--o{ orders : "customer_id"`,
+		`    orders ||--o{ items : "order_id"`,
+	} {
+		if !strings.Contains(mermaid, expected) {
+			t.Fatalf("expected %q in:\n%s", expected, mermaid)
+		}
+	}
+
+	if got := mermaidIdentifier("public.order items"); got != "public_order_items" {
+		t.Fatalf("unexpected identifier %q", got)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	graph := newTestGraph(t).Neighbourhood("orders", 1)
+
+	dot := DOT(graph)
+
+	for _, expected := range []string{
+		"digraph erd {\n",
+		`"orders" [label="{orders|id : INTEGER (PK)\lcustomer_id : INTEGER (FK)\l}"];`,
+		`"orders" -> "customers" [label="customer_id → id"];`,
+	} {
+		if !strings.Contains(dot, expected) {
+			t.Fatalf("expected %q in:\n%s", expected, dot)
+		}
+	}
+}
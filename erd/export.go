@@ -0,0 +1,122 @@
+package erd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var mermaidIdentifierReplacer = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// mermaidIdentifier turns names like schema.table or character varying
+// into words Mermaid accepts
+func mermaidIdentifier(name string) string {
+	identifier := strings.Trim(mermaidIdentifierReplacer.ReplaceAllString(name, "_"), "_")
+	if identifier == "" {
+		return "_"
+	}
+	return identifier
+}
+
+// Mermaid returns the graph as a Mermaid erDiagram
+func Mermaid(graph *Graph) string {
+	var sb strings.Builder
+
+	sb.WriteString("erDiagram\n")
+
+	for _, table := range graph.Tables {
+		sb.WriteString(fmt.Sprintf("    %s {\n", mermaidIdentifier(table.Name)))
+
+		for _, column := range table.Columns {
+			columnType := column.Type
+			if columnType == "" {
+				columnType = "unknown"
+			}
+
+			line := fmt.Sprintf("        %s %s", mermaidIdentifier(columnType), mermaidIdentifier(column.Name))
+
+			keys := []string{}
+			if column.PrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if column.ForeignKey {
+				keys = append(keys, "FK")
+			}
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ",")
+			}
+
+			sb.WriteString(line + "\n")
+		}
+
+		sb.WriteString("    }\n")
+	}
+
+	for _, foreignKey := range graph.ForeignKeys {
+		sb.WriteString(fmt.Sprintf("    %s ||--o{ %s : %q\n",
+			mermaidIdentifier(foreignKey.ReferencedTable),
+			mermaidIdentifier(foreignKey.Table),
+			strings.Join(foreignKey.Columns, ", "),
+		))
+	}
+
+	return sb.String()
+}
+
+var dotRecordReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"{", `\{`,
+	"}", `\}`,
+	"|", `\|`,
+	"<", `\<`,
+	">", `\>`,
+)
+
+var dotStringReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// DOT returns the graph as a Graphviz digraph with a record node per table
+func DOT(graph *Graph) string {
+	var sb strings.Builder
+
+	sb.WriteString("digraph erd {\n")
+	sb.WriteString("    rankdir=RL;\n")
+	sb.WriteString("    node [shape=record];\n")
+
+	for _, table := range graph.Tables {
+		columns := []string{}
+
+		for _, column := range table.Columns {
+			line := column.Name
+			if column.Type != "" {
+				line += " : " + column.Type
+			}
+			if column.PrimaryKey {
+				line += " (PK)"
+			}
+			if column.ForeignKey {
+				line += " (FK)"
+			}
+
+			columns = append(columns, dotRecordReplacer.Replace(line)+`\l`)
+		}
+
+		sb.WriteString(fmt.Sprintf("    \"%s\" [label=\"{%s|%s}\"];\n",
+			dotStringReplacer.Replace(table.Name),
+			dotRecordReplacer.Replace(table.Name),
+			strings.Join(columns, ""),
+		))
+	}
+
+	for _, foreignKey := range graph.ForeignKeys {
+		sb.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\" [label=\"%s\"];\n",
+			dotStringReplacer.Replace(foreignKey.Table),
+			dotStringReplacer.Replace(foreignKey.ReferencedTable),
+			dotStringReplacer.Replace(strings.Join(foreignKey.Columns, ", ")+" → "+strings.Join(foreignKey.ReferencedColumns, ", ")),
+		))
+	}
+
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
@@ -0,0 +1,196 @@
+package erd
+
+import (
+	"slices"
+	"sort"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+type Column struct {
+	Name       string
+	Type       string
+	PrimaryKey bool
+	ForeignKey bool
+}
+
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Graph holds the tables of a database and the foreign keys between them
+type Graph struct {
+	Tables      []Table
+	ForeignKeys []models.ForeignKey
+}
+
+// Load builds the graph of a database from the driver metadata
+func Load(db drivers.Driver, database string) (*Graph, error) {
+	tableNames, err := drivers.GetTableNames(db, database)
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeys, err := drivers.GetAllForeignKeys(db, database)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &Graph{ForeignKeys: foreignKeys}
+
+	for _, tableName := range tableNames {
+		columns, err := db.GetTableColumns(database, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		primaryKeys, err := db.GetPrimaryKeyColumnNames(database, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		table := Table{Name: tableName}
+
+		for i, column := range columns {
+			// The first row holds the column headers
+			if i == 0 || len(column) == 0 {
+				continue
+			}
+
+			newColumn := Column{Name: column[0]}
+			if len(column) > 1 {
+				newColumn.Type = column[1]
+			}
+			newColumn.PrimaryKey = slices.Contains(primaryKeys, newColumn.Name)
+
+			for _, foreignKey := range foreignKeys {
+				if foreignKey.Table == tableName && slices.Contains(foreignKey.Columns, newColumn.Name) {
+					newColumn.ForeignKey = true
+				}
+			}
+
+			table.Columns = append(table.Columns, newColumn)
+		}
+
+		graph.Tables = append(graph.Tables, table)
+	}
+
+	return graph, nil
+}
+
+// Table returns the table with the given name or nil
+func (graph *Graph) Table(name string) *Table {
+	for i := range graph.Tables {
+		if graph.Tables[i].Name == name {
+			return &graph.Tables[i]
+		}
+	}
+	return nil
+}
+
+// TableNames returns the sorted names of the tables of the graph
+func (graph *Graph) TableNames() []string {
+	names := []string{}
+	for _, table := range graph.Tables {
+		names = append(names, table.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Neighbourhood returns a graph with the given table and every table at
+// most hops foreign keys away from it, in either direction.
+func (graph *Graph) Neighbourhood(table string, hops int) *Graph {
+	if graph.Table(table) == nil {
+		return &Graph{}
+	}
+
+	distances := map[string]int{table: 0}
+	queue := []string{table}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if distances[current] >= hops {
+			continue
+		}
+
+		for _, foreignKey := range graph.ForeignKeys {
+			var next string
+			switch current {
+			case foreignKey.Table:
+				next = foreignKey.ReferencedTable
+			case foreignKey.ReferencedTable:
+				next = foreignKey.Table
+			default:
+				continue
+			}
+
+			if _, seen := distances[next]; !seen && graph.Table(next) != nil {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	neighbourhood := &Graph{}
+
+	for _, t := range graph.Tables {
+		if _, ok := distances[t.Name]; ok {
+			neighbourhood.Tables = append(neighbourhood.Tables, t)
+		}
+	}
+
+	for _, foreignKey := range graph.ForeignKeys {
+		_, hasTable := distances[foreignKey.Table]
+		_, hasReferencedTable := distances[foreignKey.ReferencedTable]
+
+		if hasTable && hasReferencedTable {
+			neighbourhood.ForeignKeys = append(neighbourhood.ForeignKeys, foreignKey)
+		}
+	}
+
+	return neighbourhood
+}
+
+// ranks places every table one rank after the tables it references, so
+// referenced tables end up on the left of the diagram
+func (graph *Graph) ranks() map[string]int {
+	ranks := map[string]int{}
+	visiting := map[string]bool{}
+
+	var rank func(table string) int
+	rank = func(table string) int {
+		if r, ok := ranks[table]; ok {
+			return r
+		}
+
+		// Cycles are broken by treating the back reference as a root
+		if visiting[table] {
+			return -1
+		}
+		visiting[table] = true
+
+		r := 0
+		for _, foreignKey := range graph.ForeignKeys {
+			if foreignKey.Table != table || foreignKey.ReferencedTable == table || graph.Table(foreignKey.ReferencedTable) == nil {
+				continue
+			}
+			r = max(r, rank(foreignKey.ReferencedTable)+1)
+		}
+
+		visiting[table] = false
+		ranks[table] = r
+
+		return r
+	}
+
+	for _, name := range graph.TableNames() {
+		rank(name)
+	}
+
+	return ranks
+}
@@ -0,0 +1,273 @@
+package erd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// box is a table placed on the canvas
+type box struct {
+	table  *Table
+	lines  []string
+	x, y   int
+	width  int
+	height int
+}
+
+// row returns the canvas row of a column, or of the header if the
+// column is not part of the table
+func (b *box) row(column string) int {
+	for i, c := range b.table.Columns {
+		if c.Name == column {
+			return b.y + 3 + i
+		}
+	}
+	return b.y + 1
+}
+
+type canvas [][]rune
+
+func newCanvas(width, height int) canvas {
+	c := make(canvas, height)
+	for y := range c {
+		c[y] = []rune(strings.Repeat(" ", width))
+	}
+	return c
+}
+
+func (c canvas) text(x, y int, text string) {
+	for _, r := range text {
+		c[y][x] = r
+		x++
+	}
+}
+
+// line draws a connector cell, turning crossings into '+'
+func (c canvas) line(x, y int, r rune) {
+	switch c[y][x] {
+	case ' ', r:
+		c[y][x] = r
+	case '-', '|', '+':
+		c[y][x] = '+'
+	}
+}
+
+func (c canvas) horizontal(y, fromX, toX int) {
+	if fromX > toX {
+		fromX, toX = toX, fromX
+	}
+	for x := fromX; x <= toX; x++ {
+		c.line(x, y, '-')
+	}
+}
+
+func (c canvas) vertical(x, fromY, toY int) {
+	if fromY > toY {
+		fromY, toY = toY, fromY
+	}
+	for y := fromY; y <= toY; y++ {
+		c.line(x, y, '|')
+	}
+}
+
+func (c canvas) String() string {
+	lines := make([]string, len(c))
+	for y, row := range c {
+		lines[y] = strings.TrimRight(string(row), " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+func newBox(table *Table) *box {
+	markerWidth, nameWidth := 0, 0
+	markers := make([]string, len(table.Columns))
+
+	for i, column := range table.Columns {
+		keys := []string{}
+		if column.PrimaryKey {
+			keys = append(keys, "PK")
+		}
+		if column.ForeignKey {
+			keys = append(keys, "FK")
+		}
+		markers[i] = strings.Join(keys, ",")
+
+		markerWidth = max(markerWidth, len(markers[i]))
+		nameWidth = max(nameWidth, utf8.RuneCountInString(column.Name))
+	}
+
+	lines := []string{}
+	for i, column := range table.Columns {
+		line := fmt.Sprintf("%-*s %-*s %s", markerWidth, markers[i], nameWidth, column.Name, column.Type)
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+
+	width := utf8.RuneCountInString(table.Name)
+	for _, line := range lines {
+		width = max(width, utf8.RuneCountInString(line))
+	}
+
+	return &box{
+		table:  table,
+		lines:  lines,
+		width:  width + 4,
+		height: len(lines) + 4,
+	}
+}
+
+func (b *box) draw(c canvas) {
+	border := "+" + strings.Repeat("-", b.width-2) + "+"
+
+	c.text(b.x, b.y, border)
+	c.text(b.x, b.y+1, "| "+b.table.Name)
+	c.text(b.x+b.width-1, b.y+1, "|")
+	c.text(b.x, b.y+2, border)
+
+	for i, line := range b.lines {
+		c.text(b.x, b.y+3+i, "| "+line)
+		c.text(b.x+b.width-1, b.y+3+i, "|")
+	}
+
+	c.text(b.x, b.y+b.height-1, border)
+}
+
+// edge is a foreign key routed through the gutters of the diagram
+type edge struct {
+	from, to   *box
+	fromColumn string
+	toColumn   string
+	fromLane   int
+	toLane     int
+	fromGutter int
+	toGutter   int
+	channel    int
+	fromX, toX int
+	fromY, toY int
+}
+
+// Render lays out the graph as boxes and connectors. Referenced tables
+// are placed to the left of the tables pointing to them, and every
+// connector leaves the referencing column on the left side of its box and
+// ends with an arrow on the right side of the referenced box.
+func Render(graph *Graph) string {
+	if len(graph.Tables) == 0 {
+		return ""
+	}
+
+	ranks := graph.ranks()
+
+	columnCount := 0
+	for _, r := range ranks {
+		columnCount = max(columnCount, r+1)
+	}
+
+	columns := make([][]*box, columnCount)
+	boxes := map[string]*box{}
+
+	for _, name := range graph.TableNames() {
+		b := newBox(graph.Table(name))
+		boxes[name] = b
+		columns[ranks[name]] = append(columns[ranks[name]], b)
+	}
+
+	// Gutter i sits on the left of column i, the last one on the right of the diagram
+	lanes := make([]int, columnCount+1)
+	edges := []*edge{}
+
+	for _, foreignKey := range graph.ForeignKeys {
+		from, to := boxes[foreignKey.Table], boxes[foreignKey.ReferencedTable]
+		if from == nil || to == nil || len(foreignKey.Columns) == 0 {
+			continue
+		}
+
+		e := &edge{
+			from:       from,
+			to:         to,
+			fromColumn: foreignKey.Columns[0],
+			fromGutter: ranks[foreignKey.Table],
+			toGutter:   ranks[foreignKey.ReferencedTable] + 1,
+			channel:    len(edges),
+		}
+		if len(foreignKey.ReferencedColumns) > 0 {
+			e.toColumn = foreignKey.ReferencedColumns[0]
+		}
+
+		e.fromLane = lanes[e.fromGutter]
+		lanes[e.fromGutter]++
+		e.toLane = lanes[e.toGutter]
+		lanes[e.toGutter]++
+
+		edges = append(edges, e)
+	}
+
+	gutterWidths := make([]int, len(lanes))
+	for i, count := range lanes {
+		switch {
+		case count > 0:
+			gutterWidths[i] = count*2 + 3
+		case i > 0 && i < columnCount:
+			gutterWidths[i] = 4
+		}
+	}
+
+	// Connectors run horizontally in channels above the boxes so they
+	// never cross a box
+	top := len(edges)
+	if top > 0 {
+		top++
+	}
+
+	gutterX := make([]int, len(lanes))
+	width, height := 0, 0
+
+	for i, column := range columns {
+		gutterX[i] = width
+		width += gutterWidths[i]
+
+		columnWidth, y := 0, top
+		for _, b := range column {
+			b.x, b.y = width, y
+			y += b.height + 1
+			columnWidth = max(columnWidth, b.width)
+		}
+
+		width += columnWidth
+		height = max(height, y)
+	}
+	gutterX[columnCount] = width
+	width += gutterWidths[columnCount]
+
+	c := newCanvas(width, height)
+
+	for _, column := range columns {
+		for _, b := range column {
+			b.draw(c)
+		}
+	}
+
+	for _, e := range edges {
+		e.fromX = gutterX[e.fromGutter] + 2 + e.fromLane*2
+		e.toX = gutterX[e.toGutter] + 2 + e.toLane*2
+		e.fromY = e.from.row(e.fromColumn)
+		e.toY = e.to.row(e.toColumn)
+
+		c.horizontal(e.fromY, e.fromX, e.from.x-1)
+		c.vertical(e.fromX, e.channel, e.fromY)
+		c.horizontal(e.channel, e.fromX, e.toX)
+		c.vertical(e.toX, e.channel, e.toY)
+		c.horizontal(e.toY, e.to.x+e.to.width, e.toX)
+	}
+
+	// Corners and arrows are drawn last so crossings do not hide them
+	for _, e := range edges {
+		for _, corner := range [][2]int{
+			{e.fromX, e.fromY}, {e.fromX, e.channel}, {e.toX, e.channel}, {e.toX, e.toY},
+		} {
+			c[corner[1]][corner[0]] = '+'
+		}
+		c[e.toY][e.to.x+e.to.width] = '<'
+	}
+
+	return c.String()
+}
@@ -0,0 +1,185 @@
+package headless
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formats results can be written in
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatTSV   = "tsv"
+)
+
+// Formats lists the formats, the default one first
+var Formats = []string{FormatTable, FormatCSV, FormatJSON, FormatTSV}
+
+// writer writes the rows of a query result, whose SQL NULL values are not
+// valid
+type writer func(out io.Writer, columns []string, rows [][]sql.NullString) error
+
+var writers = map[string]writer{
+	FormatTable: writeTable,
+	FormatCSV:   writeCSV,
+	FormatJSON:  writeJSON,
+	FormatTSV:   writeTSV,
+}
+
+// writeTable writes the rows in aligned columns under a header, followed by
+// the row count like psql. NULL is written as an empty value.
+func writeTable(out io.Writer, columns []string, rows [][]sql.NullString) error {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(tableValue(value.String)))
+			}
+		}
+	}
+
+	line := func(values []string) string {
+		cells := make([]string, len(values))
+		for i, value := range values {
+			cells[i] = value + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value))
+		}
+		return strings.TrimRight(strings.Join(cells, " | "), " ")
+	}
+
+	separators := make([]string, len(columns))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+
+	var text strings.Builder
+	text.WriteString(line(columns) + "\n")
+	text.WriteString(strings.Join(separators, "-+-") + "\n")
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i := range values {
+			if i < len(row) {
+				values[i] = tableValue(row[i].String)
+			}
+		}
+		text.WriteString(line(values) + "\n")
+	}
+
+	noun := "rows"
+	if len(rows) == 1 {
+		noun = "row"
+	}
+	fmt.Fprintf(&text, "(%d %s)\n\n", len(rows), noun)
+
+	_, err := io.WriteString(out, text.String())
+	return err
+}
+
+// tableValue keeps a value on one line of a table
+func tableValue(value string) string {
+	return strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\t", `\t`).Replace(value)
+}
+
+// writeCSV writes the rows as RFC 4180 CSV with a header. NULL is written
+// as an empty field, like an empty string, which CSV can't tell apart.
+func writeCSV(out io.Writer, columns []string, rows [][]sql.NullString) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(rowStrings(row)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeTSV writes the rows tab separated with a header, escaping tabs,
+// newlines and backslashes like PostgreSQL COPY does. NULL is written as an
+// empty value.
+func writeTSV(out io.Writer, columns []string, rows [][]sql.NullString) error {
+	escape := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+	var text strings.Builder
+	line := func(values []string) {
+		for i, value := range values {
+			if i > 0 {
+				text.WriteByte('\t')
+			}
+			text.WriteString(escape.Replace(value))
+		}
+		text.WriteByte('\n')
+	}
+
+	line(columns)
+	for _, row := range rows {
+		line(rowStrings(row))
+	}
+
+	_, err := io.WriteString(out, text.String())
+	return err
+}
+
+// writeJSON writes the rows as an array of objects keyed by column. Values
+// are strings, as the drivers return them, and null for NULL.
+func writeJSON(out io.Writer, columns []string, rows [][]sql.NullString) error {
+	var text strings.Builder
+	text.WriteString("[")
+
+	for r, row := range rows {
+		if r > 0 {
+			text.WriteString(",")
+		}
+		text.WriteString("\n  {")
+
+		for i, column := range columns {
+			if i > 0 {
+				text.WriteString(", ")
+			}
+			key, err := json.Marshal(column)
+			if err != nil {
+				return err
+			}
+			text.Write(key)
+			text.WriteString(": ")
+
+			value := []byte("null")
+			if i < len(row) && row[i].Valid {
+				value, err = json.Marshal(row[i].String)
+			}
+			if err != nil {
+				return err
+			}
+			text.Write(value)
+		}
+
+		text.WriteString("}")
+	}
+
+	if len(rows) > 0 {
+		text.WriteString("\n")
+	}
+	text.WriteString("]\n")
+
+	_, err := io.WriteString(out, text.String())
+	return err
+}
+
+// rowStrings returns the values of a row, NULL being an empty string
+func rowStrings(row []sql.NullString) []string {
+	values := make([]string, len(row))
+	for i, value := range row {
+		values[i] = value.String
+	}
+	return values
+}
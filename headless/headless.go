@@ -0,0 +1,252 @@
+// Package headless runs SQL on a connection without the terminal UI, for
+// shell scripts: results are written to stdout, errors to stderr, and how
+// the run went is told by the exit code.
+package headless
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sqlcmder/data/secrets"
+	"sqlcmder/db"
+	"sqlcmder/drivers"
+	"sqlcmder/logger"
+	"sqlcmder/models"
+	"sqlcmder/sqlparse"
+)
+
+// Exit codes of a run
+const (
+	ExitOK         = 0
+	ExitStatement  = 1 // A statement failed
+	ExitUsage      = 2 // The options are wrong or there is no SQL to run
+	ExitConnection = 3 // Connecting failed
+)
+
+// Options tell what a run executes, on which connection, and where it
+// writes to.
+type Options struct {
+	Connection string // Name of a configured connection, or a URL
+	Execute    string // SQL to run
+	File       string // File of SQL to run, stdin when empty or -
+	Format     string // table, csv, json or tsv
+	// Guard holds the rules statements are checked with, like in the editor.
+	// Statements they warn about, and changes on production connections, are
+	// refused unless Yes is set, as there is no one to confirm them.
+	Guard models.GuardConfig
+	Yes   bool
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Prompt secrets.Prompt // Asks for passwords and passphrases
+}
+
+// Run runs the SQL of the options statement by statement, stopping at the
+// first failing one, and returns the exit code. Connection names a
+// configured connection or is a URL to connect to.
+func Run(options Options, connections []models.Connection) int {
+	write, ok := writers[options.Format]
+	if !ok {
+		fmt.Fprintf(options.Stderr, "unknown format %q, expected %s\n", options.Format, strings.Join(Formats, ", "))
+		return ExitUsage
+	}
+
+	if options.Connection == "" {
+		fmt.Fprintln(options.Stderr, "no connection given, name a configured one or give a URL with -c")
+		return ExitUsage
+	}
+
+	sql, err := readSQL(options)
+	if err != nil {
+		fmt.Fprintln(options.Stderr, err)
+		return ExitUsage
+	}
+
+	connection, driver, closeConnection, err := connect(options.Connection, connections, options.Prompt)
+	if err != nil {
+		fmt.Fprintln(options.Stderr, err)
+		return ExitConnection
+	}
+	defer closeConnection()
+
+	statements := sqlparse.SplitStatements(driver.GetProvider(), sql)
+	for i, statement := range statements {
+		if err := execute(driver, connection, statement, write, options); err != nil {
+			if len(statements) > 1 {
+				err = fmt.Errorf("statement %d: %w", i+1, err)
+			}
+			fmt.Fprintln(options.Stderr, err)
+			return ExitStatement
+		}
+	}
+
+	return ExitOK
+}
+
+// readSQL returns the SQL to run, from the options, a file or stdin
+func readSQL(options Options) (string, error) {
+	if options.Execute != "" && options.File != "" {
+		return "", errors.New("-e and -f cannot be used together")
+	}
+
+	sql := options.Execute
+	if sql == "" {
+		var data []byte
+		var err error
+		if options.File == "" || options.File == "-" {
+			data, err = io.ReadAll(options.Stdin)
+		} else {
+			data, err = os.ReadFile(options.File)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read the SQL to run: %w", err)
+		}
+		sql = string(data)
+	}
+
+	if strings.TrimSpace(sql) == "" {
+		return "", errors.New("no SQL to run, give it with -e, -f or on stdin")
+	}
+
+	return sql, nil
+}
+
+// execute runs a statement, writing the rows of a query in the format of
+// the run and the outcome of other statements to stderr. Statements other
+// than queries are refused on read-only connections, and guarded ones
+// without options.Yes.
+func execute(driver drivers.Driver, connection models.Connection, statement sqlparse.Statement, write writer, options Options) error {
+	logger.Info("Execute headless SQL", map[string]any{"connection": connection.Name, "sql": statement.Text})
+
+	if statement.Kind() != sqlparse.StatementQuery && connection.ReadOnly {
+		return errors.New("the connection is read-only, only queries can be run")
+	}
+
+	if !options.Yes {
+		if err := guard(driver, connection, statement, options.Guard); err != nil {
+			return err
+		}
+	}
+
+	if statement.Kind() != sqlparse.StatementQuery {
+
+		result, err := driver.ExecuteDMLStatement(statement.Text)
+		if err != nil {
+			return err
+		}
+		if result != "" {
+			fmt.Fprintln(options.Stderr, result)
+		}
+		return nil
+	}
+
+	columns, rows, err := query(db.SQL(driver), statement.Text)
+	if err != nil {
+		return err
+	}
+
+	return write(options.Stdout, columns, rows)
+}
+
+// guard refuses the statements the UI asks to confirm: changes on production
+// connections and the statements the rules of the guard warn about
+func guard(driver drivers.Driver, connection models.Connection, statement sqlparse.Statement, rules models.GuardConfig) error {
+	if statement.Kind() != sqlparse.StatementQuery && connection.IsProduction() {
+		return fmt.Errorf("%s is a production connection, run with -yes to change it", connection.Name)
+	}
+
+	warnings := sqlparse.Lint(driver.GetProvider(), statement.Text, sqlparse.GuardOptions(rules), func(table string) (int64, bool) {
+		return drivers.EstimateRows(driver, table)
+	})
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	messages := []string{}
+	for _, warning := range warnings {
+		messages = append(messages, warning.Message())
+	}
+	return fmt.Errorf("refused, run with -yes to run it anyway: %s", strings.Join(messages, "; "))
+}
+
+// query runs a query, returning its columns and rows. Unlike the rows of
+// Driver.ExecuteQuery, NULL values are told apart from empty strings.
+func query(database *sql.DB, statement string) ([]string, [][]sql.NullString, error) {
+	if database == nil {
+		return nil, nil, errors.New("not connected")
+	}
+
+	result, err := database.Query(statement)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer result.Close()
+
+	columns, err := result.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := [][]sql.NullString{}
+	for result.Next() {
+		row := make([]sql.NullString, len(columns))
+		values := make([]any, len(columns))
+		for i := range row {
+			values[i] = &row[i]
+		}
+
+		if err := result.Scan(values...); err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	return columns, rows, result.Err()
+}
+
+// connect connects to the configured connection named target, or to target
+// as a URL. The connection is closed with closeConnection.
+func connect(target string, connections []models.Connection, prompt secrets.Prompt) (connection models.Connection, driver drivers.Driver, closeConnection func(), err error) {
+	for _, configured := range connections {
+		if configured.Name == target {
+			return open(configured, prompt)
+		}
+	}
+
+	if _, err := drivers.ParseDSN(target); err != nil {
+		return connection, nil, nil, fmt.Errorf("no connection is named %s, and it is not a URL: %w", target, err)
+	}
+
+	parsed, driver, closeConnection, err := db.InitFromArg(target, prompt)
+	if err != nil {
+		return connection, nil, nil, err
+	}
+
+	return *parsed, driver, closeConnection, nil
+}
+
+// open connects to a configured connection like the UI does, with
+// db.Connect. Connections starting commands first are refused, as they are
+// started by the UI only.
+func open(connection models.Connection, prompt secrets.Prompt) (models.Connection, drivers.Driver, func(), error) {
+	if len(connection.Commands) > 0 {
+		return connection, nil, nil, fmt.Errorf("connection %s runs commands before connecting, which only the UI does", connection.Name)
+	}
+
+	dsn, err := db.ConnectionDSN(&connection, prompt)
+	if err != nil {
+		return connection, nil, nil, fmt.Errorf("could not get the password of %s: %w", connection.Name, err)
+	}
+
+	driver, closeConnection, err := db.Connect(connection, dsn, db.Tunnel(prompt))
+	if err != nil {
+		return connection, nil, nil, fmt.Errorf("could not connect to %s: %w", connection.Name, err)
+	}
+
+	return connection, driver, closeConnection, nil
+}
@@ -0,0 +1,197 @@
+package headless
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// newDatabase creates a SQLite database with a users table
+func newDatabase(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "app.db")
+	database := &drivers.SQLite{}
+	if err := database.Connect(path); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer database.Connection.Close()
+
+	if _, err := database.Connection.Exec(`CREATE TABLE users (id INTEGER, name TEXT, email TEXT);
+		INSERT INTO users VALUES (1, 'Ada', 'ada@example.com'), (2, 'Tab	Name', NULL)`); err != nil {
+		t.Fatalf("setup error = %v", err)
+	}
+
+	return path
+}
+
+func run(options Options, connections []models.Connection) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	options.Stdout, options.Stderr = &stdout, &stderr
+	if options.Stdin == nil {
+		options.Stdin = strings.NewReader("")
+	}
+	if options.Format == "" {
+		options.Format = FormatTable
+	}
+
+	code := Run(options, connections)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Formats(t *testing.T) {
+	path := newDatabase(t)
+	query := "SELECT id, name, email FROM users ORDER BY id"
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatTable, "id | name      | email\n---+-----------+----------------\n1  | Ada       | ada@example.com\n2  | Tab\\tName |\n(2 rows)\n\n"},
+		{FormatCSV, "id,name,email\n1,Ada,ada@example.com\n2,Tab\tName,\n"},
+		{FormatTSV, "id\tname\temail\n1\tAda\tada@example.com\n2\tTab\\tName\t\n"},
+		{FormatJSON, "[\n  {\"id\": \"1\", \"name\": \"Ada\", \"email\": \"ada@example.com\"},\n  {\"id\": \"2\", \"name\": \"Tab\\tName\", \"email\": null}\n]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			code, stdout, stderr := run(Options{Connection: path, Execute: query, Format: tt.format}, nil)
+			if code != ExitOK {
+				t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+			}
+			if stdout != tt.expected {
+				t.Fatalf("expected:\n%q\ngot:\n%q", tt.expected, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Sources(t *testing.T) {
+	path := newDatabase(t)
+	connections := []models.Connection{{Name: "app", Driver: drivers.DriverSqlite, DSN: path}}
+
+	script := filepath.Join(t.TempDir(), "script.sql")
+	if err := os.WriteFile(script, []byte("-- add a user\nINSERT INTO users VALUES (3, 'Grace', NULL);\nSELECT COUNT(*) AS total FROM users;"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	code, stdout, stderr := run(Options{Connection: "app", File: script, Format: FormatCSV}, connections)
+	if code != ExitOK || stdout != "total\n3\n" || stderr != "1 rows affected\n" {
+		t.Fatalf("unexpected run of the file: %d %q %q", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = run(Options{Connection: "app", Stdin: strings.NewReader("SELECT name FROM users WHERE id = 3"), Format: FormatCSV}, connections)
+	if code != ExitOK || stdout != "name\nGrace\n" {
+		t.Fatalf("unexpected run of stdin: %d %q %q", code, stdout, stderr)
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	path := newDatabase(t)
+	connections := []models.Connection{
+		{Name: "app", Driver: drivers.DriverSqlite, DSN: path},
+		{Name: "reports", Driver: drivers.DriverSqlite, DSN: path, ReadOnly: true},
+		{Name: "tunnelled", Driver: drivers.DriverSqlite, DSN: path, Commands: []*models.Command{{Command: "true"}}},
+		{Name: "prod", Driver: drivers.DriverSqlite, DSN: path, Environment: models.EnvironmentProd},
+	}
+
+	tests := []struct {
+		name    string
+		options Options
+		code    int
+		stderr  string
+		stdout  string
+	}{
+		{
+			name:    "unknown format",
+			options: Options{Connection: "app", Execute: "SELECT 1", Format: "xml"},
+			code:    ExitUsage,
+			stderr:  "unknown format \"xml\", expected table, csv, json, tsv\n",
+		},
+		{
+			name:    "both -e and -f",
+			options: Options{Connection: "app", Execute: "SELECT 1", File: "script.sql"},
+			code:    ExitUsage,
+			stderr:  "-e and -f cannot be used together\n",
+		},
+		{
+			name:    "no SQL",
+			options: Options{Connection: "app", Stdin: strings.NewReader("  \n")},
+			code:    ExitUsage,
+			stderr:  "no SQL to run, give it with -e, -f or on stdin\n",
+		},
+		{
+			name:    "no connection",
+			options: Options{Execute: "SELECT 1"},
+			code:    ExitUsage,
+			stderr:  "no connection given, name a configured one or give a URL with -c\n",
+		},
+		{
+			name:    "unknown connection",
+			options: Options{Connection: "nowhere", Execute: "SELECT 1"},
+			code:    ExitConnection,
+		},
+		{
+			name:    "commands",
+			options: Options{Connection: "tunnelled", Execute: "SELECT 1"},
+			code:    ExitConnection,
+			stderr:  "connection tunnelled runs commands before connecting, which only the UI does\n",
+		},
+		{
+			name:    "failing statement",
+			options: Options{Connection: "app", Execute: "SELECT 1 AS one; SELECT * FROM missing; SELECT 2"},
+			code:    ExitStatement,
+			stdout:  "one\n---\n1\n(1 row)\n\n",
+			stderr:  "statement 2: SQL logic error: no such table: missing (1)\n",
+		},
+		{
+			name:    "production change",
+			options: Options{Connection: "prod", Execute: "UPDATE users SET name = 'Ada' WHERE id = 1"},
+			code:    ExitStatement,
+			stderr:  "prod is a production connection, run with -yes to change it\n",
+		},
+		{
+			name:    "production query",
+			options: Options{Connection: "prod", Execute: "SELECT 1 AS one"},
+			code:    ExitOK,
+			stdout:  "one\n---\n1\n(1 row)\n\n",
+		},
+		{
+			name:    "guarded statement",
+			options: Options{Connection: "app", Execute: "DELETE FROM users", Guard: models.GuardConfig{UnfilteredChanges: true}},
+			code:    ExitStatement,
+			stderr:  "refused, run with -yes to run it anyway: DELETE without WHERE changes every row of users\n",
+		},
+		{
+			name:    "guarded statement with -yes",
+			options: Options{Connection: "prod", Execute: "UPDATE users SET name = name", Guard: models.GuardConfig{UnfilteredChanges: true}, Yes: true},
+			code:    ExitOK,
+		},
+		{
+			name:    "read-only",
+			options: Options{Connection: "reports", Execute: "DELETE FROM users"},
+			code:    ExitStatement,
+			stderr:  "the connection is read-only, only queries can be run\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(tt.options, connections)
+			if code != tt.code {
+				t.Fatalf("expected exit code %d, got %d: %s", tt.code, code, stderr)
+			}
+			if tt.stderr != "" && stderr != tt.stderr {
+				t.Fatalf("expected stderr %q, got %q", tt.stderr, stderr)
+			}
+			if stdout != tt.stdout {
+				t.Fatalf("expected stdout %q, got %q", tt.stdout, stdout)
+			}
+		})
+	}
+}
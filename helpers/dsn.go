@@ -0,0 +1,157 @@
+package helpers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// SplitPassword returns a DSN without its password, and the password it held.
+// The username is kept. A DSN that is not a URL, like the path of a SQLite
+// file, is returned as is.
+func SplitPassword(dsn string) (string, string) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return dsn, ""
+	}
+
+	authority, tail := splitAuthority(rest)
+	password := ""
+
+	if userinfo, host, found := cutLast(authority, "@"); found {
+		username, escaped, hasPassword := strings.Cut(userinfo, ":")
+		if hasPassword {
+			password = unescape(escaped)
+			authority = username + "@" + host
+		}
+	}
+
+	// SQL Server also takes the password as a query parameter
+	path, query, hasQuery := strings.Cut(tail, "?")
+	if hasQuery {
+		kept := []string{}
+		for _, parameter := range strings.Split(query, "&") {
+			key, value, _ := strings.Cut(parameter, "=")
+			if strings.EqualFold(key, "password") {
+				if password == "" {
+					password, _ = url.QueryUnescape(value)
+				}
+				continue
+			}
+			kept = append(kept, parameter)
+		}
+
+		tail = path
+		if len(kept) > 0 {
+			tail += "?" + strings.Join(kept, "&")
+		}
+	}
+
+	return scheme + "://" + authority + tail, password
+}
+
+// WithPassword returns a DSN holding the given password, replacing the one it
+// held. The DSN is returned as is when the password is empty or it has no
+// username to go with it.
+func WithPassword(dsn string, password string) string {
+	if password == "" {
+		return dsn
+	}
+
+	stripped, _ := SplitPassword(dsn)
+	scheme, rest, ok := strings.Cut(stripped, "://")
+	if !ok {
+		return dsn
+	}
+
+	authority, tail := splitAuthority(rest)
+	username, host, found := cutLast(authority, "@")
+	if !found || username == "" {
+		return dsn
+	}
+
+	userinfo := url.UserPassword(unescape(username), password).String()
+	return scheme + "://" + userinfo + "@" + host + tail
+}
+
+// splitAuthority splits the part of a URL after its scheme into the
+// authority and what follows it
+func splitAuthority(rest string) (string, string) {
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		return rest[:i], rest[i:]
+	}
+	return rest, ""
+}
+
+func cutLast(s string, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func unescape(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+// defaultPorts are the ports a DSN connects to when it has none, by scheme
+var defaultPorts = map[string]string{
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"pg":         "5432",
+	"mysql":      "3306",
+	"my":         "3306",
+	"mariadb":    "3306",
+	"sqlserver":  "1433",
+	"mssql":      "1433",
+	"ms":         "1433",
+}
+
+// DSNAddress returns the host:port a DSN connects to, using the default port
+// of its scheme when it has none. It returns false for a DSN without a host,
+// like the path of a SQLite file.
+func DSNAddress(dsn string) (string, bool) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return "", false
+	}
+
+	authority, _ := splitAuthority(rest)
+	_, address, _ := cutLast(authority, "@")
+	if address == "" {
+		address = authority
+	}
+	if address == "" {
+		return "", false
+	}
+
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address, true
+	}
+
+	port, ok := defaultPorts[strings.ToLower(scheme)]
+	if !ok {
+		return "", false
+	}
+
+	return net.JoinHostPort(strings.Trim(address, "[]"), port), true
+}
+
+// WithAddress returns a DSN connecting to the given host:port instead of its
+// own host. A DSN without a host is returned as is.
+func WithAddress(dsn string, address string) string {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return dsn
+	}
+
+	authority, tail := splitAuthority(rest)
+	if userinfo, _, found := cutLast(authority, "@"); found {
+		return scheme + "://" + userinfo + "@" + address + tail
+	}
+
+	return scheme + "://" + address + tail
+}
@@ -0,0 +1,48 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"sqlcmder/drivers"
+)
+
+// environmentSuffixes end the names of the environment variables holding a
+// database URL, DATABASE_URL itself included
+var environmentSuffixes = []string{"DATABASE_URL", "_DB_URL"}
+
+// FromEnvironment returns the connections of the DATABASE_URL environment
+// variables, like DATABASE_URL or ANALYTICS_DATABASE_URL, named after their
+// variable. environ holds key=value pairs like os.Environ.
+func FromEnvironment(environ []string) ([]Candidate, error) {
+	candidates := []Candidate{}
+	errs := []error{}
+
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if value == "" || !slices.ContainsFunc(environmentSuffixes, func(suffix string) bool {
+			return strings.HasSuffix(strings.ToUpper(name), suffix)
+		}) {
+			continue
+		}
+
+		source := "$" + name
+		connection, err := drivers.ParseDSN(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+
+		connection.Name = name
+		candidate, err := newCandidate(connection, source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, errors.Join(errs...)
+}
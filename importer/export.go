@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"sqlcmder/models"
+)
+
+// ReadExport reads the connections of a file written by another sqlcmder: a
+// TOML config with [[database]] entries, or a JSON file holding a list of
+// connections or an object with a database list. JSON keys are the names of
+// the fields of the connections.
+func ReadExport(path string) ([]Candidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := struct {
+		Database []models.Connection `toml:"database" json:"database"`
+	}{}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &exported.Database); err != nil {
+			if err := json.Unmarshal(data, &exported); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	} else if err := toml.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	candidates := []Candidate{}
+	errs := []error{}
+
+	for _, connection := range exported.Database {
+		if connection.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: a connection has no name", path))
+			continue
+		}
+
+		candidate, err := newCandidate(connection, fmt.Sprintf("%s [%s]", filepath.Base(path), connection.Name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, errors.Join(errs...)
+}
@@ -0,0 +1,162 @@
+// Package importer finds connections in the files and environment of other
+// database tools, and merges them into the connections of sqlcmder.
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sqlcmder/config"
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// Candidate is a connection found by an importer, with its password when the
+// source holds one
+type Candidate struct {
+	Connection models.Connection
+	Source     string // Where the connection was found, like ~/.pgpass:3
+}
+
+// Discover reads the connections of the default locations: the files named
+// by PGPASSFILE, PGSERVICEFILE and PGSYSCONFDIR or ~/.pgpass,
+// ~/.pg_service.conf and ~/.my.cnf, and the DATABASE_URL environment
+// variables. Missing files are skipped, the errors of the others returned
+// with the candidates found anyway.
+func Discover() ([]Candidate, []error) {
+	candidates := []Candidate{}
+	errs := []error{}
+
+	type file struct {
+		path string
+		read func(string) ([]Candidate, error)
+	}
+
+	home, _ := os.UserHomeDir()
+	files := []file{
+		{envOr("PGPASSFILE", filepath.Join(home, ".pgpass")), ReadPgpass},
+		{envOr("PGSERVICEFILE", filepath.Join(home, ".pg_service.conf")), ReadPgService},
+		{filepath.Join(home, ".my.cnf"), ReadMyCnf},
+	}
+	if directory := os.Getenv("PGSYSCONFDIR"); directory != "" {
+		files = append(files, file{filepath.Join(directory, "pg_service.conf"), ReadPgService})
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file.path); err != nil {
+			continue
+		}
+
+		found, err := file.read(file.path)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		candidates = append(candidates, found...)
+	}
+
+	found, err := FromEnvironment(os.Environ())
+	if err != nil {
+		errs = append(errs, err)
+	}
+	candidates = append(candidates, found...)
+
+	return candidates, errs
+}
+
+// Merge adds to connections the candidates that are not among them yet. A
+// candidate is among them when a connection has the same driver, host, port,
+// database and user. A candidate taking the name of another connection gets
+// a number after its name.
+func Merge(connections []models.Connection, candidates []Candidate) (merged []models.Connection, added []models.Connection) {
+	merged = append([]models.Connection{}, connections...)
+	keys := map[string]bool{}
+	names := map[string]bool{}
+	for _, connection := range merged {
+		keys[connectionKey(connection)] = true
+		names[connection.Name] = true
+	}
+
+	for _, candidate := range candidates {
+		connection := candidate.Connection
+		key := connectionKey(connection)
+		if keys[key] {
+			continue
+		}
+
+		base := connection.Name
+		for i := 2; names[connection.Name]; i++ {
+			connection.Name = fmt.Sprintf("%s (%d)", base, i)
+		}
+
+		keys[key] = true
+		names[connection.Name] = true
+		merged = append(merged, connection)
+		added = append(added, connection)
+	}
+
+	return merged, added
+}
+
+// Exists reports whether a candidate is among connections, see Merge
+func Exists(connections []models.Connection, candidate Candidate) bool {
+	key := connectionKey(candidate.Connection)
+	for _, connection := range connections {
+		if connectionKey(connection) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultPorts are the ports of the drivers when a DSN has none
+var defaultPorts = map[string]string{
+	drivers.DriverPostgres: "5432",
+	drivers.DriverMySQL:    "3306",
+	drivers.DriverMSSQL:    "1433",
+}
+
+// connectionKey returns what tells a database apart: its driver, host,
+// port, database and user, read from the DSN of the connection
+func connectionKey(connection models.Connection) string {
+	parsed := connection
+	if dsn := connection.GetDSN(); dsn != "" {
+		if fromDSN, err := drivers.ParseDSN(dsn); err == nil {
+			parsed = fromDSN
+		} else {
+			return dsn
+		}
+	}
+
+	port := parsed.Port
+	if port == "" {
+		port = defaultPorts[parsed.Driver]
+	}
+
+	return strings.Join([]string{parsed.Driver, strings.ToLower(parsed.Hostname), port, parsed.DBName, parsed.Username}, "\x00")
+}
+
+// newCandidate builds the DSN of a connection found in a source. The
+// password is kept out of the DSN, stored apart when the connection is
+// imported.
+func newCandidate(connection models.Connection, source string) (Candidate, error) {
+	password := connection.Password
+	connection.Password = ""
+
+	connections := []models.Connection{connection}
+	if err := config.PrepareConnections(connections); err != nil {
+		return Candidate{}, fmt.Errorf("%s: %w", source, err)
+	}
+
+	connection = connections[0]
+	connection.Password = password
+	return Candidate{Connection: connection, Source: source}, nil
+}
+
+func envOr(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
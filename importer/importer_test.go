@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// summary is what the tests check of a candidate
+type summary struct {
+	Name, DSN, Password string
+}
+
+func summarize(candidates []Candidate) []summary {
+	summaries := []summary{}
+	for _, candidate := range candidates {
+		summaries = append(summaries, summary{candidate.Connection.Name, candidate.Connection.GetDSN(), candidate.Connection.Password})
+	}
+	return summaries
+}
+
+func TestReadPgpass(t *testing.T) {
+	path := writeFile(t, ".pgpass", `# comment
+db.internal:5432:billing:app:s3cr\:et
+*:*:*:analyst:pw
+*:*:*:*:ignored
+broken:line
+`)
+
+	candidates, err := ReadPgpass(path)
+	if err == nil {
+		t.Fatalf("expected an error for the broken line")
+	}
+
+	expected := []summary{
+		{"app@db.internal/billing", "postgres://app@db.internal:5432/billing", "s3cr:et"},
+		{"analyst@localhost", "postgres://analyst@localhost/", "pw"},
+	}
+	if got := summarize(candidates); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestReadPgService(t *testing.T) {
+	path := writeFile(t, "pg_service.conf", `[billing]
+host=db.internal
+port=6432
+dbname=billing
+user=app
+sslmode=verify-full
+application_name="sqlcmder"
+
+[local]
+dbname=scratch
+sslmode=prefer
+`)
+
+	candidates, err := ReadPgService(path)
+	if err != nil {
+		t.Fatalf("ReadPgService() error = %v", err)
+	}
+
+	expected := []summary{
+		{"billing", "postgres://app@db.internal:6432/billing?application_name=sqlcmder&sslmode=verify-full", ""},
+		{"local", "postgres://localhost/scratch?sslmode=prefer", ""},
+	}
+	if got := summarize(candidates); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestReadMyCnf(t *testing.T) {
+	path := writeFile(t, ".my.cnf", `[client]
+user = root
+password = "p@ss"
+
+[mysqld]
+port = 3307
+
+[client_prod]
+host = mysql.prod
+database = shop
+
+[mysqldump]
+quick
+
+[mysql_socket]
+socket = /tmp/mysql.sock
+`)
+
+	candidates, err := ReadMyCnf(path)
+	if err != nil {
+		t.Fatalf("ReadMyCnf() error = %v", err)
+	}
+
+	expected := []summary{
+		{"root@localhost", "mysql://root@localhost/", "p@ss"},
+		{"mysql_prod", "mysql://root@mysql.prod/shop", "p@ss"},
+	}
+	if got := summarize(candidates); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestFromEnvironment(t *testing.T) {
+	candidates, err := FromEnvironment([]string{
+		"HOME=/home/app",
+		"DATABASE_URL=postgresql://app:pw@db:5432/app",
+		"ANALYTICS_DB_URL=mysql://reader@warehouse/events",
+		"BROKEN_DATABASE_URL=postgres://db/app?sslmode=sometimes",
+		"EMPTY_DATABASE_URL=",
+	})
+	if err == nil {
+		t.Fatalf("expected an error for the invalid sslmode")
+	}
+
+	expected := []summary{
+		{"DATABASE_URL", "postgres://app@db:5432/app", "pw"},
+		{"ANALYTICS_DB_URL", "mysql://reader@warehouse/events", ""},
+	}
+	if got := summarize(candidates); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestReadExport(t *testing.T) {
+	tomlPath := writeFile(t, "config.toml", `[[database]]
+Name = 'billing'
+DsnCustom = 'postgres://app@db:5432/billing'
+group = 'payments'
+`)
+	jsonPath := writeFile(t, "connections.json", `[{"Name": "shop", "Driver": "mysql", "Hostname": "db", "Username": "root", "DBName": "shop", "ReadOnly": true}]`)
+	objectPath := writeFile(t, "export.json", `{"database": [{"Name": "local", "Driver": "sqlite3", "DBName": "/tmp/app.db"}]}`)
+
+	tests := []struct {
+		path     string
+		expected []summary
+	}{
+		{tomlPath, []summary{{"billing", "postgres://app@db:5432/billing", ""}}},
+		{jsonPath, []summary{{"shop", "mysql://root@db/shop", ""}}},
+		{objectPath, []summary{{"local", "/tmp/app.db", ""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			candidates, err := ReadExport(tt.path)
+			if err != nil {
+				t.Fatalf("ReadExport() error = %v", err)
+			}
+			if got := summarize(candidates); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+
+	candidates, _ := ReadExport(tomlPath)
+	if candidates[0].Connection.Group != "payments" {
+		t.Fatalf("expected the group to be kept, got %q", candidates[0].Connection.Group)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	existing := []models.Connection{
+		{Name: "billing", DSN: "postgres://app@db.internal/billing"},
+		{Name: "shop", DSN: "mysql://root@db/shop"},
+	}
+	candidates := []Candidate{
+		{Connection: models.Connection{Name: "app@db.internal/billing", DSN: "postgres://app@db.internal:5432/billing"}},
+		{Connection: models.Connection{Name: "shop", DSN: "mysql://root@db/other"}},
+		{Connection: models.Connection{Name: "again", DSN: "mysql://root@db:3306/other"}},
+	}
+
+	merged, added := Merge(existing, candidates)
+
+	names := []string{}
+	for _, connection := range merged {
+		names = append(names, connection.Name)
+	}
+	if expected := []string{"billing", "shop", "shop (2)"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	if len(added) != 1 || added[0].Name != "shop (2)" {
+		t.Fatalf("expected shop (2) to be added, got %+v", added)
+	}
+
+	if !Exists(existing, candidates[0]) || Exists(existing, candidates[1]) {
+		t.Fatalf("expected only the first candidate to exist")
+	}
+}
@@ -0,0 +1,53 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// iniSection is a [section] of an INI file, its keys lowercased with dashes
+// turned into underscores
+type iniSection struct {
+	name   string
+	values map[string]string
+}
+
+// readINI reads the sections of an INI file like pg_service.conf or my.cnf.
+// Comments start with # or ;, directives like !include are skipped and
+// quotes around values removed.
+func readINI(path string) ([]iniSection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections := []iniSection{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "" || text[0] == '#' || text[0] == ';' || text[0] == '!':
+			continue
+		case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+			sections = append(sections, iniSection{name: strings.TrimSpace(text[1 : len(text)-1]), values: map[string]string{}})
+			continue
+		case len(sections) == 0:
+			return nil, fmt.Errorf("%s:%d: %q is outside a section", path, line, text)
+		}
+
+		key, value, _ := strings.Cut(text, "=")
+		key = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		sections[len(sections)-1].values[key] = value
+	}
+
+	return sections, scanner.Err()
+}
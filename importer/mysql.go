@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// ReadMyCnf reads the connections of the client sections of a MySQL option
+// file: [client], [mysql] and the ones with a group suffix like
+// [client_prod], which take the values of [client] they do not set.
+// Sections without user are skipped, as are the ones using a socket.
+func ReadMyCnf(path string) ([]Candidate, error) {
+	sections, err := readINI(path)
+	if err != nil {
+		return nil, err
+	}
+
+	client := map[string]string{}
+	for _, section := range sections {
+		if section.name == "client" {
+			for key, value := range section.values {
+				client[key] = value
+			}
+		}
+	}
+
+	candidates := []Candidate{}
+	errs := []error{}
+
+	for _, section := range sections {
+		suffix, isClient := strings.CutPrefix(section.name, "client")
+		if !isClient {
+			suffix, isClient = strings.CutPrefix(section.name, "mysql")
+		}
+		if !isClient || (suffix != "" && !strings.HasPrefix(suffix, "_")) {
+			continue
+		}
+
+		values := map[string]string{}
+		for key, value := range client {
+			values[key] = value
+		}
+		for key, value := range section.values {
+			values[key] = value
+		}
+
+		if values["user"] == "" || values["socket"] != "" {
+			continue
+		}
+
+		host := values["host"]
+		if host == "" {
+			host = "localhost"
+		}
+
+		name := values["user"] + "@" + host
+		if suffix != "" {
+			name = "mysql" + suffix
+		}
+
+		candidate, err := newCandidate(models.Connection{
+			Name:     name,
+			Driver:   drivers.DriverMySQL,
+			Hostname: host,
+			Port:     values["port"],
+			Username: values["user"],
+			Password: values["password"],
+			DBName:   values["database"],
+		}, fmt.Sprintf("%s [%s]", path, section.name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, errors.Join(errs...)
+}
@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// ReadPgpass reads the connections of a PostgreSQL password file, whose lines
+// are hostname:port:database:username:password. A * hostname is localhost, a
+// * port the default one and a * database none, lines with a * username
+// being skipped as they name no user to connect as.
+func ReadPgpass(path string) ([]Candidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []Candidate{}
+	errs := []error{}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		source := fmt.Sprintf("%s:%d", path, i+1)
+		fields := splitPgpassLine(line)
+		if len(fields) != 5 {
+			errs = append(errs, fmt.Errorf("%s: expected hostname:port:database:username:password", source))
+			continue
+		}
+
+		host, port, database, user, password := fields[0], fields[1], fields[2], fields[3], fields[4]
+		if user == "*" {
+			continue
+		}
+		if host == "*" {
+			host = "localhost"
+		}
+		if port == "*" {
+			port = ""
+		}
+		if database == "*" {
+			database = ""
+		}
+
+		name := user + "@" + host
+		if database != "" {
+			name += "/" + database
+		}
+
+		candidate, err := newCandidate(models.Connection{
+			Name:     name,
+			Driver:   drivers.DriverPostgres,
+			Hostname: host,
+			Port:     port,
+			Username: user,
+			Password: password,
+			DBName:   database,
+		}, source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, errors.Join(errs...)
+}
+
+// splitPgpassLine splits a line of a password file on the colons that are
+// not escaped with a backslash, unescaping the fields
+func splitPgpassLine(line string) []string {
+	fields := []string{}
+	field := strings.Builder{}
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+
+	return append(fields, field.String())
+}
+
+// pgServiceOptions are the keys of a service file kept as options of the
+// connection
+var pgServiceOptions = []string{"sslmode", "sslrootcert", "sslcert", "sslkey", "connect_timeout", "application_name"}
+
+// ReadPgService reads the connections of a PostgreSQL connection service
+// file, one by service. Options with a value the driver does not accept are
+// left out.
+func ReadPgService(path string) ([]Candidate, error) {
+	sections, err := readINI(path)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []Candidate{}
+	errs := []error{}
+
+	for _, section := range sections {
+		values := section.values
+		host := values["host"]
+		if host == "" {
+			host = values["hostaddr"]
+		}
+		if host == "" {
+			host = "localhost"
+		}
+
+		connection := models.Connection{
+			Name:     section.name,
+			Driver:   drivers.DriverPostgres,
+			Hostname: host,
+			Port:     values["port"],
+			Username: values["user"],
+			Password: values["password"],
+			DBName:   values["dbname"],
+		}
+
+		for _, name := range pgServiceOptions {
+			value, ok := values[name]
+			if !ok || drivers.ValidateOptions(drivers.DriverPostgres, map[string]string{name: value}) != nil {
+				continue
+			}
+			if connection.Options == nil {
+				connection.Options = map[string]string{}
+			}
+			connection.Options[name] = value
+		}
+
+		candidate, err := newCandidate(connection, fmt.Sprintf("%s [%s]", path, section.name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, errors.Join(errs...)
+}
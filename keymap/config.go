@@ -42,6 +42,8 @@ const (
 	TabbedMenuGroup   = "tabbedmenu"
 	JSONViewerGroup   = "jsonviewer"
 	ConnectionFormGroup = "connectionform"
+	TableDiffGroup    = "tablediff"
+	ERDiagramGroup    = "erdiagram"
 )
 
 // Define a global KeymapSystem object with default keybinds
@@ -57,13 +59,24 @@ var Keymaps = KeymapSystem{
 			Bind{Key: Key{Char: '?'}, Cmd: cmd.HelpPopup, Description: "Help"},
 			Bind{Key: Key{Code: tcell.KeyCtrlBackslash}, Cmd: cmd.SearchGlobal, Description: "Global search"},
 			Bind{Key: Key{Code: tcell.KeyCtrlUnderscore}, Cmd: cmd.ToggleQueryHistory, Description: "Toggle query history modal"},
+			Bind{Key: Key{Code: tcell.KeyCtrlG}, Cmd: cmd.GlobalHistory, Description: "Search the history of every connection"},
+			Bind{Key: Key{Code: tcell.KeyCtrlO}, Cmd: cmd.CommandLog, Description: "Show the output of the connection commands"},
 		},
 		ConnectionGroup: {
 			Bind{Key: Key{Char: 'n'}, Cmd: cmd.NewConnection, Description: "Create a new database connection"},
 			Bind{Key: Key{Char: 'c'}, Cmd: cmd.Connect, Description: "Connect to database"},
-			Bind{Key: Key{Code: tcell.KeyEnter}, Cmd: cmd.Connect, Description: "Connect to database"},
+			Bind{Key: Key{Code: tcell.KeyEnter}, Cmd: cmd.Connect, Description: "Connect to database, or fold a group"},
 			Bind{Key: Key{Char: 'e'}, Cmd: cmd.EditConnection, Description: "Edit a database connection"},
 			Bind{Key: Key{Char: 'd'}, Cmd: cmd.DeleteConnection, Description: "Delete a database connection"},
+			Bind{Key: Key{Char: 'F'}, Cmd: cmd.ForgetCredentials, Description: "Forget the passwords asked for and lock the vault"},
+			Bind{Key: Key{Char: 'x'}, Cmd: cmd.Disconnect, Description: "Close the connection and stop its commands"},
+			Bind{Key: Key{Char: 'l'}, Cmd: cmd.CommandLog, Description: "Show the output of the connection commands"},
+			Bind{Key: Key{Char: 't'}, Cmd: cmd.TestConnection, Description: "Diagnose the connection step by step"},
+			Bind{Key: Key{Char: 'I'}, Cmd: cmd.ImportConnections, Description: "Import connections from pgpass, my.cnf, the environment or an export"},
+			Bind{Key: Key{Char: 'E'}, Cmd: cmd.ExportBundle, Description: "Export connections, global saved queries and settings to a bundle"},
+			Bind{Key: Key{Char: 'B'}, Cmd: cmd.ImportBundle, Description: "Import a bundle of connections, saved queries and settings"},
+			Bind{Key: Key{Char: '/'}, Cmd: cmd.Search, Description: "Filter connections by name, group, #tag or env:name"},
+			Bind{Key: Key{Code: tcell.KeyCtrlG}, Cmd: cmd.GlobalHistory, Description: "Search the history of every connection"},
 			Bind{Key: Key{Char: 'q'}, Cmd: cmd.Quit, Description: "Quit"},
 		},
 		TreeGroup: {
@@ -84,6 +97,7 @@ var Keymaps = KeymapSystem{
 			Bind{Key: Key{Char: 'c'}, Cmd: cmd.TreeCollapseAll, Description: "Collapse all"},
 			Bind{Key: Key{Char: 'e'}, Cmd: cmd.ExpandAll, Description: "Expand all"},
 			Bind{Key: Key{Char: 'R'}, Cmd: cmd.Refresh, Description: "Refresh tree"},
+			Bind{Key: Key{Char: 'E'}, Cmd: cmd.ShowERDiagram, Description: "Show ER diagram of the current database or table"},
 		},
 		TreeFilterGroup: {
 			Bind{Key: Key{Code: tcell.KeyEscape}, Cmd: cmd.UnfocusTreeFilter, Description: "Unfocus tree filter"},
@@ -123,11 +137,18 @@ var Keymaps = KeymapSystem{
 			Bind{Key: Key{Char: 's'}, Cmd: cmd.FocusSidebar, Description: "Focus sidebar"},
 			Bind{Key: Key{Char: 'Z'}, Cmd: cmd.ShowRowJSONViewer, Description: "Toggle JSON viewer for row"},
 			Bind{Key: Key{Char: 'z'}, Cmd: cmd.ShowCellJSONViewer, Description: "Toggle JSON viewer for cell"},
+			Bind{Key: Key{Char: 'D'}, Cmd: cmd.DiffTable, Description: "Compare table data with another table"},
+			// Foreign keys
+			Bind{Key: Key{Char: 'f'}, Cmd: cmd.FollowForeignKey, Description: "Open the row referenced by the foreign key cell"},
+			Bind{Key: Key{Char: 'F'}, Cmd: cmd.ListReferencingRows, Description: "List rows referencing the current row"},
+			Bind{Key: Key{Char: 'B'}, Cmd: cmd.NavigateBack, Description: "Go back along the foreign key path"},
 		},
 		EditorGroup: {
 			Bind{Key: Key{Code: tcell.KeyCtrlR}, Cmd: cmd.Execute, Description: "Execute query"},
 			Bind{Key: Key{Code: tcell.KeyEscape}, Cmd: cmd.UnfocusEditor, Description: "Unfocus editor"},
 			Bind{Key: Key{Code: tcell.KeyCtrlSpace}, Cmd: cmd.OpenInExternalEditor, Description: "Open in external editor"},
+			Bind{Key: Key{Code: tcell.KeyCtrlN}, Cmd: cmd.Complete, Description: "Complete keyword, function, table or column"},
+			Bind{Key: Key{Code: tcell.KeyCtrlT}, Cmd: cmd.FormatQuery, Description: "Format query"},
 		},
 		SidebarGroup: {
 			Bind{Key: Key{Char: 's'}, Cmd: cmd.UnfocusSidebar, Description: "Focus table"},
@@ -153,11 +174,27 @@ var Keymaps = KeymapSystem{
 			Bind{Key: Key{Char: 'd'}, Cmd: cmd.Delete, Description: "Delete query"},
 			Bind{Key: Key{Char: 'q'}, Cmd: cmd.Quit, Description: "Quit"},
 			Bind{Key: Key{Char: 'y'}, Cmd: cmd.Copy, Description: "Copy query to clipboard"},
+			Bind{Key: Key{Char: 'Y'}, Cmd: cmd.CopyFormatted, Description: "Copy formatted query to clipboard"},
+			Bind{Key: Key{Char: 'e'}, Cmd: cmd.Edit, Description: "Edit, rename or move saved query"},
+			Bind{Key: Key{Char: 'r'}, Cmd: cmd.Rerun, Description: "Re-run query in its database"},
+			Bind{Key: Key{Char: 'S'}, Cmd: cmd.SortDesc, Description: "Toggle sorting by duration"},
 			Bind{Key: Key{Char: '/'}, Cmd: cmd.Search, Description: "Search"},
+			Bind{Key: Key{Char: 'E'}, Cmd: cmd.OpenInExternalEditor, Description: "Open query file in external editor"},
 			Bind{Key: Key{Code: tcell.KeyCtrlUnderscore}, Cmd: cmd.ToggleQueryHistory, Description: "Toggle query history modal"},
 			Bind{Key: Key{Char: '['}, Cmd: cmd.TabPrev, Description: "Switch to previous tab"},
 			Bind{Key: Key{Char: ']'}, Cmd: cmd.TabNext, Description: "Switch to next tab"},
 		},
+		TableDiffGroup: {
+			Bind{Key: Key{Code: tcell.KeyCtrlS}, Cmd: cmd.Save, Description: "Apply sync queries to the target table"},
+			Bind{Key: Key{Char: 'y'}, Cmd: cmd.Copy, Description: "Copy sync queries to clipboard"},
+			Bind{Key: Key{Char: 'q'}, Cmd: cmd.Quit, Description: "Quit"},
+		},
+		ERDiagramGroup: {
+			Bind{Key: Key{Char: '/'}, Cmd: cmd.Search, Description: "Filter to a table and its neighbours"},
+			Bind{Key: Key{Char: 'm'}, Cmd: cmd.ExportMermaid, Description: "Export to a Mermaid file"},
+			Bind{Key: Key{Char: 'd'}, Cmd: cmd.ExportDOT, Description: "Export to a Graphviz DOT file"},
+			Bind{Key: Key{Char: 'q'}, Cmd: cmd.Quit, Description: "Quit"},
+		},
 		JSONViewerGroup: {
 			Bind{Key: Key{Char: 'Z'}, Cmd: cmd.ShowRowJSONViewer, Description: "Toggle JSON viewer"},
 			Bind{Key: Key{Char: 'z'}, Cmd: cmd.ShowCellJSONViewer, Description: "Toggle JSON viewer"},
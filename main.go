@@ -1,18 +1,22 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-sql-driver/mysql"
+	"golang.org/x/term"
 
 	"sqlcmder/cmd/app"
 	"sqlcmder/config"
 	"sqlcmder/db"
+	"sqlcmder/headless"
 	"sqlcmder/logger"
 	"sqlcmder/ui"
 )
@@ -32,10 +36,15 @@ func main() {
 		f := flag.CommandLine.Output()
 		fmt.Fprintln(f, "sqlcmder")
 		fmt.Fprintln(f, "")
-		fmt.Fprintf(f, "Usage:  %s [options] [connection_url]\n\n", os.Args[0])
+		fmt.Fprintf(f, "Usage:  %s [options] [connection_url]\n", os.Args[0])
+		fmt.Fprintf(f, "        %s -c <connection|url> [-e sql | -f script.sql] [-format table|csv|json|tsv] [-yes]\n\n", os.Args[0])
 		fmt.Fprintln(f, "  connection_url")
 		fmt.Fprintln(f, "        database URL to connect to. Omit to start in picker mode")
 		fmt.Fprintln(f, "")
+		fmt.Fprintln(f, "  With -c, the SQL of -e, -f or stdin is run without the UI and the results")
+		fmt.Fprintln(f, "  written to stdout. The exit code is 1 when a statement fails, 2 on wrong")
+		fmt.Fprintln(f, "  options and 3 when connecting fails.")
+		fmt.Fprintln(f, "")
 		fmt.Fprintln(f, "Options:")
 		flag.PrintDefaults()
 	}
@@ -48,6 +57,11 @@ func main() {
 	printVersion := flag.Bool("version", false, "Show version")
 	logLevel := flag.String("loglevel", "debug", "Log level")
 	logFile := flag.String("logfile", defaultLogFile, "Log file")
+	connectionName := flag.String("c", "", "name of a configured connection or URL to run SQL on without the UI")
+	execute := flag.String("e", "", "SQL to run on the connection of -c")
+	scriptFile := flag.String("f", "", "file of SQL to run on the connection of -c, - for stdin")
+	format := flag.String("format", headless.FormatTable, "format of the results of -c: "+strings.Join(headless.Formats, ", "))
+	yes := flag.Bool("yes", false, "run the statements of -c the UI asks to confirm")
 	flag.Parse()
 
 	if *printVersion {
@@ -77,6 +91,22 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Run the SQL and exit without starting the UI
+	if *connectionName != "" || *execute != "" || *scriptFile != "" {
+		os.Exit(headless.Run(headless.Options{
+			Connection: *connectionName,
+			Execute:    *execute,
+			File:       *scriptFile,
+			Format:     *format,
+			Guard:      app.App.Config().Guard,
+			Yes:        *yes,
+			Stdin:      os.Stdin,
+			Stdout:     os.Stdout,
+			Stderr:     os.Stderr,
+			Prompt:     promptPassword,
+		}, app.App.Connections()))
+	}
+
 	// Apply theme from configuration
 	app.App.ApplyTheme()
 
@@ -91,10 +121,11 @@ func main() {
 		// Launch into the connection picker.
 	case 1:
 		// Set a connection from the command line.
-		connection, driver, err := db.InitFromArg(args[0])
+		connection, driver, closeConnection, err := db.InitFromArg(args[0], promptPassword)
 		if err != nil {
 			log.Fatal(err)
 		}
+		defer closeConnection()
 		// Initialize the home page with the connection
 		mainPages.AddAndSwitchToPage(connection.GetDSN(), ui.NewHomePage(*connection, driver).Flex, true)
 	default:
@@ -105,3 +136,16 @@ func main() {
 		log.Fatalf("Error running app: %v", err)
 	}
 }
+
+// promptPassword asks for a password on the terminal before the UI starts
+func promptPassword(label string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("cannot ask for the password, stdin is not a terminal")
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", label)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+
+	return string(password), err
+}
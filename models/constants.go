@@ -31,10 +31,74 @@ const (
 	// SetValueList page
 	PageNameSetValue = "SetValue"
 
+	// Editor completion page
+	PageNameCompletion = "Completion"
+
 	// Query History pages
 	PageNameQueryHistory     = "QueryHistoryModal"
 	PageNameSaveQuery        = "SaveQueryModal"
 	PageNameSavedQueryDelete = "SavedQueryDeleteModal"
+	PageNameGlobalHistory    = "GlobalHistoryModal"
+
+	// Table diff page
+	PageNameTableDiff = "TableDiffModal"
+
+	// Foreign key navigation pages
+	PageNameReferencingRows = "ReferencingRowsModal"
+
+	// ER diagram page
+	PageNameERDiagram = "ERDiagramModal"
+
+	// Query parameters page
+	PageNameQueryParameters = "QueryParametersModal"
+
+	// Password page
+	PageNamePassword = "PasswordModal"
+
+	// Command log page
+	PageNameCommandLog = "CommandLogModal"
+
+	// Connection diagnostics page
+	PageNameDiagnostics = "DiagnosticsModal"
+
+	// Typed confirmation page
+	PageNameTypedConfirmation = "TypedConfirmationModal"
+
+	// Connection import page
+	PageNameImportConnections = "ImportConnectionsModal"
+
+	// Bundle file page
+	PageNameBundle = "BundleModal"
+)
+
+// Sources of a query history item
+const (
+	QuerySourceEditor  = "editor"
+	QuerySourceFilter  = "filter"
+	QuerySourceCommand = "command"
+)
+
+// Sources of a connection password
+const (
+	PasswordSourcePrompt  = "prompt"
+	PasswordSourceEnv     = "env"
+	PasswordSourceCommand = "command"
+	PasswordSourceVault   = "vault"
+)
+
+// TLS modes of a connection
+const (
+	TLSModeDisable    = "disable"
+	TLSModeRequire    = "require"     // Encrypts without checking the server certificate
+	TLSModeVerifyCA   = "verify-ca"   // Checks the server certificate is signed by the CA
+	TLSModeVerifyFull = "verify-full" // Also checks the name in the server certificate
+)
+
+// Environments of a connection
+const (
+	EnvironmentDev     = "dev"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
 )
 
 // Tab names
@@ -57,10 +121,12 @@ const (
 	EventSQLEditorEscape = "Escape"
 
 	EventResultsTableFiltering = "FilteringResultsTable"
+	EventResultsTableNavigate  = "NavigateResultsTable"
 
 	EventTreeSelectedDatabase = "SelectedDatabase"
 	EventTreeSelectedTable    = "SelectedTable"
 	EventTreeIsFiltering      = "IsFiltering"
+	EventTreeRefreshed        = "RefreshedTree"
 )
 
 // Results table menu items
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/rivo/tview"
@@ -11,7 +12,29 @@ type AppConfig struct {
 	DisableSidebar               bool
 	SidebarOverlay               bool
 	MaxQueryHistoryPerConnection int
-	Theme                        string `toml:"theme"` // Color theme: dark, light, solarized, gruvbox, nord
+	Theme                        string          `toml:"theme"` // Color theme: dark, light, solarized, gruvbox, nord
+	Formatter                    FormatterConfig `toml:"formatter"`
+	SavedQueriesDir              string          `toml:"saved_queries_dir,omitempty"` // Directory of .sql files shared by every connection
+	Guard                        GuardConfig     `toml:"guard"`
+}
+
+// GuardConfig holds the rules statements of the editor are checked with
+// before they are sent, each warning asking for a confirmation
+type GuardConfig struct {
+	UnfilteredChanges  bool  `toml:"unfiltered_changes"`   // UPDATE and DELETE without WHERE
+	Destructive        bool  `toml:"destructive"`          // DROP and TRUNCATE
+	AlterRowThreshold  int64 `toml:"alter_row_threshold"`  // ALTER TABLE of tables with more rows, 0 turns it off
+	SelectRowThreshold int64 `toml:"select_row_threshold"` // SELECT without LIMIT from tables with more rows, 0 turns it off
+	SelectLimit        int   `toml:"select_limit"`         // LIMIT offered for such a SELECT, 0 offers none
+	AutoLimit          bool  `toml:"auto_limit"`           // Appends select_limit without asking
+}
+
+// FormatterConfig holds the options of the SQL formatter
+type FormatterConfig struct {
+	KeywordCase string // upper, lower or preserve
+	IndentWidth int
+	CommaStyle  string // trailing or leading
+	LineWidth   int    // a negative width puts every item of a list on its own line
 }
 
 type Connection struct {
@@ -24,21 +47,70 @@ type Connection struct {
 	DsnValue  string // Final DSN value to use (custom or auto)
 
 	// or parse manually
-	Driver    string // Database driver (mysql, postgres, sqlite, mssql)
-	Username  string
-	Password  string
-	Hostname  string
-	Port      string
-	DBName    string
-	DSNParams string // DSN parameters/query string
+	Driver   string // Database driver (mysql, postgres, sqlite, mssql)
+	Username string
+	Password string
+	Hostname string
+	Port     string
+	DBName   string
+	// Options of the driver added to the DSN, like connect_timeout or
+	// busy_timeout, checked against the options schema of the driver. Other
+	// names are passed through as DSN parameters.
+	Options map[string]string `toml:"options,omitempty"`
+	// Deprecated: DSN parameters of older configs, moved to Options on load
+	DSNParams string `toml:"DSNParams,omitempty"`
+
+	// Where the password is read from at connect time, it is never saved
+	PasswordSource  string `toml:"password_source,omitempty"`  // prompt, env, command or vault
+	PasswordEnv     string `toml:"password_env,omitempty"`     // Environment variable holding the password
+	PasswordCommand string `toml:"password_command,omitempty"` // Command printing the password, like `pass show db/prod`
+
+	SavedQueriesDir string `toml:"saved_queries_dir,omitempty"` // Directory of .sql files holding the connection's saved queries
+
+	// Where the connection is listed, and what it is deployed as
+	Group       string   `toml:"group,omitempty"`       // Folder of the connection in the connections list
+	Tags        []string `toml:"tags,omitempty"`        // Tags the connections list is filtered with
+	Environment string   `toml:"environment,omitempty"` // dev, staging or prod
+	Color       string   `toml:"color,omitempty"`       // Color of the environment, like red or #ff8800
+
+	// Sessions are opened read-only and the UI refuses changes
+	ReadOnly bool `toml:"read_only,omitempty"`
+
+	SSH *SSHTunnel   `toml:"ssh,omitempty"` // SSH tunnel the connection goes through
+	TLS *TLSSettings `toml:"tls,omitempty"` // TLS settings, added to the DSN at connect time
 
 	Commands []*Command
 }
 
+// SSHTunnel holds the settings of an SSH tunnel to the database host
+type SSHTunnel struct {
+	Host    string
+	Port    string // 22 when empty
+	User    string
+	KeyFile string // Private key file, the SSH agent is used when empty
+
+	KnownHostsFile        string // ~/.ssh/known_hosts when empty
+	InsecureIgnoreHostKey bool   // Skips the host key check, for tests only
+
+	JumpHost string // [user@]host[:port] of a bastion the tunnel goes through
+}
+
+// TLSSettings holds the TLS settings of a connection to a MySQL, Postgres or
+// SQL Server database
+type TLSSettings struct {
+	Mode       string // disable, require, verify-ca or verify-full
+	CAFile     string // CA bundle the server certificate is checked with, the system one when empty
+	CertFile   string // Client certificate
+	KeyFile    string // Key of the client certificate
+	ServerName string // Name checked in the server certificate, the DSN host when empty
+}
+
 type Command struct {
 	Command      string
 	WaitForPort  string
 	SaveOutputTo string
+	Teardown     string // Command run once the command is stopped, when its connection closes
+	Restart      bool   // Starts the command again when it dies while its connection is open
 }
 
 type StateChange struct {
@@ -96,6 +168,18 @@ func (c *Connection) GetDSN() string {
 	return c.DSN // Fallback to legacy DSN field
 }
 
+// AsksForPassword reports whether the password of the connection is asked
+// for at connect time rather than stored anywhere
+func (c *Connection) AsksForPassword() bool {
+	return c.PasswordSource == PasswordSourcePrompt
+}
+
+// IsProduction reports whether the connection is to a production database,
+// where changes are confirmed by typing the connection name
+func (c *Connection) IsProduction() bool {
+	return strings.EqualFold(c.Environment, EnvironmentProd) || strings.EqualFold(c.Environment, "production")
+}
+
 // SetDSNValue updates the DsnValue field based on current DSN fields
 func (c *Connection) SetDSNValue() {
 	c.DsnValue = c.GetDSN()
@@ -131,12 +215,50 @@ type SidebarEditingCommitParams struct {
 
 // QueryHistoryItem represents a single entry in the query history.
 type QueryHistoryItem struct {
-	QueryText string
-	Timestamp time.Time
+	QueryText  string
+	Timestamp  time.Time
+	Connection string        `json:",omitempty"`
+	Database   string        `json:",omitempty"` // Database the query ran in
+	Duration   time.Duration `json:",omitempty"`
+	Rows       int64         `json:",omitempty"` // Rows returned or affected
+	Error      string        `json:",omitempty"` // Empty when the query succeeded
+	Source     string        `json:",omitempty"` // editor, filter or command
 }
 
 // SavedQuery represents a query that the user has saved for later use.
 type SavedQuery struct {
-	Name  string `toml:"name"`
-	Query string `toml:"query"`
+	Name        string    `toml:"name"`
+	Folder      string    `toml:"folder,omitempty"` // Slash separated path like reports/monthly
+	Description string    `toml:"description,omitempty"`
+	Tags        []string  `toml:"tags,omitempty"`
+	Query       string    `toml:"query"`
+	CreatedAt   time.Time `toml:"created_at"`
+	UpdatedAt   time.Time `toml:"updated_at"`
+	Connection  string    `toml:"connection,omitempty"` // Connection a query of a directory is meant for
+	Scope       string    `toml:"-"`                    // Connection, driver, global or directory, set when the query is read
+	File        string    `toml:"-"`                    // .sql file of a query of a directory
+}
+
+// Path returns the folder and name of the query
+func (q SavedQuery) Path() string {
+	if q.Folder == "" {
+		return q.Name
+	}
+	return q.Folder + "/" + q.Name
+}
+
+// ForeignKey is a foreign key constraint, normalized across drivers.
+type ForeignKey struct {
+	Name              string
+	Table             string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+// TableNavigation asks to open a table filtered to the rows matching Filter.
+type TableNavigation struct {
+	Database string
+	Table    string
+	Filter   string
 }
@@ -0,0 +1,417 @@
+// Package process runs and tracks the commands started for connections, like
+// tunnels or proxies, for as long as their connection is open.
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sqlcmder/logger"
+)
+
+// Streams of a line of output
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+	// StreamEvent lines tell when a process started, exited or restarted
+	StreamEvent = "sqlcmder"
+)
+
+const (
+	logLimit        = 1000
+	startTimeout    = 5 * time.Second
+	teardownTimeout = 30 * time.Second
+
+	// A process restarted right away is restarted after a delay doubling up to
+	// maxRestartDelay, until it runs for stableRunTime
+	minRestartDelay = time.Second
+	maxRestartDelay = 30 * time.Second
+	stableRunTime   = 30 * time.Second
+)
+
+// ErrStopped is returned when a command is started for a connection being
+// closed.
+var ErrStopped = errors.New("connection processes are stopped")
+
+// Line is a line of output of a process.
+type Line struct {
+	Time    time.Time
+	Command string
+	Stream  string
+	Text    string
+}
+
+// Group holds the processes started for a connection and their output.
+type Group struct {
+	Name string
+
+	mu        sync.Mutex
+	processes []*process
+	lines     []Line
+	onOutput  func()
+	stopped   bool
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+var (
+	groupsMu sync.Mutex
+	groups   = map[string]*Group{}
+)
+
+// Get returns the process group of a connection, creating it if needed.
+func Get(name string) *Group {
+	groupsMu.Lock()
+	defer groupsMu.Unlock()
+
+	group, ok := groups[name]
+	if !ok {
+		group = &Group{Name: name, done: make(chan struct{})}
+		groups[name] = group
+	}
+	return group
+}
+
+// Lookup returns the process group of a connection, if it has one.
+func Lookup(name string) (*Group, bool) {
+	groupsMu.Lock()
+	defer groupsMu.Unlock()
+
+	group, ok := groups[name]
+	return group, ok
+}
+
+// StopAll stops the processes of every connection and runs their teardown
+// commands.
+func StopAll() {
+	groupsMu.Lock()
+	all := make([]*Group, 0, len(groups))
+	for _, group := range groups {
+		all = append(all, group)
+	}
+	groupsMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, group := range all {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			group.Stop()
+		}()
+	}
+	wg.Wait()
+}
+
+// Start runs a command for the connection and tracks it until the
+// connection is closed. It returns once the command has printed a line, has
+// exited or has run for a few seconds. onExit is called with the standard
+// output of the command when its first run exits. A command that dies is
+// started again when restart is set, and teardown is run once it is stopped.
+func (g *Group) Start(command string, teardown string, restart bool, onExit func(stdout string)) error {
+	if len(strings.Fields(command)) == 0 {
+		return errors.New("empty command")
+	}
+
+	g.mu.Lock()
+	if g.stopped {
+		g.mu.Unlock()
+		return ErrStopped
+	}
+	p := &process{group: g, command: command, teardown: teardown, restart: restart}
+	g.processes = append(g.processes, p)
+	g.mu.Unlock()
+
+	started, err := p.start(onExit)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-started:
+	case <-time.After(startTimeout):
+	}
+
+	return nil
+}
+
+// Stop kills the processes of the connection, then runs their teardown
+// commands. The group is forgotten, so connecting again starts a new one.
+func (g *Group) Stop() {
+	g.mu.Lock()
+	if g.stopped {
+		g.mu.Unlock()
+		return
+	}
+	g.stopped = true
+	close(g.done)
+	processes := slices.Clone(g.processes)
+	g.mu.Unlock()
+
+	for _, p := range processes {
+		p.kill()
+	}
+	g.wg.Wait()
+
+	for i := len(processes) - 1; i >= 0; i-- {
+		if processes[i].teardown != "" && processes[i].hasRun() {
+			g.runTeardown(processes[i].teardown)
+		}
+	}
+
+	groupsMu.Lock()
+	if groups[g.Name] == g {
+		delete(groups, g.Name)
+	}
+	groupsMu.Unlock()
+}
+
+// Lines returns the output of the processes, oldest first.
+func (g *Group) Lines() []Line {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.lines)
+}
+
+// SetOnOutput sets the function called when a line of output is added.
+func (g *Group) SetOnOutput(onOutput func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.onOutput = onOutput
+}
+
+func (g *Group) log(command string, stream string, text string) {
+	g.mu.Lock()
+	g.lines = append(g.lines, Line{Time: time.Now(), Command: command, Stream: stream, Text: text})
+	if len(g.lines) > logLimit {
+		g.lines = slices.Delete(g.lines, 0, len(g.lines)-logLimit)
+	}
+	onOutput := g.onOutput
+	g.mu.Unlock()
+
+	if stream == StreamEvent {
+		logger.Info("Command "+text, map[string]any{"connection": g.Name, "command": command})
+	} else {
+		logger.Debug("Command output", map[string]any{"connection": g.Name, "command": command, "line": text})
+	}
+
+	if onOutput != nil {
+		onOutput()
+	}
+}
+
+func (g *Group) isStopped() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.stopped
+}
+
+// runTeardown runs a teardown command to its end, logging its output
+func (g *Group) runTeardown(command string) {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), teardownTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...) // #nosec G204
+	stdout := &lineWriter{group: g, command: command, stream: StreamStdout}
+	stderr := &lineWriter{group: g, command: command, stream: StreamStderr}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	g.log(command, StreamEvent, "teardown started")
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
+
+	if err != nil {
+		g.log(command, StreamEvent, "teardown failed: "+err.Error())
+		return
+	}
+	g.log(command, StreamEvent, "teardown done")
+}
+
+// process is a command tracked by a group
+type process struct {
+	group    *Group
+	command  string
+	teardown string
+	restart  bool
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stopping bool
+	ran      bool
+	restarts int
+}
+
+// start runs the command, returning a channel closed when it prints its
+// first line or exits
+func (p *process) start(onExit func(stdout string)) (<-chan struct{}, error) {
+	parts := strings.Fields(p.command)
+	cmd := exec.Command(parts[0], parts[1:]...) // #nosec G204
+
+	started := make(chan struct{})
+	var once sync.Once
+	markStarted := func() { once.Do(func() { close(started) }) }
+
+	var output bytes.Buffer
+	stdout := &lineWriter{group: p.group, command: p.command, stream: StreamStdout, onLine: markStarted, output: &output}
+	stderr := &lineWriter{group: p.group, command: p.command, stream: StreamStderr, onLine: markStarted}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return nil, ErrStopped
+	}
+	if err := cmd.Start(); err != nil {
+		p.mu.Unlock()
+		p.group.log(p.command, StreamEvent, "failed to start: "+err.Error())
+		return nil, err
+	}
+	p.cmd = cmd
+	p.ran = true
+	p.mu.Unlock()
+
+	p.group.log(p.command, StreamEvent, fmt.Sprintf("started with pid %d", cmd.Process.Pid))
+	startedAt := time.Now()
+
+	p.group.wg.Add(1)
+	go func() {
+		defer p.group.wg.Done()
+
+		err := cmd.Wait()
+		stdout.flush()
+		stderr.flush()
+		markStarted()
+
+		if err != nil {
+			p.group.log(p.command, StreamEvent, "exited: "+err.Error())
+		} else {
+			p.group.log(p.command, StreamEvent, "exited")
+		}
+
+		if onExit != nil {
+			onExit(output.String())
+		}
+
+		p.restartAfter(time.Since(startedAt))
+	}()
+
+	return started, nil
+}
+
+// restartAfter starts the command again after it exited, unless its
+// connection is being closed
+func (p *process) restartAfter(runTime time.Duration) {
+	p.mu.Lock()
+	if !p.restart || p.stopping {
+		p.mu.Unlock()
+		return
+	}
+	if runTime >= stableRunTime {
+		p.restarts = 0
+	}
+	delay := min(minRestartDelay<<p.restarts, maxRestartDelay)
+	p.restarts++
+	p.mu.Unlock()
+
+	if p.group.isStopped() {
+		return
+	}
+
+	p.group.log(p.command, StreamEvent, fmt.Sprintf("restarting in %s", delay))
+
+	select {
+	case <-time.After(delay):
+	case <-p.group.done:
+		return
+	}
+
+	if _, err := p.start(nil); err != nil && !errors.Is(err, ErrStopped) {
+		logger.Error("Failed to restart command", map[string]any{"connection": p.group.Name, "command": p.command, "error": err.Error()})
+	}
+}
+
+func (p *process) hasRun() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.ran
+}
+
+func (p *process) kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopping = true
+	if p.cmd != nil && p.cmd.Process != nil {
+		// The process may have exited already
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+// lineWriter adds what a process writes to the log of its group, line by line
+type lineWriter struct {
+	group   *Group
+	command string
+	stream  string
+	onLine  func()
+	output  *bytes.Buffer // Keeps everything written, when set
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.output != nil {
+		w.output.Write(data)
+	}
+
+	w.partial = append(w.partial, data...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(bytes.TrimRight(w.partial[:i], "\r")))
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// flush logs the last line, if it does not end with a newline
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) emit(line string) {
+	w.group.log(w.command, w.stream, line)
+	if w.onLine != nil {
+		w.onLine()
+	}
+}
@@ -0,0 +1,109 @@
+package process
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForLine waits until a line of output holds text
+func waitForLine(t *testing.T, group *Group, text string, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		found := 0
+		for _, line := range group.Lines() {
+			if strings.Contains(line.Text, text) {
+				found++
+			}
+		}
+		if found >= count {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatalf("no %d lines holding %q in %+v", count, text, group.Lines())
+}
+
+func TestStartCapturesOutput(t *testing.T) {
+	group := Get("capture")
+	t.Cleanup(group.Stop)
+
+	exited := make(chan string, 1)
+	if err := group.Start("echo hello", "", false, func(stdout string) { exited <- stdout }); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	select {
+	case stdout := <-exited:
+		if stdout != "hello\n" {
+			t.Fatalf("stdout = %q, want hello", stdout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("command did not exit")
+	}
+
+	waitForLine(t, group, "exited", 1)
+
+	lines := group.Lines()
+	if lines[1].Text != "hello" || lines[1].Stream != StreamStdout {
+		t.Fatalf("output line = %+v, want hello on stdout", lines[1])
+	}
+}
+
+func TestStartFailsForMissingCommand(t *testing.T) {
+	group := Get("missing")
+	t.Cleanup(group.Stop)
+
+	if err := group.Start("sqlcmder-no-such-command", "", false, nil); err == nil {
+		t.Fatalf("Start() succeeded for a missing command")
+	}
+}
+
+func TestRestart(t *testing.T) {
+	group := Get("restart")
+
+	if err := group.Start("echo tick", "", true, nil); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	waitForLine(t, group, "tick", 2)
+	group.Stop()
+
+	count := len(group.Lines())
+	time.Sleep(3 * minRestartDelay)
+	if len(group.Lines()) != count {
+		t.Fatalf("command restarted after Stop(): %+v", group.Lines()[count:])
+	}
+}
+
+func TestStopKillsAndTearsDown(t *testing.T) {
+	group := Get("teardown")
+
+	if err := group.Start("sleep 60", "echo torn down", true, nil); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		StopAll()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("StopAll() did not kill the command")
+	}
+
+	waitForLine(t, group, "torn down", 1)
+
+	if _, ok := Lookup("teardown"); ok {
+		t.Fatalf("stopped group is still tracked")
+	}
+	if err := group.Start("echo late", "", false, nil); err != ErrStopped {
+		t.Fatalf("Start() on a stopped group error = %v, want %v", err, ErrStopped)
+	}
+}
@@ -0,0 +1,358 @@
+package sqlparse
+
+import (
+	"slices"
+	"strings"
+
+	"sqlcmder/drivers"
+)
+
+type CompletionKind int
+
+const (
+	CompletionColumn CompletionKind = iota
+	CompletionTable
+	CompletionFunction
+	CompletionKeyword
+)
+
+func (kind CompletionKind) String() string {
+	switch kind {
+	case CompletionColumn:
+		return "column"
+	case CompletionTable:
+		return "table"
+	case CompletionFunction:
+		return "function"
+	case CompletionKeyword:
+		return "keyword"
+	}
+
+	return "unknown"
+}
+
+type Completion struct {
+	Text string
+	Kind CompletionKind
+}
+
+// Catalog provides the tables and columns completions are built from
+type Catalog interface {
+	Tables(database string) []string
+	Columns(database, table string) []string
+}
+
+// Completer suggests keywords, functions, tables and columns for the word
+// under the cursor of a SQL text
+type Completer struct {
+	Dialect  string
+	Catalog  Catalog
+	Database string
+}
+
+// TableReference is a table named in a FROM, JOIN, UPDATE or INTO clause
+type TableReference struct {
+	Name  string
+	Alias string
+}
+
+// tableKeywords are followed by a table name
+var tableKeywords = []string{"FROM", "JOIN", "UPDATE", "INTO", "TABLE"}
+
+// clauseKeywords end the list of tables of a FROM clause
+var clauseKeywords = []string{"WHERE", "GROUP", "ORDER", "HAVING", "LIMIT", "ON", "USING", "SET", "VALUES", "UNION", "EXCEPT", "INTERSECT", "SELECT", "RETURNING", "WINDOW", "OFFSET"}
+
+func isAny(token Token, words []string) bool {
+	for _, word := range words {
+		if token.Is(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// insideLiteral reports whether the cursor is within a string or a comment
+func insideLiteral(token Token, cursor int) bool {
+	if token.Type != TokenString && token.Type != TokenComment {
+		return false
+	}
+
+	if cursor <= token.Start || cursor > token.End {
+		return false
+	}
+
+	if cursor < token.End {
+		return true
+	}
+
+	switch {
+	case strings.HasPrefix(token.Text, "--"):
+		return true
+	case strings.HasPrefix(token.Text, "/*"):
+		return len(token.Text) < 4 || !strings.HasSuffix(token.Text, "*/")
+	case strings.HasPrefix(token.Text, "$"):
+		tag := token.Text[:strings.IndexByte(token.Text[1:], '$')+2]
+		return len(token.Text) < len(tag)*2 || !strings.HasSuffix(token.Text, tag)
+	default:
+		// Skip the prefix of strings like E'...'
+		opening := strings.IndexAny(token.Text, `'"`)
+		return len(token.Text) < opening+2 || token.Text[len(token.Text)-1] != token.Text[opening]
+	}
+}
+
+// statementAt returns the significant tokens of the statement containing the cursor
+func statementAt(tokens []Token, cursor int) []Token {
+	statement := []Token{}
+
+	for _, token := range tokens {
+		if token.Type == TokenPunctuation && token.Text == ";" {
+			if token.End <= cursor {
+				statement = statement[:0]
+				continue
+			}
+			break
+		}
+
+		if token.IsSignificant() {
+			statement = append(statement, token)
+		}
+	}
+
+	return statement
+}
+
+// readName reads a possibly qualified name like schema.table starting at
+// index i, returning it and the index of its last token
+func readName(tokens []Token, i int) (string, int) {
+	parts := []string{Unquote(tokens[i].Text)}
+
+	for i+2 < len(tokens) && tokens[i+1].Text == "." && (tokens[i+2].Type == TokenIdentifier || tokens[i+2].Type == TokenQuotedIdentifier) {
+		parts = append(parts, Unquote(tokens[i+2].Text))
+		i += 2
+	}
+
+	return strings.Join(parts, "."), i
+}
+
+// TableReferences returns the tables of a statement and their aliases
+func TableReferences(dialect string, tokens []Token) []TableReference {
+	references := []TableReference{}
+	expectTable, inFrom := false, false
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		switch {
+		case isAny(token, tableKeywords):
+			expectTable = true
+			inFrom = token.Is("FROM") || token.Is("JOIN")
+			continue
+		case token.Text == "," && inFrom:
+			expectTable = true
+			continue
+		case isAny(token, clauseKeywords):
+			inFrom = false
+		}
+
+		if !expectTable {
+			continue
+		}
+		expectTable = false
+
+		if token.Type != TokenIdentifier && token.Type != TokenQuotedIdentifier {
+			continue
+		}
+
+		reference := TableReference{}
+		reference.Name, i = readName(tokens, i)
+
+		if i+1 < len(tokens) && tokens[i+1].Is("AS") {
+			i++
+		}
+
+		if i+1 < len(tokens) {
+			next := tokens[i+1]
+			if next.Type == TokenQuotedIdentifier || (next.Type == TokenIdentifier && !IsKeyword(dialect, next.Text)) {
+				reference.Alias = Unquote(next.Text)
+				i++
+			}
+		}
+
+		references = append(references, reference)
+	}
+
+	return references
+}
+
+// expectsTable reports whether the tokens end where a table name is expected
+func expectsTable(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+
+	last := tokens[len(tokens)-1]
+	if isAny(last, tableKeywords) {
+		return true
+	}
+
+	if last.Text != "," {
+		return false
+	}
+
+	// A comma continues the list of tables only inside a FROM clause
+	for i := len(tokens) - 2; i >= 0; i-- {
+		if tokens[i].Is("FROM") {
+			return true
+		}
+		if isAny(tokens[i], clauseKeywords) {
+			return false
+		}
+	}
+
+	return false
+}
+
+func (completer Completer) tables() []string {
+	if completer.Catalog == nil {
+		return nil
+	}
+	return completer.Catalog.Tables(completer.Database)
+}
+
+func (completer Completer) columns(table string) []string {
+	if completer.Catalog == nil {
+		return nil
+	}
+	return completer.Catalog.Columns(completer.Database, table)
+}
+
+// tableText returns the name to insert for a table, leaving out the
+// default schema of Postgres
+func (completer Completer) tableText(table string) string {
+	if completer.Dialect == drivers.DriverPostgres {
+		return strings.TrimPrefix(table, "public.")
+	}
+	return table
+}
+
+// resolve returns the table a qualifier refers to, looking at aliases first
+func resolve(references []TableReference, qualifier string) string {
+	for _, reference := range references {
+		if strings.EqualFold(reference.Alias, qualifier) {
+			return reference.Name
+		}
+	}
+
+	for _, reference := range references {
+		name := reference.Name
+		if index := strings.LastIndex(name, "."); index != -1 {
+			name = name[index+1:]
+		}
+
+		if strings.EqualFold(reference.Name, qualifier) || strings.EqualFold(name, qualifier) {
+			return reference.Name
+		}
+	}
+
+	return qualifier
+}
+
+// Complete returns the completions for the word ending at the cursor and
+// the offset where that word starts
+func (completer Completer) Complete(sql string, cursor int) (int, []Completion) {
+	cursor = min(max(cursor, 0), len(sql))
+
+	tokens := TokenizeDialect(completer.Dialect, sql)
+
+	start, prefix := cursor, ""
+	for _, token := range tokens {
+		if insideLiteral(token, cursor) {
+			return cursor, nil
+		}
+
+		if token.End == cursor && token.IsWord() {
+			start, prefix = token.Start, token.Text
+		}
+	}
+
+	statement := statementAt(tokens, cursor)
+
+	before := []Token{}
+	for _, token := range statement {
+		if token.End <= start {
+			before = append(before, token)
+		}
+	}
+
+	references := TableReferences(completer.Dialect, statement)
+	completions := []Completion{}
+
+	add := func(kind CompletionKind, texts []string) {
+		for _, text := range texts {
+			completions = append(completions, Completion{Text: text, Kind: kind})
+		}
+	}
+
+	switch {
+	case len(before) >= 2 && before[len(before)-1].Text == "." &&
+		(before[len(before)-2].Type == TokenIdentifier || before[len(before)-2].Type == TokenQuotedIdentifier):
+		qualifier := Unquote(before[len(before)-2].Text)
+
+		columns := completer.columns(resolve(references, qualifier))
+		add(CompletionColumn, columns)
+
+		// The qualifier may be a schema rather than a table
+		if len(columns) == 0 {
+			for _, table := range completer.tables() {
+				if name, ok := strings.CutPrefix(table, qualifier+"."); ok {
+					add(CompletionTable, []string{name})
+				}
+			}
+		}
+	case expectsTable(before):
+		for _, table := range completer.tables() {
+			add(CompletionTable, []string{completer.tableText(table)})
+		}
+	default:
+		seen := map[string]bool{}
+		for _, reference := range references {
+			for _, column := range completer.columns(reference.Name) {
+				if !seen[column] {
+					seen[column] = true
+					add(CompletionColumn, []string{column})
+				}
+			}
+		}
+
+		functions, keywords := Functions(completer.Dialect), Keywords(completer.Dialect)
+
+		// Follow the case the user is typing in
+		if prefix != "" && prefix == strings.ToLower(prefix) {
+			for i := range functions {
+				functions[i] = strings.ToLower(functions[i])
+			}
+			for i := range keywords {
+				keywords[i] = strings.ToLower(keywords[i])
+			}
+		}
+
+		add(CompletionFunction, functions)
+		add(CompletionKeyword, keywords)
+	}
+
+	matches := []Completion{}
+	for _, completion := range completions {
+		if strings.HasPrefix(strings.ToLower(completion.Text), strings.ToLower(prefix)) {
+			matches = append(matches, completion)
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b Completion) int {
+		if a.Kind != b.Kind {
+			return int(a.Kind) - int(b.Kind)
+		}
+		return strings.Compare(strings.ToLower(a.Text), strings.ToLower(b.Text))
+	})
+
+	return start, slices.CompactFunc(matches, func(a, b Completion) bool { return a == b })
+}
@@ -0,0 +1,141 @@
+package sqlparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+type testCatalog map[string][]string
+
+func (catalog testCatalog) Tables(_ string) []string {
+	tables := []string{}
+	for table := range catalog {
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+func (catalog testCatalog) Columns(_, table string) []string {
+	if columns, ok := catalog[table]; ok {
+		return columns
+	}
+	return catalog["public."+table]
+}
+
+func texts(completions []Completion) []string {
+	result := []string{}
+	for _, completion := range completions {
+		result = append(result, completion.Text)
+	}
+	return result
+}
+
+func TestCompleter_Complete(t *testing.T) {
+	completer := Completer{
+		Dialect: drivers.DriverPostgres,
+		Catalog: testCatalog{
+			"public.users":  {"id", "name", "email"},
+			"public.orders": {"id", "user_id", "total"},
+			"sales.leads":   {"id", "source"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		sql           string
+		expected      []string
+		expectedStart int
+	}{
+		{name: "Tables after FROM", sql: "SELECT * FROM |", expected: []string{"orders", "sales.leads", "users"}},
+		{name: "Tables after JOIN with prefix", sql: "SELECT * FROM users u JOIN or|", expected: []string{"orders"}, expectedStart: 27},
+		{name: "Tables after comma in FROM", sql: "SELECT * FROM users, o|", expected: []string{"orders"}, expectedStart: 21},
+		{name: "Tables after UPDATE", sql: "UPDATE u|", expected: []string{"users"}, expectedStart: 7},
+		{name: "Tables after INTO", sql: "INSERT INTO u|", expected: []string{"users"}, expectedStart: 12},
+		{name: "Alias columns", sql: "SELECT u.| FROM users u", expected: []string{"email", "id", "name"}},
+		{name: "Alias columns with prefix", sql: "SELECT o.u| FROM users AS u JOIN orders o ON o.user_id = u.id", expected: []string{"user_id"}, expectedStart: 9},
+		{name: "Table name qualifier", sql: "SELECT orders.t| FROM orders", expected: []string{"total"}, expectedStart: 14},
+		{name: "Schema qualifier", sql: "SELECT * FROM sales.|", expected: []string{"leads"}},
+		{name: "Other statement", sql: "SELECT * FROM users; SELECT u.| FROM orders u", expected: []string{"id", "total", "user_id"}},
+		{name: "Inside string", sql: "SELECT 'FR|", expected: nil},
+		{name: "Inside comment", sql: "-- SEL|", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The cursor is marked with a pipe
+			cursor := strings.Index(tc.sql, "|")
+			sql := strings.Replace(tc.sql, "|", "", 1)
+
+			start, completions := completer.Complete(sql, cursor)
+
+			if tc.expected == nil {
+				if len(completions) != 0 {
+					t.Fatalf("expected no completions, got %v", texts(completions))
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(texts(completions), tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, texts(completions))
+			}
+
+			if tc.expectedStart != 0 && start != tc.expectedStart {
+				t.Fatalf("expected start %d, got %d", tc.expectedStart, start)
+			}
+		})
+	}
+}
+
+func TestCompleter_CompleteKeywordsAndFunctions(t *testing.T) {
+	completer := Completer{
+		Dialect: drivers.DriverMySQL,
+		Catalog: testCatalog{"users": {"id", "name"}},
+	}
+
+	_, completions := completer.Complete("SELECT n FROM users", len("SELECT n"))
+	if !reflect.DeepEqual(texts(completions), []string{"name", "now", "nullif", "not", "null"}) {
+		t.Fatalf("unexpected completions %v", texts(completions))
+	}
+	if completions[0].Kind != CompletionColumn || completions[1].Kind != CompletionFunction {
+		t.Fatalf("expected columns before functions, got %+v", completions)
+	}
+
+	_, completions = completer.Complete("SEL", 3)
+	if !reflect.DeepEqual(texts(completions), []string{"SELECT"}) {
+		t.Fatalf("unexpected completions %v", texts(completions))
+	}
+
+	// Dialect specific keywords
+	_, completions = completer.Complete("SELECT 1; SHO", 13)
+	if !reflect.DeepEqual(texts(completions), []string{"SHOW"}) {
+		t.Fatalf("unexpected completions %v", texts(completions))
+	}
+
+	completer.Dialect = drivers.DriverPostgres
+	_, completions = completer.Complete("SHO", 3)
+	if len(completions) != 0 {
+		t.Fatalf("expected no SHOW keyword for postgres, got %v", texts(completions))
+	}
+}
+
+func TestTableReferences(t *testing.T) {
+	tokens := []Token{}
+	for _, token := range Tokenize(`SELECT * FROM public.users u, "orders" AS o LEFT JOIN items ON items.id = o.item_id WHERE x = 1`) {
+		if token.IsSignificant() {
+			tokens = append(tokens, token)
+		}
+	}
+
+	expected := []TableReference{
+		{Name: "public.users", Alias: "u"},
+		{Name: "orders", Alias: "o"},
+		{Name: "items"},
+	}
+
+	if got := TableReferences(drivers.DriverPostgres, tokens); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
@@ -0,0 +1,461 @@
+package sqlparse
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	KeywordCaseUpper    = "upper"
+	KeywordCaseLower    = "lower"
+	KeywordCasePreserve = "preserve"
+
+	CommaStyleTrailing = "trailing"
+	CommaStyleLeading  = "leading"
+)
+
+// FormatOptions configure how Format lays out SQL
+type FormatOptions struct {
+	KeywordCase string // upper, lower or preserve
+	IndentWidth int
+	CommaStyle  string // trailing or leading
+	LineWidth   int    // 0 uses the default, a negative width puts every item of a list on its own line
+}
+
+// DefaultFormatOptions returns the options used when none are configured
+func DefaultFormatOptions() FormatOptions {
+	return FormatOptions{
+		KeywordCase: KeywordCaseUpper,
+		IndentWidth: 2,
+		CommaStyle:  CommaStyleTrailing,
+		LineWidth:   80,
+	}
+}
+
+// formatToken is a significant token or a comment, with the whitespace that
+// preceded it in the source
+type formatToken struct {
+	Token
+	spaced  bool
+	newline bool
+}
+
+func (token formatToken) isPunctuation(text string) bool {
+	return token.Type == TokenPunctuation && token.Text == text
+}
+
+func (token formatToken) isLineComment() bool {
+	return token.Type == TokenComment && !strings.HasPrefix(token.Text, "/*")
+}
+
+// clauseStarts are the keywords that start a clause on a new line, longest
+// phrases first
+var clauseStarts = [][]string{
+	{"ON", "DUPLICATE", "KEY", "UPDATE"},
+	{"LEFT", "OUTER", "JOIN"}, {"RIGHT", "OUTER", "JOIN"}, {"FULL", "OUTER", "JOIN"},
+	{"LEFT", "JOIN"}, {"RIGHT", "JOIN"}, {"FULL", "JOIN"}, {"INNER", "JOIN"}, {"CROSS", "JOIN"},
+	{"GROUP", "BY"}, {"ORDER", "BY"}, {"UNION", "ALL"}, {"INSERT", "INTO"}, {"DELETE", "FROM"},
+	{"ON", "CONFLICT"}, {"FOR", "UPDATE"},
+	{"SELECT"}, {"FROM"}, {"WHERE"}, {"HAVING"}, {"LIMIT"}, {"OFFSET"}, {"FETCH"}, {"UNION"},
+	{"EXCEPT"}, {"INTERSECT"}, {"VALUES"}, {"UPDATE"}, {"SET"}, {"RETURNING"}, {"WITH"},
+	{"WINDOW"}, {"JOIN"},
+}
+
+// listClauses put each item of their comma separated list on its own line
+var listClauses = []string{"SELECT", "FROM", "GROUP BY", "ORDER BY", "VALUES", "SET", "RETURNING", "WITH", "WINDOW"}
+
+// conditionClauses put each AND and OR on its own line
+var conditionClauses = []string{"WHERE", "HAVING"}
+
+// spacedParenKeywords are followed by a space before an opening parenthesis
+var spacedParenKeywords = []string{"AND", "AS", "EXISTS", "IN", "NOT", "ON", "OR", "OVER", "USING", "VALUES", "ANY", "ALL", "THEN", "ELSE", "WHEN"}
+
+type clause struct {
+	keyword []formatToken
+	body    []formatToken
+}
+
+type formatter struct {
+	dialect string
+	options FormatOptions
+}
+
+// Format pretty-prints SQL, putting clauses on their own lines, breaking
+// lists and conditions that do not fit the line width and indenting
+// subqueries. Strings, quoted identifiers and comments are kept as they are.
+func Format(dialect, sql string, options FormatOptions) string {
+	defaults := DefaultFormatOptions()
+	if options.IndentWidth <= 0 {
+		options.IndentWidth = defaults.IndentWidth
+	}
+	if options.KeywordCase == "" {
+		options.KeywordCase = defaults.KeywordCase
+	}
+	if options.CommaStyle == "" {
+		options.CommaStyle = defaults.CommaStyle
+	}
+	if options.LineWidth == 0 {
+		options.LineWidth = defaults.LineWidth
+	}
+
+	f := formatter{dialect: dialect, options: options}
+
+	tokens := []formatToken{}
+	spaced, newline := false, false
+	for _, token := range TokenizeDialect(dialect, sql) {
+		if token.Type == TokenWhitespace {
+			spaced, newline = true, newline || strings.Contains(token.Text, "\n")
+			continue
+		}
+		tokens = append(tokens, formatToken{Token: token, spaced: spaced, newline: newline})
+		spaced, newline = false, false
+	}
+
+	statements := []string{}
+	start, depth := 0, 0
+	for i, token := range tokens {
+		switch {
+		case token.isPunctuation("("):
+			depth++
+		case token.isPunctuation(")"):
+			depth--
+		case token.isPunctuation(";") && depth <= 0:
+			statements = append(statements, f.terminated(tokens[start:i]))
+			start, depth = i+1, 0
+		}
+	}
+
+	if start < len(tokens) {
+		statements = append(statements, f.statement(tokens[start:], 0))
+	}
+
+	return strings.Join(statements, "\n\n")
+}
+
+// terminated formats a statement that ended with a semicolon
+func (f formatter) terminated(tokens []formatToken) string {
+	statement := f.statement(tokens, 0)
+	if len(tokens) > 0 && tokens[len(tokens)-1].isLineComment() {
+		return statement + "\n;"
+	}
+	return statement + ";"
+}
+
+func (f formatter) indent(depth int) string {
+	return strings.Repeat(" ", depth*f.options.IndentWidth)
+}
+
+// word returns a token with the configured keyword case
+func (f formatter) word(token formatToken) string {
+	if token.Type != TokenKeyword {
+		return token.Text
+	}
+
+	switch f.options.KeywordCase {
+	case KeywordCaseUpper:
+		return strings.ToUpper(token.Text)
+	case KeywordCaseLower:
+		return strings.ToLower(token.Text)
+	}
+
+	return token.Text
+}
+
+// statement formats the clauses of a statement, one after the other
+func (f formatter) statement(tokens []formatToken, depth int) string {
+	lines := []string{}
+	for _, clause := range f.clauses(tokens) {
+		lines = append(lines, f.clause(clause, depth))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clauseAt returns the number of tokens of the clause keyword at index i
+func clauseAt(tokens []formatToken, i int) int {
+	for _, phrase := range clauseStarts {
+		if i+len(phrase) > len(tokens) {
+			continue
+		}
+
+		matches := true
+		for j, word := range phrase {
+			if !tokens[i+j].Is(word) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return len(phrase)
+		}
+	}
+
+	return 0
+}
+
+// matchingParen returns the index of the parenthesis closing the one at
+// index i, or the length of the tokens if it is not closed
+func matchingParen(tokens []formatToken, i int) int {
+	depth := 0
+	for j := i; j < len(tokens); j++ {
+		switch {
+		case tokens[j].isPunctuation("("):
+			depth++
+		case tokens[j].isPunctuation(")"):
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// clauses splits a statement on the clause keywords outside parentheses
+func (f formatter) clauses(tokens []formatToken) []clause {
+	clauses := []clause{}
+	current := clause{}
+
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].isPunctuation("(") {
+			end := min(matchingParen(tokens, i), len(tokens)-1)
+			current.body = append(current.body, tokens[i:end+1]...)
+			i = end
+			continue
+		}
+
+		if length := clauseAt(tokens, i); length > 0 {
+			if len(current.keyword) > 0 || len(current.body) > 0 {
+				clauses = append(clauses, current)
+			}
+			current = clause{keyword: tokens[i : i+length]}
+			i += length - 1
+			continue
+		}
+
+		current.body = append(current.body, tokens[i])
+	}
+
+	if len(current.keyword) > 0 || len(current.body) > 0 {
+		clauses = append(clauses, current)
+	}
+
+	return clauses
+}
+
+// split breaks a clause body into items on commas or on AND and OR outside
+// parentheses. A comment following a comma on the same line stays with the
+// item before it.
+func split(tokens []formatToken, onCommas bool) [][]formatToken {
+	items := [][]formatToken{}
+	current := []formatToken{}
+	between := false
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		switch {
+		case token.isPunctuation("("):
+			end := min(matchingParen(tokens, i), len(tokens)-1)
+			current = append(current, tokens[i:end+1]...)
+			i = end
+			continue
+		case onCommas && token.isPunctuation(","):
+			for i+1 < len(tokens) && tokens[i+1].Type == TokenComment && !tokens[i+1].newline {
+				current = append(current, tokens[i+1])
+				i++
+			}
+			items = append(items, current)
+			current = []formatToken{}
+			continue
+		case !onCommas && token.Is("BETWEEN"):
+			between = true
+		case !onCommas && token.Is("AND") && between:
+			between = false
+		case !onCommas && (token.Is("AND") || token.Is("OR")) && len(current) > 0:
+			items = append(items, current)
+			current = []formatToken{}
+		}
+
+		current = append(current, token)
+	}
+
+	return append(items, current)
+}
+
+// clause formats a clause on one line when it fits, otherwise with its
+// items on indented lines below the keyword
+func (f formatter) clause(c clause, depth int) string {
+	indent := f.indent(depth)
+
+	if len(c.keyword) == 0 {
+		return indent + f.inline(c.body, depth)
+	}
+
+	words := []string{}
+	for _, token := range c.keyword {
+		// Clause words like LIMIT are not keywords of every dialect
+		token.Type = TokenKeyword
+		words = append(words, f.word(token))
+	}
+	keyword := strings.Join(words, " ")
+
+	if len(c.body) == 0 {
+		return indent + keyword
+	}
+
+	phrase := strings.ToUpper(strings.Join(words, " "))
+	isList, isCondition := false, false
+	for _, name := range listClauses {
+		isList = isList || phrase == name
+	}
+	for _, name := range conditionClauses {
+		isCondition = isCondition || phrase == name
+	}
+
+	items := [][]formatToken{c.body}
+	if isList || isCondition {
+		items = split(c.body, isList)
+	}
+
+	rendered := make([]string, len(items))
+	comments := make([]string, len(items))
+	multiline := false
+	for i, item := range items {
+		// A trailing line comment goes after the comma of its item
+		if last := len(item) - 1; last > 0 && item[last].isLineComment() {
+			comments[i] = item[last].Text
+			item = item[:last]
+		}
+		rendered[i] = f.inline(item, depth+1)
+		multiline = multiline || strings.Contains(rendered[i], "\n") || (comments[i] != "" && i < len(items)-1)
+	}
+
+	separator := " "
+	if isList {
+		separator = ", "
+	}
+	line := indent + keyword + " " + strings.Join(rendered, separator)
+
+	fits := utf8.RuneCountInString(line) <= f.options.LineWidth
+	if f.options.LineWidth < 0 {
+		fits = len(items) == 1
+	}
+	if fits && !multiline {
+		if comment := comments[len(comments)-1]; comment != "" {
+			line += " " + comment
+		}
+		return line
+	}
+
+	lines := []string{indent + keyword}
+	for i, text := range rendered {
+		switch {
+		case isList && f.options.CommaStyle == CommaStyleLeading && i > 0:
+			text = ", " + text
+		case isList && f.options.CommaStyle != CommaStyleLeading && i < len(rendered)-1:
+			text += ","
+		}
+
+		if comments[i] != "" {
+			text += " " + comments[i]
+		}
+
+		lines = append(lines, f.indent(depth+1)+text)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// isSubquery reports whether the tokens inside parentheses are a query
+func isSubquery(tokens []formatToken) bool {
+	for _, token := range tokens {
+		if token.Type != TokenComment {
+			return token.Is("SELECT") || token.Is("WITH")
+		}
+	}
+	return false
+}
+
+// isValue reports whether a token ends an operand, making a following
+// minus or plus sign binary
+func isValue(token formatToken) bool {
+	switch token.Type {
+	case TokenIdentifier, TokenQuotedIdentifier, TokenNumber, TokenString, TokenPlaceholder:
+		return true
+	}
+	return token.isPunctuation(")")
+}
+
+// spaceBefore reports whether a space separates two consecutive tokens
+func spaceBefore(previous, beforePrevious *formatToken, token formatToken) bool {
+	if previous == nil {
+		return false
+	}
+
+	switch {
+	case token.isPunctuation(")"), token.isPunctuation(","), token.isPunctuation("."), token.isPunctuation(";"):
+		return false
+	case previous.isPunctuation("("), previous.isPunctuation("."):
+		return false
+	case token.Text == "::" || previous.Text == "::":
+		return false
+	case token.isPunctuation("("):
+		if previous.Type == TokenKeyword {
+			for _, keyword := range spacedParenKeywords {
+				if previous.Is(keyword) {
+					return true
+				}
+			}
+		}
+		// Keep function calls like COUNT(*) together
+		return token.spaced || previous.isPunctuation(",")
+	case previous.Type == TokenOperator && (previous.Text == "-" || previous.Text == "+"):
+		// Unary signs stick to their operand
+		return beforePrevious != nil && isValue(*beforePrevious)
+	}
+
+	return true
+}
+
+// inline formats tokens on a single line, except for subqueries and line
+// comments which start new lines
+func (f formatter) inline(tokens []formatToken, depth int) string {
+	var builder strings.Builder
+	var previous, beforePrevious *formatToken
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if previous != nil && previous.isLineComment() {
+			builder.WriteString("\n" + f.indent(depth))
+		} else if spaceBefore(previous, beforePrevious, token) {
+			builder.WriteString(" ")
+		}
+
+		if token.isPunctuation("(") {
+			end := matchingParen(tokens, i)
+			inner := tokens[i+1 : min(end, len(tokens))]
+
+			if isSubquery(inner) {
+				builder.WriteString("(\n" + f.statement(inner, depth+1) + "\n" + f.indent(depth))
+			} else {
+				builder.WriteString("(" + f.inline(inner, depth))
+			}
+
+			if end < len(tokens) {
+				builder.WriteString(")")
+				token = tokens[end]
+			} else {
+				token = tokens[len(tokens)-1]
+			}
+			i = end
+		} else {
+			builder.WriteString(f.word(token))
+		}
+
+		beforePrevious, previous = previous, &token
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,74 @@
+package sqlparse
+
+import (
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+func TestFormat(t *testing.T) {
+	narrow := DefaultFormatOptions()
+	narrow.LineWidth = 20
+
+	leading := DefaultFormatOptions()
+	leading.CommaStyle = CommaStyleLeading
+	leading.KeywordCase = KeywordCaseLower
+	leading.IndentWidth = 4
+	leading.LineWidth = -1
+
+	testCases := []struct {
+		name     string
+		dialect  string
+		sql      string
+		options  FormatOptions
+		expected string
+	}{
+		{
+			name:     "Clauses on their own lines",
+			sql:      "select a.id, count(*) from customers a left join orders b on b.customer_id = a.id group by a.id limit 10",
+			options:  DefaultFormatOptions(),
+			expected: "SELECT a.id, count(*)\nFROM customers a\nLEFT JOIN orders b ON b.customer_id = a.id\nGROUP BY a.id\nLIMIT 10",
+		},
+		{
+			name:     "Lists and conditions over the line width",
+			sql:      "SELECT id, name, email FROM users WHERE active = 1 AND age BETWEEN 18 AND 30 OR admin",
+			options:  narrow,
+			expected: "SELECT\n  id,\n  name,\n  email\nFROM users\nWHERE\n  active = 1\n  AND age BETWEEN 18 AND 30\n  OR admin",
+		},
+		{
+			name:     "Leading commas and lower case",
+			sql:      "SELECT a, b FROM t",
+			options:  leading,
+			expected: "select\n    a\n    , b\nfrom t",
+		},
+		{
+			name:     "Subquery",
+			sql:      "SELECT id FROM t WHERE id IN (SELECT customer_id FROM orders)",
+			options:  DefaultFormatOptions(),
+			expected: "SELECT id\nFROM t\nWHERE\n  id IN (\n    SELECT customer_id\n    FROM orders\n  )",
+		},
+		{
+			name:     "Statements, literals and comments",
+			dialect:  drivers.DriverPostgres,
+			sql:      "update t set a = 'Select  x', b = -1 -- note\nwhere id = $1::int;select 1",
+			options:  DefaultFormatOptions(),
+			expected: "UPDATE t\nSET a = 'Select  x', b = -1 -- note\nWHERE id = $1::int;\n\nSELECT 1",
+		},
+		{
+			name:     "MySQL hash comment and backticks",
+			dialect:  drivers.DriverMySQL,
+			sql:      "insert into `t` (a) values (1) # done",
+			options:  DefaultFormatOptions(),
+			expected: "INSERT INTO `t` (a)\nVALUES (1) # done",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted := Format(tc.dialect, tc.sql, tc.options)
+			if formatted != tc.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", tc.expected, formatted)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package sqlparse
+
+import (
+	"slices"
+	"strings"
+
+	"sqlcmder/drivers"
+)
+
+var commonKeywords = []string{
+	"ADD", "ALL", "ALTER", "AND", "ANY", "AS", "ASC", "BEGIN", "BETWEEN", "BY",
+	"CASCADE", "CASE", "CHECK", "COLUMN", "COMMIT", "CONSTRAINT", "CREATE", "CROSS",
+	"DATABASE", "DEFAULT", "DELETE", "DESC", "DISTINCT", "DROP", "ELSE", "END",
+	"EXCEPT", "EXISTS", "FOREIGN", "FROM", "FULL", "GROUP", "HAVING", "IN", "INDEX",
+	"INNER", "INSERT", "INTERSECT", "INTO", "IS", "JOIN", "KEY", "LEFT", "LIKE",
+	"NOT", "NULL", "ON", "OR", "ORDER", "OUTER", "PRIMARY", "REFERENCES", "RIGHT",
+	"ROLLBACK", "SELECT", "SET", "TABLE", "THEN", "TRANSACTION", "TRUNCATE", "UNION",
+	"UNIQUE", "UPDATE", "USING", "VALUES", "VIEW", "WHEN", "WHERE", "WITH",
+}
+
+var dialectKeywords = map[string][]string{
+	drivers.DriverMySQL: {
+		"AUTO_INCREMENT", "CHANGE", "DATABASES", "DESCRIBE", "DUPLICATE", "ENGINE",
+		"EXPLAIN", "FORCE", "IGNORE", "LIMIT", "LOCK", "MODIFY", "OFFSET", "REGEXP",
+		"RENAME", "REPLACE", "SHOW", "STRAIGHT_JOIN", "TABLES", "UNLOCK", "UNSIGNED", "USE",
+	},
+	drivers.DriverPostgres: {
+		"ANALYZE", "CONFLICT", "DO", "EXPLAIN", "FETCH", "FIRST", "ILIKE", "LATERAL",
+		"LIMIT", "MATERIALIZED", "NOTHING", "NULLS", "OFFSET", "OVER", "PARTITION",
+		"RECURSIVE", "RETURNING", "SCHEMA", "SEQUENCE", "SIMILAR", "VACUUM", "WINDOW",
+	},
+	drivers.DriverSqlite: {
+		"ABORT", "ATTACH", "AUTOINCREMENT", "CONFLICT", "DETACH", "EXPLAIN", "GLOB",
+		"IGNORE", "LIMIT", "OFFSET", "PRAGMA", "RAISE", "REINDEX", "REPLACE",
+		"RETURNING", "ROWID", "VACUUM", "WITHOUT",
+	},
+	drivers.DriverMSSQL: {
+		"APPLY", "DECLARE", "EXEC", "EXECUTE", "FETCH", "GO", "IDENTITY", "MERGE",
+		"NEXT", "NOLOCK", "OFFSET", "OUTPUT", "OVER", "PARTITION", "PERCENT", "PRINT",
+		"ROWS", "TOP", "USE",
+	},
+}
+
+var commonFunctions = []string{
+	"ABS", "AVG", "CAST", "COALESCE", "COUNT", "LOWER", "MAX", "MIN", "NULLIF",
+	"ROUND", "SUM", "UPPER",
+}
+
+var dialectFunctions = map[string][]string{
+	drivers.DriverMySQL: {
+		"CONCAT", "CONCAT_WS", "CURDATE", "DATE_ADD", "DATE_FORMAT", "DATE_SUB",
+		"GROUP_CONCAT", "IFNULL", "JSON_EXTRACT", "LENGTH", "NOW", "SUBSTRING",
+		"TRIM", "UNIX_TIMESTAMP",
+	},
+	drivers.DriverPostgres: {
+		"AGE", "ARRAY_AGG", "CONCAT", "CURRENT_DATE", "DATE_TRUNC", "EXTRACT",
+		"GENERATE_SERIES", "JSON_AGG", "JSONB_BUILD_OBJECT", "LENGTH", "NOW",
+		"STRING_AGG", "SUBSTRING", "TO_CHAR", "TRIM",
+	},
+	drivers.DriverSqlite: {
+		"DATE", "DATETIME", "GROUP_CONCAT", "IFNULL", "INSTR", "JSON_EXTRACT",
+		"LENGTH", "PRINTF", "RANDOM", "STRFTIME", "SUBSTR", "TRIM", "TYPEOF",
+	},
+	drivers.DriverMSSQL: {
+		"CHARINDEX", "CONCAT", "CONVERT", "DATEADD", "DATEDIFF", "FORMAT",
+		"GETDATE", "ISNULL", "LEN", "NEWID", "STRING_AGG", "SUBSTRING", "TRIM",
+	},
+}
+
+func merge(common []string, dialect []string) []string {
+	words := append(slices.Clone(common), dialect...)
+	slices.Sort(words)
+	return slices.Compact(words)
+}
+
+// Keywords returns the keywords of a dialect, which is the provider name of a driver
+func Keywords(dialect string) []string {
+	return merge(commonKeywords, dialectKeywords[dialect])
+}
+
+// Functions returns the built-in functions of a dialect
+func Functions(dialect string) []string {
+	return merge(commonFunctions, dialectFunctions[dialect])
+}
+
+// IsKeyword reports whether a word is a keyword of the dialect, ignoring case
+func IsKeyword(dialect, word string) bool {
+	word = strings.ToUpper(word)
+	return slices.Contains(commonKeywords, word) || slices.Contains(dialectKeywords[dialect], word)
+}
@@ -0,0 +1,263 @@
+package sqlparse
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"sqlcmder/drivers"
+)
+
+type TokenType int
+
+const (
+	TokenWhitespace TokenType = iota
+	TokenComment
+	TokenString
+	TokenNumber
+	TokenKeyword
+	TokenIdentifier
+	TokenQuotedIdentifier
+	TokenPlaceholder
+	TokenOperator
+	TokenPunctuation
+)
+
+// Token is a piece of a SQL text, Start and End being byte offsets
+type Token struct {
+	Type  TokenType
+	Text  string
+	Start int
+	End   int
+}
+
+// Is reports whether the token is the given word, ignoring case
+func (token Token) Is(word string) bool {
+	return (token.Type == TokenKeyword || token.Type == TokenIdentifier) && strings.EqualFold(token.Text, word)
+}
+
+// IsWord reports whether the token is a keyword or an unquoted identifier
+func (token Token) IsWord() bool {
+	return token.Type == TokenKeyword || token.Type == TokenIdentifier
+}
+
+// IsSignificant reports whether the token is neither whitespace nor a comment
+func (token Token) IsSignificant() bool {
+	return token.Type != TokenWhitespace && token.Type != TokenComment
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Tokenize splits a SQL text into tokens without dialect specific rules
+func Tokenize(sql string) []Token {
+	return TokenizeDialect("", sql)
+}
+
+// TokenizeDialect splits a SQL text into tokens following the quoting,
+// comment and placeholder rules of a dialect. Unterminated strings, quoted
+// identifiers and comments run until the end of the text, so the tokens
+// always cover the whole input.
+func TokenizeDialect(dialect, sql string) []Token {
+	tokens := []Token{}
+
+	isMySQL := dialect == drivers.DriverMySQL
+	isPostgres := dialect == drivers.DriverPostgres
+	isMSSQL := dialect == drivers.DriverMSSQL
+
+	for position := 0; position < len(sql); {
+		start := position
+		r, size := utf8.DecodeRuneInString(sql[position:])
+		next := byte(0)
+		if position+1 < len(sql) {
+			next = sql[position+1]
+		}
+
+		var tokenType TokenType
+
+		switch {
+		case unicode.IsSpace(r):
+			tokenType = TokenWhitespace
+			position = wordEnd(sql, position, unicode.IsSpace)
+		case r == '-' && next == '-', r == '#' && isMySQL:
+			tokenType = TokenComment
+			position = indexFrom(sql, position, "\n", 0)
+		case r == '/' && next == '*':
+			tokenType = TokenComment
+			position = indexFrom(sql, position+2, "*/", 2)
+		case r == '\'':
+			tokenType = TokenString
+			position = quotedEnd(sql, position, '\'', isMySQL)
+		case strings.ContainsRune("EeNnXxBb", r) && next == '\'':
+			// Prefixed strings like E'...' or N'...'
+			tokenType = TokenString
+			position = quotedEnd(sql, position+1, '\'', isMySQL || (isPostgres && (r == 'E' || r == 'e')))
+		case r == '"' && isMySQL:
+			tokenType = TokenString
+			position = quotedEnd(sql, position, '"', true)
+		case r == '"', r == '`' && !isPostgres && !isMSSQL:
+			tokenType = TokenQuotedIdentifier
+			position = quotedEnd(sql, position, byte(r), false)
+		case r == '[' && (isMSSQL || dialect == drivers.DriverSqlite):
+			tokenType = TokenQuotedIdentifier
+			position = quotedEnd(sql, position, ']', false)
+		case r == '?' && next == '{' && bracedParameterEnd(sql, position) != -1:
+			tokenType = TokenPlaceholder
+			position = bracedParameterEnd(sql, position)
+		case r == '$' && isPostgres && next >= '0' && next <= '9':
+			tokenType = TokenPlaceholder
+			position = wordEnd(sql, position+1, unicode.IsDigit)
+		case r == '$' && isPostgres:
+			if end, ok := dollarQuotedEnd(sql, position); ok {
+				tokenType = TokenString
+				position = end
+			} else if isIdentifierStart(rune(next)) {
+				tokenType = TokenPlaceholder
+				position = wordEnd(sql, position+1, isIdentifierPart)
+			} else {
+				tokenType = TokenOperator
+				position++
+			}
+		case r == '?' && !isPostgres:
+			tokenType = TokenPlaceholder
+			position = wordEnd(sql, position+1, unicode.IsDigit)
+		case (r == ':' || r == '$' || (r == '@' && !isPostgres)) && position+1 < len(sql) && isIdentifierStart(rune(next)):
+			tokenType = TokenPlaceholder
+			position = wordEnd(sql, position+1, isIdentifierPart)
+		case r == '@' && next == '@' && isMSSQL:
+			tokenType = TokenPlaceholder
+			position = wordEnd(sql, position+2, isIdentifierPart)
+		case unicode.IsDigit(r) || (r == '.' && next >= '0' && next <= '9'):
+			tokenType = TokenNumber
+			position = numberEnd(sql, position)
+		case isIdentifierStart(r):
+			tokenType = TokenIdentifier
+			position = wordEnd(sql, position, isIdentifierPart)
+			if IsKeyword(dialect, sql[start:position]) {
+				tokenType = TokenKeyword
+			}
+		case strings.ContainsRune("(),;.", r):
+			tokenType = TokenPunctuation
+			position += size
+		default:
+			tokenType = TokenOperator
+			position += size
+			for position < len(sql) && strings.IndexByte("<>=!|&+-*/%^~:", sql[position]) != -1 {
+				// Do not swallow the start of a comment
+				if sql[position] == '-' && position+1 < len(sql) && sql[position+1] == '-' {
+					break
+				}
+				position++
+			}
+		}
+
+		tokens = append(tokens, Token{Type: tokenType, Text: sql[start:position], Start: start, End: position})
+	}
+
+	return tokens
+}
+
+// wordEnd returns the position of the first rune not matching the predicate
+func wordEnd(sql string, position int, matches func(rune) bool) int {
+	for position < len(sql) {
+		r, size := utf8.DecodeRuneInString(sql[position:])
+		if !matches(r) {
+			break
+		}
+		position += size
+	}
+	return position
+}
+
+// bracedParameterEnd returns the end of a named parameter like ?{name}, or
+// -1 if the braces do not hold a name
+func bracedParameterEnd(sql string, position int) int {
+	end := wordEnd(sql, position+2, isIdentifierPart)
+	if end == position+2 || end >= len(sql) || sql[end] != '}' {
+		return -1
+	}
+	return end + 1
+}
+
+// dollarQuotedEnd returns the end of a Postgres $tag$...$tag$ string
+func dollarQuotedEnd(sql string, position int) (int, bool) {
+	tagEnd := strings.IndexByte(sql[position+1:], '$')
+	if tagEnd == -1 {
+		return 0, false
+	}
+
+	tag := sql[position : position+tagEnd+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if !isIdentifierPart(r) || r == '$' {
+			return 0, false
+		}
+	}
+
+	return indexFrom(sql, position+len(tag), tag, len(tag)), true
+}
+
+// indexFrom returns the position after the first terminator found from
+// position on, or the length of the text if there is none
+func indexFrom(sql string, position int, terminator string, terminatorLength int) int {
+	index := strings.Index(sql[position:], terminator)
+	if index == -1 {
+		return len(sql)
+	}
+	return position + index + terminatorLength
+}
+
+// quotedEnd returns the position after the closing quote, treating doubled
+// quotes, and backslashes if asked, as escapes
+func quotedEnd(sql string, position int, quote byte, backslashEscapes bool) int {
+	position++
+	for position < len(sql) {
+		if backslashEscapes && sql[position] == '\\' {
+			position += 2
+			continue
+		}
+		if sql[position] == quote {
+			if position+1 < len(sql) && sql[position+1] == quote {
+				position += 2
+				continue
+			}
+			return position + 1
+		}
+		position++
+	}
+	return len(sql)
+}
+
+func numberEnd(sql string, position int) int {
+	for position < len(sql) {
+		c := sql[position]
+		switch {
+		case c >= '0' && c <= '9', c == '.':
+		case (c == 'e' || c == 'E') && position+1 < len(sql) && (sql[position+1] == '-' || sql[position+1] == '+' || (sql[position+1] >= '0' && sql[position+1] <= '9')):
+			position++
+		default:
+			return position
+		}
+		position++
+	}
+	return position
+}
+
+// Unquote removes the quotes around an identifier
+func Unquote(identifier string) string {
+	if len(identifier) < 2 {
+		return identifier
+	}
+
+	first, last := identifier[0], identifier[len(identifier)-1]
+	if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') {
+		inner := identifier[1 : len(identifier)-1]
+		return strings.ReplaceAll(inner, string(last)+string(last), string(last))
+	}
+
+	return identifier
+}
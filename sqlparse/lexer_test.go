@@ -0,0 +1,129 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+func TestTokenize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sql      string
+		expected []TokenType
+	}{
+		{
+			name:     "Select",
+			sql:      "SELECT a.id FROM t",
+			expected: []TokenType{TokenKeyword, TokenWhitespace, TokenIdentifier, TokenPunctuation, TokenIdentifier, TokenWhitespace, TokenKeyword, TokenWhitespace, TokenIdentifier},
+		},
+		{
+			name:     "Strings and comments",
+			sql:      "'it''s' -- note\n/* block */",
+			expected: []TokenType{TokenString, TokenWhitespace, TokenComment, TokenWhitespace, TokenComment},
+		},
+		{
+			name:     "Operators and numbers",
+			sql:      "x>=1.5e3",
+			expected: []TokenType{TokenIdentifier, TokenOperator, TokenNumber},
+		},
+		{
+			name:     "Quoted identifiers",
+			sql:      "\"a b\".`c`",
+			expected: []TokenType{TokenQuotedIdentifier, TokenPunctuation, TokenQuotedIdentifier},
+		},
+		{
+			name:     "Unterminated string",
+			sql:      "'abc",
+			expected: []TokenType{TokenString},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := Tokenize(tc.sql)
+
+			types := []TokenType{}
+			text := ""
+			for _, token := range tokens {
+				types = append(types, token.Type)
+				text += tc.sql[token.Start:token.End]
+			}
+
+			if !reflect.DeepEqual(types, tc.expected) {
+				t.Fatalf("expected %v, got %v (%+v)", tc.expected, types, tokens)
+			}
+
+			if text != tc.sql {
+				t.Fatalf("tokens do not cover the input: %q", text)
+			}
+		})
+	}
+}
+
+func TestTokenizeDialect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  string
+		sql      string
+		expected []TokenType
+	}{
+		{
+			name:     "MySQL double quoted string and hash comment",
+			dialect:  drivers.DriverMySQL,
+			sql:      `"a\"b" # note`,
+			expected: []TokenType{TokenString, TokenWhitespace, TokenComment},
+		},
+		{
+			name:     "Postgres placeholders, casts and dollar quotes",
+			dialect:  drivers.DriverPostgres,
+			sql:      "$1::date $$it's$$ E'\\n'",
+			expected: []TokenType{TokenPlaceholder, TokenOperator, TokenIdentifier, TokenWhitespace, TokenString, TokenWhitespace, TokenString},
+		},
+		{
+			name:     "MSSQL brackets and variables",
+			dialect:  drivers.DriverMSSQL,
+			sql:      "[my table] @p1 N'x'",
+			expected: []TokenType{TokenQuotedIdentifier, TokenWhitespace, TokenPlaceholder, TokenWhitespace, TokenString},
+		},
+		{
+			name:     "Postgres named parameters",
+			dialect:  drivers.DriverPostgres,
+			sql:      "$id ?{name} ?",
+			expected: []TokenType{TokenPlaceholder, TokenWhitespace, TokenPlaceholder, TokenWhitespace, TokenOperator},
+		},
+		{
+			name:     "SQLite placeholders",
+			dialect:  drivers.DriverSqlite,
+			sql:      "? ?2 :name $id",
+			expected: []TokenType{TokenPlaceholder, TokenWhitespace, TokenPlaceholder, TokenWhitespace, TokenPlaceholder, TokenWhitespace, TokenPlaceholder},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			types := []TokenType{}
+			for _, token := range TokenizeDialect(tc.dialect, tc.sql) {
+				types = append(types, token.Type)
+			}
+
+			if !reflect.DeepEqual(types, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, types)
+			}
+		})
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	for input, expected := range map[string]string{
+		`"a""b"`: `a"b`,
+		"`c`":    "c",
+		"[d]]e]": "d]e",
+		"e":      "e",
+	} {
+		if got := Unquote(input); got != expected {
+			t.Errorf("Unquote(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
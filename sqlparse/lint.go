@@ -0,0 +1,254 @@
+package sqlparse
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// LintRule names a check of Lint
+type LintRule string
+
+const (
+	// RuleUnfilteredChange warns on UPDATE and DELETE without WHERE
+	RuleUnfilteredChange LintRule = "unfiltered-change"
+	// RuleDestructive warns on DROP and TRUNCATE
+	RuleDestructive LintRule = "destructive"
+	// RuleLargeAlter warns on ALTER TABLE of a large table
+	RuleLargeAlter LintRule = "large-alter"
+	// RuleUnboundedSelect warns on SELECT without LIMIT from a large table
+	RuleUnboundedSelect LintRule = "unbounded-select"
+)
+
+// LintOptions turn the checks of Lint on and off
+type LintOptions struct {
+	UnfilteredChanges  bool
+	Destructive        bool
+	AlterRowThreshold  int64 // ALTER TABLE of tables with more rows warns, 0 turns it off
+	SelectRowThreshold int64 // SELECT without LIMIT from tables with more rows warns, 0 turns it off
+}
+
+// GuardOptions returns the checks of Lint turned on by the guard of the
+// config
+func GuardOptions(guard models.GuardConfig) LintOptions {
+	return LintOptions{
+		UnfilteredChanges:  guard.UnfilteredChanges,
+		Destructive:        guard.Destructive,
+		AlterRowThreshold:  guard.AlterRowThreshold,
+		SelectRowThreshold: guard.SelectRowThreshold,
+	}
+}
+
+// TableRows returns about how many rows a table has, reporting false when it
+// is not known
+type TableRows func(table string) (int64, bool)
+
+// Warning is a risky statement found by Lint
+type Warning struct {
+	Rule      LintRule
+	Statement string
+	Table     string
+	Rows      int64 // Estimated rows of the table, for the large table rules
+
+	limitAt   int    // Offset of the SQL text where a limit goes, -1 when none can
+	limitText string // Format of the limit, like " LIMIT %d"
+}
+
+// Message describes the risk of the statement
+func (warning Warning) Message() string {
+	table := warning.Table
+	if table == "" {
+		table = "the table"
+	}
+
+	switch warning.Rule {
+	case RuleUnfilteredChange:
+		keyword, _, _ := strings.Cut(warning.Statement, " ")
+		return fmt.Sprintf("%s without WHERE changes every row of %s", strings.ToUpper(keyword), table)
+	case RuleDestructive:
+		return fmt.Sprintf("%s can't be undone", truncate(warning.Statement, 60))
+	case RuleLargeAlter:
+		return fmt.Sprintf("ALTER TABLE of %s (about %d rows) may lock or rewrite it for a long time", table, warning.Rows)
+	case RuleUnboundedSelect:
+		return fmt.Sprintf("SELECT without LIMIT from %s (about %d rows) reads every row", table, warning.Rows)
+	}
+	return string(warning.Rule)
+}
+
+// Limitable reports whether WithLimit can add a limit to the statement
+func (warning Warning) Limitable() bool {
+	return warning.Rule == RuleUnboundedSelect && warning.limitAt >= 0
+}
+
+// Lint returns the warnings of the statements of a SQL text. The large table
+// rules read table sizes with rows, a table of unknown size never warning.
+func Lint(dialect, sql string, options LintOptions, rows TableRows) []Warning {
+	warnings := []Warning{}
+
+	for _, statement := range SplitStatements(dialect, sql) {
+		tokens := topLevel(statement.Tokens)
+		first := tokens[0]
+		warning := Warning{Statement: strings.Join(strings.Fields(statement.Text), " "), limitAt: -1}
+		if references := TableReferences(dialect, tokens); len(references) > 0 {
+			warning.Table = references[0].Name
+		}
+
+		switch {
+		case (first.Is("UPDATE") || first.Is("DELETE")) && options.UnfilteredChanges:
+			if !slices.ContainsFunc(tokens, func(token Token) bool { return token.Is("WHERE") }) {
+				warning.Rule = RuleUnfilteredChange
+				warnings = append(warnings, warning)
+			}
+
+		case (first.Is("DROP") || first.Is("TRUNCATE")) && options.Destructive:
+			warning.Rule, warning.Table = RuleDestructive, objectName(tokens)
+			warnings = append(warnings, warning)
+
+		case first.Is("ALTER") && len(tokens) > 1 && tokens[1].Is("TABLE") && options.AlterRowThreshold > 0 && warning.Table != "":
+			if estimate, ok := rows(warning.Table); ok && estimate > options.AlterRowThreshold {
+				warning.Rule, warning.Rows = RuleLargeAlter, estimate
+				warnings = append(warnings, warning)
+			}
+
+		case (first.Is("SELECT") || first.Is("WITH")) && options.SelectRowThreshold > 0 && statement.Kind() == StatementQuery:
+			if isBounded(tokens) {
+				continue
+			}
+
+			warning.Rule = RuleUnboundedSelect
+			for _, reference := range TableReferences(dialect, tokens) {
+				if estimate, ok := rows(reference.Name); ok && estimate > options.SelectRowThreshold && estimate > warning.Rows {
+					warning.Table, warning.Rows = reference.Name, estimate
+				}
+			}
+			if warning.Rows == 0 {
+				continue
+			}
+
+			warning.limitAt, warning.limitText = limitPosition(dialect, tokens)
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings
+}
+
+// WithLimit adds a limit to the statements of the SQL text the warnings that
+// can have one come from
+func WithLimit(sql string, warnings []Warning, limit int) string {
+	limitable := []Warning{}
+	for _, warning := range warnings {
+		if warning.Limitable() {
+			limitable = append(limitable, warning)
+		}
+	}
+
+	// From the end, so the offsets of the other statements stay right
+	slices.SortFunc(limitable, func(a, b Warning) int { return b.limitAt - a.limitAt })
+	for _, warning := range limitable {
+		sql = sql[:warning.limitAt] + fmt.Sprintf(warning.limitText, limit) + sql[warning.limitAt:]
+	}
+
+	return sql
+}
+
+// topLevel returns the tokens outside parentheses, keeping the parentheses
+func topLevel(tokens []Token) []Token {
+	top := []Token{}
+	depth := 0
+
+	for _, token := range tokens {
+		switch {
+		case token.Type == TokenPunctuation && token.Text == "(":
+			if depth == 0 {
+				top = append(top, token)
+			}
+			depth++
+		case token.Type == TokenPunctuation && token.Text == ")":
+			depth = max(depth-1, 0)
+			if depth == 0 {
+				top = append(top, token)
+			}
+		case depth == 0:
+			top = append(top, token)
+		}
+	}
+
+	return top
+}
+
+// objectName returns the name of what a statement like DROP TABLE IF EXISTS
+// name or TRUNCATE name is about: its first name past the keywords
+func objectName(tokens []Token) string {
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i].Type == TokenIdentifier || tokens[i].Type == TokenQuotedIdentifier {
+			name, _ := readName(tokens, i)
+			return name
+		}
+	}
+	return ""
+}
+
+// aggregates return one row without GROUP BY
+var aggregates = []string{"COUNT", "SUM", "AVG", "MIN", "MAX"}
+
+// isBounded reports whether a query returns a bounded number of rows: it has
+// a LIMIT, TOP or FETCH, or only aggregates without GROUP BY
+func isBounded(tokens []Token) bool {
+	grouped, aggregated := false, false
+
+	for i, token := range tokens {
+		switch {
+		case isAny(token, []string{"LIMIT", "TOP", "FETCH"}):
+			return true
+		case token.Is("GROUP"):
+			grouped = true
+		case isAny(token, aggregates) && i+1 < len(tokens) && tokens[i+1].Text == "(":
+			aggregated = true
+		}
+	}
+
+	return aggregated && !grouped
+}
+
+// limitPosition returns where a limit goes in a query and its format: TOP
+// after SELECT for SQL Server, LIMIT at the end or before a locking FOR
+// clause for the other databases
+func limitPosition(dialect string, tokens []Token) (int, string) {
+	if dialect == drivers.DriverMSSQL {
+		if !tokens[0].Is("SELECT") || slices.ContainsFunc(tokens, func(token Token) bool {
+			return isAny(token, []string{"UNION", "EXCEPT", "INTERSECT"})
+		}) {
+			return -1, ""
+		}
+
+		at := tokens[0]
+		if len(tokens) > 1 && (tokens[1].Is("DISTINCT") || tokens[1].Is("ALL")) {
+			at = tokens[1]
+		}
+		return at.End, " TOP (%d)"
+	}
+
+	afterFrom := false
+	for _, token := range tokens {
+		if token.Is("FROM") {
+			afterFrom = true
+		}
+		if afterFrom && token.Is("FOR") {
+			return token.Start, "LIMIT %d "
+		}
+	}
+
+	return tokens[len(tokens)-1].End, " LIMIT %d"
+}
+
+// truncate shortens a text to at most length runes
+func truncate(text string, length int) string {
+	if runes := []rune(text); len(runes) > length {
+		return string(runes[:length]) + "..."
+	}
+	return text
+}
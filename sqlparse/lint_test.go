@@ -0,0 +1,105 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+func TestLint(t *testing.T) {
+	sizes := map[string]int64{"orders": 2_000_000, "shop.orders": 2_000_000, "countries": 250}
+	rows := func(table string) (int64, bool) {
+		size, ok := sizes[table]
+		return size, ok
+	}
+	options := LintOptions{
+		UnfilteredChanges:  true,
+		Destructive:        true,
+		AlterRowThreshold:  1_000_000,
+		SelectRowThreshold: 100_000,
+	}
+
+	testCases := []struct {
+		name     string
+		sql      string
+		options  *LintOptions
+		expected []LintRule
+		tables   []string
+	}{
+		{"Delete without where", "DELETE FROM orders", nil, []LintRule{RuleUnfilteredChange}, []string{"orders"}},
+		{"Update with where", "UPDATE orders SET paid = true WHERE id = 1", nil, []LintRule{}, []string{}},
+		{"Where in a subquery only", "UPDATE orders SET total = (SELECT 1 FROM countries WHERE id = 1)", nil, []LintRule{RuleUnfilteredChange}, []string{"orders"}},
+		{"Drop and truncate", "DROP TABLE countries; TRUNCATE orders", nil, []LintRule{RuleDestructive, RuleDestructive}, []string{"countries", "orders"}},
+		{"Alter large table", "ALTER TABLE shop.orders ADD COLUMN note text", nil, []LintRule{RuleLargeAlter}, []string{"shop.orders"}},
+		{"Alter small table", "ALTER TABLE countries ADD COLUMN note text", nil, []LintRule{}, []string{}},
+		{"Select large table", "SELECT * FROM countries c JOIN orders o ON o.country = c.id", nil, []LintRule{RuleUnboundedSelect}, []string{"orders"}},
+		{"Select with limit", "SELECT * FROM orders LIMIT 10", nil, []LintRule{}, []string{}},
+		{"Count", "SELECT count(*) FROM orders", nil, []LintRule{}, []string{}},
+		{"Grouped count", "SELECT status, count(*) FROM orders GROUP BY status", nil, []LintRule{RuleUnboundedSelect}, []string{"orders"}},
+		{"Limit in a subquery only", "SELECT * FROM orders WHERE id IN (SELECT id FROM orders LIMIT 5)", nil, []LintRule{RuleUnboundedSelect}, []string{"orders"}},
+		{"Unknown table", "SELECT * FROM audit", nil, []LintRule{}, []string{}},
+		{"Rules off", "DELETE FROM orders; DROP TABLE orders; SELECT * FROM orders", &LintOptions{}, []LintRule{}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lintOptions := options
+			if tc.options != nil {
+				lintOptions = *tc.options
+			}
+
+			rules, tables := []LintRule{}, []string{}
+			for _, warning := range Lint(drivers.DriverPostgres, tc.sql, lintOptions, rows) {
+				rules = append(rules, warning.Rule)
+				tables = append(tables, warning.Table)
+			}
+			if !reflect.DeepEqual(rules, tc.expected) || !reflect.DeepEqual(tables, tc.tables) {
+				t.Fatalf("expected %v on %v, got %v on %v", tc.expected, tc.tables, rules, tables)
+			}
+		})
+	}
+}
+
+func TestWarningMessage(t *testing.T) {
+	warnings := Lint(drivers.DriverMySQL, "delete from orders; SELECT * FROM orders", LintOptions{UnfilteredChanges: true, SelectRowThreshold: 10}, func(string) (int64, bool) {
+		return 1200, true
+	})
+
+	expected := []string{
+		"DELETE without WHERE changes every row of orders",
+		"SELECT without LIMIT from orders (about 1200 rows) reads every row",
+	}
+	messages := []string{}
+	for _, warning := range warnings {
+		messages = append(messages, warning.Message())
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Fatalf("expected %q, got %q", expected, messages)
+	}
+}
+
+func TestWithLimit(t *testing.T) {
+	rows := func(string) (int64, bool) { return 1_000_000, true }
+	options := LintOptions{UnfilteredChanges: true, SelectRowThreshold: 1000}
+
+	testCases := []struct {
+		name     string
+		dialect  string
+		sql      string
+		expected string
+	}{
+		{"Every statement", drivers.DriverPostgres, "SELECT * FROM a; DELETE FROM b;\nSELECT * FROM c -- all", "SELECT * FROM a LIMIT 500; DELETE FROM b;\nSELECT * FROM c LIMIT 500 -- all"},
+		{"Locking clause", drivers.DriverMySQL, "SELECT * FROM a FOR UPDATE", "SELECT * FROM a LIMIT 500 FOR UPDATE"},
+		{"Top", drivers.DriverMSSQL, "SELECT DISTINCT name FROM a", "SELECT DISTINCT TOP (500) name FROM a"},
+		{"No top for a union", drivers.DriverMSSQL, "SELECT name FROM a UNION SELECT name FROM b", "SELECT name FROM a UNION SELECT name FROM b"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if sql := WithLimit(tc.sql, Lint(tc.dialect, tc.sql, options, rows), 500); sql != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, sql)
+			}
+		})
+	}
+}
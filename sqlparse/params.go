@@ -0,0 +1,164 @@
+package sqlparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"sqlcmder/drivers"
+)
+
+// Parameter is a named placeholder of a query like :id, $id or ?{id}
+type Parameter struct {
+	Name string
+	Type string // type hint like date in :since::date, empty when there is none
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var dateLayouts = []string{"2006-01-02"}
+
+var timestampLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+var timeLayouts = []string{"15:04:05", "15:04"}
+
+// parameterName returns the name of a named placeholder. Positional
+// placeholders and variables like @name are not named parameters.
+func parameterName(token Token) (string, bool) {
+	if token.Type != TokenPlaceholder || len(token.Text) < 2 {
+		return "", false
+	}
+
+	switch {
+	case strings.HasPrefix(token.Text, "?{"):
+		return token.Text[2 : len(token.Text)-1], true
+	case token.Text[0] == ':', token.Text[0] == '$' && isIdentifierStart(rune(token.Text[1])):
+		return token.Text[1:], true
+	}
+
+	return "", false
+}
+
+// typeHint returns the type of a cast like ::date following the token at
+// index i and the number of tokens it spans
+func typeHint(tokens []Token, i int) (string, int) {
+	if i+2 < len(tokens) && tokens[i+1].Text == "::" && tokens[i+2].IsWord() {
+		return strings.ToLower(tokens[i+2].Text), 2
+	}
+	return "", 0
+}
+
+// Parameters returns the named parameters of a query in the order they first
+// appear. Placeholders inside strings and comments are ignored.
+func Parameters(dialect, sql string) []Parameter {
+	parameters := []Parameter{}
+	seen := map[string]int{}
+
+	tokens := TokenizeDialect(dialect, sql)
+	for i, token := range tokens {
+		name, ok := parameterName(token)
+		if !ok {
+			continue
+		}
+
+		hint, _ := typeHint(tokens, i)
+
+		if index, ok := seen[name]; ok {
+			if parameters[index].Type == "" {
+				parameters[index].Type = hint
+			}
+			continue
+		}
+
+		seen[name] = len(parameters)
+		parameters = append(parameters, Parameter{Name: name, Type: hint})
+	}
+
+	return parameters
+}
+
+func parseTime(value string, layouts []string) (string, error) {
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("expected a value like %s", layouts[0])
+}
+
+// ConvertParameter validates a value against the type hint of its parameter
+// and returns the value to bind
+func ConvertParameter(value, hint string) (any, error) {
+	value = strings.TrimSpace(value)
+
+	switch hint {
+	case "":
+		return value, nil
+	case "int", "integer", "int2", "int4", "int8", "smallint", "bigint", "tinyint":
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return number, nil
+	case "float", "float4", "float8", "real", "double", "numeric", "decimal", "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return number, nil
+	case "bool", "boolean", "bit":
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return boolean, nil
+	case "date":
+		return parseTime(value, dateLayouts)
+	case "timestamp", "timestamptz", "datetime", "datetime2":
+		return parseTime(value, timestampLayouts)
+	case "time":
+		return parseTime(value, timeLayouts)
+	case "uuid", "uniqueidentifier":
+		if !uuidPattern.MatchString(value) {
+			return nil, fmt.Errorf("%q is not a UUID", value)
+		}
+		return value, nil
+	}
+
+	return value, nil
+}
+
+// BindParameters replaces the named parameters of a query with the
+// placeholders of a driver and returns the arguments to execute it with.
+// Type hints are kept as casts for Postgres and removed for other dialects.
+func BindParameters(dialect, sql string, values map[string]any, placeholder func(index int) string) (string, []any, error) {
+	var builder strings.Builder
+	args := []any{}
+
+	tokens := TokenizeDialect(dialect, sql)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		name, ok := parameterName(token)
+		if !ok {
+			builder.WriteString(token.Text)
+			continue
+		}
+
+		value, ok := values[name]
+		if !ok {
+			return "", nil, fmt.Errorf("no value for parameter %s", name)
+		}
+
+		args = append(args, value)
+		builder.WriteString(placeholder(len(args)))
+
+		if _, length := typeHint(tokens, i); length > 0 && dialect != drivers.DriverPostgres {
+			i += length
+		}
+	}
+
+	return builder.String(), args, nil
+}
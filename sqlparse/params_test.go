@@ -0,0 +1,135 @@
+package sqlparse
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+func TestParameters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  string
+		sql      string
+		expected []Parameter
+	}{
+		{
+			name:     "All syntaxes",
+			dialect:  drivers.DriverMySQL,
+			sql:      "SELECT * FROM t WHERE a = :a AND b = $b AND c = ?{c} AND d = ?",
+			expected: []Parameter{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		},
+		{
+			name:     "Type hints and repeats",
+			dialect:  drivers.DriverPostgres,
+			sql:      "SELECT * FROM t WHERE created_at >= :since::date AND id = $1 OR updated_at >= :since AND x = $id",
+			expected: []Parameter{{Name: "since", Type: "date"}, {Name: "id"}},
+		},
+		{
+			name:     "Strings, comments and variables",
+			dialect:  drivers.DriverMSSQL,
+			sql:      "SELECT ':a', @b -- :c\nWHERE x = :d",
+			expected: []Parameter{{Name: "d"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parameters := Parameters(tc.dialect, tc.sql)
+			if !reflect.DeepEqual(parameters, tc.expected) {
+				t.Fatalf("expected %+v, got %+v", tc.expected, parameters)
+			}
+		})
+	}
+}
+
+func TestConvertParameter(t *testing.T) {
+	testCases := []struct {
+		value    string
+		hint     string
+		expected any
+		fails    bool
+	}{
+		{value: "abc", hint: "", expected: "abc"},
+		{value: " 42 ", hint: "int", expected: int64(42)},
+		{value: "4.2", hint: "int", fails: true},
+		{value: "4.2", hint: "numeric", expected: 4.2},
+		{value: "true", hint: "boolean", expected: true},
+		{value: "2024-02-30", hint: "date", fails: true},
+		{value: "2024-02-01", hint: "date", expected: "2024-02-01"},
+		{value: "2024-02-01 10:30:00", hint: "timestamp", expected: "2024-02-01 10:30:00"},
+		{value: "10:30", hint: "time", expected: "10:30"},
+		{value: "not-a-uuid", hint: "uuid", fails: true},
+		{value: "x", hint: "text", expected: "x"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.hint+" "+tc.value, func(t *testing.T) {
+			value, err := ConvertParameter(tc.value, tc.hint)
+			if tc.fails {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value != tc.expected {
+				t.Fatalf("expected %#v, got %#v", tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestBindParameters(t *testing.T) {
+	values := map[string]any{"since": "2024-01-01", "id": int64(7)}
+
+	testCases := []struct {
+		name        string
+		dialect     string
+		placeholder func(int) string
+		sql         string
+		expected    string
+		args        []any
+	}{
+		{
+			name:        "Postgres keeps casts",
+			dialect:     drivers.DriverPostgres,
+			placeholder: func(index int) string { return fmt.Sprintf("$%d", index) },
+			sql:         "SELECT * FROM t WHERE d >= :since::date AND id = :id AND ':id' <> ''",
+			expected:    "SELECT * FROM t WHERE d >= $1::date AND id = $2 AND ':id' <> ''",
+			args:        []any{"2024-01-01", int64(7)},
+		},
+		{
+			name:        "MySQL drops type hints and repeats values",
+			dialect:     drivers.DriverMySQL,
+			placeholder: func(int) string { return "?" },
+			sql:         "SELECT * FROM t WHERE d >= :since::date OR id = ?{id} OR parent = :id",
+			expected:    "SELECT * FROM t WHERE d >= ? OR id = ? OR parent = ?",
+			args:        []any{"2024-01-01", int64(7), int64(7)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args, err := BindParameters(tc.dialect, tc.sql, values, tc.placeholder)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if query != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, query)
+			}
+			if !reflect.DeepEqual(args, tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args)
+			}
+		})
+	}
+
+	if _, _, err := BindParameters("", "SELECT :missing", values, func(int) string { return "?" }); err == nil {
+		t.Fatal("expected an error for a parameter without a value")
+	}
+}
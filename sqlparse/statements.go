@@ -0,0 +1,199 @@
+package sqlparse
+
+import "strings"
+
+// StatementKind tells what a statement does to a database
+type StatementKind int
+
+const (
+	// StatementQuery only reads, like SELECT, SHOW or EXPLAIN
+	StatementQuery StatementKind = iota
+	// StatementDML changes rows, like INSERT, UPDATE or DELETE
+	StatementDML
+	// StatementDDL changes the schema or privileges, like CREATE or GRANT
+	StatementDDL
+	// StatementOther changes the session or a transaction, like SET or BEGIN
+	StatementOther
+)
+
+func (kind StatementKind) String() string {
+	switch kind {
+	case StatementDML:
+		return "DML"
+	case StatementDDL:
+		return "DDL"
+	case StatementOther:
+		return "other"
+	}
+	return "query"
+}
+
+var dmlKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "REPLACE": true,
+	"UPSERT": true, "COPY": true, "LOAD": true, "CALL": true, "EXEC": true, "EXECUTE": true, "DO": true,
+}
+
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"COMMENT": true, "GRANT": true, "REVOKE": true,
+}
+
+var queryKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "EXPLAIN": true, "DESCRIBE": true,
+	"DESC": true, "VALUES": true, "TABLE": true, "PRAGMA": true,
+}
+
+// Statement is a statement of a SQL text, without its semicolon
+type Statement struct {
+	Text   string
+	Tokens []Token // Significant tokens only
+}
+
+// SplitStatements splits a SQL text on the semicolons outside strings and
+// comments. Statements with nothing but comments are left out.
+func SplitStatements(dialect, sql string) []Statement {
+	statements := []Statement{}
+	start := 0
+	current := []Token{}
+
+	flush := func(end int) {
+		if len(current) > 0 {
+			statements = append(statements, Statement{Text: strings.TrimSpace(sql[start:end]), Tokens: current})
+		}
+		current = []Token{}
+		start = end
+	}
+
+	for _, token := range TokenizeDialect(dialect, sql) {
+		if token.Type == TokenPunctuation && token.Text == ";" {
+			flush(token.Start)
+			start = token.End
+			continue
+		}
+		if token.IsSignificant() {
+			current = append(current, token)
+		}
+	}
+	flush(len(sql))
+
+	return statements
+}
+
+// Kind returns what the statement does. WITH queries holding an INSERT,
+// UPDATE or DELETE and SELECT INTO change rows, PRAGMA setting a value is
+// not a query, and EXPLAIN ANALYZE is the kind of the statement it runs.
+func (statement Statement) Kind() StatementKind {
+	return kindOf(statement.Tokens)
+}
+
+func kindOf(tokens []Token) StatementKind {
+	if len(tokens) == 0 {
+		return StatementQuery
+	}
+
+	first := strings.ToUpper(tokens[0].Text)
+	if tokens[0].Text == "(" {
+		first = "SELECT"
+	}
+
+	switch {
+	case dmlKeywords[first]:
+		return StatementDML
+	case ddlKeywords[first]:
+		return StatementDDL
+	case !queryKeywords[first]:
+		return StatementOther
+	}
+
+	if first == "EXPLAIN" {
+		if explained, analyze := explainedStatement(tokens[1:]); analyze {
+			return kindOf(explained)
+		}
+		return StatementQuery
+	}
+
+	for i, token := range tokens[1:] {
+		switch {
+		case first == "WITH" && token.IsWord() && dmlKeywords[strings.ToUpper(token.Text)]:
+			// A function like REPLACE(name, 'a', 'b') is not a statement
+			if next := i + 2; next < len(tokens) && tokens[next].Text == "(" {
+				continue
+			}
+			return StatementDML
+		case first == "SELECT" && token.Is("INTO"):
+			return StatementDML
+		case first == "PRAGMA" && token.Text == "=":
+			return StatementOther
+		}
+	}
+
+	return StatementQuery
+}
+
+// explainOptions are the words between EXPLAIN and the statement it explains
+var explainOptions = map[string]bool{
+	"ANALYZE": true, "ANALYSE": true, "VERBOSE": true, "EXTENDED": true, "PARTITIONS": true,
+	"FORMAT": true, "QUERY": true, "PLAN": true,
+}
+
+// explainedStatement returns the tokens of the statement explained by the
+// tokens following EXPLAIN, and whether EXPLAIN runs it, which it does with
+// ANALYZE
+func explainedStatement(tokens []Token) (explained []Token, analyze bool) {
+	i := 0
+
+	// EXPLAIN (ANALYZE, FORMAT JSON) of PostgreSQL
+	if i < len(tokens) && tokens[i].Text == "(" {
+		for i++; i < len(tokens) && tokens[i].Text != ")"; i++ {
+			if tokens[i].Is("ANALYZE") || tokens[i].Is("ANALYSE") {
+				off := i+1 < len(tokens) && (tokens[i+1].Is("FALSE") || tokens[i+1].Is("OFF") || tokens[i+1].Text == "0")
+				analyze = analyze || !off
+			}
+		}
+		return tokens[min(i+1, len(tokens)):], analyze
+	}
+
+	for ; i < len(tokens); i++ {
+		word := strings.ToUpper(tokens[i].Text)
+		switch {
+		case word == "ANALYZE" || word == "ANALYSE":
+			analyze = true
+		case word == "FORMAT" && i+2 < len(tokens) && tokens[i+1].Text == "=":
+			// FORMAT=JSON of MySQL
+			i += 2
+		case !tokens[i].IsWord() || !explainOptions[word]:
+			return tokens[i:], analyze
+		}
+	}
+
+	return nil, analyze
+}
+
+// Kinds returns the kind of each statement of a SQL text.
+func Kinds(dialect, sql string) []StatementKind {
+	kinds := []StatementKind{}
+	for _, statement := range SplitStatements(dialect, sql) {
+		kinds = append(kinds, statement.Kind())
+	}
+	return kinds
+}
+
+// IsReadOnly reports whether every statement of a SQL text is a query.
+func IsReadOnly(dialect, sql string) bool {
+	for _, kind := range Kinds(dialect, sql) {
+		if kind != StatementQuery {
+			return false
+		}
+	}
+	return true
+}
+
+// Changes reports whether a SQL text has a DML or DDL statement.
+func Changes(dialect, sql string) bool {
+	for _, kind := range Kinds(dialect, sql) {
+		if kind == StatementDML || kind == StatementDDL {
+			return true
+		}
+	}
+	return false
+}
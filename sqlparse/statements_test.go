@@ -0,0 +1,107 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+
+	"sqlcmder/drivers"
+)
+
+func TestSplitStatements(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "Semicolons",
+			sql:      "SELECT 1; SELECT 2 ;\n",
+			expected: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:     "Strings and comments",
+			dialect:  drivers.DriverPostgres,
+			sql:      "SELECT ';' -- ;\n; /* ; */ ; INSERT INTO t VALUES ($$;$$)",
+			expected: []string{"SELECT ';' -- ;", "INSERT INTO t VALUES ($$;$$)"},
+		},
+		{
+			name:     "Empty",
+			sql:      " ; -- comment",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			texts := []string{}
+			for _, statement := range SplitStatements(tc.dialect, tc.sql) {
+				texts = append(texts, statement.Text)
+			}
+			if !reflect.DeepEqual(texts, tc.expected) {
+				t.Fatalf("expected %q, got %q", tc.expected, texts)
+			}
+		})
+	}
+}
+
+func TestKinds(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sql      string
+		expected []StatementKind
+	}{
+		{"Select", "select * from t", []StatementKind{StatementQuery}},
+		{"Parenthesized select", "(SELECT 1) UNION (SELECT 2)", []StatementKind{StatementQuery}},
+		{"Show and explain", "SHOW TABLES; EXPLAIN SELECT 1", []StatementKind{StatementQuery, StatementQuery}},
+		{"Leading comment", "/* cleanup */ DELETE FROM t", []StatementKind{StatementDML}},
+		{"Insert update", "INSERT INTO t VALUES (1); UPDATE t SET a = 1", []StatementKind{StatementDML, StatementDML}},
+		{"With delete", "WITH old AS (DELETE FROM t RETURNING *) SELECT * FROM old", []StatementKind{StatementDML}},
+		{"With select", "WITH x AS (SELECT 1) SELECT * FROM x", []StatementKind{StatementQuery}},
+		{"Select into", "SELECT * INTO backup FROM t", []StatementKind{StatementDML}},
+		{"DDL", "CREATE TABLE t (id int); drop table t; GRANT SELECT ON t TO app", []StatementKind{StatementDDL, StatementDDL, StatementDDL}},
+		{"Pragma", "PRAGMA journal_mode; PRAGMA journal_mode = wal", []StatementKind{StatementQuery, StatementOther}},
+		{"Session", "SET search_path = app; BEGIN", []StatementKind{StatementOther, StatementOther}},
+		{"Explain analyze delete", "EXPLAIN ANALYZE DELETE FROM orders", []StatementKind{StatementDML}},
+		{"Explain analyze verbose update", "explain analyze verbose UPDATE t SET a = 1", []StatementKind{StatementDML}},
+		{"Explain options analyze", "EXPLAIN (ANALYZE, FORMAT JSON) INSERT INTO t VALUES (1)", []StatementKind{StatementDML}},
+		{"Explain options without analyze", "EXPLAIN (ANALYZE false, COSTS) DELETE FROM t; EXPLAIN (VERBOSE) DELETE FROM t", []StatementKind{StatementQuery, StatementQuery}},
+		{"Explain delete", "EXPLAIN DELETE FROM orders", []StatementKind{StatementQuery}},
+		{"Explain format", "EXPLAIN FORMAT=JSON DELETE FROM t; EXPLAIN ANALYZE FORMAT=TREE UPDATE t SET a = 1", []StatementKind{StatementQuery, StatementDML}},
+		{"Explain analyze select", "EXPLAIN ANALYZE SELECT * FROM orders", []StatementKind{StatementQuery}},
+		{"With replace function", "WITH x AS (SELECT REPLACE(name, 'a', 'b') AS name FROM t) SELECT * FROM x", []StatementKind{StatementQuery}},
+		{"With insert", "WITH x AS (SELECT 1 AS id) INSERT INTO t SELECT id FROM x", []StatementKind{StatementDML}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if kinds := Kinds(drivers.DriverPostgres, tc.sql); !reflect.DeepEqual(kinds, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, kinds)
+			}
+		})
+	}
+}
+
+func TestChangesAndIsReadOnly(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		changes  bool
+		readOnly bool
+	}{
+		{"SELECT 1", false, true},
+		{"SELECT 1; UPDATE t SET a = 1", true, false},
+		{"SET work_mem = '1GB'", false, false},
+		{"TRUNCATE t", true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.sql, func(t *testing.T) {
+			if changes := Changes("", tc.sql); changes != tc.changes {
+				t.Fatalf("Changes() = %t, expected %t", changes, tc.changes)
+			}
+			if readOnly := IsReadOnly("", tc.sql); readOnly != tc.readOnly {
+				t.Fatalf("IsReadOnly() = %t, expected %t", readOnly, tc.readOnly)
+			}
+		})
+	}
+}
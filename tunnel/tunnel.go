@@ -0,0 +1,348 @@
+// Package tunnel forwards database connections through SSH tunnels.
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"sqlcmder/data/secrets"
+	"sqlcmder/logger"
+	"sqlcmder/models"
+)
+
+const (
+	defaultSSHPort = "22"
+	dialTimeout    = 15 * time.Second
+)
+
+// Tunnel listens on a local port and forwards every connection to a remote
+// address through an SSH connection, itself possibly going through a jump
+// host.
+type Tunnel struct {
+	listener net.Listener
+	clients  []*ssh.Client // The jump host client comes first
+	agent    net.Conn      // Connection to the SSH agent, when it authenticates
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Open connects to the SSH host of the settings and starts forwarding a
+// local port to the remote address, a host:port as seen from the SSH host.
+// The passphrase of an encrypted key file is asked for with prompt.
+func Open(settings models.SSHTunnel, remoteAddress string, prompt secrets.Prompt) (*Tunnel, error) {
+	if settings.Host == "" {
+		return nil, errors.New("no SSH host set")
+	}
+
+	// The key is read once, so its passphrase is asked for once when the
+	// jump host authenticates with it too
+	auth, agentConn, err := authMethod(settings.KeyFile, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tunnel{conns: map[net.Conn]struct{}{}, agent: agentConn}
+
+	config, err := clientConfig(settings, settings.User, auth)
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	address := net.JoinHostPort(settings.Host, withDefault(settings.Port, defaultSSHPort))
+
+	var client *ssh.Client
+	if settings.JumpHost == "" {
+		client, err = ssh.Dial("tcp", address, config)
+		if err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to connect to SSH host %s: %w", address, err)
+		}
+	} else {
+		jumpUser, jumpAddress := parseHost(settings.JumpHost, settings.User)
+
+		jumpConfig, err := clientConfig(settings, jumpUser, auth)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+
+		jumpClient, err := ssh.Dial("tcp", jumpAddress, jumpConfig)
+		if err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to connect to SSH jump host %s: %w", jumpAddress, err)
+		}
+		t.clients = append(t.clients, jumpClient)
+
+		client, err = dialThrough(jumpClient, address, config)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+	}
+	t.clients = append(t.clients, client)
+
+	t.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("failed to listen for the SSH tunnel: %w", err)
+	}
+
+	logger.Info("Opened SSH tunnel", map[string]any{"ssh": address, "jump": settings.JumpHost, "remote": remoteAddress, "local": t.LocalAddress()})
+
+	t.wg.Add(1)
+	go t.serve(remoteAddress)
+
+	return t, nil
+}
+
+// LocalAddress returns the host:port the tunnel listens on.
+func (t *Tunnel) LocalAddress() string {
+	return t.listener.Addr().String()
+}
+
+// Close stops the tunnel, closing the connections going through it.
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+
+	if t.listener != nil {
+		t.listener.Close()
+	}
+	for conn := range t.conns {
+		conn.Close()
+	}
+	t.mu.Unlock()
+
+	t.wg.Wait()
+
+	// The client through the jump host is closed before the jump host one
+	var err error
+	for i := len(t.clients) - 1; i >= 0; i-- {
+		err = errors.Join(err, t.clients[i].Close())
+	}
+	if t.agent != nil {
+		err = errors.Join(err, t.agent.Close())
+	}
+
+	logger.Info("Closed SSH tunnel", nil)
+	return err
+}
+
+// serve forwards the connections accepted on the local port until the
+// tunnel is closed
+func (t *Tunnel) serve(remoteAddress string) {
+	defer t.wg.Done()
+
+	client := t.clients[len(t.clients)-1]
+
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		remote, err := client.Dial("tcp", remoteAddress)
+		if err != nil {
+			logger.Error("Failed to forward through the SSH tunnel", map[string]any{"remote": remoteAddress, "error": err.Error()})
+			local.Close()
+			continue
+		}
+
+		if !t.track(local, remote) {
+			local.Close()
+			remote.Close()
+			return
+		}
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.forward(local, remote)
+		}()
+	}
+}
+
+func (t *Tunnel) track(conns ...net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return false
+	}
+	for _, conn := range conns {
+		t.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+// forward copies data both ways until either side is done
+func (t *Tunnel) forward(local net.Conn, remote net.Conn) {
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+
+	<-done
+	local.Close()
+	remote.Close()
+	<-done
+
+	t.mu.Lock()
+	delete(t.conns, local)
+	delete(t.conns, remote)
+	t.mu.Unlock()
+}
+
+// dialThrough opens an SSH connection to address through another one
+func dialThrough(jumpClient *ssh.Client, address string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := jumpClient.Dial("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach SSH host %s through the jump host: %w", address, err)
+	}
+
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to SSH host %s: %w", address, err)
+	}
+
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
+// clientConfig returns the SSH client settings of a tunnel for the given user
+func clientConfig(settings models.SSHTunnel, user string, auth ssh.AuthMethod) (*ssh.ClientConfig, error) {
+	hostKeyCallback, err := hostKeyCallback(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	}, nil
+}
+
+// authMethod authenticates with the key file, or with the SSH agent when
+// there is none. The connection to the agent is returned to be closed with
+// the tunnel.
+func authMethod(keyFile string, prompt secrets.Prompt) (ssh.AuthMethod, net.Conn, error) {
+	if keyFile == "" {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, nil, errors.New("no SSH key file set and no SSH agent running")
+		}
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to the SSH agent: %w", err)
+		}
+
+		return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), conn, nil
+	}
+
+	data, err := os.ReadFile(expandHome(keyFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read SSH key file: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+
+	var missingPassphrase *ssh.PassphraseMissingError
+	if errors.As(err, &missingPassphrase) {
+		if prompt == nil {
+			return nil, nil, secrets.ErrNoPrompt
+		}
+
+		passphrase, promptErr := prompt("Passphrase of " + filepath.Base(keyFile))
+		if promptErr != nil {
+			return nil, nil, promptErr
+		}
+
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse SSH key file: %w", err)
+	}
+
+	return ssh.PublicKeys(signer), nil, nil
+}
+
+// hostKeyCallback checks host keys against the known_hosts file
+func hostKeyCallback(settings models.SSHTunnel) (ssh.HostKeyCallback, error) {
+	if settings.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil // #nosec G106
+	}
+
+	file := settings.KnownHostsFile
+	if file == "" {
+		file = "~/.ssh/known_hosts"
+	}
+
+	callback, err := knownhosts.New(expandHome(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_hosts file: %w", err)
+	}
+
+	return callback, nil
+}
+
+// parseHost splits a [user@]host[:port] specification
+func parseHost(spec string, defaultUser string) (string, string) {
+	user := defaultUser
+	if u, host, found := strings.Cut(spec, "@"); found {
+		user, spec = u, host
+	}
+
+	if _, _, err := net.SplitHostPort(spec); err != nil {
+		spec = net.JoinHostPort(strings.Trim(spec, "[]"), defaultSSHPort)
+	}
+
+	return user, spec
+}
+
+func withDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,335 @@
+package tunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"sqlcmder/models"
+)
+
+// testServer is an in-process SSH server forwarding direct-tcpip channels,
+// which is what ssh -L and jump hosts rely on
+type testServer struct {
+	listener net.Listener
+	hostKey  ssh.Signer
+	clients  chan string
+}
+
+func newTestServer(t *testing.T, authorized ssh.PublicKey) *testServer {
+	t.Helper()
+
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey() error = %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testServer{listener: listener, hostKey: hostKey, clients: make(chan string, 10)}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(conn, config)
+		}
+	}()
+
+	return server
+}
+
+func (s *testServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer serverConn.Close()
+
+	s.clients <- serverConn.User()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.FormatUint(uint64(payload.Port), 10)))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go io.Copy(target, channel)
+			io.Copy(channel, target)
+		}()
+	}
+}
+
+func (s *testServer) address() string {
+	return s.listener.Addr().String()
+}
+
+// startEchoServer starts a TCP server standing for the database
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// writeClientKey writes a private key file and returns its public key
+func writeClientKey(t *testing.T, dir string) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey() error = %v", err)
+	}
+
+	return path, signer.PublicKey()
+}
+
+func writeKnownHosts(t *testing.T, dir string, servers ...*testServer) string {
+	t.Helper()
+
+	lines := []string{}
+	for _, server := range servers {
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(server.address())}, server.hostKey.PublicKey()))
+	}
+
+	path := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func assertEcho(t *testing.T, address string) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatalf("ReadFull() error = %v", err)
+	}
+	if string(reply) != "ping" {
+		t.Fatalf("reply = %q, want ping", reply)
+	}
+}
+
+func splitAddress(t *testing.T, address string) (string, string) {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatalf("SplitHostPort() error = %v", err)
+	}
+	return host, port
+}
+
+func TestTunnel(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, publicKey := writeClientKey(t, dir)
+	server := newTestServer(t, publicKey)
+	database := startEchoServer(t)
+	host, port := splitAddress(t, server.address())
+
+	tunnel, err := Open(models.SSHTunnel{
+		Host:           host,
+		Port:           port,
+		User:           "deploy",
+		KeyFile:        keyFile,
+		KnownHostsFile: writeKnownHosts(t, dir, server),
+	}, database, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if user := <-server.clients; user != "deploy" {
+		t.Fatalf("SSH user = %q, want deploy", user)
+	}
+
+	assertEcho(t, tunnel.LocalAddress())
+	assertEcho(t, tunnel.LocalAddress())
+
+	if err := tunnel.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := net.Dial("tcp", tunnel.LocalAddress()); err == nil {
+		t.Fatalf("tunnel still listening after Close()")
+	}
+}
+
+func TestTunnelThroughJumpHost(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, publicKey := writeClientKey(t, dir)
+	jump := newTestServer(t, publicKey)
+	server := newTestServer(t, publicKey)
+	database := startEchoServer(t)
+	host, port := splitAddress(t, server.address())
+
+	tunnel, err := Open(models.SSHTunnel{
+		Host:           host,
+		Port:           port,
+		User:           "deploy",
+		KeyFile:        keyFile,
+		KnownHostsFile: writeKnownHosts(t, dir, jump, server),
+		JumpHost:       "bastion@" + jump.address(),
+	}, database, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer tunnel.Close()
+
+	if user := <-jump.clients; user != "bastion" {
+		t.Fatalf("jump host user = %q, want bastion", user)
+	}
+	if user := <-server.clients; user != "deploy" {
+		t.Fatalf("SSH user = %q, want deploy", user)
+	}
+
+	assertEcho(t, tunnel.LocalAddress())
+}
+
+func TestTunnelRejectsUnknownHostKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, publicKey := writeClientKey(t, dir)
+	server := newTestServer(t, publicKey)
+	other := newTestServer(t, publicKey)
+	host, port := splitAddress(t, server.address())
+
+	// The known_hosts file knows the key of another server only
+	knownHosts := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.address())}, other.hostKey.PublicKey())
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err := Open(models.SSHTunnel{
+		Host:           host,
+		Port:           port,
+		User:           "deploy",
+		KeyFile:        keyFile,
+		KnownHostsFile: knownHosts,
+	}, startEchoServer(t), nil)
+	if err == nil || !strings.Contains(err.Error(), "key mismatch") {
+		t.Fatalf("Open() error = %v, want a host key mismatch", err)
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		spec        string
+		wantUser    string
+		wantAddress string
+	}{
+		{"bastion.example.com", "deploy", "bastion.example.com:22"},
+		{"admin@bastion.example.com:2222", "admin", "bastion.example.com:2222"},
+		{"[::1]", "deploy", "[::1]:22"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			user, address := parseHost(tt.spec, "deploy")
+			if user != tt.wantUser || address != tt.wantAddress {
+				t.Fatalf("parseHost(%q) = %q, %q, want %q, %q", tt.spec, user, address, tt.wantUser, tt.wantAddress)
+			}
+		})
+	}
+}
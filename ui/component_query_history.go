@@ -2,7 +2,8 @@ package ui
 
 import (
 	"sort"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -12,6 +13,7 @@ import (
 	"sqlcmder/cli"
 	"sqlcmder/logger"
 	"sqlcmder/data/history"
+	"sqlcmder/data/queries"
 	"sqlcmder/helpers"
 	"sqlcmder/models"
 )
@@ -32,6 +34,10 @@ type QueryHistoryComponent struct {
 	onQuerySelected      func(query string)
 	onSave               func()
 	connectionIdentifier string
+	dialect              string
+	library              queries.Library
+	sortByDuration       bool
+	onRerun              func(item models.QueryHistoryItem)
 }
 
 // NewQueryHistoryComponent creates a new QueryHistoryComponent.
@@ -64,7 +70,7 @@ func NewQueryHistoryComponent(connectionIdentifier string, onSelect func(query s
 
 	qhc.table.SetSelectedStyle(tcell.StyleDefault.Background(app.Styles.SecondaryTextColor).Foreground(tview.Styles.ContrastSecondaryTextColor))
 	qhc.table.SetBorderColor(app.Styles.PrimaryTextColor)
-	qhc.table.SetTitle(" Query History (Newest First) ")
+	qhc.updateTitle()
 
 	layout := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(qhc.filterInput, 2, 0, false).
@@ -87,8 +93,11 @@ func NewQueryHistoryComponent(connectionIdentifier string, onSelect func(query s
 			app.App.SetFocus(qhc.filterInput)
 			return nil
 		case commands.Copy:
-			row, _ := qhc.table.GetSelection()
-			queryStr := qhc.table.GetCell(row, 1).GetReference().(string)
+			item, ok := qhc.selectedItem()
+			if !ok {
+				return event
+			}
+			queryStr := item.QueryText
 
 			clipboard := helpers.NewClipboard()
 
@@ -98,6 +107,43 @@ func NewQueryHistoryComponent(connectionIdentifier string, onSelect func(query s
 				return event
 			}
 			return nil
+		case commands.CopyFormatted:
+			if qhc.GetIsFiltering() {
+				return event
+			}
+
+			item, ok := qhc.selectedItem()
+			if !ok {
+				return event
+			}
+			queryStr := item.QueryText
+
+			clipboard := helpers.NewClipboard()
+
+			err := clipboard.Write(formatQuery(qhc.dialect, queryStr))
+			if err != nil {
+				logger.Info("Error copying formatted query", map[string]any{"error": err.Error()})
+				return event
+			}
+			return nil
+		case commands.Rerun:
+			if qhc.GetIsFiltering() {
+				return event
+			}
+
+			if item, ok := qhc.selectedItem(); ok && qhc.onRerun != nil {
+				qhc.onRerun(item)
+			}
+			return nil
+		case commands.SortDesc:
+			if qhc.GetIsFiltering() {
+				return event
+			}
+
+			qhc.sortByDuration = !qhc.sortByDuration
+			qhc.updateTitle()
+			qhc.filterTable(qhc.filterInput.GetText())
+			return nil
 		}
 
 		return event
@@ -132,7 +178,8 @@ func (qhc *QueryHistoryComponent) showSaveQueryModal() {
 		selectedQuery := qhc.displayedHistory[row-1].QueryText
 
 		if selectedQuery != "" {
-			saveModal := NewSaveQueryModal(qhc.connectionIdentifier, selectedQuery, func() {
+			query := models.SavedQuery{Query: selectedQuery, Scope: queries.ScopeConnection}
+			saveModal := NewSaveQueryModal(qhc.library, query, false, func() {
 				mainPages.RemovePage(pageNameSaveQuery)
 				if qhc.onSave != nil {
 					qhc.onSave()
@@ -150,22 +197,49 @@ func (qhc *QueryHistoryComponent) populateTable(items []models.QueryHistoryItem)
 	copy(qhc.displayedHistory, items)
 
 	sort.SliceStable(qhc.displayedHistory, func(i, j int) bool {
+		if qhc.sortByDuration && qhc.displayedHistory[i].Duration != qhc.displayedHistory[j].Duration {
+			return qhc.displayedHistory[i].Duration > qhc.displayedHistory[j].Duration
+		}
 		return qhc.displayedHistory[i].Timestamp.After(qhc.displayedHistory[j].Timestamp)
 	})
 
-	headers := []string{"Timestamp", "Query"}
+	headers := []string{"Timestamp", "Database", "Duration", "Rows", "Status", "Source", "Query"}
 	for c, header := range headers {
+		expansion := 0
+		if c == len(headers)-1 {
+			expansion = 1
+		}
 		qhc.table.SetCell(0, c, tview.NewTableCell(header).
 			SetSelectable(false).
 			SetTextColor(app.Styles.TertiaryTextColor).
-			SetAlign(tview.AlignCenter).SetExpansion(c))
+			SetAlign(tview.AlignCenter).SetExpansion(expansion))
 	}
 
 	for r, item := range qhc.displayedHistory {
-		qhc.table.SetCell(r+1, 0, tview.NewTableCell(item.Timestamp.Format("2006-01-02 15:04:05")).SetMaxWidth(20))
+		qhc.table.SetCell(r+1, 0, tview.NewTableCell(item.Timestamp.Local().Format("2006-01-02 15:04:05")).SetMaxWidth(20))
+		qhc.table.SetCell(r+1, 1, tview.NewTableCell(item.Database).SetMaxWidth(20))
+		qhc.table.SetCell(r+1, 2, tview.NewTableCell(formatDuration(item.Duration)).SetAlign(tview.AlignRight))
+
+		rows := ""
+		if item.Source != "" {
+			rows = strconv.FormatInt(item.Rows, 10)
+		}
+		qhc.table.SetCell(r+1, 3, tview.NewTableCell(rows).SetAlign(tview.AlignRight))
+
+		// Items recorded before the details were kept have no source
+		statusCell := tview.NewTableCell("")
+		if item.Error != "" {
+			statusCell.SetText(item.Error).SetMaxWidth(30).SetTextColor(app.Styles.ErrorColor)
+		} else if item.Source != "" {
+			statusCell.SetText("ok")
+		}
+		qhc.table.SetCell(r+1, 4, statusCell)
+
+		qhc.table.SetCell(r+1, 5, tview.NewTableCell(item.Source))
+
 		queryCell := tview.NewTableCell(item.QueryText).SetExpansion(1)
 		queryCell.SetReference(item.QueryText)
-		qhc.table.SetCell(r+1, 1, queryCell)
+		qhc.table.SetCell(r+1, 6, queryCell)
 	}
 
 	if len(qhc.displayedHistory) > 0 {
@@ -173,23 +247,61 @@ func (qhc *QueryHistoryComponent) populateTable(items []models.QueryHistoryItem)
 	}
 }
 
+// filterTable shows the items matching the filter text, which searches the
+// queries and can hold conditions like db:name, status:error or duration>1s
 func (qhc *QueryHistoryComponent) filterTable(filterText string) {
-	filterText = strings.ToLower(strings.TrimSpace(filterText))
-	if filterText == "" {
-		qhc.populateTable(qhc.originalHistory)
-		return
+	qhc.populateTable(history.Filter(qhc.originalHistory, filterText))
+}
+
+// selectedItem returns the history item of the selected row
+func (qhc *QueryHistoryComponent) selectedItem() (models.QueryHistoryItem, bool) {
+	row, _ := qhc.table.GetSelection()
+	if row <= 0 || row-1 >= len(qhc.displayedHistory) {
+		return models.QueryHistoryItem{}, false
 	}
+	return qhc.displayedHistory[row-1], true
+}
 
-	var filteredHistory []models.QueryHistoryItem
-	for _, item := range qhc.originalHistory {
-		if strings.Contains(strings.ToLower(item.QueryText), filterText) {
-			filteredHistory = append(filteredHistory, item)
-		}
+// formatDuration shows a duration in milliseconds, or microseconds when shorter
+func formatDuration(duration time.Duration) string {
+	switch {
+	case duration <= 0:
+		return ""
+	case duration < time.Millisecond:
+		return duration.Round(time.Microsecond).String()
+	}
+	return duration.Round(time.Millisecond).String()
+}
+
+func (qhc *QueryHistoryComponent) updateTitle() {
+	order := "Newest First"
+	if qhc.sortByDuration {
+		order = "Slowest First"
+	}
+
+	if qhc.state.isFiltering {
+		qhc.table.SetTitle(" Query History (Filtered, " + order + ") ")
+	} else {
+		qhc.table.SetTitle(" Query History (" + order + ") ")
 	}
-	qhc.populateTable(filteredHistory)
 }
 
 // GetPrimitive returns the primitive for this component.
+// SetLibrary sets the saved queries library queries are saved to
+func (qhc *QueryHistoryComponent) SetLibrary(library queries.Library) {
+	qhc.library = library
+}
+
+// SetOnRerun sets the function running a history item again
+func (qhc *QueryHistoryComponent) SetOnRerun(onRerun func(item models.QueryHistoryItem)) {
+	qhc.onRerun = onRerun
+}
+
+// SetDialect sets the dialect queries are formatted in when copied
+func (qhc *QueryHistoryComponent) SetDialect(dialect string) {
+	qhc.dialect = dialect
+}
+
 func (qhc *QueryHistoryComponent) GetPrimitive() tview.Primitive {
 	return qhc.Flex
 }
@@ -197,11 +309,7 @@ func (qhc *QueryHistoryComponent) GetPrimitive() tview.Primitive {
 // SetIsFiltering sets the filtering state of the component.
 func (qhc *QueryHistoryComponent) SetIsFiltering(filtering bool) {
 	qhc.state.isFiltering = filtering
-	if filtering {
-		qhc.table.SetTitle(" Query History (Filtered) ")
-	} else {
-		qhc.table.SetTitle(" Query History (Newest First) ")
-	}
+	qhc.updateTitle()
 }
 
 func (qhc *QueryHistoryComponent) LoadHistory(connectionIdentifier string) {
@@ -224,7 +332,7 @@ func (qhc *QueryHistoryComponent) LoadHistory(connectionIdentifier string) {
 		historyItems = []models.QueryHistoryItem{}
 	}
 	qhc.originalHistory = historyItems
-	qhc.populateTable(historyItems)
+	qhc.filterTable(qhc.filterInput.GetText())
 	App.ForceDraw()
 }
 
@@ -1,7 +1,11 @@
 package ui
 
 import (
+	"os"
+	"os/exec"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -22,26 +26,46 @@ type SavedQueriesState struct {
 
 // SavedQueriesComponent is a component that displays saved queries.
 type SavedQueriesComponent struct {
-	Primitive            tview.Primitive
-	state                *SavedQueriesState
-	table                *tview.Table
-	filterInput          *tview.InputField
-	originalQueries      []models.SavedQuery
-	displayedQueries     []models.SavedQuery
-	onQuerySelected      func(query string)
-	connectionIdentifier string
+	Primitive        tview.Primitive
+	state            *SavedQueriesState
+	table            *tview.Table
+	filterInput      *tview.InputField
+	originalQueries  []models.SavedQuery
+	displayedQueries []models.SavedQuery
+	onQuerySelected  func(query string)
+	library          queries.Library
+	watching         bool
+}
+
+// savedQueriesWatchInterval is how often the directories of saved queries are
+// checked for changes made outside the application
+const savedQueriesWatchInterval = 2 * time.Second
+
+// savedQueriesDirectories returns the directories of .sql files of a
+// connection: its own, then the one shared by every connection.
+func savedQueriesDirectories(connection models.Connection) []string {
+	directories := []string{}
+
+	for _, directory := range []string{connection.SavedQueriesDir, app.App.Config().SavedQueriesDir} {
+		directory = queries.ExpandDirectory(strings.TrimSpace(directory))
+		if directory != "" && !slices.Contains(directories, directory) {
+			directories = append(directories, directory)
+		}
+	}
+
+	return directories
 }
 
 // NewSavedQueriesComponent creates a new SavedQueriesComponent.
-func NewSavedQueriesComponent(connectionIdentifier string, onSelect func(query string)) *SavedQueriesComponent {
+func NewSavedQueriesComponent(library queries.Library, onSelect func(query string)) *SavedQueriesComponent {
 	state := &SavedQueriesState{
 		isFiltering: false,
 	}
 
 	sqc := &SavedQueriesComponent{
-		state:                state,
-		onQuerySelected:      onSelect,
-		connectionIdentifier: connectionIdentifier,
+		state:           state,
+		onQuerySelected: onSelect,
+		library:         library,
 	}
 
 	sqc.filterInput = tview.NewInputField().
@@ -78,12 +102,18 @@ func NewSavedQueriesComponent(connectionIdentifier string, onSelect func(query s
 		switch command {
 		case commands.Delete:
 			sqc.handleDelete()
+		case commands.Edit:
+			sqc.showEditQueryModal()
+			return nil
+		case commands.OpenInExternalEditor:
+			sqc.openInExternalEditor()
+			return nil
 		case commands.Search:
 			sqc.SetIsFiltering(true)
 			app.App.SetFocus(sqc.filterInput)
 		case commands.Copy:
 			row, _ := sqc.table.GetSelection()
-			queryStr := sqc.table.GetCell(row, 1).GetReference().(string)
+			queryStr := sqc.table.GetCell(row, 4).GetReference().(string)
 
 			clipboard := helpers.NewClipboard()
 
@@ -127,9 +157,9 @@ func (sqc *SavedQueriesComponent) handleDelete() {
 		confirmation.SetText("Are you sure you want to delete this query?")
 		confirmation.SetDoneFunc(func(_ int, buttonLabel string) {
 			if buttonLabel == "Yes" {
-				err := queries.DeleteSavedQuery(sqc.connectionIdentifier, selectedQuery.Name)
+				err := sqc.library.Delete(selectedQuery)
 				if err != nil {
-					// TODO: Show error
+					logger.Error("Failed to delete saved query", map[string]any{"error": err, "query": selectedQuery.Path()})
 					return
 				}
 				sqc.loadQueries()
@@ -141,33 +171,121 @@ func (sqc *SavedQueriesComponent) handleDelete() {
 	}
 }
 
+// showEditQueryModal edits, renames or moves the selected query
+func (sqc *SavedQueriesComponent) showEditQueryModal() {
+	row, _ := sqc.table.GetSelection()
+	if row > 0 && row-1 < len(sqc.displayedQueries) {
+		editModal := NewSaveQueryModal(sqc.library, sqc.displayedQueries[row-1], true, func() {
+			sqc.loadQueries()
+			App.SetFocus(sqc.table)
+		})
+		mainPages.AddPage(pageNameSaveQuery, editModal, true, true)
+	}
+}
+
+// openInExternalEditor edits the file of the selected query of a directory
+// with the user's editor
+func (sqc *SavedQueriesComponent) openInExternalEditor() {
+	row, _ := sqc.table.GetSelection()
+	if row <= 0 || row-1 >= len(sqc.displayedQueries) {
+		return
+	}
+
+	selectedQuery := sqc.displayedQueries[row-1]
+	if selectedQuery.File == "" {
+		logger.Info("Only queries of a directory can be opened in an external editor", map[string]any{"query": selectedQuery.Path()})
+		return
+	}
+
+	app.App.Suspend(func() {
+		cmd := exec.Command(getEditor(), selectedQuery.File)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			logger.Error("Error executing command", map[string]any{"error": err.Error(), "command": cmd.String()})
+		}
+	})
+
+	sqc.loadQueries()
+}
+
+// Watch reloads the queries whenever a file of the library's directories
+// changes, for as long as the query history modal is open.
+func (sqc *SavedQueriesComponent) Watch() {
+	if sqc.watching || len(sqc.library.Directories) == 0 {
+		return
+	}
+	sqc.watching = true
+
+	go func() {
+		signature := sqc.library.Signature()
+		ticker := time.NewTicker(savedQueriesWatchInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			done := make(chan bool)
+			App.QueueUpdate(func() {
+				done <- !mainPages.HasPage(pageNameQueryHistory)
+			})
+			if <-done {
+				App.QueueUpdate(func() {
+					sqc.watching = false
+				})
+				return
+			}
+
+			if current := sqc.library.Signature(); current != signature {
+				signature = current
+				App.QueueUpdateDraw(func() {
+					sqc.loadQueries()
+				})
+			}
+		}
+	}()
+}
+
 func (sqc *SavedQueriesComponent) loadQueries() {
-	queries, err := queries.ReadSavedQueries(sqc.connectionIdentifier)
+	savedQueries, err := sqc.library.All()
 	if err != nil {
-		// TODO: Show error
+		logger.Error("Failed to read saved queries", map[string]any{"error": err, "connection": sqc.library.ConnectionIdentifier})
 		return
 	}
-	sqc.originalQueries = queries
-	sqc.populateTable(queries)
+	sqc.originalQueries = savedQueries
+	sqc.filterTable(sqc.filterInput.GetText())
 }
 
 func (sqc *SavedQueriesComponent) populateTable(queries []models.SavedQuery) {
 	sqc.table.Clear()
 	sqc.displayedQueries = queries
 
-	headers := []string{"Name", "Query"}
+	headers := []string{"Name", "Scope", "Tags", "Updated", "Query"}
 	for c, header := range headers {
+		expansion := 0
+		if c == len(headers)-1 {
+			expansion = 1
+		}
 		sqc.table.SetCell(0, c, tview.NewTableCell(header).
 			SetSelectable(false).
 			SetTextColor(app.Styles.TertiaryTextColor).
-			SetAlign(tview.AlignCenter).SetExpansion(c))
+			SetAlign(tview.AlignCenter).SetExpansion(expansion))
 	}
 
 	for r, item := range sqc.displayedQueries {
-		sqc.table.SetCell(r+1, 0, tview.NewTableCell(item.Name).SetMaxWidth(30))
-		queryCell := tview.NewTableCell(item.Query).SetExpansion(1)
+		sqc.table.SetCell(r+1, 0, tview.NewTableCell(item.Path()).SetMaxWidth(30))
+		sqc.table.SetCell(r+1, 1, tview.NewTableCell(item.Scope))
+		sqc.table.SetCell(r+1, 2, tview.NewTableCell(strings.Join(item.Tags, ", ")).SetMaxWidth(20))
+
+		updated := ""
+		if !item.UpdatedAt.IsZero() {
+			updated = item.UpdatedAt.Format("2006-01-02 15:04")
+		}
+		sqc.table.SetCell(r+1, 3, tview.NewTableCell(updated))
+
+		queryCell := tview.NewTableCell(strings.Join(strings.Fields(item.Query), " ")).SetExpansion(1)
 		queryCell.SetReference(item.Query)
-		sqc.table.SetCell(r+1, 1, queryCell)
+		sqc.table.SetCell(r+1, 4, queryCell)
 	}
 
 	if len(sqc.displayedQueries) > 0 {
@@ -175,20 +293,10 @@ func (sqc *SavedQueriesComponent) populateTable(queries []models.SavedQuery) {
 	}
 }
 
+// filterTable shows the queries whose name, folder, tags or body match the
+// filter text, best matches first
 func (sqc *SavedQueriesComponent) filterTable(filterText string) {
-	filterText = strings.ToLower(strings.TrimSpace(filterText))
-	if filterText == "" {
-		sqc.populateTable(sqc.originalQueries)
-		return
-	}
-
-	var filteredQueries []models.SavedQuery
-	for _, item := range sqc.originalQueries {
-		if strings.Contains(strings.ToLower(item.Name), filterText) {
-			filteredQueries = append(filteredQueries, item)
-		}
-	}
-	sqc.populateTable(filteredQueries)
+	sqc.populateTable(queries.Search(sqc.originalQueries, filterText))
 }
 
 // GetPrimitive returns the primitive for this component.
@@ -29,6 +29,18 @@ const (
 	pageNameQueryHistory           = models.PageNameQueryHistory
 	pageNameSaveQuery              = models.PageNameSaveQuery
 	pageNameSavedQueryDelete       = models.PageNameSavedQueryDelete
+	pageNameGlobalHistory          = models.PageNameGlobalHistory
+	pageNameTableDiff              = models.PageNameTableDiff
+	pageNameERDiagram              = models.PageNameERDiagram
+	pageNameQueryParameters        = models.PageNameQueryParameters
+	pageNameCompletion             = models.PageNameCompletion
+	pageNameReferencingRows        = models.PageNameReferencingRows
+	pageNamePassword               = models.PageNamePassword
+	pageNameCommandLog             = models.PageNameCommandLog
+	pageNameDiagnostics            = models.PageNameDiagnostics
+	pageNameTypedConfirmation      = models.PageNameTypedConfirmation
+	pageNameImportConnections      = models.PageNameImportConnections
+	pageNameBundle                 = models.PageNameBundle
 )
 
 // Tab name aliases from models package
@@ -48,9 +60,11 @@ const (
 	eventSQLEditorQuery        = models.EventSQLEditorQuery
 	eventSQLEditorEscape       = models.EventSQLEditorEscape
 	eventResultsTableFiltering = models.EventResultsTableFiltering
+	eventResultsTableNavigate  = models.EventResultsTableNavigate
 	eventTreeSelectedDatabase  = models.EventTreeSelectedDatabase
 	eventTreeSelectedTable     = models.EventTreeSelectedTable
 	eventTreeIsFiltering       = models.EventTreeIsFiltering
+	eventTreeRefreshed         = models.EventTreeRefreshed
 )
 
 // Menu item aliases from models package
@@ -182,6 +182,11 @@ func NewTree(dbName string, dbdriver drivers.Driver) *Tree {
 			tree.ExpandAll()
 		case commands.Refresh:
 			tree.Refresh(dbName)
+		case commands.ShowERDiagram:
+			database, table := tree.currentNodeTable()
+			if database != "" {
+				mainPages.AddPage(pageNameERDiagram, NewERDiagramModal(tree.DBDriver, database, table), true, true)
+			}
 		}
 		return nil
 	})
@@ -361,6 +366,33 @@ func (tree *Tree) Publish(change models.StateChange) {
 }
 
 // Getters and Setters
+// currentNodeTable returns the database and table of the focused node, the
+// table being empty for database and schema nodes
+func (tree *Tree) currentNodeTable() (string, string) {
+	node := tree.GetCurrentNode()
+	if node == nil || node.GetReference() == nil {
+		return "", ""
+	}
+
+	split := strings.Split(node.GetReference().(string), ".")
+
+	switch node.GetLevel() {
+	case 1:
+		return split[0], ""
+	case 2:
+		if node.GetChildren() == nil && len(split) > 1 {
+			return split[0], split[1]
+		}
+		return split[0], ""
+	case 3:
+		if len(split) > 2 {
+			return split[0], split[1] + "." + split[2]
+		}
+	}
+
+	return "", ""
+}
+
 func (tree *Tree) GetSelectedDatabase() string {
 	return tree.state.selectedDatabase
 }
@@ -589,6 +621,10 @@ func (tree *Tree) Refresh(dbName string) {
 	rootNode.ClearChildren()
 	// re-add nodes
 	tree.InitializeNodes(dbName)
+	tree.Publish(models.StateChange{
+		Key:   eventTreeRefreshed,
+		Value: dbName,
+	})
 }
 
 func (tree *Tree) ClearSearch() {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -14,6 +15,7 @@ import (
 	"sqlcmder/keymap"
 	"sqlcmder/logger"
 	"sqlcmder/models"
+	"sqlcmder/sqlparse"
 )
 
 type SQLEditorState struct {
@@ -28,12 +30,17 @@ type SQLEditor struct {
 	DBDriver             drivers.Driver
 	connectionIdentifier string
 	currentDatabase      string
+	Catalog              *drivers.Catalog
+	tree                 *Tree
+	errorLine            int
 }
 
 func NewSQLEditor(connectionURL string) *SQLEditor {
 	textarea := tview.NewTextArea()
 	textarea.SetBorder(true)
 	textarea.SetTitleAlign(tview.AlignLeft)
+	// Highlighting maps tokens to lines and columns of the text
+	textarea.SetWrap(false)
 	textarea.SetPlaceholder("Input your SQL query here, press ctrl+R run, ESC return\nShortcut commands: backup <filename> | import <filename>")
 	sqlEditor := &SQLEditor{
 		TextArea: textarea,
@@ -41,7 +48,11 @@ func NewSQLEditor(connectionURL string) *SQLEditor {
 			isFocused: false,
 		},
 		ConnectionURL: connectionURL,
+		errorLine:     -1,
 	}
+	sqlEditor.SetChangedFunc(func() {
+		sqlEditor.errorLine = -1
+	})
 	sqlEditor.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		command := keymap.Keymaps.Group(keymap.EditorGroup).Resolve(event)
 
@@ -50,6 +61,14 @@ func NewSQLEditor(connectionURL string) *SQLEditor {
 			sqlEditor.Publish(eventSQLEditorQuery, sqlEditor.GetText())
 			return nil
 
+		case commands.Complete:
+			sqlEditor.showCompletions(false)
+			return nil
+
+		case commands.FormatQuery:
+			sqlEditor.SetText(formatQuery(sqlEditor.dialect(), sqlEditor.GetText()), true)
+			return nil
+
 		case commands.UnfocusEditor:
 			sqlEditor.Publish(eventSQLEditorEscape, "")
 
@@ -64,6 +83,13 @@ func NewSQLEditor(connectionURL string) *SQLEditor {
 			}
 		}
 
+		// Columns are offered as soon as a table or alias is qualified
+		if event.Key() == tcell.KeyRune && event.Rune() == '.' {
+			go App.QueueUpdateDraw(func() {
+				sqlEditor.showCompletions(true)
+			})
+		}
+
 		return event
 	})
 
@@ -105,6 +131,84 @@ func (s *SQLEditor) SetCurrentDatabase(database string) {
 	s.currentDatabase = database
 }
 
+// dialect returns the provider of the editor's driver
+func (s *SQLEditor) dialect() string {
+	if s.DBDriver == nil {
+		return ""
+	}
+	return s.DBDriver.GetProvider()
+}
+
+// completionDatabase returns the database completions are looked up in
+func (s *SQLEditor) completionDatabase() string {
+	if s.currentDatabase != "" {
+		return s.currentDatabase
+	}
+
+	if s.tree != nil && s.tree.GetSelectedDatabase() != "" {
+		return s.tree.GetSelectedDatabase()
+	}
+
+	if s.Catalog != nil {
+		return s.Catalog.Current()
+	}
+
+	return ""
+}
+
+// showCompletions opens the completion list for the word under the cursor.
+// When auto is set the list only opens for tables and columns.
+func (s *SQLEditor) showCompletions(auto bool) {
+	_, start, end := s.GetSelection()
+	if start != end {
+		return
+	}
+
+	completer := sqlparse.Completer{Dialect: s.dialect(), Database: s.completionDatabase()}
+	if s.Catalog != nil {
+		completer.Catalog = s.Catalog
+	}
+
+	wordStart, completions := completer.Complete(s.GetText(), end)
+	if len(completions) == 0 {
+		return
+	}
+
+	if auto {
+		for _, completion := range completions {
+			if completion.Kind != sqlparse.CompletionColumn && completion.Kind != sqlparse.CompletionTable {
+				return
+			}
+		}
+	}
+
+	list := NewCompletionList(completions)
+	list.OnFinish(func(completion *sqlparse.Completion) {
+		App.SetFocus(s)
+
+		if completion != nil {
+			s.Replace(wordStart, end, completion.Text)
+		}
+	}, func(event *tcell.EventKey) {
+		App.SetFocus(s)
+		s.InputHandler()(event, func(p tview.Primitive) {
+			App.SetFocus(p)
+		})
+
+		// Narrow the list while the word is being typed
+		isWordRune := event.Key() == tcell.KeyRune && (event.Rune() == '_' || unicode.IsLetter(event.Rune()) || unicode.IsDigit(event.Rune()))
+		if isWordRune || event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 {
+			s.showCompletions(false)
+		}
+	})
+
+	x, y, _, _ := s.GetInnerRect()
+	_, _, row, column := s.GetCursor()
+	offsetRow, offsetColumn := s.GetOffset()
+
+	list.Show(x+column-offsetColumn, y+row-offsetRow+1)
+}
+
 func (s *SQLEditor) Highlight() {
 	s.SetBorderColor(app.Styles.PrimaryTextColor)
 	s.SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.PrimaryTextColor))
@@ -115,6 +219,18 @@ func (s *SQLEditor) SetBlur() {
 	s.SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.UnfocusedTextColor))
 }
 
+// formatQuery formats a query with the formatter options of the config
+func formatQuery(dialect, query string) string {
+	formatter := app.App.Config().Formatter
+
+	return sqlparse.Format(dialect, query, sqlparse.FormatOptions{
+		KeywordCase: formatter.KeywordCase,
+		IndentWidth: formatter.IndentWidth,
+		CommaStyle:  formatter.CommaStyle,
+		LineWidth:   formatter.LineWidth,
+	})
+}
+
 // openExternalEditor opens the user's preferred editor to edit the query.
 // It should be called within app.Suspend() to ensure the TUI is properly restored.
 func openExternalEditor(currentText string, connectionURL string) string {
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+	"sqlcmder/sqlparse"
+)
+
+// syntaxColor returns the theme color a token is drawn with
+func syntaxColor(tokenType sqlparse.TokenType) (tcell.Color, bool) {
+	scheme := models.ActiveColorScheme
+	if scheme == nil {
+		return tcell.ColorDefault, false
+	}
+
+	switch tokenType {
+	case sqlparse.TokenKeyword:
+		return scheme.Highlight, true
+	case sqlparse.TokenString:
+		return scheme.AccentGreen, true
+	case sqlparse.TokenNumber:
+		return scheme.AccentYellow, true
+	case sqlparse.TokenComment:
+		return scheme.MutedText, true
+	case sqlparse.TokenIdentifier, sqlparse.TokenQuotedIdentifier:
+		return scheme.TextColor, true
+	case sqlparse.TokenPlaceholder:
+		return scheme.WarningColor, true
+	}
+
+	return tcell.ColorDefault, false
+}
+
+// Draw draws the text area, then colors its visible text by token type and
+// marks the line of the last error
+func (s *SQLEditor) Draw(screen tcell.Screen) {
+	s.TextArea.Draw(screen)
+
+	text := s.GetText()
+	if text == "" {
+		return
+	}
+
+	x, y, width, height := s.GetInnerRect()
+	offsetRow, offsetColumn := s.GetOffset()
+	_, textBackground, _ := s.GetTextStyle().Decompose()
+
+	tokens := sqlparse.TokenizeDialect(s.dialect(), text)
+
+	row, column, tokenIndex, position := 0, 0, 0, 0
+	state := -1
+	for remaining := text; remaining != "" && row-offsetRow < height; {
+		var cluster string
+		var boundaries int
+		cluster, remaining, boundaries, state = uniseg.StepString(remaining, state)
+
+		clusterWidth := boundaries >> uniseg.ShiftWidth
+		if cluster == "\t" {
+			clusterWidth = tview.TabSize
+		}
+
+		for tokenIndex < len(tokens)-1 && tokens[tokenIndex].End <= position {
+			tokenIndex++
+		}
+		position += len(cluster)
+
+		if cluster == "\n" || cluster == "\r\n" {
+			row++
+			column = 0
+			continue
+		}
+
+		screenY := y + row - offsetRow
+		color, ok := syntaxColor(tokens[tokenIndex].Type)
+		for i := 0; ok && i < clusterWidth && row >= offsetRow; i++ {
+			screenX := x + column + i - offsetColumn
+			if screenX < x || screenX >= x+width {
+				continue
+			}
+
+			// Leave the selection as the text area drew it
+			mainc, combc, style, _ := screen.GetContent(screenX, screenY)
+			if _, background, _ := style.Decompose(); background != textBackground {
+				continue
+			}
+			screen.SetContent(screenX, screenY, mainc, combc, style.Foreground(color))
+		}
+
+		column += clusterWidth
+	}
+
+	if s.errorLine < offsetRow || s.errorLine >= offsetRow+height || models.ActiveColorScheme == nil {
+		return
+	}
+
+	screenY := y + s.errorLine - offsetRow
+	for screenX := x; screenX < x+width; screenX++ {
+		mainc, combc, style, _ := screen.GetContent(screenX, screenY)
+		screen.SetContent(screenX, screenY, mainc, combc, style.Background(models.ActiveColorScheme.ErrorColor))
+	}
+}
+
+// MarkError moves the cursor to where the database reports the error of a
+// query and marks that line until the text changes
+func (s *SQLEditor) MarkError(err error, query string) {
+	text := s.GetText()
+
+	// The query may be a part of the editor text
+	queryStart := strings.Index(text, query)
+	if queryStart == -1 {
+		return
+	}
+
+	offset, ok := drivers.ErrorPosition(err, query)
+	if !ok {
+		return
+	}
+	offset += queryStart
+
+	App.QueueUpdateDraw(func() {
+		s.Select(offset, offset)
+		s.errorLine = strings.Count(text[:offset], "\n")
+	})
+}
@@ -1,12 +1,17 @@
 package ui
 
 import (
-	"net/url"
+	"maps"
+	"net"
+	"slices"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
 	"sqlcmder/cmd/app"
+	"sqlcmder/data/secrets"
+	"sqlcmder/db"
 	"sqlcmder/drivers"
 	"sqlcmder/helpers"
 	"sqlcmder/models"
@@ -25,8 +30,61 @@ type ConnectionForm struct {
 	UserField   *tview.InputField
 	PassField   *tview.InputField
 	DBNameField *tview.InputField
-	SSLCheckbox *tview.Checkbox
 	DSNField    *tview.InputField
+	// Where the password is read from at connect time, and the environment
+	// variable or command it is read with
+	PassSourceField *tview.DropDown
+	PassRefField    *tview.InputField
+	// SSH tunnel settings, the tunnel is not used when SSHHostField is empty
+	SSHHostField     *tview.InputField
+	SSHKeyField      *tview.InputField
+	JumpHostField    *tview.InputField
+	KnownHostsField  *tview.InputField
+	sshInsecureHosts bool
+	// TLS settings, added to the DSN at connect time
+	TLSModeField       *tview.DropDown
+	TLSCAField         *tview.InputField
+	TLSCertField       *tview.InputField
+	TLSKeyField        *tview.InputField
+	TLSServerNameField *tview.InputField
+	// Options of the driver, as name=value pairs separated by semicolons
+	OptionsField *tview.InputField
+	// Group, tags and environment of the connection in the connections list
+	GroupField       *tview.InputField
+	TagsField        *tview.InputField
+	EnvironmentField *tview.DropDown
+	color            string
+	// Opens read-only sessions and refuses changes in the UI
+	ReadOnlyField *tview.Checkbox
+}
+
+// passwordSourceNone is offered for connections without a password source,
+// which connect with the password they hold, if any
+const passwordSourceNone = "none"
+
+// passwordSources are the password sources offered by the connection form
+var passwordSources = []string{
+	models.PasswordSourceVault,
+	models.PasswordSourcePrompt,
+	models.PasswordSourceEnv,
+	models.PasswordSourceCommand,
+	passwordSourceNone,
+}
+
+// environments are the environments offered by the connection form
+var environments = []string{
+	"none",
+	models.EnvironmentDev,
+	models.EnvironmentStaging,
+	models.EnvironmentProd,
+}
+
+// tlsModes are the TLS modes offered by the connection form
+var tlsModes = []string{
+	models.TLSModeDisable,
+	models.TLSModeRequire,
+	models.TLSModeVerifyCA,
+	models.TLSModeVerifyFull,
 }
 
 func NewConnectionForm(connectionPages *models.ConnectionPages) *ConnectionForm {
@@ -39,93 +97,75 @@ func NewConnectionForm(connectionPages *models.ConnectionPages) *ConnectionForm
 	hostField := tview.NewInputField().SetLabel("Hostname").SetText("localhost").SetFieldWidth(0)
 	portField := tview.NewInputField().SetLabel("Port").SetText("5432").SetFieldWidth(0)
 	userField := tview.NewInputField().SetLabel("Username").SetText("postgres").SetFieldWidth(0)
-	passField := tview.NewInputField().SetLabel("Password").SetText("postgres").SetFieldWidth(0)
+	passField := tview.NewInputField().SetLabel("Password").SetFieldWidth(0)
 	dbNameField := tview.NewInputField().SetLabel("DB Name").SetFieldWidth(0)
-	sslCheckbox := tview.NewCheckbox().SetLabel("SSL Mode").SetChecked(false)
 	dsnField := tview.NewInputField().SetLabel("DSN").SetFieldWidth(0)
-
-	// Helper function to auto-generate DSN
-	generateAutoDSN := func() string {
-		dbType := dbTypeField.GetText()
-		hostname := hostField.GetText()
-		port := portField.GetText()
-		username := userField.GetText()
-		password := passField.GetText()
-		database := dbNameField.GetText()
-		sslMode := sslCheckbox.IsChecked()
-
-		// Build connection string
-		var connectionString string
-		switch dbType {
-		case drivers.DriverPostgres:
-			sslModeStr := "disable"
-			if sslMode {
-				sslModeStr = "require"
-			}
-			if username != "" && password != "" {
-				connectionString = "postgres://" + username + ":" + password + "@" + hostname + ":" + port + "/" + database + "?sslmode=" + sslModeStr
-			} else {
-				connectionString = "postgres://" + hostname + ":" + port + "/" + database + "?sslmode=" + sslModeStr
-			}
-		case drivers.DriverMySQL:
-			if username != "" && password != "" {
-				connectionString = "mysql://" + username + ":" + password + "@" + hostname + ":" + port + "/" + database
-			} else {
-				connectionString = "mysql://" + hostname + ":" + port + "/" + database
-			}
-		case drivers.DriverSqlite:
-			connectionString = database
-		case drivers.DriverMSSQL:
-			sslModeStr := "disable"
-			if sslMode {
-				sslModeStr = "true"
-			}
-			if username != "" && password != "" {
-				connectionString = "sqlserver://" + username + ":" + password + "@" + hostname + ":" + port + "?database=" + database + "&encrypt=" + sslModeStr
-			} else {
-				connectionString = "sqlserver://" + hostname + ":" + port + "?database=" + database + "&encrypt=" + sslModeStr
-			}
-		}
-
-		return connectionString
-	}
-
-	// Function to update DSN field with auto-generated value
-	updateDSNField := func() {
-		autoDSN := generateAutoDSN()
-		dsnField.SetText(autoDSN)
-	}
-
-	// Generate initial DSN
-	updateDSNField()
+	passSourceField := tview.NewDropDown().SetLabel("Pass From").SetOptions(passwordSources, nil).SetCurrentOption(slices.Index(passwordSources, models.PasswordSourcePrompt))
+	passRefField := tview.NewInputField().SetLabel("Env/Cmd").SetFieldWidth(0)
+	sshHostField := tview.NewInputField().SetLabel("SSH Host").SetPlaceholder("user@host:22").SetFieldWidth(0)
+	sshKeyField := tview.NewInputField().SetLabel("SSH Key").SetPlaceholder("agent").SetFieldWidth(0)
+	jumpHostField := tview.NewInputField().SetLabel("Jump Host").SetPlaceholder("user@bastion:22").SetFieldWidth(0)
+	knownHostsField := tview.NewInputField().SetLabel("KnownHost").SetPlaceholder("~/.ssh/known_hosts").SetFieldWidth(0)
+	tlsModeField := tview.NewDropDown().SetLabel("TLS Mode").SetOptions(tlsModes, nil).SetCurrentOption(0)
+	tlsCAField := tview.NewInputField().SetLabel("TLS CA").SetPlaceholder("system CAs").SetFieldWidth(0)
+	tlsCertField := tview.NewInputField().SetLabel("TLS Cert").SetFieldWidth(0)
+	tlsKeyField := tview.NewInputField().SetLabel("TLS Key").SetFieldWidth(0)
+	tlsServerNameField := tview.NewInputField().SetLabel("TLS Name").SetPlaceholder("DSN host").SetFieldWidth(0)
+	optionsField := tview.NewInputField().SetLabel("Options").SetPlaceholder("connect_timeout=10; application_name=sqlcmder").SetFieldWidth(0)
+	groupField := tview.NewInputField().SetLabel("Group").SetFieldWidth(0)
+	tagsField := tview.NewInputField().SetLabel("Tags").SetPlaceholder("billing, replica").SetFieldWidth(0)
+	environmentField := tview.NewDropDown().SetLabel("Env").SetOptions(environments, nil).SetCurrentOption(0)
+	readOnlyField := tview.NewCheckbox().SetLabel("Read Only")
 
 	// Set colors for all fields
-	for _, field := range []*tview.InputField{dbTypeField, nameField, hostField, portField, userField, passField, dbNameField, dsnField} {
+	for _, field := range []*tview.InputField{dbTypeField, nameField, hostField, portField, userField, passField, dbNameField, dsnField, passRefField, sshHostField, sshKeyField, jumpHostField, knownHostsField, tlsCAField, tlsCertField, tlsKeyField, tlsServerNameField, optionsField, groupField, tagsField} {
 		field.SetFieldBackgroundColor(app.Styles.InverseTextColor)
 		field.SetLabelColor(app.Styles.PrimaryTextColor)
 		field.SetFieldTextColor(app.Styles.ContrastSecondaryTextColor)
 	}
-	sslCheckbox.SetFieldBackgroundColor(app.Styles.InverseTextColor)
-	sslCheckbox.SetLabelColor(app.Styles.PrimaryTextColor)
+	for _, field := range []*tview.DropDown{passSourceField, tlsModeField, environmentField} {
+		field.SetFieldBackgroundColor(app.Styles.InverseTextColor)
+		field.SetLabelColor(app.Styles.PrimaryTextColor)
+		field.SetFieldTextColor(app.Styles.ContrastSecondaryTextColor)
+	}
+	readOnlyField.SetFieldBackgroundColor(app.Styles.InverseTextColor)
+	readOnlyField.SetLabelColor(app.Styles.PrimaryTextColor)
+	readOnlyField.SetFieldTextColor(app.Styles.ContrastSecondaryTextColor)
 
 	// Create left column form
 	leftForm := tview.NewForm()
 	leftForm.SetFieldBackgroundColor(app.Styles.InverseTextColor)
 	leftForm.SetLabelColor(app.Styles.PrimaryTextColor)
-	leftForm.AddFormItem(nameField)   // 1. Connection Name
-	leftForm.AddFormItem(userField)   // 2. Username
-	leftForm.AddFormItem(passField)   // 3. Password
-	leftForm.AddFormItem(dbNameField) // 4. DB Name
+	leftForm.AddFormItem(nameField)          // 1. Connection Name
+	leftForm.AddFormItem(userField)          // 2. Username
+	leftForm.AddFormItem(passField)          // 3. Password
+	leftForm.AddFormItem(dbNameField)        // 4. DB Name
+	leftForm.AddFormItem(passSourceField)    // 5. Password source
+	leftForm.AddFormItem(sshHostField)       // 6. SSH host
+	leftForm.AddFormItem(jumpHostField)      // 7. SSH jump host
+	leftForm.AddFormItem(tlsModeField)       // 8. TLS mode
+	leftForm.AddFormItem(tlsCertField)       // 9. TLS client certificate
+	leftForm.AddFormItem(tlsServerNameField) // 10. TLS server name
+	leftForm.AddFormItem(groupField)         // 11. Group
+	leftForm.AddFormItem(environmentField)   // 12. Environment
 	leftForm.SetBorder(false)
 
 	// Create right column form
 	rightForm := tview.NewForm()
 	rightForm.SetFieldBackgroundColor(app.Styles.InverseTextColor)
 	rightForm.SetLabelColor(app.Styles.PrimaryTextColor)
-	rightForm.AddFormItem(dbTypeField) // 1. Database Type
-	rightForm.AddFormItem(hostField)   // 2. Hostname
-	rightForm.AddFormItem(portField)   // 3. Port
-	rightForm.AddFormItem(dsnField)    // 4. DSN (Optional)
+	rightForm.AddFormItem(dbTypeField)     // 1. Database Type
+	rightForm.AddFormItem(hostField)       // 2. Hostname
+	rightForm.AddFormItem(portField)       // 3. Port
+	rightForm.AddFormItem(dsnField)        // 4. DSN (Optional)
+	rightForm.AddFormItem(passRefField)    // 5. Password variable or command
+	rightForm.AddFormItem(sshKeyField)     // 6. SSH key file
+	rightForm.AddFormItem(knownHostsField) // 7. SSH known_hosts file
+	rightForm.AddFormItem(tlsCAField)      // 8. TLS CA bundle
+	rightForm.AddFormItem(tlsKeyField)     // 9. TLS client key
+	rightForm.AddFormItem(optionsField)    // 10. Driver options
+	rightForm.AddFormItem(tagsField)       // 11. Tags
+	rightForm.AddFormItem(readOnlyField)   // 12. Read-only mode
 	rightForm.SetBorder(false)
 
 	// Create two-column layout
@@ -156,6 +196,13 @@ func NewConnectionForm(connectionPages *models.ConnectionPages) *ConnectionForm
 	buttonsWrapper.AddItem(connectButton, 0, 1, false)
 	buttonsWrapper.AddItem(nil, 1, 0, false)
 
+	diagnoseButton := tview.NewButton("[yellow]F4 [dark]Diagnose")
+	diagnoseButton.SetStyle(tcell.StyleDefault.Background(app.Styles.ButtonBackgroundColor))
+	diagnoseButton.SetBorder(true)
+
+	buttonsWrapper.AddItem(diagnoseButton, 0, 1, false)
+	buttonsWrapper.AddItem(nil, 1, 0, false)
+
 	cancelButton := tview.NewButton("[yellow]Esc [dark]Cancel")
 	cancelButton.SetStyle(tcell.StyleDefault.Background(app.Styles.ButtonBackgroundColor))
 	cancelButton.SetBorder(true)
@@ -187,25 +234,66 @@ func NewConnectionForm(connectionPages *models.ConnectionPages) *ConnectionForm
 		UserField:   userField,
 		PassField:   passField,
 		DBNameField: dbNameField,
-		SSLCheckbox: sslCheckbox,
 		DSNField:    dsnField,
+
+		PassSourceField: passSourceField,
+		PassRefField:    passRefField,
+		SSHHostField:    sshHostField,
+		SSHKeyField:     sshKeyField,
+		JumpHostField:   jumpHostField,
+		KnownHostsField: knownHostsField,
+
+		TLSModeField:       tlsModeField,
+		TLSCAField:         tlsCAField,
+		TLSCertField:       tlsCertField,
+		TLSKeyField:        tlsKeyField,
+		TLSServerNameField: tlsServerNameField,
+		OptionsField:       optionsField,
+
+		GroupField:       groupField,
+		TagsField:        tagsField,
+		EnvironmentField: environmentField,
+		ReadOnlyField:    readOnlyField,
+	}
+
+	// Generate initial DSN
+	if dsn, err := form.buildConnectionString(); err == nil {
+		dsnField.SetText(dsn)
 	}
 
 	// Define tab order: row by row (left to right, top to bottom)
 	tabOrder := []tview.Primitive{
-		nameField,   // Row 1 Left
-		dbTypeField, // Row 1 Right
-		userField,   // Row 2 Left
-		hostField,   // Row 2 Right
-		passField,   // Row 3 Left
-		portField,   // Row 3 Right
-		dbNameField, // Row 4 Left
-		dsnField,    // Row 4 Right
+		nameField,          // Row 1 Left
+		dbTypeField,        // Row 1 Right
+		userField,          // Row 2 Left
+		hostField,          // Row 2 Right
+		passField,          // Row 3 Left
+		portField,          // Row 3 Right
+		dbNameField,        // Row 4 Left
+		dsnField,           // Row 4 Right
+		passSourceField,    // Row 5 Left
+		passRefField,       // Row 5 Right
+		sshHostField,       // Row 6 Left
+		sshKeyField,        // Row 6 Right
+		jumpHostField,      // Row 7 Left
+		knownHostsField,    // Row 7 Right
+		tlsModeField,       // Row 8 Left
+		tlsCAField,         // Row 8 Right
+		tlsCertField,       // Row 9 Left
+		tlsKeyField,        // Row 9 Right
+		tlsServerNameField, // Row 10 Left
+		optionsField,       // Row 10 Right
+		groupField,         // Row 11 Left
+		tagsField,          // Row 11 Right
+		environmentField,   // Row 12 Left
+		readOnlyField,      // Row 12 Right
 	}
 
 	// Setup custom tab navigation
 	setupTabNavigation := func(field tview.Primitive, index int) {
-		if f, ok := field.(*tview.InputField); ok {
+		if f, ok := field.(interface {
+			SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey) *tview.Box
+		}); ok {
 			f.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 				if event.Key() == tcell.KeyTab {
 					nextIndex := (index + 1) % len(tabOrder)
@@ -264,6 +352,9 @@ func (form *ConnectionForm) inputCapture(connectionPages *models.ConnectionPages
 		} else if event.Key() == tcell.KeyF3 {
 			// F3 - Save & Test + Connect
 			go form.saveTestAndConnect()
+		} else if event.Key() == tcell.KeyF4 {
+			// F4 - Diagnose the connection step by step
+			go form.diagnose()
 		}
 		return event
 	}
@@ -271,6 +362,13 @@ func (form *ConnectionForm) inputCapture(connectionPages *models.ConnectionPages
 
 // saveAndTestConnection tests the connection first, then saves if test passes
 func (form *ConnectionForm) saveAndTestConnection() {
+	form.testAndSave()
+}
+
+// testAndSave builds the connection described by the form, tests it and
+// saves it if the test passes. It returns the saved connection with the DSN
+// holding its password.
+func (form *ConnectionForm) testAndSave() (models.Connection, string, bool) {
 	// Get form field values
 	dbType := form.DbTypeField.GetText()
 	connectionName := form.NameField.GetText()
@@ -279,18 +377,27 @@ func (form *ConnectionForm) saveAndTestConnection() {
 	username := form.UserField.GetText()
 	password := form.PassField.GetText()
 	database := form.DBNameField.GetText()
-	sslMode := form.SSLCheckbox.IsChecked()
 	dsn := form.DSNField.GetText()
 
 	if connectionName == "" {
 		form.StatusText.SetText("Connection name is required").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-		return
+		return models.Connection{}, "", false
+	}
+
+	options, err := form.options()
+	if err != nil {
+		form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return models.Connection{}, "", false
 	}
 
 	// Build connection string with priority: custom DSN > auto-generated
 	var connectionString string
 	var dsnCustom string
-	dsnAuto := form.buildConnectionString(dbType, hostname, port, username, password, database, sslMode)
+	dsnAuto, err := form.buildConnectionString()
+	if err != nil {
+		form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return models.Connection{}, "", false
+	}
 
 	// Use custom DSN if provided, otherwise use auto-generated and show hint
 	if dsn != "" {
@@ -303,15 +410,23 @@ func (form *ConnectionForm) saveAndTestConnection() {
 		App.Draw()
 	}
 
+	formConnection := models.Connection{Name: connectionName, Driver: dbType, SSH: form.sshTunnel(), TLS: form.tlsSettings()}
+	form.applyPasswordSource(&formConnection)
+
+	password, err = form.password(formConnection)
+	if err != nil {
+		form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return models.Connection{}, "", false
+	}
+
 	// Test connection first
 	form.StatusText.SetText("Testing connection...").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.WarningColor))
 	App.Draw()
 
 	// Test the connection
-	testResult := form.testConnectionSync(connectionString)
-	if !testResult {
-		form.StatusText.SetText("Connection test failed. Please check your settings.").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-		return
+	if !form.testConnectionSync(formConnection, helpers.WithPassword(connectionString, password)) {
+		form.StatusText.SetText("Connection test failed. Press F4 to see which step fails.").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return models.Connection{}, "", false
 	}
 
 	// If test passes, proceed with save
@@ -337,11 +452,21 @@ func (form *ConnectionForm) saveAndTestConnection() {
 		Username:  username,
 		Password:  password,
 		DBName:    database,
+		Options:   options,
 		DSN:       connectionString, // Keep for backward compatibility
 		DsnCustom: dsnCustom,
 		DsnAuto:   dsnAuto,
 		DsnValue:  connectionString,
 	}
+	form.applyPasswordSource(&parsedDatabaseData)
+	parsedDatabaseData.SSH = formConnection.SSH
+	parsedDatabaseData.TLS = formConnection.TLS
+	form.applyGrouping(&parsedDatabaseData)
+
+	if err := storePassword(parsedDatabaseData, password); err != nil {
+		form.StatusText.SetText("Save failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return models.Connection{}, "", false
+	}
 
 	switch form.Action {
 	case actionNewConnection:
@@ -349,17 +474,17 @@ func (form *ConnectionForm) saveAndTestConnection() {
 		err := app.App.SaveConnections(newDatabases)
 		if err != nil {
 			form.StatusText.SetText("Save failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-			return
+			return models.Connection{}, "", false
 		}
 		configPath := app.App.GetConfigFilePath()
 		form.StatusText.SetText("Saved to: " + configPath).SetTextColor(app.Styles.TertiaryTextColor)
 
 	case actionEditConnection:
 		newDatabases = make([]models.Connection, len(databases))
-		row, _ := connectionsTable.GetSelection()
+		_, index, _ := connectionsTable.SelectedConnection()
 
 		for i, database := range databases {
-			if i == row {
+			if i == index {
 				newDatabases[i] = parsedDatabaseData
 			} else {
 				newDatabases[i] = database
@@ -369,79 +494,62 @@ func (form *ConnectionForm) saveAndTestConnection() {
 		err := app.App.SaveConnections(newDatabases)
 		if err != nil {
 			form.StatusText.SetText("Save failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-			return
+			return models.Connection{}, "", false
 		}
 		configPath := app.App.GetConfigFilePath()
 		form.StatusText.SetText("Saved to: " + configPath).SetTextColor(app.Styles.TertiaryTextColor)
 	}
 
 	connectionsTable.SetConnections(newDatabases)
-	// Note: connectionPages is not available in this context,
-	// the page switching will be handled by the calling function
-}
 
-// testConnectionSync tests connection synchronously and returns true if successful
-func (form *ConnectionForm) testConnectionSync(connectionString string) bool {
-	// Parse connection string to get driver type
-	parsedURL, err := url.Parse(connectionString)
-	if err != nil {
-		return false
-	}
-
-	var driver drivers.Driver
-	switch parsedURL.Scheme {
-	case "postgres":
-		driver = &drivers.Postgres{}
-	case "mysql":
-		driver = &drivers.MySQL{}
-	case "sqlite":
-		driver = &drivers.SQLite{}
-	case "sqlserver":
-		driver = &drivers.MSSQL{}
-	default:
-		return false
-	}
+	return parsedDatabaseData, helpers.WithPassword(connectionString, password), true
+}
 
-	// Test connection
-	err = driver.Connect(connectionString)
+// testConnectionSync connects to a connection synchronously, the way it is
+// connected to, and returns true if successful
+func (form *ConnectionForm) testConnectionSync(connection models.Connection, dsn string) bool {
+	_, closeConnection, err := db.Connect(connection, dsn, openTunnel)
 	if err != nil {
 		return false
 	}
 
-	// Connection successful
+	closeConnection()
 	return true
 }
 
-func (form *ConnectionForm) testConnection(connectionString string) {
-	parsed, err := helpers.ParseConnectionString(connectionString)
-	if err != nil {
-		form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-		return
+// diagnose diagnoses the connection of the form step by step, with its DSN
+// or the one built from its fields
+func (form *ConnectionForm) diagnose() {
+	connectionString := form.DSNField.GetText()
+	if connectionString == "" {
+		var err error
+		connectionString, err = form.buildConnectionString()
+		if err != nil {
+			form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+			App.Draw()
+			return
+		}
 	}
 
-	form.StatusText.SetText("Connecting...").SetTextColor(app.Styles.TertiaryTextColor)
-
-	var db drivers.Driver
-
-	switch parsed.Driver {
-	case drivers.DriverMySQL:
-		db = &drivers.MySQL{}
-	case drivers.DriverPostgres:
-		db = &drivers.Postgres{}
-	case drivers.DriverSqlite:
-		db = &drivers.SQLite{}
-	case drivers.DriverMSSQL:
-		db = &drivers.MSSQL{}
+	connection := models.Connection{
+		Name:   form.NameField.GetText(),
+		Driver: form.DbTypeField.GetText(),
+		SSH:    form.sshTunnel(),
+		TLS:    form.tlsSettings(),
 	}
+	if connection.Name == "" {
+		connection.Name = "new connection"
+	}
+	form.applyPasswordSource(&connection)
 
-	err = db.TestConnection(connectionString)
-
+	password, err := form.password(connection)
 	if err != nil {
 		form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-	} else {
-		form.StatusText.SetText("Connection success").SetTextColor(app.Styles.TertiaryTextColor)
+		App.Draw()
+		return
 	}
-	App.ForceDraw()
+
+	diagnoseConnection(connection, helpers.WithPassword(connectionString, password))
 }
 
 // SetAction sets the action for the connection form (new or edit)
@@ -475,74 +583,100 @@ func (form *ConnectionForm) setDatabasePreset(dbType string) {
 		form.HostField.SetText("localhost")
 		form.PortField.SetText("5432")
 		form.UserField.SetText("postgres")
-		form.PassField.SetText("postgres")
+		form.PassField.SetText("")
 		form.DBNameField.SetText("")
-		form.SSLCheckbox.SetChecked(false)
+		form.setTLSSettings(nil)
 	case drivers.DriverMySQL:
 		form.HostField.SetText("localhost")
 		form.PortField.SetText("3306")
 		form.UserField.SetText("root")
-		form.PassField.SetText("root")
+		form.PassField.SetText("")
 		form.DBNameField.SetText("")
-		form.SSLCheckbox.SetChecked(false)
+		form.setTLSSettings(nil)
 	case drivers.DriverSqlite:
 		form.HostField.SetText("")
 		form.PortField.SetText("")
 		form.UserField.SetText("")
 		form.PassField.SetText("")
 		form.DBNameField.SetText("./sqlite.db")
-		form.SSLCheckbox.SetChecked(false)
+		form.setTLSSettings(nil)
 	case drivers.DriverMSSQL:
 		form.HostField.SetText("localhost")
 		form.PortField.SetText("1433")
 		form.UserField.SetText("")
 		form.PassField.SetText("")
 		form.DBNameField.SetText("")
-		form.SSLCheckbox.SetChecked(false)
+		form.setTLSSettings(nil)
 	}
 
+	// Options are specific to a driver
+	form.OptionsField.SetText("")
+
 	form.StatusText.SetText("Preset: " + dbType + " | Use Tab to navigate between fields").SetTextColor(app.Styles.TertiaryTextColor)
 }
 
-// buildConnectionString constructs a database connection string from individual components
-func (form *ConnectionForm) buildConnectionString(dbType, hostname, port, username, password, database string, sslMode bool) string {
-	var connectionString string
+// buildConnectionString builds the DSN of the connection described by the
+// form fields. Without TLS settings, TLS is turned off unless an option
+// turns it on.
+func (form *ConnectionForm) buildConnectionString() (string, error) {
+	options, err := form.options()
+	if err != nil {
+		return "", err
+	}
+
+	dbType := form.DbTypeField.GetText()
+	withTLS := form.tlsSettings() != nil
+	options = maps.Clone(options)
 
 	switch dbType {
 	case drivers.DriverPostgres:
-		sslModeStr := "disable"
-		if sslMode {
-			sslModeStr = "require"
+		if _, ok := options["sslmode"]; !ok {
+			options = withOption(options, "sslmode", tlsOption(withTLS, "require", "disable"))
 		}
-		if username != "" && password != "" {
-			connectionString = "postgres://" + username + ":" + password + "@" + hostname + ":" + port + "/" + database + "?sslmode=" + sslModeStr
-		} else {
-			connectionString = "postgres://" + hostname + ":" + port + "/" + database + "?sslmode=" + sslModeStr
+	case drivers.DriverMSSQL:
+		if _, ok := options["encrypt"]; !ok {
+			options = withOption(options, "encrypt", tlsOption(withTLS, "true", "disable"))
 		}
+	}
 
-	case drivers.DriverMySQL:
-		if username != "" && password != "" {
-			connectionString = "mysql://" + username + ":" + password + "@" + hostname + ":" + port + "/" + database
-		} else {
-			connectionString = "mysql://" + hostname + ":" + port + "/" + database
-		}
+	return drivers.BuildDSN(models.Connection{
+		Driver:   dbType,
+		Hostname: form.HostField.GetText(),
+		Port:     form.PortField.GetText(),
+		Username: form.UserField.GetText(),
+		Password: form.PassField.GetText(),
+		DBName:   form.DBNameField.GetText(),
+		Options:  options,
+	})
+}
 
-	case drivers.DriverSqlite:
-		connectionString = database // SQLite just needs the database file path
+// options returns the driver options typed in the form
+func (form *ConnectionForm) options() (map[string]string, error) {
+	options, err := drivers.ParseOptions(form.OptionsField.GetText())
+	if err != nil {
+		return nil, err
+	}
 
-	case drivers.DriverMSSQL:
-		sslModeStr := "disable"
-		if sslMode {
-			sslModeStr = "true"
-		}
-		if username != "" && password != "" {
-			connectionString = "sqlserver://" + username + ":" + password + "@" + hostname + ":" + port + "?database=" + database + "&encrypt=" + sslModeStr
-		} else {
-			connectionString = "sqlserver://" + hostname + ":" + port + "?database=" + database + "&encrypt=" + sslModeStr
-		}
+	if err := drivers.ValidateOptions(form.DbTypeField.GetText(), options); err != nil {
+		return nil, err
 	}
 
-	return connectionString
+	return options, nil
+}
+
+func withOption(options map[string]string, name string, value string) map[string]string {
+	if options == nil {
+		options = map[string]string{}
+	}
+	options[name] = value
+	return options
+}
+
+func tlsOption(withTLS bool, enabled string, disabled string) string {
+	if withTLS {
+		return enabled
+	}
+	return disabled
 }
 
 // getOrAutoGenerateDSN returns the DSN from field or auto-generates it if empty
@@ -552,15 +686,12 @@ func (form *ConnectionForm) getOrAutoGenerateDSN() string {
 
 	// If DSN is empty, auto-generate it and show hint
 	if dsn == "" {
-		dbType := form.DbTypeField.GetText()
-		hostname := form.HostField.GetText()
-		port := form.PortField.GetText()
-		username := form.UserField.GetText()
-		password := form.PassField.GetText()
-		database := form.DBNameField.GetText()
-		sslMode := form.SSLCheckbox.IsChecked()
-
-		dsn = form.buildConnectionString(dbType, hostname, port, username, password, database, sslMode)
+		var err error
+		dsn, err = form.buildConnectionString()
+		if err != nil {
+			form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+			return ""
+		}
 		form.StatusText.SetText("[green]DSN: " + dsn).SetDynamicColors(true)
 	}
 
@@ -569,15 +700,11 @@ func (form *ConnectionForm) getOrAutoGenerateDSN() string {
 
 // autoGenerateDSN generates DSN automatically and updates the DSN field
 func (form *ConnectionForm) autoGenerateDSN() {
-	dbType := form.DbTypeField.GetText()
-	hostname := form.HostField.GetText()
-	port := form.PortField.GetText()
-	username := form.UserField.GetText()
-	password := form.PassField.GetText()
-	database := form.DBNameField.GetText()
-	sslMode := form.SSLCheckbox.IsChecked()
-
-	autoDSN := form.buildConnectionString(dbType, hostname, port, username, password, database, sslMode)
+	autoDSN, err := form.buildConnectionString()
+	if err != nil {
+		form.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return
+	}
 	form.DSNField.SetText(autoDSN)
 
 	// Show status message with DSN value - consistent with getOrAutoGenerateDSN style
@@ -586,150 +713,218 @@ func (form *ConnectionForm) autoGenerateDSN() {
 
 // saveTestAndConnect tests the connection, saves it, and then connects to the database
 func (form *ConnectionForm) saveTestAndConnect() {
-	// Get form field values
-	dbType := form.DbTypeField.GetText()
-	connectionName := form.NameField.GetText()
-	hostname := form.HostField.GetText()
-	port := form.PortField.GetText()
-	username := form.UserField.GetText()
-	password := form.PassField.GetText()
-	database := form.DBNameField.GetText()
-	sslMode := form.SSLCheckbox.IsChecked()
-	dsn := form.DSNField.GetText()
-
-	if connectionName == "" {
-		form.StatusText.SetText("Connection name is required").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+	parsedDatabaseData, dsn, ok := form.testAndSave()
+	if !ok {
 		return
 	}
 
-	// Build connection string with priority: custom DSN > auto-generated
-	var connectionString string
-	var dsnCustom string
-	dsnAuto := form.buildConnectionString(dbType, hostname, port, username, password, database, sslMode)
+	// Now connect to the database
+	form.StatusText.SetText("Connecting to database...").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.WarningColor))
+	App.Draw()
 
-	// Use custom DSN if provided, otherwise use auto-generated and show hint
-	if dsn != "" {
-		dsnCustom = dsn
-		connectionString = dsn
-	} else {
-		connectionString = dsnAuto
-		// Show hint that DSN was auto-generated
-		form.StatusText.SetText("[green]DSN: " + dsnAuto).SetDynamicColors(true)
-		App.Draw()
+	dbDriver, closeConnection, err := db.Connect(parsedDatabaseData, dsn, openTunnel)
+	if err != nil {
+		form.StatusText.SetText("Connection failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return
 	}
 
-	// Test connection first
-	form.StatusText.SetText("Testing connection...").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.WarningColor))
+	// Success - navigate to home page
+	form.StatusText.SetText("Connected successfully!").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.SuccessColor))
 	App.Draw()
 
-	// Test the connection
-	testResult := form.testConnectionSync(connectionString)
-	if !testResult {
-		form.StatusText.SetText("Connection test failed. Please check your settings.").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-		return
-	}
+	// Create home page and navigate to it
+	newHome := NewHomePage(parsedDatabaseData, dbDriver)
+	newHome.Tree.SetCurrentNode(newHome.Tree.GetRoot())
+	newHome.Tree.Wrapper.SetTitle(parsedDatabaseData.Name)
+	newHome.closers = append(newHome.closers, closeConnection)
 
-	// If test passes, proceed with save
-	form.StatusText.SetText("Connection test passed. Saving...").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.SuccessColor))
+	// Add page to main pages and switch to it
+	mainPages.AddAndSwitchToPage(parsedDatabaseData.Name, newHome, true)
+	homes.Store(parsedDatabaseData.Name, newHome)
+	App.QueueUpdate(connectionsTable.Refresh)
+	App.SetFocus(newHome.Tree)
 	App.Draw()
+}
 
-	// Validate connection string only if it's not empty
-	if connectionString != "" {
-		_, err := helpers.ParseConnectionString(connectionString)
-		if err != nil {
-			form.StatusText.SetText("Warning: " + err.Error() + " (saved anyway)").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.WarningColor))
-		}
+// applyPasswordSource sets the password source chosen in the form on a
+// connection
+func (form *ConnectionForm) applyPasswordSource(connection *models.Connection) {
+	_, source := form.PassSourceField.GetCurrentOption()
+	reference := form.PassRefField.GetText()
+	if source == passwordSourceNone {
+		source = ""
 	}
 
-	databases := app.App.Connections()
-	newDatabases := make([]models.Connection, len(databases))
+	connection.PasswordSource = source
+	connection.PasswordEnv = ""
+	connection.PasswordCommand = ""
 
-	parsedDatabaseData := models.Connection{
-		Name:      connectionName,
-		Driver:    dbType,
-		Hostname:  hostname,
-		Port:      port,
-		Username:  username,
-		Password:  password,
-		DBName:    database,
-		DSN:       connectionString, // Keep for backward compatibility
-		DsnCustom: dsnCustom,
-		DsnAuto:   dsnAuto,
-		DsnValue:  connectionString,
+	switch source {
+	case models.PasswordSourceEnv:
+		connection.PasswordEnv = reference
+	case models.PasswordSourceCommand:
+		connection.PasswordCommand = reference
 	}
+}
 
-	switch form.Action {
-	case actionNewConnection:
-		newDatabases = append(databases, parsedDatabaseData)
-		err := app.App.SaveConnections(newDatabases)
-		if err != nil {
-			form.StatusText.SetText("Save failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-			return
-		}
-		configPath := app.App.GetConfigFilePath()
-		form.StatusText.SetText("Saved to: " + configPath).SetTextColor(app.Styles.TertiaryTextColor)
+// setPasswordSource shows the password source of a connection in the form
+func (form *ConnectionForm) setPasswordSource(connection models.Connection) {
+	source := connection.PasswordSource
+	if source == "" {
+		source = passwordSourceNone
+	}
+	form.PassSourceField.SetCurrentOption(max(slices.Index(passwordSources, source), 0))
 
-	case actionEditConnection:
-		newDatabases = make([]models.Connection, len(databases))
-		row, _ := connectionsTable.GetSelection()
+	switch connection.PasswordSource {
+	case models.PasswordSourceEnv:
+		form.PassRefField.SetText(connection.PasswordEnv)
+	case models.PasswordSourceCommand:
+		form.PassRefField.SetText(connection.PasswordCommand)
+	default:
+		form.PassRefField.SetText("")
+	}
+}
 
-		for i, database := range databases {
-			if i == row {
-				newDatabases[i] = parsedDatabaseData
-			} else {
-				newDatabases[i] = database
-			}
+// applyGrouping sets the group, tags, environment and read-only mode of the
+// form on a connection
+func (form *ConnectionForm) applyGrouping(connection *models.Connection) {
+	connection.Group = strings.TrimSpace(form.GroupField.GetText())
+	connection.Tags = nil
+	for _, tag := range strings.Split(form.TagsField.GetText(), ",") {
+		if tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")); tag != "" {
+			connection.Tags = append(connection.Tags, tag)
 		}
+	}
 
-		err := app.App.SaveConnections(newDatabases)
-		if err != nil {
-			form.StatusText.SetText("Save failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-			return
-		}
-		configPath := app.App.GetConfigFilePath()
-		form.StatusText.SetText("Saved to: " + configPath).SetTextColor(app.Styles.TertiaryTextColor)
+	connection.Environment = ""
+	if index, environment := form.EnvironmentField.GetCurrentOption(); index > 0 {
+		connection.Environment = environment
 	}
+	connection.Color = form.color
+	connection.ReadOnly = form.ReadOnlyField.IsChecked()
+}
 
-	connectionsTable.SetConnections(newDatabases)
+// setGrouping shows the group, tags, environment and read-only mode of a
+// connection in the form
+func (form *ConnectionForm) setGrouping(connection models.Connection) {
+	form.GroupField.SetText(connection.Group)
+	form.TagsField.SetText(strings.Join(connection.Tags, ", "))
+	form.color = connection.Color
+	form.ReadOnlyField.SetChecked(connection.ReadOnly)
 
-	// Now connect to the database
-	form.StatusText.SetText("Connecting to database...").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.WarningColor))
-	App.Draw()
+	index := slices.Index(environments, connection.Environment)
+	if index < 0 && connection.IsProduction() {
+		index = slices.Index(environments, models.EnvironmentProd)
+	}
+	form.EnvironmentField.SetCurrentOption(max(index, 0))
+}
 
-	// Create database driver
-	var dbDriver drivers.Driver
-	switch dbType {
-	case drivers.DriverMySQL:
-		dbDriver = &drivers.MySQL{}
-	case drivers.DriverPostgres:
-		dbDriver = &drivers.Postgres{}
-	case drivers.DriverSqlite:
-		dbDriver = &drivers.SQLite{}
-	case drivers.DriverMSSQL:
-		dbDriver = &drivers.MSSQL{}
-	default:
-		form.StatusText.SetText("Unsupported database type: " + dbType).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-		return
+// password returns the password typed in the form. When none is typed, it
+// is read from an environment variable or command source, or asked for, so
+// the connection can be tested.
+func (form *ConnectionForm) password(connection models.Connection) (string, error) {
+	if password := form.PassField.GetText(); password != "" {
+		return password, nil
+	}
+
+	switch connection.PasswordSource {
+	case models.PasswordSourcePrompt, models.PasswordSourceEnv, models.PasswordSourceCommand:
+		return secrets.Password(connection, askPassword)
 	}
 
-	// Connect to database
-	err := dbDriver.Connect(connectionString)
+	return "", nil
+}
+
+// storePassword keeps the password of a connection in the vault when it is
+// the password source of the connection
+func storePassword(connection models.Connection, password string) error {
+	if connection.PasswordSource != models.PasswordSourceVault || password == "" {
+		return nil
+	}
+
+	vault, err := secrets.UnlockVault(askPassword)
 	if err != nil {
-		form.StatusText.SetText("Connection failed: " + err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
-		return
+		return err
 	}
 
-	// Success - navigate to home page
-	form.StatusText.SetText("Connected successfully!").SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.SuccessColor))
-	App.Draw()
+	return vault.Set(connection.Name, password)
+}
 
-	// Create home page and navigate to it
-	newHome := NewHomePage(parsedDatabaseData, dbDriver)
-	newHome.Tree.SetCurrentNode(newHome.Tree.GetRoot())
-	newHome.Tree.Wrapper.SetTitle(parsedDatabaseData.Name)
+// sshTunnel returns the SSH tunnel settings of the form, or nil when no SSH
+// host is set
+func (form *ConnectionForm) sshTunnel() *models.SSHTunnel {
+	host := strings.TrimSpace(form.SSHHostField.GetText())
+	if host == "" {
+		return nil
+	}
 
-	// Add page to main pages and switch to it
-	mainPages.AddAndSwitchToPage(parsedDatabaseData.Name, newHome, true)
-	App.SetFocus(newHome.Tree)
-	App.Draw()
+	settings := &models.SSHTunnel{
+		KeyFile:               strings.TrimSpace(form.SSHKeyField.GetText()),
+		KnownHostsFile:        strings.TrimSpace(form.KnownHostsField.GetText()),
+		JumpHost:              strings.TrimSpace(form.JumpHostField.GetText()),
+		InsecureIgnoreHostKey: form.sshInsecureHosts,
+	}
+
+	if user, rest, found := strings.Cut(host, "@"); found {
+		settings.User, host = user, rest
+	}
+
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		settings.Host, settings.Port = hostname, port
+	} else {
+		settings.Host = host
+	}
+
+	return settings
+}
+
+// setSSHTunnel shows the SSH tunnel settings of a connection in the form
+func (form *ConnectionForm) setSSHTunnel(settings *models.SSHTunnel) {
+	if settings == nil {
+		settings = &models.SSHTunnel{}
+	}
+
+	host := settings.Host
+	if host != "" && settings.Port != "" {
+		host = net.JoinHostPort(host, settings.Port)
+	}
+	if host != "" && settings.User != "" {
+		host = settings.User + "@" + host
+	}
+
+	form.SSHHostField.SetText(host)
+	form.SSHKeyField.SetText(settings.KeyFile)
+	form.JumpHostField.SetText(settings.JumpHost)
+	form.KnownHostsField.SetText(settings.KnownHostsFile)
+	form.sshInsecureHosts = settings.InsecureIgnoreHostKey
+}
+
+// tlsSettings returns the TLS settings of the form, or nil when TLS is
+// disabled
+func (form *ConnectionForm) tlsSettings() *models.TLSSettings {
+	_, mode := form.TLSModeField.GetCurrentOption()
+	if mode == "" || mode == models.TLSModeDisable {
+		return nil
+	}
+
+	return &models.TLSSettings{
+		Mode:       mode,
+		CAFile:     strings.TrimSpace(form.TLSCAField.GetText()),
+		CertFile:   strings.TrimSpace(form.TLSCertField.GetText()),
+		KeyFile:    strings.TrimSpace(form.TLSKeyField.GetText()),
+		ServerName: strings.TrimSpace(form.TLSServerNameField.GetText()),
+	}
+}
+
+// setTLSSettings shows the TLS settings of a connection in the form
+func (form *ConnectionForm) setTLSSettings(settings *models.TLSSettings) {
+	if settings == nil {
+		settings = &models.TLSSettings{}
+	}
+
+	form.TLSModeField.SetCurrentOption(max(slices.Index(tlsModes, settings.Mode), 0))
+	form.TLSCAField.SetText(settings.CAFile)
+	form.TLSCertField.SetText(settings.CertFile)
+	form.TLSKeyField.SetText(settings.KeyFile)
+	form.TLSServerNameField.SetText(settings.ServerName)
 }
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rivo/tview"
+
+	"sqlcmder/models"
+)
+
+func TestConnectionFormSSHTunnel(t *testing.T) {
+	form := &ConnectionForm{
+		SSHHostField:    tview.NewInputField(),
+		SSHKeyField:     tview.NewInputField(),
+		JumpHostField:   tview.NewInputField(),
+		KnownHostsField: tview.NewInputField(),
+	}
+
+	tests := []struct {
+		name     string
+		settings *models.SSHTunnel
+	}{
+		{"none", nil},
+		{"host only", &models.SSHTunnel{Host: "db.example.com"}},
+		{"full", &models.SSHTunnel{
+			Host:                  "db.example.com",
+			Port:                  "2222",
+			User:                  "deploy",
+			KeyFile:               "~/.ssh/id_ed25519",
+			KnownHostsFile:        "/etc/ssh/known_hosts",
+			InsecureIgnoreHostKey: true,
+			JumpHost:              "admin@bastion:22",
+		}},
+		{"ipv6", &models.SSHTunnel{Host: "::1", Port: "22"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form.setSSHTunnel(tt.settings)
+			if got := form.sshTunnel(); !reflect.DeepEqual(got, tt.settings) {
+				t.Fatalf("sshTunnel() = %+v, want %+v", got, tt.settings)
+			}
+		})
+	}
+}
+
+func TestConnectionFormTLSSettings(t *testing.T) {
+	form := &ConnectionForm{
+		TLSModeField:       tview.NewDropDown().SetOptions(tlsModes, nil),
+		TLSCAField:         tview.NewInputField(),
+		TLSCertField:       tview.NewInputField(),
+		TLSKeyField:        tview.NewInputField(),
+		TLSServerNameField: tview.NewInputField(),
+	}
+
+	tests := []struct {
+		name     string
+		settings *models.TLSSettings
+	}{
+		{"none", nil},
+		{"require", &models.TLSSettings{Mode: models.TLSModeRequire}},
+		{"full", &models.TLSSettings{
+			Mode:       models.TLSModeVerifyFull,
+			CAFile:     "/etc/ssl/db-ca.pem",
+			CertFile:   "~/.postgresql/postgresql.crt",
+			KeyFile:    "~/.postgresql/postgresql.key",
+			ServerName: "db.example.com",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form.setTLSSettings(tt.settings)
+			if got := form.tlsSettings(); !reflect.DeepEqual(got, tt.settings) {
+				t.Fatalf("tlsSettings() = %+v, want %+v", got, tt.settings)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/sqlparse"
+)
+
+const completionListMaxItems = 10
+
+// CompletionList shows the completions for the word under the editor cursor
+type CompletionList struct {
+	*tview.List
+	completions []sqlparse.Completion
+}
+
+func NewCompletionList(completions []sqlparse.Completion) *CompletionList {
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.ShowSecondaryText(false)
+	list.SetHighlightFullLine(true)
+	list.SetSelectedStyle(tcell.StyleDefault.Background(app.Styles.SecondaryTextColor).Foreground(tview.Styles.ContrastSecondaryTextColor))
+
+	for _, completion := range completions {
+		list.AddItem(fmt.Sprintf("%s [%s]%s", tview.Escape(completion.Text), app.Styles.TertiaryTextColor.Name(), completion.Kind), "", 0, nil)
+	}
+
+	return &CompletionList{List: list, completions: completions}
+}
+
+// OnFinish calls the callback with the chosen completion, or nil when the
+// list is dismissed. Any other key is handed to onKey.
+func (list *CompletionList) OnFinish(callback func(completion *sqlparse.Completion), onKey func(event *tcell.EventKey)) {
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter, tcell.KeyTab:
+			list.Hide()
+			callback(&list.completions[list.GetCurrentItem()])
+			return nil
+		case tcell.KeyEsc:
+			list.Hide()
+			callback(nil)
+			return nil
+		case tcell.KeyCtrlN:
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case tcell.KeyCtrlP:
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			return event
+		}
+
+		list.Hide()
+		onKey(event)
+		return nil
+	})
+}
+
+// Show places the list below the given screen position, keeping it on screen
+func (list *CompletionList) Show(x, y int) {
+	width := 0
+	for _, completion := range list.completions {
+		width = max(width, utf8.RuneCountInString(completion.Text)+len(completion.Kind.String())+1)
+	}
+	width = min(width+2, 60)
+	height := min(len(list.completions), completionListMaxItems) + 2
+
+	_, _, screenWidth, screenHeight := mainPages.GetRect()
+	if x+width > screenWidth {
+		x = max(screenWidth-width, 0)
+	}
+	if y+height > screenHeight {
+		y = max(y-height-1, 0)
+	}
+
+	list.SetRect(x, y, width, height)
+	mainPages.AddPage(pageNameCompletion, list, false, true)
+	App.SetFocus(list)
+}
+
+func (list *CompletionList) Hide() {
+	mainPages.RemovePage(pageNameCompletion)
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/bundle"
+	"sqlcmder/cmd/app"
+	"sqlcmder/data/queries"
+)
+
+// defaultBundleFile is the file offered to export a bundle to
+const defaultBundleFile = "sqlcmder-bundle.toml"
+
+// BundleFileModal asks for the file a bundle is exported to or imported from.
+type BundleFileModal struct {
+	tview.Primitive
+	form     *tview.Form
+	onSubmit func(path string)
+}
+
+// NewBundleFileModal creates a new BundleFileModal with the given title.
+func NewBundleFileModal(title string, onSubmit func(path string)) *BundleFileModal {
+	bfm := &BundleFileModal{onSubmit: onSubmit}
+
+	bfm.form = tview.NewForm().SetFieldStyle(
+		tcell.StyleDefault.
+			Background(app.Styles.SecondaryTextColor).
+			Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonActivatedStyle(tcell.StyleDefault.
+		Background(app.Styles.InverseTextColor).
+		Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonStyle(tcell.StyleDefault.
+		Background(app.Styles.ButtonBackgroundColor).
+		Foreground(app.Styles.PrimaryTextColor),
+	)
+
+	path := defaultBundleFile
+	if home, err := os.UserHomeDir(); err == nil {
+		path = filepath.Join(home, defaultBundleFile)
+	}
+
+	bfm.form.AddInputField("File", path, 60, nil, nil)
+	bfm.form.AddButton("OK", bfm.submit).AddButton("Cancel", bfm.close)
+
+	bfm.form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			bfm.close()
+			return nil
+		}
+
+		if event.Key() == tcell.KeyEnter {
+			if _, button := bfm.form.GetFocusedItemIndex(); button == 1 {
+				bfm.close()
+			} else {
+				bfm.submit()
+			}
+			return nil
+		}
+
+		return event
+	})
+
+	bfm.form.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft)
+
+	bfm.Primitive = tview.NewGrid().
+		SetRows(0, 7, 0).
+		SetColumns(0, 80, 0).
+		AddItem(bfm.form, 1, 1, 1, 1, 0, 0, true)
+
+	return bfm
+}
+
+func (bfm *BundleFileModal) submit() {
+	path := strings.TrimSpace(bfm.form.GetFormItem(0).(*tview.InputField).GetText())
+	if path == "" {
+		return
+	}
+
+	bfm.close()
+	bfm.onSubmit(queries.ExpandDirectory(path))
+}
+
+func (bfm *BundleFileModal) close() {
+	mainPages.RemovePage(pageNameBundle)
+}
+
+// showBundleFile asks for the file of a bundle, then hands it to onSubmit
+func showBundleFile(title string, onSubmit func(path string)) {
+	modal := NewBundleFileModal(title, onSubmit)
+	mainPages.AddPage(pageNameBundle, modal, true, true)
+	App.SetFocus(modal.form)
+}
+
+// globalQueries returns the library of the saved queries shared by every
+// connection
+func globalQueries() queries.Library {
+	return queries.NewLibrary("", "")
+}
+
+// exportBundle writes the connections, global saved queries and settings to
+// a bundle file
+func exportBundle(path string) {
+	saved, err := globalQueries().Read(queries.ScopeGlobal)
+	if err != nil {
+		showBundleError("Export failed: " + err.Error())
+		return
+	}
+
+	exported := bundle.New(app.App.Config(), app.App.Connections(), saved)
+	if err := bundle.Write(path, exported); err != nil {
+		showBundleError("Export failed: " + err.Error())
+		return
+	}
+
+	connectionSelectionPage.StatusText.SetText(fmt.Sprintf("Exported %d connections and %d saved queries to %s", len(exported.Connections), len(exported.Queries), path)).SetTextColor(app.Styles.TertiaryTextColor)
+}
+
+// importBundle reads a bundle file and asks what to do with the connections
+// and saved queries named like existing ones before merging it
+func importBundle(path string) {
+	imported, err := bundle.Read(path)
+	if err != nil {
+		showBundleError("Import failed: " + err.Error())
+		return
+	}
+
+	library := globalQueries()
+	saved, err := library.Read(queries.ScopeGlobal)
+	if err != nil {
+		showBundleError("Import failed: " + err.Error())
+		return
+	}
+
+	current := bundle.Bundle{
+		AppConfig:   app.App.Config(),
+		Connections: app.App.Connections(),
+		Queries:     saved,
+	}
+
+	text := fmt.Sprintf("%s holds %d connections and %d saved queries, %d named like existing ones.",
+		filepath.Base(path), len(imported.Connections), len(imported.Queries), bundle.Conflicts(current, imported))
+	if imported.AppConfig != nil {
+		text += "\n\nReplace also replaces the settings."
+	}
+
+	resolutions := map[string]bundle.Resolution{
+		"Replace":   bundle.Replace,
+		"Keep both": bundle.KeepBoth,
+		"Keep mine": bundle.KeepExisting,
+	}
+
+	confirmationModal := NewConfirmationModal(text)
+	confirmationModal.ClearButtons()
+	confirmationModal.AddButtons([]string{"Replace", "Keep both", "Keep mine", "Cancel"})
+	confirmationModal.SetDoneFunc(func(_ int, buttonLabel string) {
+		mainPages.RemovePage(pageNameConfirmation)
+
+		resolution, ok := resolutions[buttonLabel]
+		if !ok {
+			return
+		}
+
+		merged, result := bundle.Merge(current, imported, resolution)
+		if err := applyBundle(library, merged); err != nil {
+			showBundleError("Import failed: " + err.Error())
+			return
+		}
+
+		connectionSelectionPage.StatusText.SetText("Imported " + filepath.Base(path) + ": " + result.String()).SetTextColor(app.Styles.TertiaryTextColor)
+	})
+
+	mainPages.AddPage(pageNameConfirmation, confirmationModal, true, true)
+	App.SetFocus(confirmationModal)
+}
+
+// applyBundle saves the merged connections, global saved queries and
+// settings
+func applyBundle(library queries.Library, merged bundle.Bundle) error {
+	if err := library.Replace(queries.ScopeGlobal, merged.Queries); err != nil {
+		return err
+	}
+
+	if settings := app.App.Config(); merged.AppConfig != settings {
+		*settings = *merged.AppConfig
+		app.App.ApplyTheme()
+	}
+
+	if err := app.App.SaveConnections(merged.Connections); err != nil {
+		return err
+	}
+
+	connectionsTable.SetConnections(merged.Connections)
+	return nil
+}
+
+func showBundleError(message string) {
+	errorModal := NewErrorModal(message)
+	errorModal.SetDoneFunc(func(_ int, _ string) {
+		mainPages.RemovePage(pageNameErrorModal)
+	})
+	mainPages.AddPage(pageNameErrorModal, errorModal, true, true)
+	App.SetFocus(errorModal)
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/process"
+)
+
+// CommandLogModal shows what the commands of a connection printed, updated
+// as they print more.
+type CommandLogModal struct {
+	tview.Primitive
+	Log   *tview.TextView
+	group *process.Group
+}
+
+// NewCommandLogModal creates a new CommandLogModal for the commands of a
+// connection.
+func NewCommandLogModal(name string) *CommandLogModal {
+	clm := &CommandLogModal{
+		Log: tview.NewTextView(),
+	}
+
+	clm.Log.SetDynamicColors(true)
+	clm.Log.SetScrollable(true)
+	clm.Log.SetWrap(false)
+	clm.Log.SetBorder(true)
+	clm.Log.SetTitle(fmt.Sprintf(" Commands of %s (Esc to close) ", name))
+	clm.Log.SetTitleAlign(tview.AlignLeft)
+	clm.Log.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'q' {
+			clm.close()
+			return nil
+		}
+		return event
+	})
+
+	if group, ok := process.Lookup(name); ok {
+		clm.group = group
+		group.SetOnOutput(func() {
+			App.QueueUpdateDraw(clm.refresh)
+		})
+	}
+	clm.refresh()
+
+	clm.Primitive = tview.NewGrid().
+		SetRows(0, 25, 0).
+		SetColumns(0, 120, 0).
+		AddItem(clm.Log, 1, 1, 1, 1, 0, 0, true)
+
+	return clm
+}
+
+// refresh shows the lines logged so far, keeping the end in view
+func (clm *CommandLogModal) refresh() {
+	if clm.group == nil {
+		clm.Log.SetText("No command is running for this connection.")
+		return
+	}
+
+	lines := clm.group.Lines()
+	if len(lines) == 0 {
+		clm.Log.SetText("No output yet.")
+		return
+	}
+
+	var text strings.Builder
+	for _, line := range lines {
+		color := app.Styles.PrimaryTextColor
+		switch line.Stream {
+		case process.StreamStderr:
+			color = app.Styles.ErrorColor
+		case process.StreamEvent:
+			color = app.Styles.TertiaryTextColor
+		}
+
+		fmt.Fprintf(&text, "[%s]%s [%s] %s[-]\n",
+			color.String(),
+			line.Time.Format("15:04:05"),
+			tview.Escape(line.Command),
+			tview.Escape(line.Text),
+		)
+	}
+
+	clm.Log.SetText(text.String())
+	clm.Log.ScrollToEnd()
+}
+
+func (clm *CommandLogModal) close() {
+	if clm.group != nil {
+		clm.group.SetOnOutput(nil)
+	}
+	mainPages.RemovePage(pageNameCommandLog)
+}
+
+// showCommandLog opens the log of the commands of a connection
+func showCommandLog(name string) {
+	mainPages.RemovePage(pageNameCommandLog)
+	mainPages.AddPage(pageNameCommandLog, NewCommandLogModal(name), true, true)
+}
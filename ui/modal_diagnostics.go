@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/db"
+	"sqlcmder/diagnose"
+	"sqlcmder/models"
+)
+
+// DiagnosticsModal shows the steps of the diagnosis of a connection, each
+// passing or failing as it runs.
+type DiagnosticsModal struct {
+	tview.Primitive
+	Result *tview.TextView
+	cancel context.CancelFunc
+}
+
+// NewDiagnosticsModal creates a new DiagnosticsModal for a connection, cancel
+// stopping the diagnosis when the modal is closed.
+func NewDiagnosticsModal(name string, cancel context.CancelFunc) *DiagnosticsModal {
+	dm := &DiagnosticsModal{
+		Result: tview.NewTextView(),
+		cancel: cancel,
+	}
+
+	dm.Result.SetDynamicColors(true)
+	dm.Result.SetScrollable(true)
+	dm.Result.SetWordWrap(true)
+	dm.Result.SetBorder(true)
+	dm.Result.SetBorderPadding(0, 0, 1, 1)
+	dm.Result.SetTitle(fmt.Sprintf(" Diagnosis of %s (Esc to close) ", name))
+	dm.Result.SetTitleAlign(tview.AlignLeft)
+	dm.Result.SetText("Starting...")
+	dm.Result.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'q' {
+			dm.close()
+			return nil
+		}
+		return event
+	})
+
+	dm.Primitive = tview.NewGrid().
+		SetRows(0, 25, 0).
+		SetColumns(0, 100, 0).
+		AddItem(dm.Result, 1, 1, 1, 1, 0, 0, true)
+
+	return dm
+}
+
+// setSteps shows the steps of the diagnosis, and its outcome once they all
+// ran
+func (dm *DiagnosticsModal) setSteps(steps []diagnose.Step) {
+	var text strings.Builder
+	done := true
+
+	for _, step := range steps {
+		label, color := "    ", app.Styles.TertiaryTextColor
+		switch step.Status {
+		case diagnose.StatusRunning:
+			label, color = "... ", app.Styles.WarningColor
+			done = false
+		case diagnose.StatusPending:
+			done = false
+		case diagnose.StatusPassed:
+			label, color = "PASS", app.Styles.SuccessColor
+		case diagnose.StatusFailed:
+			label, color = "FAIL", app.Styles.ErrorColor
+		case diagnose.StatusSkipped:
+			label = "SKIP"
+		}
+
+		duration := ""
+		if step.Status == diagnose.StatusPassed || step.Status == diagnose.StatusFailed {
+			duration = step.Duration.Round(time.Millisecond / 10).String()
+		}
+
+		fmt.Fprintf(&text, "[%s]%s[-] %-30s %10s\n", color.String(), label, step.Name, duration)
+		if step.Detail != "" {
+			fmt.Fprintf(&text, "     [%s]%s[-]\n", app.Styles.TertiaryTextColor.String(), tview.Escape(step.Detail))
+		}
+	}
+
+	if done {
+		if failed, ok := diagnose.Failed(steps); ok {
+			fmt.Fprintf(&text, "\n[%s]Failed at: %s[-]\n", app.Styles.ErrorColor.String(), failed.Name)
+		} else {
+			fmt.Fprintf(&text, "\n[%s]Connection OK[-]\n", app.Styles.SuccessColor.String())
+		}
+	}
+
+	dm.Result.SetText(text.String())
+}
+
+// setError shows why the diagnosis could not start
+func (dm *DiagnosticsModal) setError(err error) {
+	dm.Result.SetText(fmt.Sprintf("[%s]%s[-]", app.Styles.ErrorColor.String(), tview.Escape(err.Error())))
+}
+
+func (dm *DiagnosticsModal) close() {
+	dm.cancel()
+	mainPages.RemovePage(pageNameDiagnostics)
+}
+
+// diagnoseConnection opens the diagnostics modal and diagnoses a connection
+// to dsn, which holds its password. It goes through the SSH tunnel of the
+// connection like connecting does. It must not be called on the UI
+// goroutine.
+func diagnoseConnection(connection models.Connection, dsn string) {
+	ctx, cancel := context.WithCancel(App.Context())
+	defer cancel()
+
+	modal := NewDiagnosticsModal(connection.Name, cancel)
+	App.QueueUpdateDraw(func() {
+		mainPages.RemovePage(pageNameDiagnostics)
+		mainPages.AddPage(pageNameDiagnostics, modal, true, true)
+		App.SetFocus(modal.Result)
+	})
+
+	opened, closeTunnel, err := openDSN(connection, dsn)
+	if err != nil {
+		App.QueueUpdateDraw(func() {
+			modal.setError(err)
+		})
+		return
+	}
+	defer closeTunnel()
+
+	settings := db.ConnectionTLS(connection, dsn)
+	target := diagnose.Target{Driver: connection.Driver, DSN: opened, TLS: &settings}
+
+	diagnose.Run(ctx, target, func(steps []diagnose.Step) {
+		App.QueueUpdateDraw(func() {
+			modal.setSteps(steps)
+		})
+	})
+}
@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	commands "sqlcmder/cli"
+	"sqlcmder/cmd/app"
+	"sqlcmder/drivers"
+	"sqlcmder/erd"
+	"sqlcmder/keymap"
+)
+
+// ERDiagramModal draws the tables of a database and the foreign keys
+// between them, optionally limited to a table and its neighbours.
+type ERDiagramModal struct {
+	tview.Primitive
+	Diagram     *tview.TextView
+	TableInput  *tview.InputField
+	HopsInput   *tview.InputField
+	ExportInput *tview.InputField
+	StatusText  *tview.TextView
+	Footer      *tview.Pages
+	database    string
+	graph       *erd.Graph
+	exporter    func(*erd.Graph) string
+}
+
+func NewERDiagramModal(dbDriver drivers.Driver, database, table string) *ERDiagramModal {
+	edm := &ERDiagramModal{
+		Diagram:     tview.NewTextView(),
+		TableInput:  tview.NewInputField(),
+		HopsInput:   tview.NewInputField(),
+		ExportInput: tview.NewInputField(),
+		StatusText:  tview.NewTextView(),
+		Footer:      tview.NewPages(),
+		database:    database,
+	}
+
+	edm.Diagram.SetWrap(false)
+	edm.Diagram.SetScrollable(true)
+	edm.Diagram.SetBorder(true)
+	edm.Diagram.SetTitle(fmt.Sprintf(" ER diagram of %s ", database))
+	edm.Diagram.SetTitleAlign(tview.AlignLeft)
+	edm.Diagram.SetText("Loading...")
+	edm.Diagram.SetInputCapture(edm.diagramInputCapture)
+
+	edm.TableInput.SetLabel("Table ")
+	edm.TableInput.SetText(table)
+	edm.TableInput.SetPlaceholder("all tables")
+	edm.TableInput.SetAutocompleteFunc(edm.autocompleteTable)
+	edm.TableInput.SetDoneFunc(edm.filterDone)
+
+	hops := ""
+	if table != "" {
+		hops = "1"
+	}
+
+	edm.HopsInput.SetLabel(" Hops ")
+	edm.HopsInput.SetText(hops)
+	edm.HopsInput.SetFieldWidth(4)
+	edm.HopsInput.SetAcceptanceFunc(tview.InputFieldInteger)
+	edm.HopsInput.SetDoneFunc(edm.filterDone)
+
+	for _, input := range []*tview.InputField{edm.TableInput, edm.HopsInput, edm.ExportInput} {
+		input.SetFieldStyle(tcell.StyleDefault.
+			Background(app.Styles.SecondaryTextColor).
+			Foreground(app.Styles.ContrastSecondaryTextColor),
+		)
+		input.SetLabelColor(app.Styles.TertiaryTextColor)
+	}
+
+	edm.ExportInput.SetLabel("Export to ")
+	edm.ExportInput.SetDoneFunc(edm.exportDone)
+
+	edm.StatusText.SetDynamicColors(true)
+	edm.StatusText.SetTextColor(app.Styles.TertiaryTextColor)
+
+	filter := tview.NewFlex().
+		AddItem(edm.TableInput, 0, 1, false).
+		AddItem(edm.HopsInput, 10, 0, false)
+
+	keybindings := tview.NewTextView()
+	keybindings.SetDynamicColors(true)
+	keybindings.SetWrap(false)
+
+	for _, command := range keymap.Keymaps.Group(keymap.ERDiagramGroup) {
+		keybindings.SetText(fmt.Sprintf("%s [yellow](%s) [default]%s", keybindings.GetText(false), command.Key.String(), command.Description))
+	}
+
+	edm.Footer.AddPage("status", edm.StatusText, true, true)
+	edm.Footer.AddPage("export", edm.ExportInput, true, false)
+
+	container := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, false).
+		AddItem(edm.Diagram, 0, 1, true).
+		AddItem(edm.Footer, 1, 0, false).
+		AddItem(keybindings, 1, 0, false)
+	container.SetBorderPadding(0, 0, 1, 1)
+
+	edm.Primitive = tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(container, 0, 8, true).
+			AddItem(nil, 0, 1, false), 0, 8, true).
+		AddItem(nil, 0, 1, false)
+
+	go edm.load(dbDriver)
+
+	return edm
+}
+
+func (edm *ERDiagramModal) load(dbDriver drivers.Driver) {
+	graph, err := erd.Load(dbDriver, edm.database)
+
+	App.QueueUpdateDraw(func() {
+		if err != nil {
+			edm.Diagram.SetText("")
+			edm.setStatus(err.Error(), app.Styles.ErrorColor)
+			return
+		}
+
+		edm.graph = graph
+		edm.render()
+	})
+}
+
+// filtered returns the part of the graph selected by the table and hops inputs
+func (edm *ERDiagramModal) filtered() *erd.Graph {
+	table := strings.TrimSpace(edm.TableInput.GetText())
+	if table == "" {
+		return edm.graph
+	}
+
+	hops, err := strconv.Atoi(edm.HopsInput.GetText())
+	if err != nil {
+		hops = 1
+	}
+
+	return edm.graph.Neighbourhood(table, hops)
+}
+
+func (edm *ERDiagramModal) render() {
+	if edm.graph == nil {
+		return
+	}
+
+	graph := edm.filtered()
+
+	if len(graph.Tables) == 0 {
+		edm.Diagram.SetText("")
+		if table := edm.TableInput.GetText(); table != "" {
+			edm.setStatus(fmt.Sprintf("Table %s not found", table), app.Styles.WarningColor)
+		} else {
+			edm.setStatus("No tables", app.Styles.WarningColor)
+		}
+		return
+	}
+
+	edm.Diagram.SetText(tview.Escape(erd.Render(graph)))
+	edm.Diagram.ScrollToBeginning()
+	edm.setStatus(fmt.Sprintf("%d tables, %d foreign keys", len(graph.Tables), len(graph.ForeignKeys)), app.Styles.TertiaryTextColor)
+}
+
+func (edm *ERDiagramModal) autocompleteTable(currentText string) []string {
+	if edm.graph == nil || currentText == "" {
+		return nil
+	}
+
+	entries := []string{}
+	for _, name := range edm.graph.TableNames() {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(currentText)) {
+			entries = append(entries, name)
+		}
+	}
+
+	return entries
+}
+
+func (edm *ERDiagramModal) filterDone(key tcell.Key) {
+	switch key {
+	case tcell.KeyEnter:
+		edm.render()
+		App.SetFocus(edm.Diagram)
+	case tcell.KeyEsc:
+		App.SetFocus(edm.Diagram)
+	case tcell.KeyTab, tcell.KeyBacktab:
+		if App.GetFocus() == edm.TableInput {
+			App.SetFocus(edm.HopsInput)
+		} else {
+			App.SetFocus(edm.TableInput)
+		}
+	}
+}
+
+func (edm *ERDiagramModal) export(exporter func(*erd.Graph) string, extension string) {
+	if edm.graph == nil {
+		return
+	}
+
+	name := edm.database
+	if table := strings.TrimSpace(edm.TableInput.GetText()); table != "" {
+		name = table
+	}
+
+	edm.exporter = exporter
+	edm.ExportInput.SetText(strings.NewReplacer("/", "_", " ", "_").Replace(name) + extension)
+	edm.Footer.SwitchToPage("export")
+	App.SetFocus(edm.ExportInput)
+}
+
+func (edm *ERDiagramModal) exportDone(key tcell.Key) {
+	edm.Footer.SwitchToPage("status")
+	App.SetFocus(edm.Diagram)
+
+	if key != tcell.KeyEnter {
+		return
+	}
+
+	path := strings.TrimSpace(edm.ExportInput.GetText())
+	if path == "" {
+		return
+	}
+
+	if err := os.WriteFile(path, []byte(edm.exporter(edm.filtered())), 0o644); err != nil {
+		edm.setStatus(err.Error(), app.Styles.ErrorColor)
+		return
+	}
+
+	edm.setStatus(fmt.Sprintf("Exported to %s", path), app.Styles.SuccessColor)
+}
+
+func (edm *ERDiagramModal) diagramInputCapture(event *tcell.EventKey) *tcell.EventKey {
+	command := keymap.Keymaps.Group(keymap.ERDiagramGroup).Resolve(event)
+
+	switch {
+	case command == commands.Quit || event.Key() == tcell.KeyEsc:
+		mainPages.RemovePage(pageNameERDiagram)
+		return nil
+	case command == commands.Search:
+		App.SetFocus(edm.TableInput)
+		return nil
+	case command == commands.ExportMermaid:
+		edm.export(erd.Mermaid, ".mmd")
+		return nil
+	case command == commands.ExportDOT:
+		edm.export(erd.DOT, ".dot")
+		return nil
+	}
+
+	return event
+}
+
+func (edm *ERDiagramModal) setStatus(text string, color tcell.Color) {
+	edm.StatusText.SetText(text).SetTextColor(color)
+}
@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	commands "sqlcmder/cli"
+	"sqlcmder/cmd/app"
+	"sqlcmder/data/history"
+	"sqlcmder/helpers"
+	"sqlcmder/keymap"
+	"sqlcmder/logger"
+	"sqlcmder/models"
+)
+
+// GlobalHistoryModal searches the query history of every connection.
+type GlobalHistoryModal struct {
+	tview.Primitive
+	table       *tview.Table
+	filterInput *tview.InputField
+	items       []models.QueryHistoryItem
+	displayed   []models.QueryHistoryItem
+	onSelect    func(item models.QueryHistoryItem)
+}
+
+// NewGlobalHistoryModal creates a new GlobalHistoryModal. Selecting an item
+// closes the modal and hands the item to onSelect.
+func NewGlobalHistoryModal(onSelect func(item models.QueryHistoryItem)) *GlobalHistoryModal {
+	ghm := &GlobalHistoryModal{onSelect: onSelect}
+
+	ghm.filterInput = tview.NewInputField().
+		SetLabel("Search: ").
+		SetFieldWidth(30).SetFieldStyle(
+		tcell.StyleDefault.
+			Background(app.Styles.SecondaryTextColor).
+			Foreground(app.Styles.ContrastSecondaryTextColor),
+	)
+	ghm.filterInput.SetBorderPadding(1, 0, 1, 0)
+
+	ghm.table = tview.NewTable().
+		SetBorders(true).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	ghm.table.SetSelectedStyle(tcell.StyleDefault.Background(app.Styles.SecondaryTextColor).Foreground(tview.Styles.ContrastSecondaryTextColor))
+	ghm.table.SetBorderColor(app.Styles.PrimaryTextColor)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(ghm.filterInput, 2, 0, false).
+		AddItem(ghm.table, 0, 1, true)
+	layout.SetBorder(true).SetTitle(" History of Every Connection ").SetTitleAlign(tview.AlignLeft)
+
+	ghm.filterInput.SetChangedFunc(func(text string) {
+		ghm.populateTable(history.Filter(ghm.items, text))
+	})
+
+	ghm.filterInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter || key == tcell.KeyEscape {
+			App.SetFocus(ghm.table)
+		}
+	})
+
+	ghm.table.SetSelectedFunc(func(row int, _ int) {
+		if row > 0 && row-1 < len(ghm.displayed) {
+			mainPages.RemovePage(pageNameGlobalHistory)
+			if ghm.onSelect != nil {
+				ghm.onSelect(ghm.displayed[row-1])
+			}
+		}
+	})
+
+	ghm.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			mainPages.RemovePage(pageNameGlobalHistory)
+			return nil
+		}
+
+		switch keymap.Keymaps.Group(keymap.QueryHistoryGroup).Resolve(event) {
+		case commands.Quit, commands.ToggleQueryHistory:
+			mainPages.RemovePage(pageNameGlobalHistory)
+			return nil
+		case commands.Search:
+			App.SetFocus(ghm.filterInput)
+			return nil
+		case commands.Copy:
+			row, _ := ghm.table.GetSelection()
+			if row > 0 && row-1 < len(ghm.displayed) {
+				if err := helpers.NewClipboard().Write(ghm.displayed[row-1].QueryText); err != nil {
+					logger.Info("Error copying query", map[string]any{"error": err.Error()})
+				}
+			}
+			return nil
+		}
+
+		if keymap.Keymaps.Group(keymap.HomeGroup).Resolve(event) == commands.GlobalHistory {
+			mainPages.RemovePage(pageNameGlobalHistory)
+			return nil
+		}
+
+		return event
+	})
+
+	ghm.Primitive = tview.NewGrid().
+		SetRows(0, 30, 0).
+		SetColumns(0, 150, 0).
+		AddItem(layout, 1, 1, 1, 1, 0, 0, true)
+
+	return ghm
+}
+
+// Load reads the history of every connection
+func (ghm *GlobalHistoryModal) Load() {
+	items, err := history.ReadAllHistory()
+	if err != nil {
+		logger.Error("Failed to read the history of every connection", map[string]any{"error": err})
+		items = []models.QueryHistoryItem{}
+	}
+
+	ghm.items = items
+	ghm.populateTable(history.Filter(ghm.items, ghm.filterInput.GetText()))
+}
+
+func (ghm *GlobalHistoryModal) populateTable(items []models.QueryHistoryItem) {
+	ghm.table.Clear()
+	ghm.displayed = items
+
+	headers := []string{"Connection", "Timestamp", "Database", "Status", "Query"}
+	for c, header := range headers {
+		expansion := 0
+		if c == len(headers)-1 {
+			expansion = 1
+		}
+		ghm.table.SetCell(0, c, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(app.Styles.TertiaryTextColor).
+			SetAlign(tview.AlignCenter).SetExpansion(expansion))
+	}
+
+	for r, item := range items {
+		ghm.table.SetCell(r+1, 0, tview.NewTableCell(item.Connection).SetMaxWidth(20).SetTextColor(app.Styles.TertiaryTextColor))
+		ghm.table.SetCell(r+1, 1, tview.NewTableCell(item.Timestamp.Local().Format("2006-01-02 15:04:05")))
+		ghm.table.SetCell(r+1, 2, tview.NewTableCell(item.Database).SetMaxWidth(20))
+
+		statusCell := tview.NewTableCell("")
+		if item.Error != "" {
+			statusCell.SetText(item.Error).SetMaxWidth(30).SetTextColor(app.Styles.ErrorColor)
+		} else if item.Source != "" {
+			statusCell.SetText("ok")
+		}
+		ghm.table.SetCell(r+1, 3, statusCell)
+
+		ghm.table.SetCell(r+1, 4, tview.NewTableCell(item.QueryText).SetExpansion(1))
+	}
+
+	if len(items) > 0 {
+		ghm.table.Select(1, 0)
+	}
+}
+
+// GetPrimitive returns the primitive for this component.
+func (ghm *GlobalHistoryModal) GetPrimitive() tview.Primitive {
+	return ghm.Primitive
+}
+
+// showGlobalHistory opens the history of every connection. Opening a query
+// connects to its connection first if needed.
+func showGlobalHistory() {
+	if mainPages.HasPage(pageNameGlobalHistory) {
+		mainPages.SwitchToPage(pageNameGlobalHistory)
+		return
+	}
+
+	modal := NewGlobalHistoryModal(openGlobalHistoryItem)
+	modal.Load()
+	mainPages.AddPage(pageNameGlobalHistory, modal, true, true)
+	App.SetFocus(modal.table)
+}
+
+// openGlobalHistoryItem opens a query of the history in an editor tab of the
+// connection it ran on
+func openGlobalHistoryItem(item models.QueryHistoryItem) {
+	connection, ok := findHistoryConnection(connectionsTable.GetConnections(), item.Connection)
+	if !ok {
+		errorModal := NewErrorModal("No configured connection matches '" + item.Connection + "'")
+		errorModal.SetDoneFunc(func(_ int, _ string) {
+			mainPages.RemovePage(pageNameErrorModal)
+		})
+		mainPages.AddPage(pageNameErrorModal, errorModal, true, true)
+		return
+	}
+
+	if !mainPages.HasPage(connection.Name) {
+		// Connection errors are shown on the connections page
+		mainPages.SwitchToPage(pageNameConnections)
+	}
+
+	go func() {
+		connectionSelectionPage.Connect(connection)
+
+		value, ok := homes.Load(connection.Name)
+		if !ok {
+			return
+		}
+
+		App.QueueUpdateDraw(func() {
+			value.(*Home).openQuery(item.QueryText)
+		})
+	}()
+}
+
+// findHistoryConnection returns the connection a history item was recorded
+// for, by name or by the name its history file was given
+func findHistoryConnection(connections []models.Connection, identifier string) (models.Connection, bool) {
+	for _, connection := range connections {
+		if connection.Name == identifier || history.SanitizeFilename(connection.Name) == history.SanitizeFilename(identifier) {
+			return connection, true
+		}
+	}
+	return models.Connection{}, false
+}
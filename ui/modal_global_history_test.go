@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestFindHistoryConnection(t *testing.T) {
+	connections := []models.Connection{
+		{Name: "local"},
+		{Name: "Prod DB"},
+	}
+
+	tests := []struct {
+		name       string
+		identifier string
+		want       string
+		found      bool
+	}{
+		{name: "by name", identifier: "local", want: "local", found: true},
+		{name: "by history file name", identifier: "prod_db", want: "Prod DB", found: true},
+		{name: "unknown", identifier: "staging", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection, found := findHistoryConnection(connections, tt.identifier)
+			if found != tt.found || connection.Name != tt.want {
+				t.Fatalf("findHistoryConnection(%q) = %q, %v, want %q, %v", tt.identifier, connection.Name, found, tt.want, tt.found)
+			}
+		})
+	}
+}
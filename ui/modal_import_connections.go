@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/importer"
+	"sqlcmder/models"
+)
+
+// ImportConnectionsModal lists the connections found in the files and
+// environment of other tools, to pick the ones to add. Connections already
+// configured are shown but not selected.
+type ImportConnectionsModal struct {
+	tview.Primitive
+	table      *tview.Table
+	fileInput  *tview.InputField
+	status     *tview.TextView
+	candidates []importer.Candidate
+	selected   []bool
+}
+
+// NewImportConnectionsModal creates a new ImportConnectionsModal
+func NewImportConnectionsModal() *ImportConnectionsModal {
+	icm := &ImportConnectionsModal{
+		table:  tview.NewTable(),
+		status: tview.NewTextView(),
+	}
+
+	icm.fileInput = tview.NewInputField().
+		SetLabel("Export file: ").
+		SetFieldWidth(60).SetFieldStyle(
+		tcell.StyleDefault.
+			Background(app.Styles.SecondaryTextColor).
+			Foreground(app.Styles.ContrastSecondaryTextColor),
+	)
+	icm.fileInput.SetBorderPadding(1, 0, 1, 0)
+
+	icm.table.SetBorders(true).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	icm.table.SetSelectedStyle(tcell.StyleDefault.Background(app.Styles.SecondaryTextColor).Foreground(tview.Styles.ContrastSecondaryTextColor))
+	icm.table.SetBorderColor(app.Styles.PrimaryTextColor)
+
+	icm.status.SetDynamicColors(true)
+	icm.status.SetBorderPadding(0, 0, 1, 1)
+	icm.status.SetText(fmt.Sprintf("[%s]Space select, a select all, Enter import, Tab load an export file, Esc close[-]", app.Styles.TertiaryTextColor.String()))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(icm.fileInput, 2, 0, false).
+		AddItem(icm.table, 0, 1, true).
+		AddItem(icm.status, 2, 0, false)
+	layout.SetBorder(true).SetTitle(" Import Connections ").SetTitleAlign(tview.AlignLeft)
+
+	icm.fileInput.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			icm.loadFile(strings.TrimSpace(icm.fileInput.GetText()))
+			App.SetFocus(icm.table)
+		case tcell.KeyEscape, tcell.KeyTab:
+			App.SetFocus(icm.table)
+		}
+	})
+
+	icm.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			mainPages.RemovePage(pageNameImportConnections)
+			return nil
+		case tcell.KeyTab:
+			App.SetFocus(icm.fileInput)
+			return nil
+		case tcell.KeyEnter:
+			go icm.importSelected()
+			return nil
+		}
+
+		switch event.Rune() {
+		case ' ':
+			row, _ := icm.table.GetSelection()
+			if row > 0 && row-1 < len(icm.selected) {
+				icm.selected[row-1] = !icm.selected[row-1]
+				icm.populateTable()
+			}
+			return nil
+		case 'a':
+			all := true
+			for _, selected := range icm.selected {
+				all = all && selected
+			}
+			for i := range icm.selected {
+				icm.selected[i] = !all
+			}
+			icm.populateTable()
+			return nil
+		case 'q':
+			mainPages.RemovePage(pageNameImportConnections)
+			return nil
+		}
+
+		return event
+	})
+
+	icm.Primitive = tview.NewGrid().
+		SetRows(0, 30, 0).
+		SetColumns(0, 150, 0).
+		AddItem(layout, 1, 1, 1, 1, 0, 0, true)
+
+	return icm
+}
+
+// loadFile lists the connections of an export of sqlcmder instead of the
+// ones discovered
+func (icm *ImportConnectionsModal) loadFile(path string) {
+	if path == "" {
+		return
+	}
+
+	candidates, err := importer.ReadExport(path)
+	icm.setCandidates(candidates)
+	if err != nil {
+		icm.setErrors([]error{err})
+	}
+}
+
+// setCandidates lists candidates, selecting the ones not configured yet
+func (icm *ImportConnectionsModal) setCandidates(candidates []importer.Candidate) {
+	connections := app.App.Connections()
+
+	icm.candidates = candidates
+	icm.selected = make([]bool, len(candidates))
+	for i, candidate := range candidates {
+		icm.selected[i] = !importer.Exists(connections, candidate)
+	}
+
+	icm.populateTable()
+	icm.status.SetText(fmt.Sprintf("[%s]Found %d connections. Space select, a select all, Enter import, Tab load an export file, Esc close[-]", app.Styles.TertiaryTextColor.String(), len(candidates)))
+}
+
+// setErrors shows the sources that could not be read
+func (icm *ImportConnectionsModal) setErrors(errs []error) {
+	if err := errors.Join(errs...); err != nil {
+		icm.status.SetText(fmt.Sprintf("[%s]%s[-]", app.Styles.ErrorColor.String(), tview.Escape(strings.ReplaceAll(err.Error(), "\n", "; "))))
+	}
+}
+
+func (icm *ImportConnectionsModal) populateTable() {
+	row, _ := icm.table.GetSelection()
+	icm.table.Clear()
+
+	connections := app.App.Connections()
+
+	headers := []string{"", "Name", "Driver", "DSN", "Source"}
+	for c, header := range headers {
+		expansion := 0
+		if c == len(headers)-1 {
+			expansion = 1
+		}
+		icm.table.SetCell(0, c, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(app.Styles.TertiaryTextColor).
+			SetAlign(tview.AlignCenter).SetExpansion(expansion))
+	}
+
+	for r, candidate := range icm.candidates {
+		mark := "[ ]"
+		if icm.selected[r] {
+			mark = "[x]"
+		}
+
+		name := tview.NewTableCell(tview.Escape(candidate.Connection.Name)).SetMaxWidth(30)
+		if importer.Exists(connections, candidate) {
+			name.SetText(tview.Escape(candidate.Connection.Name) + " (exists)").SetTextColor(app.Styles.TertiaryTextColor)
+		}
+
+		icm.table.SetCell(r+1, 0, tview.NewTableCell(tview.Escape(mark)))
+		icm.table.SetCell(r+1, 1, name)
+		icm.table.SetCell(r+1, 2, tview.NewTableCell(candidate.Connection.Driver))
+		icm.table.SetCell(r+1, 3, tview.NewTableCell(tview.Escape(candidate.Connection.GetDSN())).SetMaxWidth(60))
+		icm.table.SetCell(r+1, 4, tview.NewTableCell(tview.Escape(candidate.Source)).SetTextColor(app.Styles.TertiaryTextColor).SetExpansion(1))
+	}
+
+	if row < 1 {
+		row = 1
+	}
+	if len(icm.candidates) > 0 {
+		icm.table.Select(min(row, len(icm.candidates)), 0)
+	}
+}
+
+// importSelected adds the selected connections to the configured ones,
+// keeping their passwords in the vault. It must not be called on the UI
+// goroutine, as the vault may ask for its passphrase.
+func (icm *ImportConnectionsModal) importSelected() {
+	selected := []importer.Candidate{}
+	for i, candidate := range icm.candidates {
+		if icm.selected[i] {
+			selected = append(selected, candidate)
+		}
+	}
+
+	merged, added := importer.Merge(app.App.Connections(), selected)
+
+	for i, connection := range added {
+		password := connection.Password
+		if password == "" || connection.PasswordSource != "" {
+			continue
+		}
+
+		connection.PasswordSource = models.PasswordSourceVault
+		if err := storePassword(connection, password); err != nil {
+			App.QueueUpdateDraw(func() {
+				icm.status.SetText(fmt.Sprintf("[%s]Import failed: %s[-]", app.Styles.ErrorColor.String(), tview.Escape(err.Error())))
+			})
+			return
+		}
+
+		added[i] = connection
+		for j := range merged {
+			if merged[j].Name == connection.Name {
+				merged[j] = connection
+			}
+		}
+	}
+
+	err := app.App.SaveConnections(merged)
+
+	App.QueueUpdateDraw(func() {
+		if err != nil {
+			icm.status.SetText(fmt.Sprintf("[%s]Import failed: %s[-]", app.Styles.ErrorColor.String(), tview.Escape(err.Error())))
+			return
+		}
+
+		connectionsTable.SetConnections(merged)
+		mainPages.RemovePage(pageNameImportConnections)
+		connectionSelectionPage.StatusText.SetText(fmt.Sprintf("Imported %d connections", len(added))).SetTextColor(app.Styles.TertiaryTextColor)
+	})
+}
+
+// showImportConnections opens the import of connections, listing the ones
+// found in the default locations
+func showImportConnections() {
+	modal := NewImportConnectionsModal()
+	mainPages.AddPage(pageNameImportConnections, modal, true, true)
+	App.SetFocus(modal.table)
+
+	go func() {
+		candidates, errs := importer.Discover()
+		App.QueueUpdateDraw(func() {
+			modal.setCandidates(candidates)
+			modal.setErrors(errs)
+		})
+	}()
+}
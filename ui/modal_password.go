@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"errors"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/db"
+	"sqlcmder/models"
+)
+
+// errPasswordCancelled is returned when the password modal is closed without
+// an answer.
+var errPasswordCancelled = errors.New("password prompt cancelled")
+
+// PasswordModal asks for a password or passphrase, masking what is typed.
+type PasswordModal struct {
+	tview.Primitive
+	form     *tview.Form
+	onSubmit func(password string)
+	onCancel func()
+}
+
+// NewPasswordModal creates a new PasswordModal with the given label.
+func NewPasswordModal(label string, onSubmit func(password string), onCancel func()) *PasswordModal {
+	pm := &PasswordModal{
+		onSubmit: onSubmit,
+		onCancel: onCancel,
+	}
+
+	pm.form = tview.NewForm().SetFieldStyle(
+		tcell.StyleDefault.
+			Background(app.Styles.SecondaryTextColor).
+			Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonActivatedStyle(tcell.StyleDefault.
+		Background(app.Styles.InverseTextColor).
+		Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonStyle(tcell.StyleDefault.
+		Background(app.Styles.ButtonBackgroundColor).
+		Foreground(app.Styles.PrimaryTextColor),
+	)
+
+	pm.form.AddPasswordField(label, "", 30, '*', nil)
+	pm.form.AddButton("OK", pm.submit).AddButton("Cancel", pm.cancel)
+
+	pm.form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			pm.cancel()
+			return nil
+		}
+
+		if event.Key() == tcell.KeyEnter {
+			if _, button := pm.form.GetFocusedItemIndex(); button == 1 {
+				pm.cancel()
+			} else {
+				pm.submit()
+			}
+			return nil
+		}
+
+		return event
+	})
+
+	pm.form.SetBorder(true).SetTitle(" Password ").SetTitleAlign(tview.AlignLeft)
+
+	pm.Primitive = tview.NewGrid().
+		SetRows(0, 7, 0).
+		SetColumns(0, 60, 0).
+		AddItem(pm.form, 1, 1, 1, 1, 0, 0, true)
+
+	return pm
+}
+
+func (pm *PasswordModal) submit() {
+	password := pm.form.GetFormItem(0).(*tview.InputField).GetText()
+	mainPages.RemovePage(pageNamePassword)
+
+	if pm.onSubmit != nil {
+		pm.onSubmit(password)
+	}
+}
+
+func (pm *PasswordModal) cancel() {
+	mainPages.RemovePage(pageNamePassword)
+
+	if pm.onCancel != nil {
+		pm.onCancel()
+	}
+}
+
+// GetPrimitive returns the primitive for this component.
+func (pm *PasswordModal) GetPrimitive() tview.Primitive {
+	return pm.Primitive
+}
+
+// askPassword shows the password modal and waits for the answer. It must
+// not be called on the UI goroutine.
+func askPassword(label string) (string, error) {
+	answer := make(chan string, 1)
+	cancelled := make(chan struct{}, 1)
+
+	App.QueueUpdateDraw(func() {
+		modal := NewPasswordModal(label, func(password string) {
+			answer <- password
+		}, func() {
+			cancelled <- struct{}{}
+		})
+		mainPages.AddPage(pageNamePassword, modal, true, true)
+		App.SetFocus(modal.form)
+	})
+
+	select {
+	case password := <-answer:
+		return password, nil
+	case <-cancelled:
+		return "", errPasswordCancelled
+	case <-App.Context().Done():
+		return "", errPasswordCancelled
+	}
+}
+
+// connectionDSN returns the DSN of a connection holding the password read
+// from its password source, asking for it if needed. The password is kept on
+// the connection for the tools that need it, like backups. It must not be
+// called on the UI goroutine.
+func connectionDSN(connection *models.Connection) (string, error) {
+	return db.ConnectionDSN(connection, askPassword)
+}
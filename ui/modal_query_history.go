@@ -5,8 +5,10 @@ import (
 	"github.com/rivo/tview"
 
 	"sqlcmder/cmd/app"
+	"sqlcmder/data/queries"
 	"sqlcmder/keymap"
 	"sqlcmder/cli"
+	"sqlcmder/models"
 )
 
 type QueryHistoryModal struct {
@@ -19,13 +21,15 @@ type QueryHistoryModal struct {
 	grid                  *tview.Grid
 }
 
-func NewQueryHistoryModal(connectionIdentifier string, onSelect func(query string)) *QueryHistoryModal {
+func NewQueryHistoryModal(library queries.Library, onSelect func(query string), onRerun func(item models.QueryHistoryItem)) *QueryHistoryModal {
+	connectionIdentifier := library.ConnectionIdentifier
+
 	qhm := &QueryHistoryModal{
 		onQuerySelected:      onSelect,
 		connectionIdentifier: connectionIdentifier,
 	}
 
-	qhm.savedQueriesComponent = NewSavedQueriesComponent(connectionIdentifier, func(query string) {
+	qhm.savedQueriesComponent = NewSavedQueriesComponent(library, func(query string) {
 		mainPages.RemovePage(pageNameQueryHistory)
 		onSelect(query)
 	})
@@ -36,6 +40,12 @@ func NewQueryHistoryModal(connectionIdentifier string, onSelect func(query strin
 		qhm.savedQueriesComponent.Refresh()
 		App.SetFocus(qhm.grid)
 	})
+	qhm.queryHistoryComponent.SetLibrary(library)
+	qhm.queryHistoryComponent.SetDialect(library.Driver)
+	qhm.queryHistoryComponent.SetOnRerun(func(item models.QueryHistoryItem) {
+		mainPages.RemovePage(pageNameQueryHistory)
+		onRerun(item)
+	})
 
 	qhm.tabbedPane = NewTabbedPane()
 	qhm.tabbedPane.AppendTab("Saved Queries", qhm.savedQueriesComponent, savedQueryTabReference)
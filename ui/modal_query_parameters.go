@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/sqlparse"
+)
+
+// QueryParametersModal asks for the values of the named parameters of a query.
+type QueryParametersModal struct {
+	tview.Primitive
+	form       *tview.Form
+	errorText  *tview.TextView
+	parameters []sqlparse.Parameter
+	onSubmit   func(values map[string]any, texts map[string]string)
+}
+
+// NewQueryParametersModal creates a new QueryParametersModal filled with the
+// values given last time.
+func NewQueryParametersModal(parameters []sqlparse.Parameter, remembered map[string]string, onSubmit func(values map[string]any, texts map[string]string)) *QueryParametersModal {
+	qpm := &QueryParametersModal{
+		parameters: parameters,
+		onSubmit:   onSubmit,
+	}
+
+	qpm.form = tview.NewForm().SetFieldStyle(
+		tcell.StyleDefault.
+			Background(app.Styles.SecondaryTextColor).
+			Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonActivatedStyle(tcell.StyleDefault.
+		Background(app.Styles.InverseTextColor).
+		Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonStyle(tcell.StyleDefault.
+		Background(app.Styles.ButtonBackgroundColor).
+		Foreground(app.Styles.PrimaryTextColor),
+	)
+
+	for _, parameter := range parameters {
+		label := parameter.Name
+		if parameter.Type != "" {
+			label = fmt.Sprintf("%s (%s)", parameter.Name, parameter.Type)
+		}
+		qpm.form.AddInputField(label, remembered[parameter.Name], 30, nil, nil)
+	}
+
+	qpm.form.AddButton("Run", qpm.submit).AddButton("Cancel", qpm.cancel)
+
+	qpm.form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			qpm.cancel()
+			return nil
+		}
+
+		if event.Key() == tcell.KeyEnter {
+			if _, button := qpm.form.GetFocusedItemIndex(); button == 1 {
+				qpm.cancel()
+			} else {
+				qpm.submit()
+			}
+			return nil
+		}
+
+		return event
+	})
+
+	qpm.errorText = tview.NewTextView().SetTextColor(app.Styles.ErrorColor)
+	qpm.errorText.SetBorderPadding(0, 0, 1, 1)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(qpm.form, 0, 1, true).
+		AddItem(qpm.errorText, 1, 0, false)
+	layout.SetBorder(true).SetTitle(" Query Parameters ").SetTitleAlign(tview.AlignLeft)
+
+	height := len(parameters)*2 + 6
+
+	qpm.Primitive = tview.NewGrid().
+		SetRows(0, height, 0).
+		SetColumns(0, 60, 0).
+		AddItem(layout, 1, 1, 1, 1, 0, 0, true)
+
+	return qpm
+}
+
+// submit validates the values against the type hints of their parameters
+func (qpm *QueryParametersModal) submit() {
+	values := map[string]any{}
+	texts := map[string]string{}
+
+	for i, parameter := range qpm.parameters {
+		text := qpm.form.GetFormItem(i).(*tview.InputField).GetText()
+
+		value, err := sqlparse.ConvertParameter(text, parameter.Type)
+		if err != nil {
+			qpm.errorText.SetText(fmt.Sprintf("%s: %s", parameter.Name, err))
+			qpm.form.SetFocus(i)
+			App.SetFocus(qpm.form)
+			return
+		}
+
+		values[parameter.Name] = value
+		texts[parameter.Name] = text
+	}
+
+	if qpm.onSubmit != nil {
+		qpm.onSubmit(values, texts)
+	}
+}
+
+func (qpm *QueryParametersModal) cancel() {
+	mainPages.RemovePage(pageNameQueryParameters)
+}
+
+// GetPrimitive returns the primitive for this component.
+func (qpm *QueryParametersModal) GetPrimitive() tview.Primitive {
+	return qpm.Primitive
+}
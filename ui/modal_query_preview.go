@@ -24,7 +24,9 @@ type QueryPreviewModal struct {
 	Error    *tview.Modal
 }
 
-func NewQueryPreviewModal(queries *[]models.DBDMLChange, dbdriver drivers.Driver, onFinish func()) *QueryPreviewModal {
+// NewQueryPreviewModal creates a new QueryPreviewModal saving the changes to
+// a connection, its name being typed first when it is a production one.
+func NewQueryPreviewModal(queries *[]models.DBDMLChange, dbdriver drivers.Driver, connection *models.Connection, onFinish func()) *QueryPreviewModal {
 	modal := func(p tview.Primitive) tview.Primitive {
 		return tview.NewFlex().
 			AddItem(nil, 0, 1, false).
@@ -83,6 +85,19 @@ func NewQueryPreviewModal(queries *[]models.DBDMLChange, dbdriver drivers.Driver
 		if command == commands.Quit || event.Key() == tcell.KeyEsc {
 			mainPages.RemovePage(pageNameDMLPreview)
 		} else if command == commands.Save {
+			if connection != nil && connection.IsProduction() {
+				confirmProduction(connection, fmt.Sprintf("Save %d changes?", len(*queries)), func() {
+					if err := dbdriver.ExecutePendingChanges(*queries); err != nil {
+						r.SetError(err.Error())
+						return
+					}
+
+					onFinish()
+					mainPages.RemovePage(pageNameDMLPreview)
+				})
+				return nil
+			}
+
 			confirmationModal := NewConfirmationModal("Are you sure you want to save the queries?")
 
 			confirmationModal.SetDoneFunc(func(_ int, buttonLabel string) {
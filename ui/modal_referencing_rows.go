@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+)
+
+// ReferencingRowsModal lists the tables with rows pointing to a given row.
+type ReferencingRowsModal struct {
+	tview.Primitive
+	Table *tview.Table
+}
+
+func NewReferencingRowsModal(tableName string, results []referencingRows, onSelect func(result referencingRows)) *ReferencingRowsModal {
+	table := tview.NewTable()
+	table.SetBorders(true)
+	table.SetBorder(true)
+	table.SetTitle(fmt.Sprintf(" Rows referencing %s ", tableName))
+	table.SetFixed(1, 0)
+	table.SetSelectable(true, false)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(app.Styles.SecondaryTextColor).Foreground(tview.Styles.ContrastSecondaryTextColor))
+
+	for i, header := range []string{"Table", "Columns", "Rows"} {
+		table.SetCell(0, i, tview.NewTableCell(header).SetSelectable(false).SetTextColor(app.Styles.TertiaryTextColor))
+	}
+
+	for i, result := range results {
+		table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(result.ForeignKey.Table)).SetExpansion(1))
+		table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(strings.Join(result.ForeignKey.Columns, ", "))).SetExpansion(1))
+		table.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(result.Count)).SetAlign(tview.AlignRight))
+	}
+
+	if len(results) == 0 {
+		table.SetCell(1, 0, tview.NewTableCell("No table references this row").SetSelectable(false))
+	}
+
+	table.SetSelectedFunc(func(row, _ int) {
+		if row < 1 || row > len(results) {
+			return
+		}
+
+		mainPages.RemovePage(pageNameReferencingRows)
+		onSelect(results[row-1])
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'q' {
+			mainPages.RemovePage(pageNameReferencingRows)
+			return nil
+		}
+		return event
+	})
+
+	grid := tview.NewGrid().
+		SetRows(0, max(len(results)*2+3, 5), 0).
+		SetColumns(0, 80, 0).
+		AddItem(table, 1, 1, 1, 1, 0, 0, true)
+
+	return &ReferencingRowsModal{
+		Primitive: grid,
+		Table:     table,
+	}
+}
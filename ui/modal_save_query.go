@@ -1,33 +1,52 @@
 package ui
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
 	"sqlcmder/cmd/app"
 	"sqlcmder/data/queries"
+	"sqlcmder/models"
 )
 
-// SaveQueryModal is a modal for saving a query with a name.
+// SaveQueryModal is a modal for saving a query with a name, or for editing
+// a saved query.
 type SaveQueryModal struct {
 	tview.Primitive
-	form                 *tview.Form
-	grid                 *tview.Grid
-	query                string
-	onSave               func()
-	connectionIdentifier string
+	form       *tview.Form
+	grid       *tview.Grid
+	statusText *tview.TextView
+	original   models.SavedQuery
+	editing    bool
+	scopes     []string
+	onSave     func()
+	library    queries.Library
 }
 
-// NewSaveQueryModal creates a new SaveQueryModal.
-func NewSaveQueryModal(connectionIdentifier, query string, onSave func()) *SaveQueryModal {
+// NewSaveQueryModal creates a new SaveQueryModal. When editing, the query is
+// replaced in the library instead of added to it.
+func NewSaveQueryModal(library queries.Library, query models.SavedQuery, editing bool, onSave func()) *SaveQueryModal {
 	sqm := &SaveQueryModal{
-		query:                query,
-		onSave:               onSave,
-		connectionIdentifier: connectionIdentifier,
+		original: query,
+		editing:  editing,
+		onSave:   onSave,
+		library:  library,
 	}
 
+	sqm.scopes = library.AvailableScopes()
+
+	scopeIndex := max(slices.Index(sqm.scopes, query.Scope), 0)
+
 	sqm.form = tview.NewForm().
-		AddInputField("Name", "", 20, nil, nil).
+		AddInputField("Name", query.Name, 40, nil, nil).
+		AddInputField("Folder", query.Folder, 40, nil, nil).
+		AddInputField("Description", query.Description, 40, nil, nil).
+		AddInputField("Tags", strings.Join(query.Tags, ", "), 40, nil, nil).
+		AddDropDown("Scope", sqm.scopes, scopeIndex, nil).
+		AddTextArea("Query", query.Query, 40, 6, 0, nil).
 		AddButton("Save", sqm.save).
 		AddButton("Cancel", sqm.cancel).SetFieldStyle(
 		tcell.StyleDefault.
@@ -47,36 +66,81 @@ func NewSaveQueryModal(connectionIdentifier, query string, onSave func()) *SaveQ
 			return nil
 		}
 
-		if event.Key() == tcell.KeyEnter {
-			sqm.save()
+		// Enter starts a new line in the query
+		item, button := sqm.form.GetFocusedItemIndex()
+		if event.Key() == tcell.KeyEnter && sqm.form.GetFormItemIndex("Query") != item {
+			if button == 1 {
+				sqm.cancel()
+			} else {
+				sqm.save()
+			}
 			return nil
 		}
 
 		return event
 	})
 
-	sqm.form.SetBorder(true).SetTitle(" Save Query ").SetTitleAlign(tview.AlignLeft)
+	title := " Save Query "
+	if editing {
+		title = " Edit Saved Query "
+	}
+
+	sqm.statusText = tview.NewTextView().SetTextColor(app.Styles.ErrorColor)
+	sqm.statusText.SetBorderPadding(0, 0, 1, 1)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(sqm.form, 0, 1, true).
+		AddItem(sqm.statusText, 1, 0, false)
+	layout.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft)
 
 	sqm.grid = tview.NewGrid().
-		SetRows(0, 7, 0).
-		SetColumns(0, 50, 0).
-		AddItem(sqm.form, 1, 1, 1, 1, 0, 0, true)
+		SetRows(0, 22, 0).
+		SetColumns(0, 60, 0).
+		AddItem(layout, 1, 1, 1, 1, 0, 0, true)
 
 	sqm.Primitive = sqm.grid
 
 	return sqm
 }
 
+// savedQuery returns the query described by the form
+func (sqm *SaveQueryModal) savedQuery() models.SavedQuery {
+	query := sqm.original
+
+	query.Name = strings.TrimSpace(sqm.form.GetFormItemByLabel("Name").(*tview.InputField).GetText())
+	query.Folder = strings.Trim(strings.TrimSpace(sqm.form.GetFormItemByLabel("Folder").(*tview.InputField).GetText()), "/")
+	query.Description = strings.TrimSpace(sqm.form.GetFormItemByLabel("Description").(*tview.InputField).GetText())
+	query.Query = sqm.form.GetFormItemByLabel("Query").(*tview.TextArea).GetText()
+
+	query.Tags = nil
+	for _, tag := range strings.Split(sqm.form.GetFormItemByLabel("Tags").(*tview.InputField).GetText(), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			query.Tags = append(query.Tags, tag)
+		}
+	}
+
+	_, scope := sqm.form.GetFormItemByLabel("Scope").(*tview.DropDown).GetCurrentOption()
+	query.Scope = scope
+
+	return query
+}
+
 func (sqm *SaveQueryModal) save() {
-	name := sqm.form.GetFormItem(0).(*tview.InputField).GetText()
-	if name == "" {
-		// TODO: Show an error message
+	query := sqm.savedQuery()
+	if query.Name == "" {
+		sqm.statusText.SetText("Name is required")
 		return
 	}
 
-	err := queries.SaveQuery(sqm.connectionIdentifier, name, sqm.query)
+	var err error
+	if sqm.editing {
+		err = sqm.library.Update(sqm.original, query)
+	} else {
+		err = sqm.library.Save(query)
+	}
+
 	if err != nil {
-		// TODO: Show an error message
+		sqm.statusText.SetText(err.Error())
 		return
 	}
 
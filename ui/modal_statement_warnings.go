@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/drivers"
+	"sqlcmder/sqlparse"
+)
+
+const (
+	warningButtonRun    = "Run"
+	warningButtonCancel = "Cancel"
+)
+
+// lintStatement returns the warnings of a statement about to be run with a
+// driver, the sizes of its tables read from the database statistics. With
+// AutoLimit, the limit of the guard is added to the queries that can have one
+// and they do not warn. It must not be called on the UI goroutine.
+func lintStatement(driver drivers.Driver, statement string) (string, []sqlparse.Warning) {
+	guard := app.App.Config().Guard
+
+	warnings := sqlparse.Lint(driver.GetProvider(), statement, sqlparse.GuardOptions(guard), func(table string) (int64, bool) {
+		return drivers.EstimateRows(driver, table)
+	})
+
+	if guard.AutoLimit && guard.SelectLimit > 0 {
+		statement = sqlparse.WithLimit(statement, warnings, guard.SelectLimit)
+		warnings = slices.DeleteFunc(warnings, sqlparse.Warning.Limitable)
+	}
+
+	return statement, warnings
+}
+
+// confirmWarnings shows the warnings of a statement, run with the statement
+// once confirmed, or with the statement limited when a limit is picked.
+// onCancel is called otherwise. It must be called on the UI goroutine.
+func confirmWarnings(statement string, warnings []sqlparse.Warning, run func(statement string), onCancel func()) {
+	limit := app.App.Config().Guard.SelectLimit
+	limitButton := fmt.Sprintf("Add LIMIT %d", limit)
+
+	text := "This statement may be risky:\n"
+	for _, warning := range warnings {
+		text += "\n- " + tview.Escape(warning.Message())
+	}
+
+	buttons := []string{warningButtonRun}
+	if limit > 0 && slices.ContainsFunc(warnings, sqlparse.Warning.Limitable) {
+		buttons = append(buttons, limitButton)
+	}
+	buttons = append(buttons, warningButtonCancel)
+
+	modal := NewConfirmationModal(strings.TrimSpace(text))
+	modal.ClearButtons()
+	modal.AddButtons(buttons)
+	modal.SetDoneFunc(func(_ int, buttonLabel string) {
+		mainPages.RemovePage(pageNameConfirmation)
+
+		switch buttonLabel {
+		case warningButtonRun:
+			run(statement)
+		case limitButton:
+			run(sqlparse.WithLimit(statement, warnings, limit))
+		default:
+			if onCancel != nil {
+				onCancel()
+			}
+		}
+	})
+
+	mainPages.AddPage(pageNameConfirmation, modal, true, true)
+	App.SetFocus(modal)
+}
@@ -0,0 +1,360 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	commands "sqlcmder/cli"
+	"sqlcmder/cmd/app"
+	"sqlcmder/db"
+	"sqlcmder/drivers"
+	"sqlcmder/helpers"
+	"sqlcmder/keymap"
+	"sqlcmder/logger"
+	"sqlcmder/models"
+)
+
+const tableDiffCurrentConnection = "(current connection)"
+
+// TableDiffModal compares a table with another table, possibly on another
+// connection, and generates the queries that make the target match the source.
+type TableDiffModal struct {
+	tview.Primitive
+	Pages       *tview.Pages
+	Form        *tview.Form
+	Table       *tview.Table
+	StatusText  *tview.TextView
+	source      drivers.TableReference
+	connection  *models.Connection // Connection of the source table
+	target      *models.Connection // Connection of the target table
+	closeTarget func()             // Closes the driver and tunnel opened for the target
+	closed      bool
+	diff        *drivers.TableDiff
+	diffs       []drivers.TableRowDiff
+}
+
+func NewTableDiffModal(source drivers.TableReference, connection *models.Connection) *TableDiffModal {
+	modal := func(p tview.Primitive) tview.Primitive {
+		return tview.NewFlex().
+			AddItem(nil, 0, 1, false).
+			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(p, 0, 3, true).
+				AddItem(nil, 0, 1, false), 0, 3, true).
+			AddItem(nil, 0, 1, false)
+	}
+
+	tdm := &TableDiffModal{
+		Pages:      tview.NewPages(),
+		Form:       tview.NewForm(),
+		Table:      tview.NewTable(),
+		StatusText: tview.NewTextView(),
+		source:     source,
+		connection: connection,
+	}
+
+	connectionNames := []string{tableDiffCurrentConnection}
+	for _, conn := range app.App.Connections() {
+		connectionNames = append(connectionNames, conn.Name)
+	}
+
+	tdm.Form.AddDropDown("Target connection", connectionNames, 0, nil)
+	tdm.Form.AddInputField("Target database", source.Database, 40, nil, nil)
+	tdm.Form.AddInputField("Target table", source.Table, 40, nil, nil)
+	tdm.Form.AddButton("Compare", tdm.compare)
+	tdm.Form.AddButton("Cancel", tdm.close)
+	tdm.Form.SetFieldStyle(tcell.StyleDefault.
+		Background(app.Styles.SecondaryTextColor).
+		Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonActivatedStyle(tcell.StyleDefault.
+		Background(app.Styles.InverseTextColor).
+		Foreground(app.Styles.ContrastSecondaryTextColor),
+	).SetButtonStyle(tcell.StyleDefault.
+		Background(app.Styles.ButtonBackgroundColor).
+		Foreground(app.Styles.PrimaryTextColor),
+	)
+	tdm.Form.SetBorder(true)
+	tdm.Form.SetTitle(fmt.Sprintf(" Compare %s with ", source.Table))
+	tdm.Form.SetTitleAlign(tview.AlignLeft)
+	tdm.Form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			tdm.close()
+			return nil
+		}
+		return event
+	})
+
+	tdm.Table.SetBorders(true)
+	tdm.Table.SetBorder(true)
+	tdm.Table.SetFixed(1, 1)
+	tdm.Table.SetSelectable(true, false)
+	tdm.Table.SetSelectedStyle(tcell.StyleDefault.Background(app.Styles.SecondaryTextColor).Foreground(tview.Styles.ContrastSecondaryTextColor))
+	tdm.Table.SetInputCapture(tdm.tableInputCapture)
+
+	keybindings := tview.NewTextView()
+	keybindings.SetDynamicColors(true)
+	keybindings.SetWrap(false)
+	keybindings.SetBorder(true)
+	keybindings.SetTitle(" Keybindings ")
+
+	for _, command := range keymap.Keymaps.Group(keymap.TableDiffGroup) {
+		keybindings.SetText(fmt.Sprintf("%s [yellow](%s) [default]%s", keybindings.GetText(false), command.Key.String(), command.Description))
+	}
+
+	tdm.StatusText.SetDynamicColors(true)
+	tdm.StatusText.SetTextColor(app.Styles.TertiaryTextColor)
+
+	results := tview.NewFlex().SetDirection(tview.FlexColumnCSS).
+		AddItem(tdm.Table, 0, 1, true).
+		AddItem(keybindings, 3, 0, false)
+
+	formContainer := tview.NewFlex().SetDirection(tview.FlexColumnCSS).
+		AddItem(tdm.Form, 0, 1, true).
+		AddItem(tdm.StatusText, 1, 0, false)
+
+	tdm.Pages.AddPage("form", formContainer, true, true)
+	tdm.Pages.AddPage("results", results, true, false)
+
+	tdm.Primitive = modal(tdm.Pages)
+
+	return tdm
+}
+
+func (tdm *TableDiffModal) compare() {
+	_, connectionName := tdm.Form.GetFormItem(0).(*tview.DropDown).GetCurrentOption()
+	targetDatabase := tdm.Form.GetFormItem(1).(*tview.InputField).GetText()
+	targetTable := tdm.Form.GetFormItem(2).(*tview.InputField).GetText()
+
+	if targetTable == "" {
+		tdm.setStatus("Target table is required", app.Styles.ErrorColor)
+		return
+	}
+
+	tdm.setStatus("Comparing...", app.Styles.TertiaryTextColor)
+	tdm.closeTargetDriver()
+
+	go func() {
+		target, targetDriver, closeTarget, err := tdm.targetDriver(connectionName)
+		if err != nil {
+			App.QueueUpdateDraw(func() {
+				tdm.setStatus(err.Error(), app.Styles.ErrorColor)
+			})
+			return
+		}
+
+		tableDiff := drivers.NewTableDiff(tdm.source, drivers.TableReference{
+			Driver:   targetDriver,
+			Database: targetDatabase,
+			Table:    targetTable,
+		})
+
+		diffs := []drivers.TableRowDiff{}
+		err = tableDiff.Run(func(diff drivers.TableRowDiff) error {
+			diffs = append(diffs, diff)
+			return nil
+		})
+
+		App.QueueUpdateDraw(func() {
+			// The target stays open for the sync queries to be applied to it
+			tdm.closeTarget = closeTarget
+			if tdm.closed {
+				tdm.closeTargetDriver()
+				return
+			}
+
+			if err != nil {
+				tdm.closeTargetDriver()
+				tdm.setStatus(err.Error(), app.Styles.ErrorColor)
+				return
+			}
+
+			tdm.target = target
+			tdm.diff = tableDiff
+			tdm.diffs = diffs
+			tdm.populateTable()
+			tdm.Pages.SwitchToPage("results")
+			App.SetFocus(tdm.Table)
+		})
+	}()
+}
+
+// targetDriver returns the current connection and its driver or connects to
+// the selected one. What was opened for the target is closed with
+// closeTarget.
+func (tdm *TableDiffModal) targetDriver(connectionName string) (target *models.Connection, driver drivers.Driver, closeTarget func(), err error) {
+	if connectionName == tableDiffCurrentConnection {
+		return tdm.connection, tdm.source.Driver, func() {}, nil
+	}
+
+	for _, conn := range app.App.Connections() {
+		if conn.Name != connectionName {
+			continue
+		}
+
+		dsn, err := connectionDSN(&conn)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		driver, closeTarget, err := db.Connect(conn, dsn, openTunnel)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return &conn, driver, closeTarget, nil
+	}
+
+	return nil, nil, nil, fmt.Errorf("connection %s not found", connectionName)
+}
+
+func (tdm *TableDiffModal) populateTable() {
+	tdm.Table.Clear()
+
+	inserted, deleted, changed := 0, 0, 0
+	for _, diff := range tdm.diffs {
+		switch diff.Type {
+		case drivers.TableDiffInserted:
+			inserted++
+		case drivers.TableDiffDeleted:
+			deleted++
+		case drivers.TableDiffChanged:
+			changed++
+		}
+	}
+
+	tdm.Table.SetTitle(fmt.Sprintf(" %s → %s: %d inserted, %d deleted, %d changed ", tdm.source.Table, tdm.diff.Target.Table, inserted, deleted, changed))
+
+	tdm.Table.SetCell(0, 0, tview.NewTableCell("Change").SetSelectable(false).SetTextColor(app.Styles.TertiaryTextColor))
+	for i, column := range tdm.diff.Columns {
+		tdm.Table.SetCell(0, i+1, tview.NewTableCell(tview.Escape(column)).SetSelectable(false).SetTextColor(app.Styles.TertiaryTextColor))
+	}
+
+	for rowIndex, diff := range tdm.diffs {
+		row := rowIndex + 1
+
+		typeCell := tview.NewTableCell(diff.Type.String())
+
+		switch diff.Type {
+		case drivers.TableDiffInserted:
+			typeCell.SetBackgroundColor(colorTableInsert)
+			for i, value := range diff.Source {
+				tdm.Table.SetCell(row, i+1, tview.NewTableCell(tview.Escape(value)).SetBackgroundColor(colorTableInsert))
+			}
+		case drivers.TableDiffDeleted:
+			typeCell.SetBackgroundColor(colorTableDelete)
+			for i, value := range diff.Target {
+				tdm.Table.SetCell(row, i+1, tview.NewTableCell(tview.Escape(value)).SetBackgroundColor(colorTableDelete))
+			}
+		case drivers.TableDiffChanged:
+			typeCell.SetBackgroundColor(colorTableChange)
+			for i, value := range diff.Source {
+				tdm.Table.SetCell(row, i+1, tview.NewTableCell(tview.Escape(value)))
+			}
+			for _, i := range diff.ChangedColumns {
+				text := fmt.Sprintf("%s → %s", diff.Target[i], diff.Source[i])
+				tdm.Table.SetCell(row, i+1, tview.NewTableCell(tview.Escape(text)).SetBackgroundColor(colorTableChange))
+			}
+		}
+
+		tdm.Table.SetCell(row, 0, typeCell)
+	}
+
+	if len(tdm.diffs) == 0 {
+		tdm.Table.SetCell(1, 0, tview.NewTableCell("Tables are identical").SetSelectable(false))
+	}
+
+	tdm.Table.Select(1, 0)
+}
+
+func (tdm *TableDiffModal) tableInputCapture(event *tcell.EventKey) *tcell.EventKey {
+	command := keymap.Keymaps.Group(keymap.TableDiffGroup).Resolve(event)
+
+	switch {
+	case command == commands.Quit || event.Key() == tcell.KeyEsc:
+		tdm.close()
+		return nil
+	case command == commands.Copy:
+		queries, err := tdm.diff.SyncQueries(tdm.diffs)
+		if err != nil {
+			tdm.showError(err.Error())
+			return nil
+		}
+
+		if err := helpers.NewClipboard().Write(strings.Join(queries, ";\n") + ";"); err != nil {
+			logger.Info("Error copying sync queries", map[string]any{"error": err.Error()})
+		}
+		return nil
+	case command == commands.Save:
+		if len(tdm.diffs) == 0 {
+			return nil
+		}
+
+		if tdm.target != nil && tdm.target.ReadOnly {
+			tdm.showError(fmt.Sprintf("%s is read-only", tdm.target.Name))
+			return nil
+		}
+
+		text := fmt.Sprintf("Apply %d changes to %s?", len(tdm.diffs), tdm.diff.Target.Table)
+		if tdm.target != nil && tdm.target.IsProduction() {
+			confirmProduction(tdm.target, tview.Escape(text), tdm.sync)
+			return nil
+		}
+
+		confirmationModal := NewConfirmationModal(text)
+		confirmationModal.SetDoneFunc(func(_ int, buttonLabel string) {
+			mainPages.RemovePage(pageNameConfirmation)
+
+			if buttonLabel != "Yes" {
+				return
+			}
+
+			tdm.sync()
+		})
+
+		mainPages.AddPage(pageNameConfirmation, confirmationModal, true, true)
+		return nil
+	}
+
+	return event
+}
+
+// sync applies the differences to the target table
+func (tdm *TableDiffModal) sync() {
+	if err := tdm.diff.Target.Driver.ExecutePendingChanges(tdm.diff.SyncChanges(tdm.diffs)); err != nil {
+		tdm.showError(err.Error())
+		return
+	}
+
+	tdm.close()
+}
+
+func (tdm *TableDiffModal) showError(message string) {
+	errorModal := NewErrorModal(message)
+	errorModal.SetDoneFunc(func(_ int, _ string) {
+		mainPages.RemovePage(pageNameErrorModal)
+		App.SetFocus(tdm.Table)
+	})
+
+	mainPages.AddPage(pageNameErrorModal, errorModal, true, true)
+}
+
+func (tdm *TableDiffModal) setStatus(text string, color tcell.Color) {
+	tdm.StatusText.SetText(text).SetTextColor(color)
+}
+
+func (tdm *TableDiffModal) close() {
+	mainPages.RemovePage(pageNameTableDiff)
+	tdm.closed = true
+	tdm.closeTargetDriver()
+}
+
+// closeTargetDriver closes the connection opened to the target, if any
+func (tdm *TableDiffModal) closeTargetDriver() {
+	if tdm.closeTarget != nil {
+		go tdm.closeTarget()
+		tdm.closeTarget = nil
+	}
+}
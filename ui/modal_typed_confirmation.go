@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"sqlcmder/cmd/app"
+	"sqlcmder/models"
+)
+
+// TypedConfirmationModal asks for a word to be typed, like the name of a
+// production connection, before going on with a change.
+type TypedConfirmationModal struct {
+	tview.Primitive
+	Input     *tview.InputField
+	status    *tview.TextView
+	expected  string
+	onConfirm func()
+}
+
+// NewTypedConfirmationModal creates a new TypedConfirmationModal telling what
+// is about to happen, onConfirm being called once expected is typed.
+func NewTypedConfirmationModal(text string, expected string, onConfirm func()) *TypedConfirmationModal {
+	tcm := &TypedConfirmationModal{
+		Input:     tview.NewInputField(),
+		status:    tview.NewTextView(),
+		expected:  expected,
+		onConfirm: onConfirm,
+	}
+
+	message := tview.NewTextView()
+	message.SetDynamicColors(true)
+	message.SetWordWrap(true)
+	message.SetText(text)
+	message.SetTextColor(app.Styles.PrimaryTextColor)
+
+	tcm.Input.SetLabel(fmt.Sprintf("Type %s: ", tview.Escape(expected)))
+	tcm.Input.SetLabelColor(app.Styles.PrimaryTextColor)
+	tcm.Input.SetFieldBackgroundColor(app.Styles.InverseTextColor)
+	tcm.Input.SetFieldTextColor(app.Styles.ContrastSecondaryTextColor)
+	tcm.Input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			tcm.confirm()
+		case tcell.KeyEsc:
+			tcm.close()
+		}
+	})
+
+	tcm.status.SetText("Enter to confirm, Esc to cancel")
+	tcm.status.SetTextColor(app.Styles.TertiaryTextColor)
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(message, 0, 1, false).
+		AddItem(tcm.Input, 1, 0, true).
+		AddItem(tcm.status, 1, 0, false)
+	content.SetBorder(true)
+	content.SetBorderPadding(1, 0, 1, 1)
+	content.SetBorderColor(app.Styles.ErrorColor)
+	content.SetTitle(" Confirm change ")
+	content.SetTitleAlign(tview.AlignLeft)
+
+	tcm.Primitive = tview.NewGrid().
+		SetRows(0, 11, 0).
+		SetColumns(0, 80, 0).
+		AddItem(content, 1, 1, 1, 1, 0, 0, true)
+
+	return tcm
+}
+
+func (tcm *TypedConfirmationModal) confirm() {
+	if tcm.Input.GetText() != tcm.expected {
+		tcm.status.SetText(fmt.Sprintf("Type %s exactly to confirm", tview.Escape(tcm.expected)))
+		tcm.status.SetTextColor(app.Styles.ErrorColor)
+		return
+	}
+
+	tcm.close()
+	tcm.onConfirm()
+}
+
+func (tcm *TypedConfirmationModal) close() {
+	mainPages.RemovePage(pageNameTypedConfirmation)
+}
+
+// confirmProduction runs action once the name of a production connection is
+// typed, and right away for other connections. It must be called on the UI
+// goroutine.
+func confirmProduction(connection *models.Connection, text string, action func()) {
+	if connection == nil || !connection.IsProduction() {
+		action()
+		return
+	}
+
+	modal := NewTypedConfirmationModal(fmt.Sprintf("[%s]%s is a production connection.[-]\n\n%s",
+		environmentColor(*connection).String(), tview.Escape(connection.Name), text), connection.Name, action)
+	mainPages.AddPage(pageNameTypedConfirmation, modal, true, true)
+	App.SetFocus(modal.Input)
+}
+
+// environmentColor returns the color of the environment of a connection:
+// its own color, or green, yellow and red for dev, staging and prod
+func environmentColor(connection models.Connection) tcell.Color {
+	if connection.Color != "" {
+		if color := tcell.GetColor(connection.Color); color != tcell.ColorDefault {
+			return color
+		}
+	}
+
+	switch {
+	case connection.IsProduction():
+		return app.Styles.ErrorColor
+	case connection.Environment == models.EnvironmentStaging:
+		return app.Styles.WarningColor
+	case connection.Environment == models.EnvironmentDev:
+		return app.Styles.SuccessColor
+	}
+	return app.Styles.PrimaryTextColor
+}
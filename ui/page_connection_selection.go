@@ -5,17 +5,22 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
 	commands "sqlcmder/cli"
 	"sqlcmder/cmd/app"
+	"sqlcmder/data/secrets"
+	"sqlcmder/db"
 	"sqlcmder/drivers"
 	"sqlcmder/helpers"
 	"sqlcmder/keymap"
 	"sqlcmder/logger"
 	"sqlcmder/models"
+	"sqlcmder/process"
+	"sqlcmder/tunnel"
 )
 
 type ConnectionSelection struct {
@@ -23,6 +28,10 @@ type ConnectionSelection struct {
 	StatusText *tview.TextView
 }
 
+// connectionSelectionPage connects to the connections opened from elsewhere,
+// like the history of every connection
+var connectionSelectionPage *ConnectionSelection
+
 func NewConnectionSelection(connectionForm *ConnectionForm, connectionPages *models.ConnectionPages) *ConnectionSelection {
 	wrapper := tview.NewFlex()
 
@@ -70,12 +79,14 @@ func NewConnectionSelection(connectionForm *ConnectionForm, connectionPages *mod
 
 	// Hint text above buttons
 	hintText := tview.NewTextView()
-	hintText.SetText("  [yellow]Up/Down[white] Select  [yellow]Enter[white] Connect  [yellow]N[white]ew  [yellow]E[white]dit  [yellow]D[white]elete  [yellow]Q[white]uit")
+	hintText.SetText("  [yellow]Up/Down[white] Select  [yellow]Enter[white] Connect/Fold  [yellow]/[white] Filter  [yellow]N[white]ew  [yellow]E[white]dit  [yellow]D[white]elete  [yellow]Q[white]uit")
 	hintText.SetDynamicColors(true)
 	hintText.SetTextAlign(tview.AlignCenter)
 	hintText.SetBackgroundColor(app.Styles.PrimitiveBackgroundColor)
 
-	wrapper.AddItem(NewConnectionsTable(), 0, 1, true)
+	table := NewConnectionsTable()
+	wrapper.AddItem(table.Filter, 1, 0, false)
+	wrapper.AddItem(table, 0, 1, true)
 	wrapper.AddItem(statusText, 4, 0, false)
 	wrapper.AddItem(hintText, 1, 0, false) // Hint area (1 line)
 	wrapper.AddItem(buttonsWrapper, 3, 0, false)
@@ -84,23 +95,59 @@ func NewConnectionSelection(connectionForm *ConnectionForm, connectionPages *mod
 		Flex:       wrapper,
 		StatusText: statusText,
 	}
+	connectionSelectionPage = cs
 
 	wrapper.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Keys typed in the filter are not commands
+		if connectionsTable.Filter.HasFocus() {
+			return event
+		}
+
 		connections := connectionsTable.GetConnections()
 
 		command := keymap.Keymaps.Group(keymap.ConnectionGroup).Resolve(event)
 
-		if len(connections) != 0 {
-			row, _ := connectionsTable.GetSelection()
-			selectedConnection := connections[row]
+		if group, ok := connectionsTable.SelectedGroup(); ok && command == commands.Connect {
+			connectionsTable.ToggleGroup(group)
+			return nil
+		}
 
+		if selectedConnection, index, ok := connectionsTable.SelectedConnection(); ok {
 			switch command {
 			case commands.Connect:
 				go cs.Connect(selectedConnection)
+			case commands.Disconnect:
+				if value, ok := homes.Load(selectedConnection.Name); ok {
+					value.(*Home).Close()
+					connectionsTable.Refresh()
+					cs.StatusText.SetText("Closed " + selectedConnection.Name).SetTextColor(app.Styles.TertiaryTextColor)
+				}
+				return nil
+			case commands.CommandLog:
+				showCommandLog(selectedConnection.Name)
+				return nil
+			case commands.TestConnection:
+				go func() {
+					dsn, err := connectionDSN(&selectedConnection)
+					if err != nil {
+						App.QueueUpdateDraw(func() {
+							cs.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+						})
+						return
+					}
+					diagnoseConnection(selectedConnection, dsn)
+				}()
+				return nil
 			case commands.EditConnection:
 				connectionPages.SwitchToPage(pageNameConnectionForm)
 				connectionForm.NameField.SetText(selectedConnection.Name)
 				connectionForm.DSNField.SetText(selectedConnection.GetDSN())
+				connectionForm.PassField.SetText(selectedConnection.Password)
+				connectionForm.setPasswordSource(selectedConnection)
+				connectionForm.setSSHTunnel(selectedConnection.SSH)
+				connectionForm.setTLSSettings(selectedConnection.TLS)
+				connectionForm.OptionsField.SetText(drivers.FormatOptions(selectedConnection.Options))
+				connectionForm.setGrouping(selectedConnection)
 				connectionForm.StatusText.SetText("")
 				// Show DSN hint/value for edit connection
 				connectionForm.showDSNHint()
@@ -109,7 +156,7 @@ func NewConnectionSelection(connectionForm *ConnectionForm, connectionPages *mod
 				return nil
 			case commands.DeleteConnection:
 				// Capture the current row and connections in this scope to avoid closure issues
-				currentRow := row
+				currentRow := index
 				currentConnections := connections
 				selectedConnectionToDelete := selectedConnection
 
@@ -177,6 +224,25 @@ func NewConnectionSelection(connectionForm *ConnectionForm, connectionPages *mod
 		}
 
 		switch command {
+		case commands.Search:
+			App.SetFocus(connectionsTable.Filter)
+			return nil
+		case commands.GlobalHistory:
+			showGlobalHistory()
+			return nil
+		case commands.ImportConnections:
+			showImportConnections()
+			return nil
+		case commands.ExportBundle:
+			showBundleFile(" Export Bundle ", exportBundle)
+			return nil
+		case commands.ImportBundle:
+			showBundleFile(" Import Bundle ", importBundle)
+			return nil
+		case commands.ForgetCredentials:
+			secrets.ForgetAll()
+			cs.StatusText.SetText("Forgot the passwords asked for and locked the vault").SetTextColor(app.Styles.TertiaryTextColor)
+			return nil
 		case commands.NewConnection:
 			connectionForm.SetAction(actionNewConnection)
 			// Reset to PostgreSQL defaults
@@ -185,10 +251,15 @@ func NewConnectionSelection(connectionForm *ConnectionForm, connectionPages *mod
 			connectionForm.HostField.SetText("localhost")
 			connectionForm.PortField.SetText("5432")
 			connectionForm.UserField.SetText("postgres")
-			connectionForm.PassField.SetText("postgres")
+			connectionForm.PassField.SetText("")
 			connectionForm.DBNameField.SetText("")
 			connectionForm.DSNField.SetText("")
 			connectionForm.StatusText.SetText("")
+			connectionForm.setPasswordSource(models.Connection{PasswordSource: models.PasswordSourcePrompt})
+			connectionForm.setSSHTunnel(nil)
+			connectionForm.setTLSSettings(nil)
+			connectionForm.OptionsField.SetText("")
+			connectionForm.setGrouping(models.Connection{})
 			// Show DSN hint for new connection
 			connectionForm.showDSNHint()
 			connectionPages.SwitchToPage(pageNameConnectionForm)
@@ -210,6 +281,15 @@ func (cs *ConnectionSelection) Connect(connection models.Connection) *tview.Appl
 		return App.Draw()
 	}
 
+	// The commands of a connection are stopped when connecting fails
+	processes := process.Get(connection.Name)
+	connected := false
+	defer func() {
+		if !connected {
+			processes.Stop()
+		}
+	}()
+
 	if len(connection.Commands) > 0 {
 
 		// Contains variables -- both the generated port and user-defined.
@@ -237,14 +317,17 @@ func (cs *ConnectionSelection) Connect(connection models.Connection) *tview.Appl
 			App.Draw()
 
 			cmd := command.Command
+			teardown := command.Teardown
 			for variable, value := range variables {
 				cmd = strings.ReplaceAll(cmd, "${"+variable+"}", value)
+				teardown = strings.ReplaceAll(teardown, "${"+variable+"}", value)
 			}
 
 			markCommandComplete := App.Register()
 			onCommandDone, waitToCaptureVariable := setupOutputVariableCommand(variables, command, markCommandComplete)
 
-			if err := helpers.RunCommand(App.Context(), cmd, onCommandDone); err != nil {
+			if err := processes.Start(cmd, teardown, command.Restart, onCommandDone); err != nil {
+				markCommandComplete()
 				cs.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
 				return App.Draw()
 			}
@@ -292,44 +375,86 @@ func (cs *ConnectionSelection) Connect(connection models.Connection) *tview.Appl
 		}
 	}
 
-	cs.StatusText.SetText("Connecting...").SetTextColor(app.Styles.TertiaryTextColor)
-	App.Draw()
+	dsn, err := connectionDSN(&connection)
+	if err != nil {
+		cs.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
+		return App.Draw()
+	}
 
-	var newDBDriver drivers.Driver
-
-	switch connection.Driver {
-	case drivers.DriverMySQL:
-		newDBDriver = &drivers.MySQL{}
-	case drivers.DriverPostgres:
-		newDBDriver = &drivers.Postgres{}
-	case drivers.DriverSqlite:
-		newDBDriver = &drivers.SQLite{}
-	case drivers.DriverMSSQL:
-		newDBDriver = &drivers.MSSQL{}
+	status := "Connecting..."
+	if connection.SSH != nil && connection.SSH.Host != "" {
+		status = "Opening SSH tunnel and connecting..."
 	}
+	cs.StatusText.SetText(status).SetTextColor(app.Styles.TertiaryTextColor)
+	App.Draw()
 
-	err := newDBDriver.Connect(connection.GetDSN())
+	newDBDriver, closeConnection, err := db.Connect(connection, dsn, openTunnel)
 	if err != nil {
+		if connection.AsksForPassword() {
+			// The password may be mistyped, it is asked for again next time
+			secrets.Forget(connection.Name)
+		}
 		cs.StatusText.SetText(err.Error()).SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
 		return App.Draw()
 	}
 
-	selectedRow, selectedCol := connectionsTable.GetSelection()
-	cell := connectionsTable.GetCell(selectedRow, selectedCol)
-	cell.SetText(fmt.Sprintf("[green]* %s", cell.Text))
 	cs.StatusText.SetText("")
 
 	newHome := NewHomePage(connection, newDBDriver)
 	newHome.Tree.SetCurrentNode(newHome.Tree.GetRoot())
 	newHome.Tree.Wrapper.SetTitle(connection.Name)
 
+	newHome.closers = append(newHome.closers, closeConnection, processes.Stop)
+	connected = true
+
 	mainPages.AddAndSwitchToPage(connection.Name, newHome, true)
+	homes.Store(connection.Name, newHome)
+	App.QueueUpdate(connectionsTable.Refresh)
 	App.SetFocus(newHome.Tree)
 
 	return App.Draw()
 }
 
-// Produces two functions: [onCommandDone] should be passed to [helpers.RunCommand],
+// openDSN returns the DSN to connect to a connection with, see db.OpenDSN.
+// The SSH tunnel is closed with closeTunnel, or when sqlcmder stops.
+func openDSN(connection models.Connection, dsn string) (opened string, closeTunnel func(), err error) {
+	return db.OpenDSN(connection, dsn, openTunnel)
+}
+
+// openTunnel opens the SSH tunnel of a connection to address, asking for
+// passphrases in the UI
+func openTunnel(connection models.Connection, address string) (localAddress string, closeTunnel func(), err error) {
+	sshTunnel, err := tunnel.Open(*connection.SSH, address, askPassword)
+	if err != nil {
+		return "", nil, err
+	}
+
+	markTunnelClosed := App.Register()
+	closed := make(chan struct{})
+	var once sync.Once
+
+	closeTunnel = func() {
+		once.Do(func() {
+			if err := sshTunnel.Close(); err != nil {
+				logger.Error("Failed to close SSH tunnel", map[string]any{"connection": connection.Name, "error": err.Error()})
+			}
+			close(closed)
+			markTunnelClosed()
+		})
+	}
+
+	go func() {
+		select {
+		case <-App.Context().Done():
+			closeTunnel()
+		case <-closed:
+		}
+	}()
+
+	return sshTunnel.LocalAddress(), closeTunnel, nil
+}
+
+// Produces two functions: [onCommandDone] should be passed to [process.Group.Start],
 // and [captureVariable] should be called after. [captureVariable] will block until
 // the output from the command is saved into [variables].
 // If no [command.SaveOutputTo] is defined, [captureVariable] is a no-op.
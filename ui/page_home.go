@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -11,6 +12,7 @@ import (
 	commands "sqlcmder/cli"
 	"sqlcmder/cmd/app"
 	"sqlcmder/data/history"
+	"sqlcmder/data/queries"
 	"sqlcmder/drivers"
 	"sqlcmder/keymap"
 	"sqlcmder/logger"
@@ -35,6 +37,20 @@ type Home struct {
 	CurrentDatabase      string
 	CurrentTable         string
 	Connection           models.Connection // Full connection details
+	ForeignKeys          *drivers.ForeignKeyCache
+	Catalog              *drivers.Catalog
+	Breadcrumb           *tview.TextView
+	navigationStack      []navigationEntry
+	closers              []func() // Stop what was started for the connection
+}
+
+// homes holds the home page of every connection connected to, by page name
+var homes sync.Map
+
+// navigationEntry is a tab visited while following foreign keys
+type navigationEntry struct {
+	Reference string
+	Label     string
 }
 
 func NewHomePage(connection models.Connection, dbdriver drivers.Driver) *Home {
@@ -67,21 +83,18 @@ func NewHomePage(connection models.Connection, dbdriver drivers.Driver) *Home {
 		ConnectionIdentifier: connectionIdentifier,
 		ConnectionURL:        connection.GetDSN(),
 		Connection:           connection, // Store full connection
+		ForeignKeys:          drivers.NewForeignKeyCache(dbdriver),
+		Catalog:              drivers.NewCatalog(dbdriver),
 	}
 
 	tabbedPane := NewTabbedPane()
 
 	home.TabbedPane = tabbedPane
 
-	qhm := NewQueryHistoryModal(connectionIdentifier, func(selectedQuery string) {
-		home.createOrFocusEditorTab()
+	library := queries.NewLibrary(connectionIdentifier, dbdriver.GetProvider())
+	library.Directories = savedQueriesDirectories(connection)
 
-		currentTab := home.TabbedPane.GetCurrentTab()
-		if currentTab != nil {
-			table := currentTab.Content.(*ResultsTable)
-			table.Editor.SetText(selectedQuery, true)
-		}
-	})
+	qhm := NewQueryHistoryModal(library, home.openQuery, home.rerunHistoryItem)
 
 	home.QueryHistoryModal = qhm
 
@@ -90,12 +103,19 @@ func NewHomePage(connection models.Connection, dbdriver drivers.Driver) *Home {
 	// Create command status bar
 	commandStatusBar := tview.NewTextView()
 	commandStatusBar.SetDynamicColors(true)
-	commandStatusBar.SetText(" [yellow]Ctrl+Left/Right[white]: Switch Panel | [yellow]CTRL + e[white]: SQL editor | [yellow]Ctrl+\\[white]: Search | [yellow]?[white]: Help")
+	commandStatusBar.SetText(environmentBadge(connection) + " [yellow]Ctrl+Left/Right[white]: Switch Panel | [yellow]CTRL + e[white]: SQL editor | [yellow]Ctrl+\\[white]: Search | [yellow]?[white]: Help")
 	commandStatusBar.SetBackgroundColor(app.Styles.PrimitiveBackgroundColor)
 	commandStatusBar.SetTextColor(app.Styles.PrimaryTextColor)
 	home.CommandStatusBar = commandStatusBar
 
+	breadcrumb := tview.NewTextView()
+	breadcrumb.SetDynamicColors(true)
+	breadcrumb.SetBackgroundColor(app.Styles.PrimitiveBackgroundColor)
+	breadcrumb.SetTextColor(app.Styles.TertiaryTextColor)
+	home.Breadcrumb = breadcrumb
+
 	go home.subscribeToTreeChanges()
+	go home.loadCatalog(home.CurrentDatabase)
 
 	leftWrapper.SetBorderColor(app.Styles.UnfocusedBorderColor)
 	leftWrapper.AddItem(tree.Wrapper, 0, 1, true)
@@ -106,6 +126,7 @@ func NewHomePage(connection models.Connection, dbdriver drivers.Driver) *Home {
 	rightWrapper.SetInputCapture(home.rightWrapperInputCapture)
 	rightWrapper.AddItem(tabbedPane.HeaderContainer, 1, 0, false)
 	rightWrapper.AddItem(tabbedPane.Pages, 0, 1, false)
+	rightWrapper.AddItem(breadcrumb, 0, 0, false)       // Only takes space while following foreign keys
 	rightWrapper.AddItem(commandStatusBar, 1, 0, false) // Status bar always visible
 
 	maincontent.AddItem(leftWrapper, 30, 1, false)
@@ -128,6 +149,23 @@ func NewHomePage(connection models.Connection, dbdriver drivers.Driver) *Home {
 	return home
 }
 
+// Close stops what was started for the connection, like its commands and
+// SSH tunnel, and removes its pages.
+func (home *Home) Close() {
+	mainPages.RemovePage(home.Connection.Name)
+	mainPages.RemovePage(home.ConnectionURL)
+	homes.Delete(home.Connection.Name)
+
+	closers := home.closers
+	home.closers = nil
+
+	go func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}()
+}
+
 func (home *Home) subscribeToTreeChanges() {
 	ch := home.Tree.Subscribe()
 
@@ -141,6 +179,10 @@ func (home *Home) subscribeToTreeChanges() {
 			home.CurrentDatabase = databaseName
 			home.CurrentTable = tableName
 
+			// Selecting from the tree starts a new foreign key path
+			home.navigationStack = nil
+			home.updateBreadcrumb()
+
 			tabReference := fmt.Sprintf("%s.%s", databaseName, tableName)
 
 			tab := home.TabbedPane.GetTabByReference(tabReference)
@@ -156,6 +198,9 @@ func (home *Home) subscribeToTreeChanges() {
 				table.SetConnection(&home.Connection)
 				table.SetDatabaseName(databaseName)
 				table.SetTableName(tableName)
+				table.ForeignKeys = home.ForeignKeys
+
+				go home.subscribeToTableChanges(table)
 
 				home.TabbedPane.AppendTab(tableName, table, tabReference)
 			}
@@ -182,15 +227,155 @@ func (home *Home) subscribeToTreeChanges() {
 			} else {
 				home.SetInputCapture(home.homeInputCapture)
 			}
+		case eventTreeSelectedDatabase:
+			database := stateChange.Value.(string)
+			if database != "" && !home.Catalog.Loaded(database) {
+				go home.loadCatalog(database)
+			}
+		case eventTreeRefreshed:
+			home.ForeignKeys.Invalidate()
+			go home.reloadCatalog()
+		}
+	}
+}
+
+// loadCatalog loads the tables and columns used by the editor completions
+func (home *Home) loadCatalog(database string) {
+	if err := home.Catalog.Load(database); err != nil {
+		logger.Error("Failed to load the completion catalog", map[string]any{"database": database, "error": err.Error()})
+	}
+}
+
+func (home *Home) reloadCatalog() {
+	if err := home.Catalog.Reload(); err != nil {
+		logger.Error("Failed to reload the completion catalog", map[string]any{"error": err.Error()})
+	}
+}
+
+func (home *Home) subscribeToTableChanges(table *ResultsTable) {
+	ch := table.Subscribe()
+
+	for stateChange := range ch {
+		switch stateChange.Key {
+		case eventResultsTableNavigate:
+			home.navigateTo(stateChange.Value.(models.TableNavigation))
 		}
 	}
 }
 
+// navigateTo opens a table filtered to the given rows, remembering the
+// current tab so navigateBack can return to it
+func (home *Home) navigateTo(navigation models.TableNavigation) {
+	currentTab := home.TabbedPane.GetCurrentTab()
+	if currentTab != nil {
+		home.navigationStack = append(home.navigationStack, navigationEntry{
+			Reference: currentTab.Reference,
+			Label:     home.tabLabel(currentTab),
+		})
+	}
+
+	tabReference := fmt.Sprintf("%s.%s?%s", navigation.Database, navigation.Table, navigation.Filter)
+
+	var table *ResultsTable
+
+	tab := home.TabbedPane.GetTabByReference(tabReference)
+	if tab != nil {
+		table = tab.Content.(*ResultsTable)
+		home.TabbedPane.SwitchToTabByReference(tab.Reference)
+	} else {
+		table = NewResultsTable(&home.ListOfDBChanges, home.Tree, home.DBDriver, home.ConnectionIdentifier, home.ConnectionURL).WithFilter()
+		table.SetConnection(&home.Connection)
+		table.SetDatabaseName(navigation.Database)
+		table.SetTableName(navigation.Table)
+		table.ForeignKeys = home.ForeignKeys
+		table.Filter.SetFilter(navigation.Filter)
+
+		go home.subscribeToTableChanges(table)
+
+		home.TabbedPane.AppendTab(navigation.Table, table, tabReference)
+	}
+
+	table.FetchRecords(nil)
+
+	home.updateBreadcrumb()
+	home.focusRightWrapper()
+	app.App.ForceDraw()
+}
+
+// navigateBack returns to the tab the last foreign key was followed from
+func (home *Home) navigateBack() {
+	for len(home.navigationStack) > 0 {
+		entry := home.navigationStack[len(home.navigationStack)-1]
+		home.navigationStack = home.navigationStack[:len(home.navigationStack)-1]
+
+		// The tab might have been closed in the meantime
+		if home.TabbedPane.GetTabByReference(entry.Reference) != nil {
+			home.TabbedPane.SwitchToTabByReference(entry.Reference)
+			break
+		}
+	}
+
+	home.updateBreadcrumb()
+	home.focusRightWrapper()
+}
+
+func (home *Home) updateBreadcrumb() {
+	if len(home.navigationStack) == 0 {
+		home.Breadcrumb.SetText("")
+		home.RightWrapper.ResizeItem(home.Breadcrumb, 0, 0)
+		return
+	}
+
+	labels := []string{}
+	for _, entry := range home.navigationStack {
+		labels = append(labels, entry.Label)
+	}
+
+	if currentTab := home.TabbedPane.GetCurrentTab(); currentTab != nil {
+		labels = append(labels, "[::b]"+home.tabLabel(currentTab)+"[::-]")
+	}
+
+	home.Breadcrumb.SetText(" " + strings.Join(labels, " › "))
+	home.RightWrapper.ResizeItem(home.Breadcrumb, 1, 0)
+}
+
+func (home *Home) tabLabel(tab *Tab) string {
+	table, ok := tab.Content.(*ResultsTable)
+	if !ok || table.Filter == nil || table.Filter.GetCurrentFilter() == "" {
+		return tview.Escape(tab.Name)
+	}
+
+	return tview.Escape(fmt.Sprintf("%s (%s)", tab.Name, table.Filter.Input.GetText()))
+}
+
+// focusedBorderColor returns the border color of the focused panel, the
+// color of the environment of the connection when it has one
+func (home *Home) focusedBorderColor() tcell.Color {
+	if home.Connection.Environment == "" && home.Connection.Color == "" {
+		return app.Styles.PrimaryTextColor
+	}
+	return environmentColor(home.Connection)
+}
+
+// environmentBadge returns the environment of a connection as shown at the
+// start of the status bar, in the color of the environment, followed by
+// READ ONLY for read-only connections
+func environmentBadge(connection models.Connection) string {
+	badge := ""
+	if connection.Environment != "" {
+		badge = fmt.Sprintf("[%s:%s:b] %s [-:-:-]", app.Styles.PrimitiveBackgroundColor.String(), environmentColor(connection).String(), strings.ToUpper(tview.Escape(connection.Environment)))
+	}
+	if connection.ReadOnly {
+		badge += fmt.Sprintf("[%s:%s:b] READ ONLY [-:-:-]", app.Styles.PrimitiveBackgroundColor.String(), app.Styles.TertiaryTextColor.String())
+	}
+	return badge
+}
+
 func (home *Home) focusRightWrapper() {
 	logger.Debug("Focus right wrapper", nil)
 	home.Tree.RemoveHighlight()
 
-	home.RightWrapper.SetBorderColor(app.Styles.PrimaryTextColor)
+	home.RightWrapper.SetBorderColor(home.focusedBorderColor())
 	home.LeftWrapper.SetBorderColor(app.Styles.UnfocusedBorderColor)
 	home.TabbedPane.Highlight()
 	tab := home.TabbedPane.GetCurrentTab()
@@ -238,7 +423,7 @@ func (home *Home) focusLeftWrapper() {
 	home.Tree.Highlight()
 
 	home.RightWrapper.SetBorderColor(app.Styles.UnfocusedBorderColor)
-	home.LeftWrapper.SetBorderColor(app.Styles.PrimaryTextColor)
+	home.LeftWrapper.SetBorderColor(home.focusedBorderColor())
 
 	tab := home.TabbedPane.GetCurrentTab()
 
@@ -313,6 +498,17 @@ func (home *Home) rightWrapperInputCapture(event *tcell.EventKey) *tcell.EventKe
 				}
 			}
 		}
+	case commands.NavigateBack:
+		tab = home.TabbedPane.GetCurrentTab()
+
+		if tab != nil && len(home.navigationStack) > 0 {
+			table := tab.Content.(*ResultsTable)
+
+			if !table.GetIsEditing() && !table.GetIsFiltering() && !table.GetIsLoading() {
+				home.navigateBack()
+				return nil
+			}
+		}
 	case commands.PagePrev:
 		tab = home.TabbedPane.GetCurrentTab()
 
@@ -463,14 +659,19 @@ func (home *Home) homeInputCapture(event *tcell.EventKey) *tcell.EventKey {
 		}
 	case commands.Save:
 		if (len(home.ListOfDBChanges) > 0) && !table.GetIsEditing() {
-			queryPreviewModal := NewQueryPreviewModal(&home.ListOfDBChanges, home.DBDriver, func() {
+			queryPreviewModal := NewQueryPreviewModal(&home.ListOfDBChanges, home.DBDriver, &home.Connection, func() {
 				for _, change := range home.ListOfDBChanges {
 					queryString, err := home.DBDriver.DMLChangeToQueryString(change)
 					if err != nil {
 						logger.Error("Failed to convert DML change to query string", map[string]any{"error": err})
 						continue
 					}
-					err = history.AddQueryToHistory(home.ConnectionIdentifier, queryString)
+					err = history.AddItem(home.ConnectionIdentifier, models.QueryHistoryItem{
+						QueryText: queryString,
+						Database:  change.Database,
+						Rows:      1,
+						Source:    models.QuerySourceCommand,
+					})
 					if err != nil {
 						logger.Error("Failed to add query to history", map[string]any{"error": err})
 					}
@@ -498,6 +699,12 @@ func (home *Home) homeInputCapture(event *tcell.EventKey) *tcell.EventKey {
 		app.App.SetFocus(home.Tree.Filter)
 		home.Tree.SetIsFiltering(true)
 		return nil
+	case commands.GlobalHistory:
+		showGlobalHistory()
+		return nil
+	case commands.CommandLog:
+		showCommandLog(home.Connection.Name)
+		return nil
 	case commands.ToggleQueryHistory:
 		if mainPages.HasPage(pageNameQueryHistory) {
 			mainPages.SwitchToPage(pageNameQueryHistory)
@@ -506,12 +713,55 @@ func (home *Home) homeInputCapture(event *tcell.EventKey) *tcell.EventKey {
 		}
 
 		home.QueryHistoryModal.queryHistoryComponent.LoadHistory(home.ConnectionIdentifier)
+		home.QueryHistoryModal.savedQueriesComponent.Watch()
 		return nil
 	}
 
 	return event
 }
 
+// openQuery opens a query in the editor tab
+func (home *Home) openQuery(query string) {
+	home.createOrFocusEditorTab()
+
+	currentTab := home.TabbedPane.GetCurrentTab()
+	if currentTab != nil {
+		table := currentTab.Content.(*ResultsTable)
+		table.Editor.SetText(query, true)
+	}
+}
+
+// rerunHistoryItem runs a query of the history again in the editor, back in
+// the database it ran in
+func (home *Home) rerunHistoryItem(item models.QueryHistoryItem) {
+	home.createOrFocusEditorTab()
+
+	tab := home.TabbedPane.GetCurrentTab()
+	if tab == nil {
+		return
+	}
+	table := tab.Content.(*ResultsTable)
+	table.Editor.SetText(item.QueryText, true)
+
+	go func() {
+		if item.Database != "" {
+			if switcher, ok := home.DBDriver.(drivers.DatabaseSwitcher); ok && switcher.GetCurrentDatabase() != item.Database {
+				if err := switcher.SwitchDatabase(item.Database); err != nil {
+					App.QueueUpdateDraw(func() {
+						table.SetError(err.Error(), nil)
+					})
+					return
+				}
+			}
+
+			table.Editor.SetCurrentDatabase(item.Database)
+			home.CurrentDatabase = item.Database
+		}
+
+		table.Editor.Publish(eventSQLEditorQuery, item.QueryText)
+	}()
+}
+
 func (home *Home) createOrFocusEditorTab() {
 	tab := home.TabbedPane.GetTabByName(tabNameEditor)
 
@@ -523,6 +773,7 @@ func (home *Home) createOrFocusEditorTab() {
 	} else {
 		tableWithEditor := NewResultsTable(&home.ListOfDBChanges, home.Tree, home.DBDriver, home.ConnectionIdentifier, home.ConnectionURL).WithEditor()
 		tableWithEditor.SetConnection(&home.Connection)
+		tableWithEditor.Editor.Catalog = home.Catalog
 		home.TabbedPane.AppendTab(tabNameEditor, tableWithEditor, tabNameEditor)
 		tableWithEditor.SetIsFiltering(true)
 		home.TabbedPane.GetCurrentTab()
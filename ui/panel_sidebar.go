@@ -29,6 +29,7 @@ type Sidebar struct {
 	state           *SidebarState
 	FieldParameters []*SidebarFieldParameters
 	subscribers     []chan models.StateChange
+	readOnly        bool
 }
 
 func NewSidebar(dbProvider string) *Sidebar {
@@ -227,6 +228,14 @@ func (sidebar *Sidebar) inputCapture(event *tcell.EventKey) *tcell.EventKey {
 
 	command := keymap.Keymaps.Group(keymap.SidebarGroup).Resolve(event)
 
+	switch command {
+	case commands.Edit, commands.SetValue:
+		if sidebar.readOnly {
+			sidebar.Publish(models.StateChange{Key: eventSidebarError, Value: commands.ErrReadOnly + ", its rows can't be changed"})
+			return nil
+		}
+	}
+
 	switch command {
 	case commands.UnfocusSidebar:
 		sidebar.Publish(models.StateChange{Key: eventSidebarUnfocusing, Value: nil})
@@ -371,6 +380,11 @@ func (sidebar *Sidebar) Subscribe() chan models.StateChange {
 	return subscriber
 }
 
+// SetReadOnly refuses the edits of the fields when the connection is read-only
+func (sidebar *Sidebar) SetReadOnly(readOnly bool) {
+	sidebar.readOnly = readOnly
+}
+
 // Publish subscribers of changes in the sidebar state
 func (sidebar *Sidebar) Publish(change models.StateChange) {
 	for _, subscriber := range sidebar.subscribers {
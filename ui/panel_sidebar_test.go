@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	commands "sqlcmder/cli"
+	"sqlcmder/models"
+)
+
+func TestSidebarRefusesEditsWhenReadOnly(t *testing.T) {
+	for _, key := range []rune{'c', 'C'} {
+		sidebar := NewSidebar("postgres")
+		sidebar.AddField("name[text]", "alice", 30, false)
+		sidebar.SetReadOnly(true)
+
+		changes := sidebar.Subscribe()
+		published := make(chan models.StateChange, 1)
+		go func() {
+			published <- <-changes
+		}()
+
+		event := tcell.NewEventKey(tcell.KeyRune, key, tcell.ModNone)
+		if got := sidebar.inputCapture(event); got != nil {
+			t.Errorf("%q: the event was not handled", key)
+		}
+
+		change := <-published
+		if change.Key != eventSidebarError {
+			t.Fatalf("%q: published %v, want %v", key, change.Key, eventSidebarError)
+		}
+		if want := commands.ErrReadOnly + ", its rows can't be changed"; change.Value != want {
+			t.Errorf("%q: error = %q, want %q", key, change.Value, want)
+		}
+
+		field := sidebar.Flex.GetItem(0).(*tview.TextArea)
+		if !field.GetDisabled() {
+			t.Errorf("%q: the field was opened for editing", key)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
@@ -11,9 +15,24 @@ import (
 type ConnectionsTable struct {
 	*tview.Table
 	Wrapper       *tview.Flex
+	Filter        *tview.InputField
 	errorTextView *tview.TextView
 	error         string
 	connections   []models.Connection
+	rows          []connectionRow
+	collapsed     map[string]bool // Groups folded in the list
+}
+
+// connectionRow is a row of the connections table, the header of a group or
+// a connection
+type connectionRow struct {
+	group string
+	index int // Index of the connection in the connections, -1 for a group header
+	count int // Number of connections shown under a group header
+}
+
+func (row connectionRow) isGroup() bool {
+	return row.index < 0
 }
 
 var connectionsTable *ConnectionsTable
@@ -24,10 +43,20 @@ func NewConnectionsTable() *ConnectionsTable {
 	errorTextView := tview.NewTextView()
 	errorTextView.SetTextStyle(tcell.StyleDefault.Foreground(app.Styles.ErrorColor))
 
+	filter := tview.NewInputField()
+	filter.SetLabel("  Filter: ")
+	filter.SetPlaceholder("press / to filter by name, group, #tag or env:prod")
+	filter.SetLabelColor(app.Styles.TertiaryTextColor)
+	filter.SetFieldBackgroundColor(app.Styles.PrimitiveBackgroundColor)
+	filter.SetFieldTextColor(app.Styles.PrimaryTextColor)
+	filter.SetPlaceholderTextColor(app.Styles.UnfocusedTextColor)
+
 	table := &ConnectionsTable{
 		Table:         tview.NewTable().SetSelectable(true, false),
 		Wrapper:       wrapper,
+		Filter:        filter,
 		errorTextView: errorTextView,
+		collapsed:     map[string]bool{},
 	}
 
 	table.SetOffset(5, 0)
@@ -38,6 +67,16 @@ func NewConnectionsTable() *ConnectionsTable {
 		table.UpdateSelectionMarker(row)
 	})
 
+	filter.SetChangedFunc(func(string) {
+		table.Refresh()
+	})
+	filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			filter.SetText("")
+		}
+		App.SetFocus(table)
+	})
+
 	wrapper.AddItem(table, 0, 1, true)
 	table.SetConnections(app.App.Connections())
 
@@ -47,9 +86,8 @@ func NewConnectionsTable() *ConnectionsTable {
 }
 
 func (ct *ConnectionsTable) AddConnection(connection models.Connection) {
-	rowCount := ct.GetRowCount()
-	ct.SetCellSimple(rowCount, 0, "  "+connection.Name) // Reserve space for * marker
 	ct.connections = append(ct.connections, connection)
+	ct.Refresh()
 }
 
 func (ct *ConnectionsTable) GetConnections() []models.Connection {
@@ -61,22 +99,89 @@ func (ct *ConnectionsTable) GetError() string {
 }
 
 func (ct *ConnectionsTable) SetConnections(connections []models.Connection) {
-	ct.connections = make([]models.Connection, 0)
+	ct.connections = slices.Clone(connections)
+
+	ct.Refresh()
+	ct.selectRow(ct.firstConnectionRow())
+
+	App.ForceDraw()
+}
 
+// Refresh shows the connections again, after the filter changed, a group was
+// folded or a connection was opened or closed. The selected connection stays
+// selected when it is still shown.
+func (ct *ConnectionsTable) Refresh() {
+	selected, hasSelection := ct.selectedRow()
+
+	ct.rows = connectionRows(ct.connections, ct.Filter.GetText(), ct.collapsed)
 	ct.Clear()
+	for row := range ct.rows {
+		ct.SetCellSimple(row, 0, ct.rowText(row, false))
+	}
 
-	for _, connection := range connections {
-		ct.AddConnection(connection)
+	row := ct.firstConnectionRow()
+	if hasSelection {
+		for i, candidate := range ct.rows {
+			if candidate.index == selected.index && (!candidate.isGroup() || candidate.group == selected.group) {
+				row = i
+				break
+			}
+		}
 	}
+	ct.selectRow(row)
+}
 
-	ct.Select(0, 0)
+// selectedRow returns the row of the table that is selected
+func (ct *ConnectionsTable) selectedRow() (connectionRow, bool) {
+	row, _ := ct.GetSelection()
+	if row < 0 || row >= len(ct.rows) {
+		return connectionRow{}, false
+	}
+	return ct.rows[row], true
+}
 
-	// Initialize selection marker for first row
-	if len(connections) > 0 {
-		ct.UpdateSelectionMarker(0)
+// SelectedConnection returns the selected connection and its index in the
+// connections. A group header selected is no connection.
+func (ct *ConnectionsTable) SelectedConnection() (models.Connection, int, bool) {
+	row, ok := ct.selectedRow()
+	if !ok || row.isGroup() {
+		return models.Connection{}, -1, false
 	}
+	return ct.connections[row.index], row.index, true
+}
 
-	App.ForceDraw()
+// SelectedGroup returns the group whose header is selected.
+func (ct *ConnectionsTable) SelectedGroup() (string, bool) {
+	row, ok := ct.selectedRow()
+	if !ok || !row.isGroup() {
+		return "", false
+	}
+	return row.group, true
+}
+
+// ToggleGroup folds or unfolds a group.
+func (ct *ConnectionsTable) ToggleGroup(group string) {
+	ct.collapsed[group] = !ct.collapsed[group]
+	ct.Refresh()
+}
+
+func (ct *ConnectionsTable) selectRow(row int) {
+	if len(ct.rows) == 0 {
+		return
+	}
+	ct.Select(row, 0)
+	ct.UpdateSelectionMarker(row)
+}
+
+// firstConnectionRow returns the row of the first connection shown, or the
+// first row when every group is folded
+func (ct *ConnectionsTable) firstConnectionRow() int {
+	for row, candidate := range ct.rows {
+		if !candidate.isGroup() {
+			return row
+		}
+	}
+	return 0
 }
 
 func (ct *ConnectionsTable) SetError(err error) {
@@ -86,19 +191,115 @@ func (ct *ConnectionsTable) SetError(err error) {
 
 // UpdateSelectionMarker updates the * marker for the selected row
 func (ct *ConnectionsTable) UpdateSelectionMarker(selectedRow int) {
-	// Update all rows
-	for i := 0; i < ct.GetRowCount(); i++ {
-		if i >= len(ct.connections) {
-			break
+	for row := range ct.rows {
+		ct.GetCell(row, 0).SetText(ct.rowText(row, row == selectedRow))
+	}
+}
+
+// rowText returns what a row shows: the name of a group and how many
+// connections it has, or the name of a connection with its environment,
+// read-only mode and tags. Open connections are shown in green.
+func (ct *ConnectionsTable) rowText(row int, selected bool) string {
+	marker := "  "
+	if selected {
+		marker = "[yellow]*[white] "
+	}
+
+	current := ct.rows[row]
+	if current.isGroup() {
+		arrow := "▾"
+		if ct.collapsed[current.group] {
+			arrow = "▸"
 		}
+		return fmt.Sprintf("%s[%s]%s %s (%d)[-]", marker, app.Styles.TertiaryTextColor.String(), arrow, tview.Escape(current.group), current.count)
+	}
+
+	connection := ct.connections[current.index]
+	text := marker
+	if connection.Group != "" {
+		text += "  "
+	}
+
+	name := tview.Escape(connection.Name)
+	if _, open := homes.Load(connection.Name); open {
+		name = fmt.Sprintf("[%s]%s[-]", app.Styles.SuccessColor.String(), name)
+	}
+	text += name
+
+	if connection.Environment != "" {
+		text += fmt.Sprintf(" [%s][%s][-]", environmentColor(connection).String(), tview.Escape(connection.Environment))
+	}
+	if connection.ReadOnly {
+		text += fmt.Sprintf(" [%s](read only)[-]", app.Styles.TertiaryTextColor.String())
+	}
+	for _, tag := range connection.Tags {
+		text += fmt.Sprintf(" [%s]#%s[-]", app.Styles.UnfocusedTextColor.String(), tview.Escape(tag))
+	}
+
+	return text
+}
+
+// connectionRows returns the rows showing the connections matching a filter.
+// Connections without a group come first, then the groups in the order they
+// first appear, folded groups hiding their connections.
+func connectionRows(connections []models.Connection, filter string, collapsed map[string]bool) []connectionRow {
+	rows := []connectionRow{}
+	groups := []string{}
+	grouped := map[string][]int{}
+
+	for i, connection := range connections {
+		if !matchesConnectionFilter(connection, filter) {
+			continue
+		}
+
+		if connection.Group == "" {
+			rows = append(rows, connectionRow{index: i})
+			continue
+		}
+
+		if _, ok := grouped[connection.Group]; !ok {
+			groups = append(groups, connection.Group)
+		}
+		grouped[connection.Group] = append(grouped[connection.Group], i)
+	}
+
+	for _, group := range groups {
+		rows = append(rows, connectionRow{group: group, index: -1, count: len(grouped[group])})
+		if collapsed[group] {
+			continue
+		}
+		for _, index := range grouped[group] {
+			rows = append(rows, connectionRow{group: group, index: index})
+		}
+	}
+
+	return rows
+}
 
-		cell := ct.GetCell(i, 0)
-		if i == selectedRow {
-			// Add * marker for selected row
-			cell.SetText("[yellow]*[white] " + ct.connections[i].Name)
-		} else {
-			// Remove * marker for other rows
-			cell.SetText("  " + ct.connections[i].Name)
+// matchesConnectionFilter reports whether a connection matches every word of
+// a filter: #tag matches a tag, env:name the environment and other words a
+// part of the name, group, environment or tags
+func matchesConnectionFilter(connection models.Connection, filter string) bool {
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		switch {
+		case strings.HasPrefix(word, "#"):
+			if !slices.ContainsFunc(connection.Tags, func(tag string) bool {
+				return strings.EqualFold(tag, word[1:])
+			}) {
+				return false
+			}
+		case strings.HasPrefix(word, "env:"):
+			if !strings.EqualFold(connection.Environment, strings.TrimPrefix(word, "env:")) {
+				return false
+			}
+		default:
+			fields := append([]string{connection.Name, connection.Group, connection.Environment}, connection.Tags...)
+			if !slices.ContainsFunc(fields, func(field string) bool {
+				return strings.Contains(strings.ToLower(field), word)
+			}) {
+				return false
+			}
 		}
 	}
+	return true
 }
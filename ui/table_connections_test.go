@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"sqlcmder/models"
+)
+
+func TestConnectionRows(t *testing.T) {
+	connections := []models.Connection{
+		{Name: "local"},
+		{Name: "billing-prod", Group: "billing", Tags: []string{"primary"}, Environment: models.EnvironmentProd},
+		{Name: "billing-replica", Group: "billing", Tags: []string{"replica"}, Environment: models.EnvironmentProd},
+		{Name: "auth-staging", Group: "auth", Environment: models.EnvironmentStaging},
+		{Name: "scratch"},
+	}
+
+	tests := []struct {
+		name      string
+		filter    string
+		collapsed map[string]bool
+		want      []connectionRow
+	}{
+		{
+			name: "groups after ungrouped",
+			want: []connectionRow{
+				{index: 0},
+				{index: 4},
+				{group: "billing", index: -1, count: 2},
+				{group: "billing", index: 1},
+				{group: "billing", index: 2},
+				{group: "auth", index: -1, count: 1},
+				{group: "auth", index: 3},
+			},
+		},
+		{
+			name:      "collapsed group",
+			collapsed: map[string]bool{"billing": true},
+			want: []connectionRow{
+				{index: 0},
+				{index: 4},
+				{group: "billing", index: -1, count: 2},
+				{group: "auth", index: -1, count: 1},
+				{group: "auth", index: 3},
+			},
+		},
+		{
+			name:   "tag",
+			filter: "#Replica",
+			want: []connectionRow{
+				{group: "billing", index: -1, count: 1},
+				{group: "billing", index: 2},
+			},
+		},
+		{
+			name:   "environment and text",
+			filter: "env:prod billing",
+			want: []connectionRow{
+				{group: "billing", index: -1, count: 2},
+				{group: "billing", index: 1},
+				{group: "billing", index: 2},
+			},
+		},
+		{
+			name:   "group name",
+			filter: "AUTH",
+			want: []connectionRow{
+				{group: "auth", index: -1, count: 1},
+				{group: "auth", index: 3},
+			},
+		},
+		{
+			name:   "no match",
+			filter: "env:dev",
+			want:   []connectionRow{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connectionRows(connections, tt.filter, tt.collapsed); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("connectionRows() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -90,6 +90,16 @@ func (filter *ResultsTableFilter) GetCurrentFilter() string {
 	return filter.currentFilter
 }
 
+// SetFilter applies a filter without publishing it, the caller fetches the records
+func (filter *ResultsTableFilter) SetFilter(where string) {
+	filter.Input.SetText(where)
+	if where == "" {
+		filter.currentFilter = ""
+	} else {
+		filter.currentFilter = "WHERE " + where
+	}
+}
+
 // Function to blur
 func (filter *ResultsTableFilter) RemoveHighlight() {
 	filter.SetBorderColor(app.Styles.UnfocusedBorderColor)
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"sqlcmder/drivers"
+	"sqlcmder/models"
+)
+
+// referencingRows is a table whose foreign key points to the selected row
+type referencingRows struct {
+	ForeignKey models.ForeignKey
+	Filter     string
+	Count      int
+}
+
+func (table *ResultsTable) Subscribe() chan models.StateChange {
+	subscriber := make(chan models.StateChange)
+	table.subscribers = append(table.subscribers, subscriber)
+	return subscriber
+}
+
+func (table *ResultsTable) Publish(change models.StateChange) {
+	for _, subscriber := range table.subscribers {
+		subscriber <- change
+	}
+}
+
+// rowValues returns the values of the given columns for a record row
+func (table *ResultsTable) rowValues(rowIndex int, columns []string) ([]string, bool) {
+	records := table.GetRecords()
+	if rowIndex <= 0 || rowIndex >= len(records) {
+		return nil, false
+	}
+
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		columnIndex := table.GetColumnIndexByName(column)
+		if columnIndex == -1 || columnIndex >= len(records[rowIndex]) {
+			return nil, false
+		}
+		values[i] = records[rowIndex][columnIndex]
+	}
+
+	return values, true
+}
+
+// followForeignKey opens the row referenced by the foreign key of the selected cell
+func (table *ResultsTable) followForeignKey(rowIndex, colIndex int) {
+	if table.ForeignKeys == nil {
+		return
+	}
+
+	column := table.GetColumnNameByIndex(colIndex)
+
+	foreignKeys, err := table.ForeignKeys.Outgoing(table.GetDatabaseName(), table.GetTableName())
+	if err != nil {
+		table.SetError(err.Error(), nil)
+		return
+	}
+
+	for _, foreignKey := range foreignKeys {
+		if !slices.Contains(foreignKey.Columns, column) {
+			continue
+		}
+
+		values, ok := table.rowValues(rowIndex, foreignKey.Columns)
+		if !ok {
+			return
+		}
+
+		filter := drivers.BuildForeignKeyFilter(table.DBDriver, foreignKey.ReferencedColumns, values)
+		if filter == "" {
+			table.SetError(fmt.Sprintf("%s is NULL, there is no row to follow", column), nil)
+			return
+		}
+
+		table.Publish(models.StateChange{
+			Key: eventResultsTableNavigate,
+			Value: models.TableNavigation{
+				Database: table.GetDatabaseName(),
+				Table:    foreignKey.ReferencedTable,
+				Filter:   filter,
+			},
+		})
+		return
+	}
+
+	table.SetError(fmt.Sprintf("%s is not part of a foreign key", column), nil)
+}
+
+// listReferencingRows shows the tables that have rows referencing the selected row
+func (table *ResultsTable) listReferencingRows(rowIndex int) {
+	if table.ForeignKeys == nil {
+		return
+	}
+
+	foreignKeys, err := table.ForeignKeys.Incoming(table.GetDatabaseName(), table.GetTableName())
+	if err != nil {
+		table.SetError(err.Error(), nil)
+		return
+	}
+
+	results := []referencingRows{}
+
+	for _, foreignKey := range foreignKeys {
+		values, ok := table.rowValues(rowIndex, foreignKey.ReferencedColumns)
+		if !ok {
+			continue
+		}
+
+		filter := drivers.BuildForeignKeyFilter(table.DBDriver, foreignKey.Columns, values)
+		if filter == "" {
+			continue
+		}
+
+		_, count, _, err := table.DBDriver.GetRecords(table.GetDatabaseName(), foreignKey.Table, "WHERE "+filter, "", 0, 1)
+		if err != nil {
+			table.SetError(err.Error(), nil)
+			return
+		}
+
+		results = append(results, referencingRows{
+			ForeignKey: foreignKey,
+			Filter:     filter,
+			Count:      count,
+		})
+	}
+
+	App.QueueUpdateDraw(func() {
+		modal := NewReferencingRowsModal(table.GetTableName(), results, func(result referencingRows) {
+			go table.Publish(models.StateChange{
+				Key: eventResultsTableNavigate,
+				Value: models.TableNavigation{
+					Database: table.GetDatabaseName(),
+					Table:    result.ForeignKey.Table,
+					Filter:   result.Filter,
+				},
+			})
+		})
+
+		mainPages.AddPage(pageNameReferencingRows, modal, true, true)
+	})
+}
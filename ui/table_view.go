@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/google/uuid"
@@ -11,11 +12,13 @@ import (
 	commands "sqlcmder/cli"
 	"sqlcmder/cmd/app"
 	"sqlcmder/data/history"
+	"sqlcmder/data/parameters"
 	"sqlcmder/drivers"
 	"sqlcmder/helpers"
 	"sqlcmder/keymap"
 	"sqlcmder/logger"
 	"sqlcmder/models"
+	"sqlcmder/sqlparse"
 )
 
 type ResultsTableState struct {
@@ -57,6 +60,8 @@ type ResultsTable struct {
 	connectionIdentifier string
 	ConnectionURL        string
 	Connection           *models.Connection
+	ForeignKeys          *drivers.ForeignKeyCache
+	subscribers          []chan models.StateChange
 }
 
 func NewResultsTable(listOfDBChanges *[]models.DBDMLChange, tree *Tree, dbdriver drivers.Driver, connectionIdentifier string, connectionURL string) *ResultsTable {
@@ -177,6 +182,7 @@ func (table *ResultsTable) WithEditor() *ResultsTable {
 	editor.SetDBDriver(table.DBDriver)
 	editor.SetConnectionIdentifier(table.connectionIdentifier)
 	editor.SetCurrentDatabase(table.GetDatabaseName())
+	editor.tree = table.Tree
 
 	editorPages := tview.NewPages()
 
@@ -236,6 +242,10 @@ func (table *ResultsTable) subscribeToSidebarChanges() {
 		switch stateChange.Key {
 		case eventSidebarEditing:
 			editing := stateChange.Value.(bool)
+			if editing && table.readOnly() {
+				table.SetError(commands.ErrReadOnly+", its rows can't be changed", nil)
+				continue
+			}
 			table.SetIsEditing(editing)
 		case eventSidebarUnfocusing:
 			App.SetFocus(table)
@@ -249,6 +259,11 @@ func (table *ResultsTable) subscribeToSidebarChanges() {
 			table.SetInputCapture(table.tableInputCapture)
 			table.SetIsEditing(false)
 
+			if table.readOnly() {
+				table.SetError(commands.ErrReadOnly+", its rows can't be changed", nil)
+				continue
+			}
+
 			row, _ := table.GetSelection()
 			changedColumnIndex := table.GetColumnIndexByName(params.ColumnName)
 			tableCell := table.GetCell(row, changedColumnIndex)
@@ -404,6 +419,14 @@ func (table *ResultsTable) tableInputCapture(event *tcell.EventKey) *tcell.Event
 		}
 	}
 
+	switch command {
+	case commands.AppendNewRow, commands.DuplicateRow, commands.Edit, commands.Delete, commands.SetValue:
+		if table.readOnly() && table.Editor == nil {
+			table.SetError(commands.ErrReadOnly+", its rows can't be changed", nil)
+			return nil
+		}
+	}
+
 	switch command {
 	case commands.AppendNewRow:
 		if table.Menu.GetSelectedOption() == 1 {
@@ -415,6 +438,26 @@ func (table *ResultsTable) tableInputCapture(event *tcell.EventKey) *tcell.Event
 		}
 	case commands.Search:
 		table.search()
+	case commands.DiffTable:
+		if table.Menu != nil && table.GetTableName() != "" {
+			tableDiffModal := NewTableDiffModal(drivers.TableReference{
+				Driver:   table.DBDriver,
+				Database: table.GetDatabaseName(),
+				Table:    table.GetTableName(),
+			}, table.Connection)
+			mainPages.AddPage(pageNameTableDiff, tableDiffModal, true, true)
+			return nil
+		}
+	case commands.FollowForeignKey:
+		if table.Menu != nil && table.Menu.GetSelectedOption() == 1 {
+			go table.followForeignKey(selectedRowIndex, selectedColumnIndex)
+			return nil
+		}
+	case commands.ListReferencingRows:
+		if table.Menu != nil && table.Menu.GetSelectedOption() == 1 {
+			go table.listReferencingRows(selectedRowIndex)
+			return nil
+		}
 	}
 
 	if rowCount == 1 || colCount == 0 {
@@ -663,77 +706,230 @@ func (table *ResultsTable) subscribeToEditorChanges() {
 				// Check for import command
 				if strings.HasPrefix(queryLower, "import ") {
 					parts := strings.Fields(query)
-					if len(parts) >= 2 {
+					if table.readOnly() {
+						table.SetError(commands.ErrReadOnly+", nothing can be imported", nil)
+					} else if len(parts) >= 2 {
 						filename := parts[1]
-						table.handleImportCommand(filename)
+						table.confirmChange("Import "+tview.Escape(filename)+"?", func() {
+							table.handleImportCommand(filename)
+						})
 					} else {
 						table.SetError("Usage: import <filename>", nil)
 					}
 					continue
 				}
 
-				if strings.Contains(queryLower, "select") {
-					table.SetLoading(true)
-					App.Draw()
+				queryParameters := sqlparse.Parameters(table.DBDriver.GetProvider(), query)
+				if len(queryParameters) > 0 {
+					table.promptParameters(query, queryParameters)
+					continue
+				}
 
-					rows, records, err := table.DBDriver.ExecuteQuery(query)
-					table.Pagination.SetTotalRecords(records)
-					table.Pagination.SetLimit(records)
+				table.runEditorQuery(query, query, nil)
+			}
+		case eventSQLEditorEscape:
+			table.SetIsFiltering(false)
+			App.SetFocus(table)
+			table.HighlightTable()
+			table.Editor.SetBlur()
+			table.SetInputCapture(table.tableInputCapture)
+			App.Draw()
+		}
+	}
+}
 
-					if err != nil {
-						table.SetLoading(false)
-						table.SetError(err.Error(), nil)
-						App.Draw()
-					} else {
-						table.UpdateRows(rows)
-						table.SetLoading(false)
-						table.SetIsFiltering(false)
-						table.HighlightTable()
-						table.Editor.SetBlur()
-						table.SetInputCapture(table.tableInputCapture)
-						table.EditorPages.SwitchToPage(pageNameTableEditorTable)
-						App.SetFocus(table)
-						// Add successful SELECT query to history
-						if err := history.AddQueryToHistory(table.connectionIdentifier, query); err != nil {
-							logger.Error("Failed to add SELECT query to history", map[string]any{"error": err, "query": query, "connection": table.connectionIdentifier})
-						}
-						App.Draw()
-					}
-				} else {
-					table.SetRecords([][]string{})
-					table.SetLoading(true)
-					App.Draw()
+// runEditorQuery executes a statement of the editor with its bound arguments.
+// The query is the editor text the statement comes from, kept in the history.
+// Risky statements run once their warnings are confirmed, DDL and DML
+// statements on production connections once the connection name is typed,
+// and only queries run on read-only connections.
+func (table *ResultsTable) runEditorQuery(query, statement string, args []any) {
+	if table.readOnly() && !sqlparse.IsReadOnly(table.DBDriver.GetProvider(), statement) {
+		table.SetError(commands.ErrReadOnly+", only queries can be run", nil)
+		return
+	}
 
-					result, err := table.DBDriver.ExecuteDMLStatement(query)
+	checked, warnings := lintStatement(table.DBDriver, statement)
+	if query == statement {
+		query = checked
+	}
+	if len(warnings) == 0 {
+		table.runCheckedQuery(query, checked, args)
+		return
+	}
 
-					if err != nil {
-						table.SetLoading(false)
-						App.Draw()
-						table.SetError(err.Error(), nil)
-					} else {
-						table.SetResultsInfo(result)
-						table.SetLoading(false)
-						table.EditorPages.SwitchToPage(pageNameTableEditorResultsInfo)
-						App.SetFocus(table.Editor)
-						// Add successful DML query to history
-						if err := history.AddQueryToHistory(table.connectionIdentifier, query); err != nil {
-							logger.Error("Failed to add DML query to history", map[string]any{"error": err, "query": query, "connection": table.connectionIdentifier})
-						}
-						App.Draw()
-					}
-				}
+	App.QueueUpdateDraw(func() {
+		confirmWarnings(checked, warnings, func(confirmed string) {
+			if query == checked {
+				query = confirmed
 			}
-		case eventSQLEditorEscape:
+			go table.runCheckedQuery(query, confirmed, args)
+		}, func() {
+			App.SetFocus(table.Editor)
+		})
+	})
+}
+
+// runCheckedQuery executes a statement of the editor whose warnings were
+// confirmed, see runEditorQuery
+func (table *ResultsTable) runCheckedQuery(query, statement string, args []any) {
+	if !sqlparse.Changes(table.DBDriver.GetProvider(), statement) {
+		table.executeEditorQuery(query, statement, args)
+		return
+	}
+
+	table.confirmChange("Run this statement?\n\n"+tview.Escape(truncateStatement(statement)), func() {
+		table.executeEditorQuery(query, statement, args)
+	})
+}
+
+// readOnly reports whether the connection of the table is read-only
+func (table *ResultsTable) readOnly() bool {
+	return table.Connection != nil && table.Connection.ReadOnly
+}
+
+// confirmChange runs a change right away, or in a goroutine once the name of
+// the connection is typed when it is a production one. It must not be called
+// on the UI goroutine.
+func (table *ResultsTable) confirmChange(text string, change func()) {
+	if table.Connection == nil || !table.Connection.IsProduction() {
+		change()
+		return
+	}
+
+	App.QueueUpdateDraw(func() {
+		confirmProduction(table.Connection, text, func() {
+			go change()
+		})
+	})
+}
+
+// truncateStatement shortens a statement shown in a confirmation
+func truncateStatement(statement string) string {
+	statement = strings.Join(strings.Fields(statement), " ")
+	if runes := []rune(statement); len(runes) > 200 {
+		return string(runes[:200]) + "..."
+	}
+	return statement
+}
+
+// executeEditorQuery executes a statement of the editor, see runEditorQuery
+func (table *ResultsTable) executeEditorQuery(query, statement string, args []any) {
+	item := models.QueryHistoryItem{
+		QueryText: query,
+		Database:  table.queryDatabase(),
+		Source:    models.QuerySourceEditor,
+	}
+
+	if strings.Contains(strings.ToLower(statement), "select") {
+		table.SetLoading(true)
+		App.Draw()
+
+		start := time.Now()
+		rows, records, err := table.DBDriver.ExecuteQuery(statement, args...)
+		item.Duration, item.Rows = time.Since(start), int64(records)
+		table.Pagination.SetTotalRecords(records)
+		table.Pagination.SetLimit(records)
+
+		table.addToHistory(item, err)
+
+		if err != nil {
+			table.SetLoading(false)
+			table.SetError(err.Error(), nil)
+			table.Editor.MarkError(err, statement)
+			App.Draw()
+		} else {
+			table.UpdateRows(rows)
+			table.SetLoading(false)
 			table.SetIsFiltering(false)
-			App.SetFocus(table)
 			table.HighlightTable()
 			table.Editor.SetBlur()
 			table.SetInputCapture(table.tableInputCapture)
+			table.EditorPages.SwitchToPage(pageNameTableEditorTable)
+			App.SetFocus(table)
+			App.Draw()
+		}
+	} else {
+		table.SetRecords([][]string{})
+		table.SetLoading(true)
+		App.Draw()
+
+		start := time.Now()
+		result, err := table.DBDriver.ExecuteDMLStatement(statement, args...)
+		item.Duration = time.Since(start)
+		fmt.Sscanf(result, "%d", &item.Rows)
+
+		table.addToHistory(item, err)
+
+		if err != nil {
+			table.SetLoading(false)
+			App.Draw()
+			table.SetError(err.Error(), nil)
+			table.Editor.MarkError(err, statement)
+		} else {
+			table.SetResultsInfo(result)
+			table.SetLoading(false)
+			table.EditorPages.SwitchToPage(pageNameTableEditorResultsInfo)
+			App.SetFocus(table.Editor)
 			App.Draw()
 		}
 	}
 }
 
+// queryDatabase returns the database queries of the editor run in
+func (table *ResultsTable) queryDatabase() string {
+	if switcher, ok := table.DBDriver.(drivers.DatabaseSwitcher); ok && switcher.GetCurrentDatabase() != "" {
+		return switcher.GetCurrentDatabase()
+	}
+
+	if table.Editor != nil {
+		return table.Editor.completionDatabase()
+	}
+
+	return table.GetDatabaseName()
+}
+
+// addToHistory records a run of a query, failed runs included
+func (table *ResultsTable) addToHistory(item models.QueryHistoryItem, err error) {
+	if err != nil {
+		item.Error = err.Error()
+	}
+
+	if err := history.AddItem(table.connectionIdentifier, item); err != nil {
+		logger.Error("Failed to add query to history", map[string]any{"error": err, "query": item.QueryText, "connection": table.connectionIdentifier})
+	}
+}
+
+// promptParameters asks for the values of the named parameters of a query,
+// then runs it with the values bound by the driver
+func (table *ResultsTable) promptParameters(query string, queryParameters []sqlparse.Parameter) {
+	remembered, err := parameters.ReadValues(table.connectionIdentifier, query)
+	if err != nil {
+		logger.Error("Failed to read parameter values", map[string]any{"error": err, "connection": table.connectionIdentifier})
+	}
+
+	App.QueueUpdateDraw(func() {
+		modal := NewQueryParametersModal(queryParameters, remembered, func(values map[string]any, texts map[string]string) {
+			mainPages.RemovePage(pageNameQueryParameters)
+			App.SetFocus(table.Editor)
+
+			if err := parameters.SaveValues(table.connectionIdentifier, query, texts); err != nil {
+				logger.Error("Failed to save parameter values", map[string]any{"error": err, "connection": table.connectionIdentifier})
+			}
+
+			statement, args, err := sqlparse.BindParameters(table.DBDriver.GetProvider(), query, values, table.DBDriver.FormatPlaceholder)
+			if err != nil {
+				table.SetError(err.Error(), nil)
+				return
+			}
+
+			go table.runEditorQuery(query, statement, args)
+		})
+
+		mainPages.AddPage(pageNameQueryParameters, modal, true, true)
+	})
+}
+
 // Getters
 
 func (table *ResultsTable) GetRecords() [][]string {
@@ -808,6 +1004,7 @@ func (table *ResultsTable) GetIsLoading() bool {
 
 func (table *ResultsTable) SetConnection(conn *models.Connection) {
 	table.Connection = conn
+	table.Sidebar.SetReadOnly(table.readOnly())
 }
 
 func (table *ResultsTable) GetIsFiltering() bool {
@@ -977,18 +1174,28 @@ func (table *ResultsTable) FetchRecords(onError func()) [][]string {
 	}
 	sort := table.GetCurrentSort()
 
+	start := time.Now()
 	records, totalRecords, executedQuery, err := table.DBDriver.GetRecords(databaseName, tableName, where, sort, table.Pagination.GetOffset(), table.Pagination.GetLimit())
 
+	// Add filter query to history if a filter was applied, failed or not
+	if where != "" {
+		if executedQuery == "" {
+			// Drivers return no query when it fails, the filter is kept instead
+			executedQuery = where
+		}
+		table.addToHistory(models.QueryHistoryItem{
+			QueryText: executedQuery,
+			Database:  databaseName,
+			Duration:  time.Since(start),
+			Rows:      int64(totalRecords),
+			Source:    models.QuerySourceFilter,
+		}, err)
+	}
+
 	if err != nil {
 		table.SetError(err.Error(), onError)
 		table.SetLoading(false)
 	} else {
-		// Add filter query to history if a filter was applied and a query was executed
-		if where != "" && executedQuery != "" {
-			if err := history.AddQueryToHistory(table.connectionIdentifier, executedQuery); err != nil {
-				logger.Error("Failed to add filter query to history", map[string]any{"error": err, "query": executedQuery, "connection": table.connectionIdentifier})
-			}
-		}
 
 		if table.GetIsFiltering() {
 			table.SetIsFiltering(false)